@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/x/cli"
 )
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "0.1.0"
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
@@ -18,14 +21,23 @@ func main() {
 
 	switch cmd {
 	case "population", "pop":
-		if err := population.RunCLI(args); err != nil {
+		if err := cli.RunCLI(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "completion":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: vega completion bash|zsh|fish")
+			os.Exit(1)
+		}
+		if err := cli.WriteCompletion(os.Stdout, args[0]); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 	case "help", "-h", "--help":
 		printUsage()
 	case "version", "-v", "--version":
-		fmt.Println("vega version 0.1.0")
+		fmt.Println("vega version " + version)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", cmd)
 		printUsage()
@@ -40,6 +52,7 @@ Usage: vega <command> [options]
 
 Commands:
   population, pop    Manage skills, personas, and profiles
+  completion <shell> Print the bash, zsh, or fish completion script
   help               Show this help message
   version            Show version information
 
@@ -0,0 +1,44 @@
+// Command install installs items into a directory and reports what it did,
+// using only the stable population API.
+//
+//	go run ./examples/install -dir /tmp/vega +sre-oncall kubernetes-ops@^1.2
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/everydev1618/vega-population/population"
+)
+
+func main() {
+	dir := flag.String("dir", "", "Installation directory (default ~/.vega)")
+	flag.Parse()
+
+	var opts []population.Option
+	if *dir != "" {
+		opts = append(opts, population.WithInstallDir(*dir))
+	}
+	client, err := population.NewClient(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := client.InstallAll(context.Background(), flag.Args(), &population.InstallOptions{
+		OnConflict: population.ConflictNewest,
+	})
+	var mismatch *population.ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		log.Fatalf("refusing a tampered manifest: %v", mismatch)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, item := range report.Items {
+		fmt.Printf("%-18s %s %s\n", item.Status, population.FormatItemName(item.Kind, item.Name), item.Version)
+	}
+}
@@ -0,0 +1,36 @@
+// Command load resolves a profile in memory, without installing anything, and
+// prints the system prompt and skills an agent runtime would use. It uses
+// only the stable population API.
+//
+//	go run ./examples/load +sre-oncall
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/everydev1618/vega-population/population"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatal("usage: load <name>")
+	}
+
+	client, err := population.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	item, err := client.Load(context.Background(), os.Args[1], &population.LoadOptions{Env: os.Getenv("VEGA_ENV")})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("# %s %s\n\n%s\n", population.FormatItemName(item.Kind, item.Name), item.Version, item.Prompt)
+	for _, skill := range item.Skills {
+		fmt.Printf("- %s %s (%d tools)\n", skill.Name, skill.Version, len(skill.Manifest.Tools))
+	}
+}
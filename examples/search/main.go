@@ -0,0 +1,32 @@
+// Command search lists the personas matching a query, using only the stable
+// population API.
+//
+//	go run ./examples/search kubernetes
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/everydev1618/vega-population/population"
+)
+
+func main() {
+	client, err := population.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results, err := client.Search(context.Background(), strings.Join(os.Args[1:], " "), &population.SearchOptions{
+		Kind: population.KindPersona,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range results {
+		fmt.Printf("%s %s: %s\n", population.FormatItemName(r.Kind, r.Name), r.Version, r.Description)
+	}
+}
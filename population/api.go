@@ -0,0 +1,62 @@
+package population
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// The stable API. Each assignment fails to compile if the signature it pins
+// changes, so a breaking change to the v1 surface can't land unnoticed. Add
+// new stable functions here; never edit an existing line without a major
+// version bump.
+var (
+	_ func(...Option) (*Client, error)         = NewClient
+	_ func(string) Option                      = WithSource
+	_ func([]SourceConfig) Option              = WithSources
+	_ func(string) Option                      = WithCacheDir
+	_ func(string) Option                      = WithInstallDir
+	_ func() Option                            = WithNoCache
+	_ func(string) Option                      = WithAuth
+	_ func(*SignaturePolicy) Option            = WithSignatureVerification
+	_ func(int) Option                         = WithConcurrency
+	_ func(*http.Client) Option                = WithHTTPClient
+	_ func(int, time.Duration) Option          = WithRetry
+	_ func(time.Duration) Option               = WithTimeout
+	_ func(string) (ItemKind, string)          = ParseItemName
+	_ func(ItemKind, string) string            = FormatItemName
+	_ func(string) error                       = ValidateItemName
+	_ func(string) (string, string)            = SplitVersion
+	_ func(string, string) int                 = CompareVersions
+	_ func(string) (*VersionConstraint, error) = ParseConstraint
+	_ func(string) (*Query, error)             = ParseQuery
+	_ func(string) (*Manifest, error)          = LoadManifest
+	_ func(string) (ConflictStrategy, error)   = ParseConflictStrategy
+
+	_ func(*Client, context.Context, string, *SearchOptions) ([]SearchResult, error)    = (*Client).Search
+	_ func(*Client, context.Context, *Query, *SearchOptions) ([]SearchResult, error)    = (*Client).SearchQuery
+	_ func(*Client, context.Context, string) (*ItemInfo, error)                         = (*Client).Info
+	_ func(*Client, context.Context, string, *InstallOptions) error                     = (*Client).Install
+	_ func(*Client, context.Context, []string, *InstallOptions) (*InstallReport, error) = (*Client).InstallAll
+	_ func(*Client, context.Context, string, *InstallOptions) (*InstallReport, error)   = (*Client).InstallWithReport
+	_ func(*Client, string) error                                                       = (*Client).Uninstall
+	_ func(*Client, ItemKind) ([]InstalledItem, error)                                  = (*Client).List
+	_ func(*Client, context.Context, string, *LoadOptions) (*LoadedItem, error)         = (*Client).Load
+	_ func(*Client, context.Context, string) ([]Example, error)                         = (*Client).Examples
+	_ func(*Client, context.Context) error                                              = (*Client).UpdateCache
+	_ func(*Client, context.Context) ([]OutdatedItem, error)                            = (*Client).Outdated
+	_ func(*Client, context.Context, []string, *UpgradeOptions) ([]OutdatedItem, error) = (*Client).Upgrade
+	_ func(*Client, context.Context, string) (*DependencyNode, error)                   = (*Client).DependencyGraph
+	_ func(*Client, string) ([]Dependent, error)                                        = (*Client).Dependents
+	_ func(*Client) string                                                              = (*Client).InstallDir
+	_ func(*Client) []SourceConfig                                                      = (*Client).Sources
+)
+
+// The stable errors.
+var (
+	_ error = (*ChecksumMismatchError)(nil)
+	_ error = (*ConflictError)(nil)
+	_ error = (*DependencyCycleError)(nil)
+	_ error = (*IncompatibilityError)(nil)
+	_ error = (*SignatureError)(nil)
+)
@@ -0,0 +1,60 @@
+package population_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/x/config"
+	"github.com/everydev1618/vega-population/population/x/manage"
+	"github.com/everydev1618/vega-population/population/x/registry"
+)
+
+func TestClientMethodsStable(t *testing.T) {
+	want := []string{
+		"DependencyGraph", "Dependents", "Examples", "Info", "Install", "InstallAll", "InstallDir",
+		"InstallWithReport", "List", "Load", "Outdated", "Search", "SearchQuery", "Source", "Sources",
+		"Uninstall", "UpdateCache", "Upgrade",
+	}
+	typ := reflect.TypeOf(&population.Client{})
+	var got []string
+	for i := 0; i < typ.NumMethod(); i++ {
+		got = append(got, typ.Method(i).Name)
+	}
+	sort.Strings(got)
+	// A new method belongs in api.go too, or in a package under x
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Client has methods %v, want %v", got, want)
+	}
+}
+
+func TestExperimentalPackagesShareClient(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	src, err := registry.NewMemorySource(map[string]*population.Manifest{
+		"kubernetes-ops": {Version: "1.2.0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := population.NewClient(registry.WithRegistry(src), population.WithNoCache(), population.WithInstallDir(home))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Install(context.Background(), "kubernetes-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if drift, err := manage.Drift(client); err != nil || len(drift) != 0 {
+		t.Errorf("Drift = %v, %v; want nothing drifted", drift, err)
+	}
+
+	tenant, err := config.ForInstallDir(client, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items, err := tenant.List(""); err != nil || len(items) != 0 {
+		t.Errorf("derived client lists %v, %v; want nothing installed", items, err)
+	}
+}
@@ -0,0 +1,16 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// TokenEnv is the environment variable holding a default source token.
+const TokenEnv = core.TokenEnv
+
+// SourceAuth holds the credentials sent to a remote source. Explicit values
+// take precedence over TokenEnv, then Keychain, then Netrc.
+type SourceAuth = core.SourceAuth
+
+// WithAuth sets a bearer token for remote sources that have no credentials
+// of their own and are on the primary source's host.
+func WithAuth(token string) Option {
+	return core.WithAuth(token)
+}
@@ -0,0 +1,24 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// InstallRecordFile is the name of the JSON install record written next to
+// each installed manifest.
+const InstallRecordFile = core.InstallRecordFile
+
+// InstallRecord describes how an item was installed: its provenance, and the
+// hashes of what was written, which tell whether it was changed since.
+type InstallRecord = core.InstallRecord
+
+// LoadInstallRecord reads the install record of an installed item directory.
+func LoadInstallRecord(dir string) (*InstallRecord, error) {
+	return core.LoadInstallRecord(dir)
+}
+
+// LocalChangesError reports an installed item that was changed since it was
+// installed, which replacing it would discard.
+type LocalChangesError = core.LocalChangesError
+
+// ChecksumMismatchError reports manifest content that doesn't match the hash
+// published in the source's index.
+type ChecksumMismatchError = core.ChecksumMismatchError
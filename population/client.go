@@ -2,178 +2,144 @@ package population
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"path/filepath"
+	"net/http"
+	"time"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
 )
 
 const (
 	// DefaultSource is the default URL for the vega-population repository.
-	DefaultSource = "https://raw.githubusercontent.com/martellcode/vega-population/main/"
-
+	DefaultSource = core.DefaultSource
 	// DefaultCacheDir is the default cache directory relative to vega home.
-	DefaultCacheDir = "cache/population"
-
-	// DefaultVegaHome is the default vega home directory.
-	DefaultVegaHome = ".vega"
+	DefaultCacheDir = core.DefaultCacheDir
+	// DefaultConcurrency is how many of a profile's dependencies are fetched
+	// at once.
+	DefaultConcurrency = core.DefaultConcurrency
 )
 
 // Client is the main entry point for library users.
-type Client struct {
-	source     string
-	cacheDir   string
-	installDir string
-	noCache    bool
-	cache      *Cache
-}
+type Client core.Client
 
 // Option configures a Client.
-type Option func(*Client)
+type Option = core.Option
 
 // WithSource sets a custom source URL or local path.
 func WithSource(url string) Option {
-	return func(c *Client) {
-		c.source = url
-	}
+	return core.WithSource(url)
 }
 
 // WithCacheDir sets a custom cache directory.
 func WithCacheDir(path string) Option {
-	return func(c *Client) {
-		c.cacheDir = path
-	}
+	return core.WithCacheDir(path)
 }
 
 // WithInstallDir sets a custom installation directory.
 func WithInstallDir(path string) Option {
-	return func(c *Client) {
-		c.installDir = path
-	}
+	return core.WithInstallDir(path)
 }
 
 // WithNoCache disables caching of index files.
 func WithNoCache() Option {
-	return func(c *Client) {
-		c.noCache = true
-	}
+	return core.WithNoCache()
 }
 
-// NewClient creates a new population Client with the given options.
-func NewClient(opts ...Option) (*Client, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("could not determine home directory: %w", err)
-	}
-
-	vegaHome := filepath.Join(home, DefaultVegaHome)
+// WithConcurrency sets how many of a profile's dependencies are fetched at
+// once while installing it (default DefaultConcurrency; 1 fetches them one
+// after another). They are still installed, and reported, in order.
+func WithConcurrency(n int) Option {
+	return core.WithConcurrency(n)
+}
 
-	c := &Client{
-		source:     DefaultSource,
-		cacheDir:   filepath.Join(vegaHome, DefaultCacheDir),
-		installDir: vegaHome,
-	}
+// WithHTTPClient sets the HTTP client remote sources are fetched with
+// (default http.DefaultClient), e.g. to use a proxy or custom TLS settings.
+func WithHTTPClient(hc *http.Client) Option {
+	return core.WithHTTPClient(hc)
+}
 
-	for _, opt := range opts {
-		opt(c)
-	}
+// WithRetry sets how many times a remote fetch is retried after a connection
+// failure, a server error, or 429 Too Many Requests (default DefaultRetries;
+// 0 never retries), and how long to wait before the first retry, doubling
+// each time (default DefaultBackoff). A Retry-After header from the server
+// overrides the wait.
+func WithRetry(max int, backoff time.Duration) Option {
+	return core.WithRetry(max, backoff)
+}
 
-	// Initialize cache
-	c.cache = NewCache(c.cacheDir, c.noCache)
+// WithTimeout bounds each remote request, including reading the response
+// (default DefaultTimeout; 0 for no timeout). A download cut off by the
+// timeout is resumed by the retry.
+func WithTimeout(d time.Duration) Option {
+	return core.WithTimeout(d)
+}
 
-	return c, nil
+// NewClient creates a new population Client with the given options. The
+// defaults come from the vega home's config file, if there is one, and the
+// environment variables overriding it.
+func NewClient(opts ...Option) (*Client, error) {
+	client, err := core.NewClient(opts...)
+	return (*Client)(client), err
 }
 
-// Search returns matching items across all types.
+// Search returns matching items across all types. The query is parsed by
+// ParseQuery, so it can match fields, negate terms, and combine them with OR.
 func (c *Client) Search(ctx context.Context, query string, opts *SearchOptions) ([]SearchResult, error) {
-	if opts == nil {
-		opts = &SearchOptions{}
-	}
+	return (*core.Client)(c).Search(ctx, query, opts)
+}
 
-	source := NewSource(c.source, c.cache)
-	return source.Search(ctx, query, opts)
+// SearchQuery returns items matching a parsed or programmatically built query
+// across all types.
+func (c *Client) SearchQuery(ctx context.Context, query *Query, opts *SearchOptions) ([]SearchResult, error) {
+	return (*core.Client)(c).SearchQuery(ctx, query, opts)
 }
 
 // Install installs an item by name.
 // The name can be prefixed with @ for personas or + for profiles.
 func (c *Client) Install(ctx context.Context, name string, opts *InstallOptions) error {
-	if opts == nil {
-		opts = &InstallOptions{}
-	}
+	return (*core.Client)(c).Install(ctx, name, opts)
+}
 
-	kind, itemName := ParseItemName(name)
-	source := NewSource(c.source, c.cache)
+// InstallWithReport installs an item like Install and reports every item it
+// installed, skipped, or found already installed, dependencies first. On
+// error the report covers the items handled before it.
+func (c *Client) InstallWithReport(ctx context.Context, name string, opts *InstallOptions) (*InstallReport, error) {
+	return (*core.Client)(c).InstallWithReport(ctx, name, opts)
+}
 
-	return source.Install(ctx, kind, itemName, c.installDir, opts)
+// InstallAll installs several items, and reports them as InstallWithReport
+// does. Before installing anything it checks the version requirements of
+// all of them and their dependencies together: items wanted at versions no
+// single version satisfies are settled by opts.OnConflict, and listed in the
+// report's Conflicts. With ConflictFail, a *ConflictError is returned and
+// nothing is installed. Likewise, requested profiles combining items their
+// skills declare incompatible return an *IncompatibilityError unless
+// opts.AllowIncompatible is set, and the report lists them otherwise.
+func (c *Client) InstallAll(ctx context.Context, names []string, opts *InstallOptions) (*InstallReport, error) {
+	return (*core.Client)(c).InstallAll(ctx, names, opts)
 }
 
 // List returns installed items of the given kind.
 // If kind is empty, returns all installed items.
 func (c *Client) List(kind ItemKind) ([]InstalledItem, error) {
-	var items []InstalledItem
-
-	kinds := []ItemKind{KindSkill, KindPersona, KindProfile}
-	if kind != "" {
-		kinds = []ItemKind{kind}
-	}
-
-	for _, k := range kinds {
-		dir := filepath.Join(c.installDir, k.Plural())
-		entries, err := os.ReadDir(dir)
-		if os.IsNotExist(err) {
-			continue
-		}
-		if err != nil {
-			return nil, fmt.Errorf("reading %s directory: %w", k.Plural(), err)
-		}
-
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
-			}
-
-			manifestPath := filepath.Join(dir, entry.Name(), "vega.yaml")
-			if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
-				continue
-			}
-
-			manifest, err := LoadManifest(manifestPath)
-			if err != nil {
-				// Skip items with invalid manifests
-				continue
-			}
-
-			items = append(items, InstalledItem{
-				Kind:    k,
-				Name:    entry.Name(),
-				Version: manifest.Version,
-				Path:    filepath.Join(dir, entry.Name()),
-			})
-		}
-	}
-
-	return items, nil
+	return (*core.Client)(c).List(kind)
 }
 
 // Info returns detailed information about an item.
 func (c *Client) Info(ctx context.Context, name string) (*ItemInfo, error) {
-	kind, itemName := ParseItemName(name)
-	source := NewSource(c.source, c.cache)
-
-	return source.Info(ctx, kind, itemName, c.installDir)
+	return (*core.Client)(c).Info(ctx, name)
 }
 
-// UpdateCache refreshes the cached index files.
+// UpdateCache refreshes the cached index files of every source.
 func (c *Client) UpdateCache(ctx context.Context) error {
-	source := NewSource(c.source, c.cache)
-	return source.UpdateCache(ctx)
+	return (*core.Client)(c).UpdateCache(ctx)
 }
 
-// Source returns the configured source URL.
+// Source returns the configured (primary) source URL.
 func (c *Client) Source() string {
-	return c.source
+	return (*core.Client)(c).Source()
 }
 
 // InstallDir returns the configured installation directory.
 func (c *Client) InstallDir() string {
-	return c.installDir
+	return (*core.Client)(c).InstallDir()
 }
@@ -0,0 +1,22 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Compatibility is what a skill declares about the items it can be combined
+// with in a profile:
+//
+//	compatibility:
+//	  personas: [incident-commander, devops-lead]
+//	  incompatible_skills: [cautious-deploys]
+//	  incompatible_tools: [force_push]
+//	  reason: tells the agent to deploy without waiting for approval
+type Compatibility = core.Compatibility
+
+// Incompatibility is a pair of items a profile combines although one of
+// them declares it doesn't work with the other.
+type Incompatibility = core.Incompatibility
+
+// IncompatibilityError is returned by an install of profiles combining
+// items one of their skills declares it doesn't work with, unless
+// InstallOptions.AllowIncompatible is set.
+type IncompatibilityError = core.IncompatibilityError
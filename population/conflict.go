@@ -0,0 +1,29 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ConflictStrategy says how an install settles items wanted at versions no
+// single version satisfies.
+type ConflictStrategy = core.ConflictStrategy
+
+const (
+	ConflictFail         = core.ConflictFail         // Report the conflicts and install nothing
+	ConflictNewest       = core.ConflictNewest       // Install the newest version any requirement wants
+	ConflictKeepExisting = core.ConflictKeepExisting // Keep the installed version, or install the newest
+)
+
+// ParseConflictStrategy parses a conflict strategy name.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	return core.ParseConflictStrategy(s)
+}
+
+// VersionRequirement is a version constraint an item places on another.
+type VersionRequirement = core.VersionRequirement
+
+// VersionConflict is an item whose requirements no single version satisfies,
+// counting the installed version unless the install is forced.
+type VersionConflict = core.VersionConflict
+
+// ConflictError is returned by an install with the ConflictFail strategy
+// when requested items want the same item at incompatible versions.
+type ConflictError = core.ConflictError
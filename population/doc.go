@@ -0,0 +1,64 @@
+// Package population provides package management for Vega skills, personas, and profiles.
+//
+// It supports installing, searching, and listing items from the vega-population repository.
+// The package can be used as a library by any Go application or through the CLI interface.
+//
+// Example usage as a library:
+//
+//	client, err := population.NewClient()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	results, err := client.Search(ctx, "kubernetes", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	for _, r := range results {
+//	    fmt.Printf("%s: %s\n", r.Name, r.Description)
+//	}
+//
+// # API Stability
+//
+// This package is the v1 API: once v1.0.0 is tagged it changes only in
+// backwards-compatible ways (new functions, options, and struct fields). It
+// holds:
+//
+//   - NewClient and the With* options that configure it
+//   - The Client methods that find, install, and read items: Search,
+//     SearchQuery, Info, Install, InstallAll, InstallWithReport, Uninstall,
+//     List, Load, Examples, UpdateCache, Outdated, Upgrade, DependencyGraph,
+//     and Dependents
+//   - The options and results of those methods, such as InstallOptions,
+//     ConflictStrategy, InstallReport, Query, SearchResult, and LoadedItem
+//   - Item names and versions: ItemKind, ParseItemName, FormatItemName,
+//     ValidateItemName, SplitVersion, CompareVersions, ParseConstraint, and
+//     ParseQuery
+//   - The manifest format: Manifest, LoadManifest, Prompt, SkillRef, Tool,
+//     and Example
+//   - The errors installs return: ChecksumMismatchError, ConflictError,
+//     DependencyCycleError, IncompatibilityError, and SignatureError
+//
+// Everything else is in the packages under population/x, which may change
+// in any release:
+//
+//   - x/registry: registries other than a source URL, such as MemorySource,
+//     and registry authoring: LocalRegistry, Publish, Push, Copy, Mirror,
+//     and BumpManifest
+//   - x/server: the registry server, the refresh daemon, and service files
+//   - x/deploy: exports, deploy targets, and secret providers
+//   - x/workspace: project workspaces, deps files, lockfiles, and sync
+//   - x/manage: backups, drift, doctor, usage, and watching the install dir
+//   - x/config: the config file, layouts, encryption, sessions, and other
+//     client options
+//   - x/source: the low-level Source and Cache
+//   - x/cli: the vega population command
+//   - x/populationtest: registries for testing programs built on the client
+//
+// Their functions taking a *Client work on the clients this package
+// creates. The implementation of both is in an internal package.
+//
+// api.go pins the signatures of this package, so changing one fails to
+// compile, and the programs under examples/ use only this package.
+package population
@@ -0,0 +1,18 @@
+package population
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// Example is a sample conversation shipped with a persona.
+type Example = core.Example
+
+// ExampleMessage is one turn of an example conversation.
+type ExampleMessage = core.ExampleMessage
+
+// Examples returns the example conversations shipped with a persona.
+func (c *Client) Examples(ctx context.Context, name string) ([]Example, error) {
+	return (*core.Client)(c).Examples(ctx, name)
+}
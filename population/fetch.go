@@ -0,0 +1,14 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+const (
+	// DefaultRetries is how many times a remote fetch is retried after
+	// failing without receiving any data.
+	DefaultRetries = core.DefaultRetries
+	// DefaultBackoff is how long a remote fetch waits before its first retry;
+	// each further retry waits twice as long.
+	DefaultBackoff = core.DefaultBackoff
+	// DefaultTimeout bounds each remote request, including reading the response.
+	DefaultTimeout = core.DefaultTimeout
+)
@@ -0,0 +1,18 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ItemFile is an extra file shipped with an item, such as a prompt template,
+// script, or reference doc, stored next to its vega.yaml. It can be written as
+// a bare path or as a mapping pinning the file's hash:
+//
+//	files:
+//	  - templates/incident.md
+//	  - path: scripts/rollout.sh
+//	    sha256: 9f86d081884c7d65...
+type ItemFile = core.ItemFile
+
+// Uninstall removes an installed item and all of its files.
+func (c *Client) Uninstall(name string) error {
+	return (*core.Client)(c).Uninstall(name)
+}
@@ -0,0 +1,40 @@
+package population
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// DependencyType says how an item depends on another.
+type DependencyType = core.DependencyType
+
+const (
+	DependsOnPersona     = core.DependsOnPersona     // A profile's persona
+	DependsOnSkill       = core.DependsOnSkill       // A profile's skill
+	DependsOnRecommended = core.DependsOnRecommended // A persona's recommended skill (not installed with it)
+	DependsOnDependency  = core.DependsOnDependency  // A skill's dependency
+)
+
+// DependencyNode is an item in a dependency graph, with the items it
+// depends on.
+type DependencyNode = core.DependencyNode
+
+// DependencyGraph fetches an item and everything it depends on: a profile's
+// persona and skills, a persona's recommended skills, and skills' dependencies. Dependencies that
+// can't be fetched are kept in the graph with an Error instead of failing it.
+func (c *Client) DependencyGraph(ctx context.Context, name string) (*DependencyNode, error) {
+	return (*core.Client)(c).DependencyGraph(ctx, name)
+}
+
+// Dependent is an installed item that depends on another, directly or
+// through its persona.
+type Dependent = core.Dependent
+
+// Dependents returns the installed items that depend on another: profiles
+// using it as their persona or a skill, or whose persona recommends it,
+// personas recommending it, and skills depending on it. It reads only
+// installed manifests.
+func (c *Client) Dependents(name string) ([]Dependent, error) {
+	return (*core.Client)(c).Dependents(name)
+}
@@ -0,0 +1,610 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archiveSyncKey is the cache entry recording when an archive source was last extracted.
+const archiveSyncKey = "archive-synced"
+
+// extractWorkers is how many files of an archive are written at once.
+const extractWorkers = 8
+
+// Limits on what an archive extracts to, so that a small archive that
+// decompresses to far more can't exhaust memory or disk. Variables so tests
+// can lower them.
+var (
+	maxArchiveEntrySize int64 = 64 << 20 // Bytes in any one file
+	maxArchiveSize      int64 = 1 << 30  // Bytes in all files together
+)
+
+// archiveRepo is a tarball or zip source, such as a registry bundle or a
+// GitHub release archive, read from an extraction in the cache directory.
+type archiveRepo struct {
+	url    string
+	format string // "tar", "tar.gz", or "zip"
+	dir    string
+
+	mu     sync.Mutex
+	synced bool // Extracted during this process
+}
+
+// archiveFormat returns the archive format a source URL names by its
+// extension, or "" if it isn't an archive:
+//
+//	https://example.com/population-1.4.0.tar.gz
+//	./bundles/internal-registry.zip
+func archiveFormat(url string) string {
+	url = strings.ToLower(strings.TrimSuffix(url, "/"))
+	switch {
+	case strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(url, ".tar"):
+		return "tar"
+	case strings.HasSuffix(url, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// newArchiveRepo returns the archive for a source URL, extracted under cacheDir.
+func newArchiveRepo(url, cacheDir string) *archiveRepo {
+	url = strings.TrimSuffix(url, "/")
+	sum := sha256.Sum256([]byte(url))
+	return &archiveRepo{
+		url:    url,
+		format: archiveFormat(url),
+		dir:    filepath.Join(cacheDir, "archives", hex.EncodeToString(sum[:8])),
+	}
+}
+
+// fetchArchive reads a file from the source's extracted archive, fetching and
+// extracting it first when the extraction is missing or older than the cache TTL.
+func (s *Source) fetchArchive(ctx context.Context, path string) ([]byte, error) {
+	if err := s.syncArchive(ctx); err != nil {
+		return nil, err
+	}
+
+	fullPath := filepath.Join(archiveRoot(s.archive.dir), filepath.FromSlash(path))
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s from %s: %w", path, s.archive.url, err)
+	}
+	return content, nil
+}
+
+// syncArchive brings the extraction up to date, at most once per process and cache TTL.
+func (s *Source) syncArchive(ctx context.Context) error {
+	repo := s.archive
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.synced {
+		return nil
+	}
+	if _, err := os.Stat(repo.dir); err == nil {
+		if _, fresh := s.cache.Get(s.cacheKey(archiveSyncKey)); fresh || s.offline {
+			repo.synced = true
+			return nil
+		}
+	}
+
+	if err := s.extractArchive(ctx); err != nil {
+		return err
+	}
+
+	repo.synced = true
+	if err := s.cache.Set(s.cacheKey(archiveSyncKey), []byte(time.Now().UTC().Format(time.RFC3339))); err != nil {
+		s.log().Warn("failed to cache "+archiveSyncKey, "source", s.name, "error", err)
+	}
+	return nil
+}
+
+// resync makes the next read fetch and extract the archive again.
+func (r *archiveRepo) resync() {
+	r.mu.Lock()
+	r.synced = false
+	r.mu.Unlock()
+}
+
+// extractArchive fetches the archive and extracts it into place.
+func (s *Source) extractArchive(ctx context.Context) error {
+	repo := s.archive
+	if err := os.MkdirAll(filepath.Dir(repo.dir), 0755); err != nil {
+		return fmt.Errorf("creating archive cache directory: %w", err)
+	}
+
+	// Download and extract into a temporary directory, so a failed
+	// extraction isn't mistaken for a good one
+	tmp, err := newTempDir(s.tempDir, "archive")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	file := repo.url
+	if strings.HasPrefix(repo.url, "http://") || strings.HasPrefix(repo.url, "https://") {
+		file = filepath.Join(tmp, "archive."+repo.format)
+		f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return fmt.Errorf("creating archive download: %w", err)
+		}
+		err = s.download(ctx, "", filePartial{f})
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	extracted := filepath.Join(tmp, "extracted")
+	if err := ExtractArchive(ctx, file, extracted); err != nil {
+		return fmt.Errorf("extracting %s: %w", repo.url, err)
+	}
+	return moveDir(extracted, repo.dir)
+}
+
+// archiveRoot returns the directory of an extraction holding the indexes.
+// Archives made from a repository, like GitHub's, wrap everything in a
+// single top-level directory, which is skipped.
+func archiveRoot(dir string) string {
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		if _, err := os.Stat(filepath.Join(dir, kind.Plural())); err == nil {
+			return dir
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// ExtractArchive extracts a .tar, .tar.gz, .tgz, or .zip file into dest,
+// which must not exist yet. Entries with absolute paths or paths leaving
+// dest, symlinks pointing outside dest, hard links, and device files are
+// rejected, as are archives with a file over 64 MiB or over 1 GiB of files in
+// all. Files are written in parallel, then read back and checked against the
+// archive before returning.
+func ExtractArchive(ctx context.Context, file, dest string) error {
+	format := archiveFormat(file)
+	if format == "" {
+		return fmt.Errorf("%s is not a .tar, .tar.gz, .tgz, or .zip archive", file)
+	}
+	if err := os.Mkdir(dest, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dest, err)
+	}
+
+	x := newExtractor(ctx, dest)
+	var err error
+	if format == "zip" {
+		err = x.extractZip(file)
+	} else {
+		err = x.extractTar(file, format == "tar.gz")
+	}
+	if werr := x.wait(); err == nil {
+		err = werr
+	}
+	if err != nil {
+		return err
+	}
+	if err := x.link(); err != nil {
+		return err
+	}
+	return x.verify()
+}
+
+// extractor writes an archive's entries under dest.
+type extractor struct {
+	ctx  context.Context
+	dest string
+	sem  chan struct{}
+	wg   sync.WaitGroup
+
+	mu    sync.Mutex
+	err   error             // First write error
+	files map[string]string // sha256 by relative path of each regular file
+	size  int64             // Bytes read from the archive so far
+	links map[string]string // Target by relative path of each symlink
+	seen  map[string]bool
+}
+
+func newExtractor(ctx context.Context, dest string) *extractor {
+	return &extractor{
+		ctx:   ctx,
+		dest:  dest,
+		sem:   make(chan struct{}, extractWorkers),
+		files: make(map[string]string),
+		links: make(map[string]string),
+		seen:  make(map[string]bool),
+	}
+}
+
+func (x *extractor) extractTar(file string, gzipped bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+		if err := x.failed(); err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeDir:
+			if err := x.dir(hdr.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			// Tar is read sequentially, so the content is read here and written by a worker
+			content, err := x.read(tr, hdr.Name)
+			if err != nil {
+				return err
+			}
+			mode := fs.FileMode(hdr.Mode)
+			if err := x.file(hdr.Name, mode, func() ([]byte, error) { return content, nil }); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := x.symlink(hdr.Name, hdr.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			return fmt.Errorf("archive entry %q is a hard link, which is not supported", hdr.Name)
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			return fmt.Errorf("archive entry %q is a device file", hdr.Name)
+		default:
+			return fmt.Errorf("archive entry %q has unsupported type %q", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+func (x *extractor) extractZip(file string) error {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", file, err)
+	}
+	defer func() {
+		// Workers read from the archive, so it stays open until they finish
+		x.wg.Wait()
+		zr.Close()
+	}()
+
+	for _, zf := range zr.File {
+		if err := x.failed(); err != nil {
+			return err
+		}
+
+		zf := zf
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			if err := x.dir(zf.Name); err != nil {
+				return err
+			}
+		case mode&fs.ModeSymlink != 0:
+			target, err := x.readZipFile(zf)
+			if err != nil {
+				return err
+			}
+			if err := x.symlink(zf.Name, string(target)); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if err := x.file(zf.Name, mode, func() ([]byte, error) { return x.readZipFile(zf) }); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("archive entry %q is a device file", zf.Name)
+		}
+	}
+	return nil
+}
+
+// readZipFile reads a zip entry, which checks its CRC.
+func (x *extractor) readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", zf.Name, err)
+	}
+	defer rc.Close()
+	return x.read(rc, zf.Name)
+}
+
+// read reads an entry's content, failing once it or the archive as a whole
+// goes over its limit rather than reading on. Sizes in headers aren't
+// trusted, since they needn't match the content.
+func (x *extractor) read(r io.Reader, name string) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxArchiveEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	if int64(len(content)) > maxArchiveEntrySize {
+		return nil, fmt.Errorf("archive entry %q is over the %d MiB limit", name, maxArchiveEntrySize>>20)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.size += int64(len(content))
+	if x.size > maxArchiveSize {
+		return nil, fmt.Errorf("archive extracts to over the %d MiB limit", maxArchiveSize>>20)
+	}
+	return content, nil
+}
+
+// archivePath checks an entry name and returns it cleaned and relative to
+// the extraction, or "." for the root.
+func archivePath(name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	switch {
+	case name == "" || strings.ContainsAny(name, "\\\x00") || path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "":
+		return "", fmt.Errorf("archive entry %q has an invalid path", name)
+	case clean == ".." || strings.HasPrefix(clean, "../"):
+		return "", fmt.Errorf("archive entry %q leaves the extraction directory", name)
+	}
+	return clean, nil
+}
+
+// claim records an entry, rejecting duplicates, which could otherwise be
+// written concurrently.
+func (x *extractor) claim(name string) (string, error) {
+	rel, err := archivePath(name)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return rel, nil
+	}
+	if x.seen[rel] {
+		return "", fmt.Errorf("archive entry %q appears more than once", name)
+	}
+	x.seen[rel] = true
+	return rel, nil
+}
+
+func (x *extractor) dir(name string) error {
+	rel, err := archivePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(x.dest, filepath.FromSlash(rel)), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", rel, err)
+	}
+	return nil
+}
+
+// file queues a regular file to be written by a worker.
+func (x *extractor) file(name string, mode fs.FileMode, read func() ([]byte, error)) error {
+	rel, err := x.claim(name)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return fmt.Errorf("archive entry %q has an invalid path", name)
+	}
+
+	// Keep the executable bit, for scripts, but nothing else
+	perm := fs.FileMode(0644)
+	if mode&0111 != 0 {
+		perm = 0755
+	}
+
+	select {
+	case x.sem <- struct{}{}:
+	case <-x.ctx.Done():
+		return x.ctx.Err()
+	}
+	x.wg.Add(1)
+	go func() {
+		defer func() {
+			<-x.sem
+			x.wg.Done()
+		}()
+
+		err := func() error {
+			content, err := read()
+			if err != nil {
+				return err
+			}
+			dest := filepath.Join(x.dest, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return fmt.Errorf("creating directory for %s: %w", rel, err)
+			}
+			// O_EXCL so nothing already at the path, like a symlink, is written through
+			f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+			if err != nil {
+				return fmt.Errorf("writing %s: %w", rel, err)
+			}
+			if _, err := f.Write(content); err != nil {
+				f.Close()
+				return fmt.Errorf("writing %s: %w", rel, err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("writing %s: %w", rel, err)
+			}
+
+			x.mu.Lock()
+			x.files[rel] = contentHash(content)
+			x.mu.Unlock()
+			return nil
+		}()
+		if err != nil {
+			x.fail(err)
+		}
+	}()
+	return nil
+}
+
+// symlink records a symlink, to be created once every file is written so
+// no file is written through it. Its target must stay inside the extraction.
+func (x *extractor) symlink(name, target string) error {
+	rel, err := x.claim(name)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return fmt.Errorf("archive entry %q has an invalid path", name)
+	}
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) || strings.ContainsAny(target, "\\\x00") {
+		return fmt.Errorf("archive symlink %q has an invalid target %q", name, target)
+	}
+	if resolved := path.Join(path.Dir(rel), target); resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("archive symlink %q points outside the extraction directory", name)
+	}
+	x.links[rel] = target
+	return nil
+}
+
+func (x *extractor) fail(err error) {
+	x.mu.Lock()
+	if x.err == nil {
+		x.err = err
+	}
+	x.mu.Unlock()
+}
+
+func (x *extractor) failed() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.err
+}
+
+// wait waits for the workers and returns the first write error.
+func (x *extractor) wait() error {
+	x.wg.Wait()
+	return x.failed()
+}
+
+// link creates the recorded symlinks, refusing any that would be created
+// through another symlink, where the check of its target no longer holds.
+func (x *extractor) link() error {
+	root, err := filepath.EvalSymlinks(x.dest)
+	if err != nil {
+		return err
+	}
+	for rel, target := range x.links {
+		dest := filepath.Join(x.dest, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", rel, err)
+		}
+		parent, err := filepath.EvalSymlinks(filepath.Dir(dest))
+		if err != nil {
+			return err
+		}
+		if parent != filepath.Join(root, filepath.FromSlash(path.Dir(rel))) {
+			return fmt.Errorf("archive symlink %q is inside another symlink", rel)
+		}
+		if err := os.Symlink(target, dest); err != nil {
+			return fmt.Errorf("creating symlink %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// verify walks the extraction and checks it holds exactly the archive's
+// entries: every file with the content that was written, every symlink
+// resolving inside the extraction, and nothing else.
+func (x *extractor) verify() error {
+	root, err := filepath.EvalSymlinks(x.dest)
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	err = filepath.WalkDir(x.dest, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(x.dest, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case d.IsDir():
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			if want, ok := x.links[rel]; !ok || target != want {
+				return fmt.Errorf("verifying %s: unexpected symlink", rel)
+			}
+			resolved, err := filepath.EvalSymlinks(p)
+			if errors.Is(err, fs.ErrNotExist) {
+				// Dangling links are harmless, and resolved lexically when recorded
+				found++
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("verifying %s: %w", rel, err)
+			}
+			if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+				return fmt.Errorf("verifying %s: symlink resolves outside the extraction directory", rel)
+			}
+			found++
+			return nil
+		case d.Type().IsRegular():
+			want, ok := x.files[rel]
+			if !ok {
+				return fmt.Errorf("verifying %s: unexpected file", rel)
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("verifying %s: %w", rel, err)
+			}
+			if contentHash(content) != want {
+				return fmt.Errorf("verifying %s: content does not match the archive", rel)
+			}
+			found++
+			return nil
+		default:
+			return fmt.Errorf("verifying %s: unexpected file type %s", rel, d.Type())
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if want := len(x.files) + len(x.links); found != want {
+		return fmt.Errorf("verifying extraction: found %d of %d entries", found, want)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zeros reads n zero bytes, which compress to almost nothing.
+func zeros(n int64) io.Reader {
+	return io.LimitReader(zeroReader{}, n)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// writeTarGz writes a .tar.gz holding files of the given sizes, all zeros.
+func writeTarGz(t *testing.T, file string, sizes map[string]int64) {
+	t.Helper()
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range sortedKeys(sizes) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: sizes[name], Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(tw, zeros(sizes[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ok.tar.gz")
+	writeTarGz(t, file, map[string]int64{"skills/index.yaml": 10, "skills/a/vega.yaml": 20})
+
+	dest := filepath.Join(dir, "out")
+	if err := ExtractArchive(context.Background(), file, dest); err != nil {
+		t.Fatalf("ExtractArchive: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "skills", "a", "vega.yaml"))
+	if err != nil || info.Size() != 20 {
+		t.Fatalf("extracted file = %v, %v; want 20 bytes", info, err)
+	}
+}
+
+// lowerArchiveLimits lowers the extraction limits for the rest of a test.
+func lowerArchiveLimits(t *testing.T, entry, total int64) {
+	entrySize, size := maxArchiveEntrySize, maxArchiveSize
+	maxArchiveEntrySize, maxArchiveSize = entry, total
+	t.Cleanup(func() { maxArchiveEntrySize, maxArchiveSize = entrySize, size })
+}
+
+func TestExtractArchiveEntryLimit(t *testing.T) {
+	lowerArchiveLimits(t, 1<<20, 4<<20)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "bomb.tar.gz")
+	writeTarGz(t, file, map[string]int64{"big": maxArchiveEntrySize + 1})
+
+	err := ExtractArchive(context.Background(), file, filepath.Join(dir, "out"))
+	if err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("ExtractArchive = %v, want an error for the entry over the limit", err)
+	}
+}
+
+func TestExtractArchiveTotalLimit(t *testing.T) {
+	lowerArchiveLimits(t, 1<<20, 4<<20)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "bomb.zip")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for i := int64(0); i <= maxArchiveSize/maxArchiveEntrySize; i++ {
+		w, err := zw.Create("files/" + strings.Repeat("x", int(i)+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(w, zeros(maxArchiveEntrySize)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	err = ExtractArchive(context.Background(), file, filepath.Join(dir, "out"))
+	if err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("ExtractArchive = %v, want an error for the archive over the limit", err)
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// TokenEnv is the environment variable holding a default source token.
+const TokenEnv = "VEGA_POPULATION_TOKEN"
+
+// SourceAuth holds the credentials sent to a remote source. Explicit values
+// take precedence over TokenEnv, then Keychain, then Netrc.
+type SourceAuth struct {
+	Token    string            `yaml:"token,omitempty"`    // Sent as "Authorization: Bearer <token>"
+	Username string            `yaml:"username,omitempty"` // Basic auth username, used when there is no token
+	Password string            `yaml:"password,omitempty"` // Basic auth password
+	Headers  map[string]string `yaml:"headers,omitempty"`  // Extra headers sent with every request
+
+	TokenEnv string `yaml:"token_env,omitempty"` // Read the token from this environment variable
+	Keychain string `yaml:"keychain,omitempty"`  // Read the token from the OS keychain under this service name
+	Netrc    bool   `yaml:"netrc,omitempty"`     // Read basic auth for the source host from ~/.netrc
+
+	// Hosts are the hosts, such as "registry.internal:8080", the client's
+	// default credentials are sent to. Empty means the primary source's host
+	// only, unless a workspace set the primary source. Credentials a source
+	// has of its own are sent to it regardless.
+	Hosts []string `yaml:"hosts,omitempty"`
+}
+
+// WithAuth sets a bearer token for remote sources that have no credentials
+// of their own and are on the primary source's host.
+func WithAuth(token string) Option {
+	return func(c *Client) {
+		c.auth = &SourceAuth{Token: token}
+	}
+}
+
+// authFor returns the credentials for a source: its own, or else the
+// client's or the token in TokenEnv if the source is on a host they may be
+// sent to, so that a token for an internal registry never reaches another
+// source. A primary source set by a workspace is never trusted by default,
+// since the workspace file comes from whatever project the user is in.
+func (c *Client) authFor(cfg SourceConfig) *SourceAuth {
+	if cfg.Auth != nil {
+		return cfg.Auth
+	}
+	auth := c.auth
+	if auth == nil && os.Getenv(TokenEnv) != "" {
+		auth = &SourceAuth{TokenEnv: TokenEnv}
+	}
+	if auth == nil {
+		return nil
+	}
+
+	host := urlHost(cfg.URL)
+	hosts := auth.Hosts
+	if len(hosts) == 0 && !c.untrusted {
+		hosts = []string{urlHost(c.Sources()[0].URL)}
+	}
+	for _, h := range hosts {
+		if host != "" && strings.EqualFold(h, host) {
+			return auth
+		}
+	}
+	return nil
+}
+
+// urlHost returns the host, with any port, of a remote source URL, or "" for
+// a local path.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Host
+}
+
+// apply adds the credentials to a request.
+func (a *SourceAuth) apply(req *http.Request) error {
+	for name, value := range a.Headers {
+		req.Header.Set(name, value)
+	}
+
+	token := a.Token
+	if token == "" && a.TokenEnv != "" {
+		token = os.Getenv(a.TokenEnv)
+	}
+	if token == "" && a.Keychain != "" {
+		var err error
+		if token, err = keychainToken(a.Keychain); err != nil {
+			return err
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	username, password := a.Username, a.Password
+	if username == "" && a.Netrc {
+		var err error
+		if username, password, err = netrcLogin(req.URL.Hostname()); err != nil {
+			return err
+		}
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	return nil
+}
+
+// keychainToken reads a token from the macOS keychain or the Secret Service on Linux.
+func keychainToken(service string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", service)
+	default:
+		return "", fmt.Errorf("keychain lookup is not supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("reading keychain entry %q: %w", service, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// netrcLogin looks up the login for host in ~/.netrc (or $NETRC), falling
+// back to the default entry. It returns empty strings if there is none.
+func netrcLogin(host string) (string, string, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("reading netrc: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", fmt.Errorf("reading netrc: %w", err)
+	}
+
+	type entry struct{ login, password string }
+	var match, fallback *entry
+	var current *entry
+
+	for i := 0; i < len(words); i++ {
+		switch words[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(words) {
+				i++
+				if words[i] == host && match == nil {
+					match = &entry{}
+					current = match
+				}
+			}
+		case "default":
+			fallback = &entry{}
+			current = fallback
+		case "login", "password":
+			if i+1 < len(words) {
+				if current != nil {
+					if words[i] == "login" {
+						current.login = words[i+1]
+					} else {
+						current.password = words[i+1]
+					}
+				}
+				i++
+			}
+		}
+	}
+
+	if match == nil {
+		match = fallback
+	}
+	if match == nil {
+		return "", "", nil
+	}
+	return match.login, match.password, nil
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestAuthForScopesDefaultCredentials(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	internal := SourceConfig{URL: "https://registry.internal:8443/", Priority: 1}
+	public := SourceConfig{URL: DefaultSource}
+	own := SourceConfig{URL: "https://partner.example/", Auth: &SourceAuth{Token: "partner"}}
+
+	client, err := NewClient(WithSources([]SourceConfig{public, internal, own}), WithAuth("secret"), WithNoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := client.authFor(internal); auth == nil || auth.Token != "secret" {
+		t.Errorf("primary source gets %+v, want the client's token", auth)
+	}
+	if auth := client.authFor(public); auth != nil {
+		t.Errorf("public source gets %+v, want no credentials", auth)
+	}
+	if auth := client.authFor(SourceConfig{URL: "https://registry.example/"}); auth != nil {
+		t.Errorf("publish target gets %+v, want no credentials", auth)
+	}
+	if auth := client.authFor(own); auth == nil || auth.Token != "partner" {
+		t.Errorf("source with its own credentials gets %+v", auth)
+	}
+
+	// Hosts lists where the default credentials may go instead
+	client.auth = &SourceAuth{Token: "secret", Hosts: []string{"REGISTRY.example"}}
+	if auth := client.authFor(SourceConfig{URL: "https://registry.example/"}); auth == nil {
+		t.Error("listed host gets no credentials")
+	}
+	if auth := client.authFor(internal); auth != nil {
+		t.Errorf("unlisted primary source gets %+v, want no credentials", auth)
+	}
+}
+
+func TestAuthForTokenEnv(t *testing.T) {
+	t.Setenv(TokenEnv, "from-env")
+	client, err := NewClient(WithSources([]SourceConfig{
+		{URL: "https://registry.internal/", Priority: 1},
+		{URL: DefaultSource},
+	}), WithNoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := client.authFor(client.Sources()[0]); auth == nil || auth.TokenEnv != TokenEnv {
+		t.Errorf("primary source gets %+v, want the token from %s", auth, TokenEnv)
+	}
+	if auth := client.authFor(client.Sources()[1]); auth != nil {
+		t.Errorf("secondary source gets %+v, want no credentials", auth)
+	}
+}
+
+func TestSearchSendsTokenToPrimaryOnly(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	serve := func(label string, manifests map[string]*Manifest) *httptest.Server {
+		src, err := NewMemorySource(manifests)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler := RegistryHandler(src)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			seen[label] = r.Header.Get("Authorization")
+			mu.Unlock()
+			handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	internal := serve("internal", map[string]*Manifest{"deploy-ops": {Version: "1.0.0"}})
+	other := serve("other", map[string]*Manifest{"docker-ops": {Version: "1.0.0"}})
+
+	client, err := NewClient(WithSources([]SourceConfig{
+		{URL: internal.URL, Priority: 1},
+		{URL: other.URL},
+	}), WithAuth("secret"), WithNoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Search(context.Background(), "ops", nil); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if seen["internal"] != "Bearer secret" {
+		t.Errorf("primary source got Authorization %q", seen["internal"])
+	}
+	if v, ok := seen["other"]; !ok || v != "" {
+		t.Errorf("other source got Authorization %q (requested: %v), want none", v, ok)
+	}
+}
+
+func TestWorkspaceSourceGetsNoDefaultToken(t *testing.T) {
+	t.Setenv(TokenEnv, "secret-token")
+	t.Setenv(HomeEnv, t.TempDir())
+
+	var mu sync.Mutex
+	var requests int
+	var authorization string
+	src, err := NewMemorySource(map[string]*Manifest{"deploy-ops": {Version: "1.0.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := RegistryHandler(src)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		authorization += r.Header.Get("Authorization")
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, WorkspaceFile), []byte("source: "+server.URL+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	client, err := NewClient(WithNoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Source() != server.URL {
+		t.Fatalf("source is %q, want the workspace's %q", client.Source(), server.URL)
+	}
+	if _, err := client.Search(context.Background(), "ops", nil); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if requests == 0 {
+		t.Fatal("workspace source was never requested")
+	}
+	if authorization != "" {
+		t.Errorf("workspace source got Authorization %q, want none", authorization)
+	}
+
+	// Listing the host opts in
+	client.auth = &SourceAuth{TokenEnv: TokenEnv, Hosts: []string{urlHost(server.URL)}}
+	if auth := client.authFor(client.Sources()[0]); auth == nil {
+		t.Error("listed workspace host gets no credentials")
+	}
+}
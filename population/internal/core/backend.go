@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Registry is a backend a client reads a registry's contents from, in place
+// of a source URL. HTTPSource, LocalSource, and GitSource are the built-in
+// ones; embedders can supply their own, such as a registry kept in a
+// database, with WithRegistry or SourceConfig.Registry. The client caches,
+// verifies, and records what a Registry returns as it does for any source.
+// Content that doesn't exist must be reported with an error wrapping
+// fs.ErrNotExist.
+type Registry interface {
+	// GetIndex returns the index.yaml of a kind, as for a source tree.
+	GetIndex(ctx context.Context, kind ItemKind) ([]byte, error)
+
+	// GetManifest returns the vega.yaml of an item: the current one when
+	// version is empty, else the archived one of that version.
+	GetManifest(ctx context.Context, kind ItemKind, name, version string) ([]byte, error)
+
+	// GetFile returns any other file, such as an item's extra files or a
+	// signature, by its slash-separated path from the registry root.
+	GetFile(ctx context.Context, path string) ([]byte, error)
+}
+
+// indexPath returns the source path of a kind's index.
+func indexPath(kind ItemKind) string {
+	return kind.Plural() + "/index.yaml"
+}
+
+// registryManifestPath returns the source path of an item's manifest, the
+// archived one when version is set.
+func registryManifestPath(kind ItemKind, name, version string) string {
+	if version == "" {
+		return fmt.Sprintf("%s/%s/vega.yaml", kind.Plural(), name)
+	}
+	return versionedManifestPath(kind, name, version)
+}
+
+// readRegistry reads a source path from a Registry, as an index, a
+// manifest, or a plain file according to the path.
+func readRegistry(ctx context.Context, r Registry, p string) ([]byte, error) {
+	parts := strings.Split(p, "/")
+	kind, isKind := kindOfPlural(parts[0])
+	switch {
+	case isKind && len(parts) == 2 && parts[1] == "index.yaml":
+		return r.GetIndex(ctx, kind)
+	case isKind && len(parts) == 3 && parts[2] == "vega.yaml":
+		return r.GetManifest(ctx, kind, parts[1], "")
+	case isKind && len(parts) == 5 && parts[2] == "versions" && parts[4] == "vega.yaml":
+		return r.GetManifest(ctx, kind, parts[1], parts[3])
+	}
+	return r.GetFile(ctx, p)
+}
+
+// RegistryHandler serves a Registry over HTTP as a source tree, so clients,
+// and tools that only take source URLs, can use it by URL.
+func RegistryHandler(r Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		p := strings.TrimPrefix(path.Clean("/"+req.URL.Path), "/")
+		content, err := readRegistry(req.Context(), r, p)
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, req, p, time.Time{}, bytes.NewReader(content))
+	})
+}
+
+// kindOfPlural returns the kind whose directory is plural.
+func kindOfPlural(plural string) (ItemKind, bool) {
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		if kind.Plural() == plural {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// registryName labels a Registry source: its String method if it has one.
+func registryName(r Registry) string {
+	if s, ok := r.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("registry:%T", r)
+}
+
+// newRegistrySource creates a Source reading from a Registry. Its name also
+// stands in for a base URL, so cached content is kept apart from other
+// sources'.
+func newRegistrySource(name string, r Registry, cache *Cache) *Source {
+	return &Source{
+		name:     name,
+		baseURL:  name,
+		cache:    cache,
+		registry: r,
+		http:     defaultHTTPPolicy(),
+	}
+}
+
+// LocalSource is a Registry reading a source tree in a local directory.
+type LocalSource struct {
+	dir string
+}
+
+// NewLocalSource returns a Registry reading the source tree in dir.
+func NewLocalSource(dir string) *LocalSource {
+	return &LocalSource{dir: dir}
+}
+
+// GetIndex implements Registry.
+func (l *LocalSource) GetIndex(ctx context.Context, kind ItemKind) ([]byte, error) {
+	return l.GetFile(ctx, indexPath(kind))
+}
+
+// GetManifest implements Registry.
+func (l *LocalSource) GetManifest(ctx context.Context, kind ItemKind, name, version string) ([]byte, error) {
+	return l.GetFile(ctx, registryManifestPath(kind, name, version))
+}
+
+// GetFile implements Registry.
+func (l *LocalSource) GetFile(_ context.Context, p string) ([]byte, error) {
+	if !fs.ValidPath(p) {
+		return nil, fmt.Errorf("reading %s: invalid path: %w", p, fs.ErrNotExist)
+	}
+	fullPath := filepath.Join(l.dir, filepath.FromSlash(p))
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading local file %s: %w", fullPath, err)
+	}
+	return content, nil
+}
+
+// String returns the directory, which labels the source.
+func (l *LocalSource) String() string {
+	return l.dir
+}
+
+// HTTPSource is a Registry reading a raw HTTP tree, such as a GitHub raw
+// URL or a server run by `vega population serve`. Files read are kept in
+// the cache, to read when offline.
+type HTTPSource struct {
+	source *Source
+}
+
+// NewHTTPSource returns a Registry reading the HTTP tree at baseURL,
+// caching in cache.
+func NewHTTPSource(baseURL string, cache *Cache) *HTTPSource {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &HTTPSource{source: &Source{
+		name:    baseURL,
+		baseURL: baseURL,
+		cache:   cache,
+		http:    defaultHTTPPolicy(),
+	}}
+}
+
+// GetIndex implements Registry.
+func (h *HTTPSource) GetIndex(ctx context.Context, kind ItemKind) ([]byte, error) {
+	return h.GetFile(ctx, indexPath(kind))
+}
+
+// GetManifest implements Registry.
+func (h *HTTPSource) GetManifest(ctx context.Context, kind ItemKind, name, version string) ([]byte, error) {
+	return h.GetFile(ctx, registryManifestPath(kind, name, version))
+}
+
+// GetFile implements Registry.
+func (h *HTTPSource) GetFile(ctx context.Context, p string) ([]byte, error) {
+	return h.source.fetchSource(ctx, p)
+}
+
+// String returns the base URL, which labels the source.
+func (h *HTTPSource) String() string {
+	return h.source.baseURL
+}
+
+// GitSource is a Registry reading a git repository, from a checkout kept in
+// the cache directory and pulled at most once per cache TTL.
+type GitSource struct {
+	source *Source
+}
+
+// NewGitSource returns a Registry reading the git repository at url
+// (git@host:org/repo.git, ssh://..., or https://....git, optionally followed
+// by #branch), checked out under cache's directory.
+func NewGitSource(url string, cache *Cache) *GitSource {
+	return &GitSource{source: &Source{
+		name:    url,
+		baseURL: url,
+		cache:   cache,
+		git:     newGitRepo(url, cache.dir),
+		http:    defaultHTTPPolicy(),
+	}}
+}
+
+// GetIndex implements Registry.
+func (g *GitSource) GetIndex(ctx context.Context, kind ItemKind) ([]byte, error) {
+	return g.GetFile(ctx, indexPath(kind))
+}
+
+// GetManifest implements Registry.
+func (g *GitSource) GetManifest(ctx context.Context, kind ItemKind, name, version string) ([]byte, error) {
+	return g.GetFile(ctx, registryManifestPath(kind, name, version))
+}
+
+// GetFile implements Registry.
+func (g *GitSource) GetFile(ctx context.Context, p string) ([]byte, error) {
+	if !fs.ValidPath(p) {
+		return nil, fmt.Errorf("reading %s: invalid path: %w", p, fs.ErrNotExist)
+	}
+	return g.source.fetchGit(ctx, path.Clean(p))
+}
+
+// String returns the repository URL, which labels the source.
+func (g *GitSource) String() string {
+	return g.source.baseURL
+}
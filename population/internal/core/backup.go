@@ -0,0 +1,457 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A backup is an archive of the vega home's state: the files of the vega
+// home under home/, and those of the install directory, with every installed
+// item and its install record, under install/. Its ChecksumsFile lists the
+// SHA-256 of every other file in it. The cache, temporary files, and daemon
+// state are left out, being rebuilt as needed.
+const (
+	backupHomeDir    = "home"
+	backupInstallDir = "install"
+)
+
+// backupHomeFiles are the files of the vega home a backup keeps.
+var backupHomeFiles = []string{ConfigFile, DefaultDepsFile, LockFile}
+
+// backupInstallFiles are the files of the install directory a backup keeps,
+// besides the items.
+var backupInstallFiles = []string{LayoutFile, PolicyFile, UsageFile}
+
+// BackupReport describes a backup written or restored.
+type BackupReport struct {
+	File  string   `json:"file" yaml:"file"`
+	Items []string `json:"items" yaml:"items"` // Installed items, e.g. "kubernetes-ops" and "@sre"
+	Files int      `json:"files" yaml:"files"` // Files backed up, besides the checksums
+}
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	Force bool // Replace the items already installed rather than failing
+}
+
+// Backup writes the vega home's installed items, install records, config,
+// deps file and lockfile, policy, and usage log to file, a .tar.gz, .tgz, or
+// .tar archive, with their checksums. Items encrypted at rest stay encrypted
+// in the backup.
+func (c *Client) Backup(ctx context.Context, file string) (*BackupReport, error) {
+	format := archiveFormat(file)
+	if format != "tar" && format != "tar.gz" {
+		return nil, fmt.Errorf("%s is not a .tar.gz, .tgz, or .tar file", file)
+	}
+
+	// Archive paths mapped to the files they are read from
+	sources := make(map[string]string)
+	for _, name := range backupHomeFiles {
+		sources[path.Join(backupHomeDir, name)] = filepath.Join(c.home, name)
+	}
+	for _, name := range backupInstallFiles {
+		sources[path.Join(backupInstallDir, name)] = filepath.Join(c.installDir, name)
+	}
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		dir := filepath.Join(c.installDir, c.layout.Dir(kind))
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(c.installDir, p)
+			if err != nil {
+				return err
+			}
+			sources[path.Join(backupInstallDir, filepath.ToSlash(rel))] = p
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", kind.Plural(), err)
+		}
+	}
+
+	report := &BackupReport{File: file, Items: []string{}}
+	var sums bytes.Buffer
+	err := writeTarFile(file, format == "tar.gz", func(tw *tar.Writer) error {
+		for _, name := range sortedKeys(sources) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			content, err := os.ReadFile(sources[name])
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // Optional files the home doesn't have
+			}
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", sources[name], err)
+			}
+			info, err := os.Stat(sources[name])
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", sources[name], err)
+			}
+			if err := writeTarEntry(tw, name, info.Mode().Perm(), content); err != nil {
+				return err
+			}
+			fmt.Fprintf(&sums, "%s  %s\n", contentHash(content), name)
+			report.Files++
+		}
+		return writeTarEntry(tw, ChecksumsFile, 0644, sums.Bytes())
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Items = backupItems(sortedKeys(sources), c.layout)
+	return report, nil
+}
+
+// writeTarFile writes a tar archive, gzipped or not, to file with write,
+// replacing file only once the archive is complete.
+func writeTarFile(file string, gzipped bool, write func(*tar.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", file, err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if gzipped {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	if err := write(tw); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", file, err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", file, err)
+	}
+	if err := os.Rename(f.Name(), file); err != nil {
+		return fmt.Errorf("writing %s: %w", file, err)
+	}
+	return nil
+}
+
+// writeTarEntry writes a regular file to a tar archive.
+func writeTarEntry(tw *tar.Writer, name string, mode fs.FileMode, content []byte) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(mode),
+		Size:     int64(len(content)),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// backupItems returns the names of the items among a backup's paths, in the
+// layout of its install directory.
+func backupItems(paths []string, layout Layout) []string {
+	items := []string{}
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		prefix := path.Join(backupInstallDir, layout.Dir(kind)) + "/"
+		for _, p := range paths {
+			rest, ok := strings.CutPrefix(p, prefix)
+			if name, file, _ := strings.Cut(rest, "/"); ok && file == "vega.yaml" {
+				items = append(items, FormatItemName(kind, name))
+			}
+		}
+	}
+	return items
+}
+
+// Restore replaces the vega home's state with a backup written by Backup,
+// after extracting it to a temporary directory and checking every file
+// against the backup's checksums, so a damaged backup changes nothing. The
+// backup is then staged next to the files it replaces and swapped in, the
+// old files moved aside until the swap is done, so a failure partway puts
+// them back. The installed items are replaced as a whole; restoring over
+// installed items fails unless opts.Force is set.
+func (c *Client) Restore(ctx context.Context, file string, opts *RestoreOptions) (*BackupReport, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+
+	stage, err := newTempDir(c.tempDir, "restore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stage)
+	extracted := filepath.Join(stage, "backup")
+	if err := ExtractArchive(ctx, file, extracted); err != nil {
+		return nil, fmt.Errorf("extracting %s: %w", file, err)
+	}
+
+	paths, err := verifyBackup(extracted)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	layout, err := LoadLayout(filepath.Join(extracted, backupInstallDir))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := checkBackupPaths(paths, layout); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	installed, err := c.List("")
+	if err != nil {
+		return nil, err
+	}
+	if len(installed) > 0 && !opts.Force {
+		return nil, fmt.Errorf("%s already has %d installed item(s) (use --force to replace them)", c.installDir, len(installed))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Files the backup lacks, which the home didn't have when it was backed
+	// up, are removed
+	swap := &restoreSwap{stages: make(map[string]string)}
+	defer swap.cleanup()
+	for _, name := range backupHomeFiles {
+		if err := swap.stage(c.home, name, filepath.Join(extracted, backupHomeDir, name)); err != nil {
+			return nil, err
+		}
+	}
+	names := append([]string(nil), backupInstallFiles...)
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		// The current layout's item directories go too, if the backup's differs
+		names = append(names, layout.Dir(kind), c.layout.Dir(kind))
+	}
+	for _, name := range names {
+		if err := swap.stage(c.installDir, name, filepath.Join(extracted, backupInstallDir, name)); err != nil {
+			return nil, err
+		}
+	}
+	if err := swap.swap(); err != nil {
+		return nil, err
+	}
+	if !c.layoutSet {
+		c.layout = layout
+	}
+	c.recordChange(ctx, "Restore from "+filepath.Base(file))
+
+	return &BackupReport{File: file, Items: backupItems(paths, layout), Files: len(paths)}, nil
+}
+
+// verifyBackup checks the extracted backup in dir against its checksums,
+// and returns the paths of its files but the checksums, sorted.
+func verifyBackup(dir string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ChecksumsFile))
+	if err != nil {
+		return nil, fmt.Errorf("not a vega backup: reading %s: %w", ChecksumsFile, err)
+	}
+	sums, err := parseChecksums(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !d.Type().IsRegular() {
+			// Backup writes only regular files; a link could point anywhere once restored
+			return fmt.Errorf("backup entry %s is not a regular file", rel)
+		}
+		if rel == ChecksumsFile {
+			return nil
+		}
+		want, ok := sums[rel]
+		if !ok {
+			return fmt.Errorf("backup has %s, which its checksums don't list", rel)
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if got := contentHash(content); got != want {
+			return fmt.Errorf("checksum mismatch for %s: the backup lists %s but the file is %s", rel, want, got)
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) != len(sums) {
+		for name := range sums {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				return nil, fmt.Errorf("backup is missing %s, which its checksums list", name)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// checkBackupPaths checks that a backup holds only what Backup writes, so
+// restoring it can't put files anywhere else in the vega home.
+func checkBackupPaths(paths []string, layout Layout) error {
+	allowed := make(map[string]bool)
+	for _, name := range backupHomeFiles {
+		allowed[path.Join(backupHomeDir, name)] = true
+	}
+	for _, name := range backupInstallFiles {
+		allowed[path.Join(backupInstallDir, name)] = true
+	}
+	var dirs []string
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		dirs = append(dirs, path.Join(backupInstallDir, layout.Dir(kind))+"/")
+	}
+	for _, p := range paths {
+		if allowed[p] {
+			continue
+		}
+		inItems := false
+		for _, dir := range dirs {
+			inItems = inItems || strings.HasPrefix(p, dir)
+		}
+		if !inItems {
+			return fmt.Errorf("unexpected file %s in backup", p)
+		}
+	}
+	return nil
+}
+
+// restoreSwap replaces paths with new content staged next to them, so that
+// swapping it in takes only renames, and moves the old paths aside until
+// done so the swap can be undone.
+type restoreSwap struct {
+	stages map[string]string // Staging directory by the directory it stages for
+	moves  []restoreMove
+}
+
+// restoreMove is one path a restoreSwap replaces.
+type restoreMove struct {
+	dst    string // The path replaced
+	staged string // Its new content, or "" to remove it
+	old    string // Where it is moved aside
+	moved  bool   // dst was moved aside
+	placed bool   // staged was moved to dst
+}
+
+// stage moves src, if it exists, next to root/name to replace it; otherwise
+// root/name is to be removed. Staging a path again does nothing.
+func (s *restoreSwap) stage(root, name, src string) error {
+	dst := filepath.Join(root, filepath.FromSlash(name))
+	for _, m := range s.moves {
+		if m.dst == dst {
+			return nil
+		}
+	}
+	dir, ok := s.stages[root]
+	if !ok {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", root, err)
+		}
+		var err error
+		if dir, err = os.MkdirTemp(root, ".restore-"); err != nil {
+			return fmt.Errorf("staging restore: %w", err)
+		}
+		s.stages[root] = dir
+	}
+
+	m := restoreMove{dst: dst, old: filepath.Join(dir, "old", filepath.FromSlash(name))}
+	if _, err := os.Lstat(src); err == nil {
+		m.staged = filepath.Join(dir, "new", filepath.FromSlash(name))
+		if err := moveDir(src, m.staged); err != nil {
+			return fmt.Errorf("staging %s: %w", name, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	s.moves = append(s.moves, m)
+	return nil
+}
+
+// swap moves every staged path into place, undoing what it did on failure.
+func (s *restoreSwap) swap() error {
+	for i := range s.moves {
+		if err := s.moves[i].swap(); err != nil {
+			s.undo()
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *restoreMove) swap() error {
+	if _, err := os.Lstat(m.dst); err == nil {
+		if err := os.MkdirAll(filepath.Dir(m.old), 0755); err != nil {
+			return fmt.Errorf("moving %s aside: %w", m.dst, err)
+		}
+		if err := os.Rename(m.dst, m.old); err != nil {
+			return fmt.Errorf("moving %s aside: %w", m.dst, err)
+		}
+		m.moved = true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if m.staged != "" {
+		if err := os.MkdirAll(filepath.Dir(m.dst), 0755); err != nil {
+			return fmt.Errorf("restoring %s: %w", m.dst, err)
+		}
+		if err := os.Rename(m.staged, m.dst); err != nil {
+			return fmt.Errorf("restoring %s: %w", m.dst, err)
+		}
+		m.placed = true
+	}
+	return nil
+}
+
+// undo puts back the paths swap replaced, in reverse order.
+func (s *restoreSwap) undo() {
+	for i := len(s.moves) - 1; i >= 0; i-- {
+		m := &s.moves[i]
+		if m.placed {
+			os.Rename(m.dst, m.staged)
+		}
+		if m.moved {
+			os.Rename(m.old, m.dst)
+		}
+	}
+}
+
+// cleanup removes the staging directories, with the old paths in them.
+func (s *restoreSwap) cleanup() {
+	for _, dir := range s.stages {
+		os.RemoveAll(dir)
+	}
+}
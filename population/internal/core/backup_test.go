@@ -0,0 +1,135 @@
+package core
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	client, _ := newMemoryClient(t, map[string]*Manifest{
+		"kubernetes-ops": {Version: "1.2.0"},
+		"docker-ops":     {Version: "1.0.0"},
+		"@sre":           {Version: "2.0.0"},
+	})
+	ctx := context.Background()
+	for _, name := range []string{"kubernetes-ops", "@sre"} {
+		if err := client.Install(ctx, name, nil); err != nil {
+			t.Fatalf("Install %s: %v", name, err)
+		}
+	}
+	want := installedNames(t, client)
+
+	file := filepath.Join(t.TempDir(), "home.tar.gz")
+	if _, err := client.Backup(ctx, file); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+
+	if err := client.Uninstall("@sre"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Install(ctx, "docker-ops", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Restore(ctx, file, nil); err == nil {
+		t.Fatal("Restore over installed items succeeded without Force")
+	}
+
+	report, err := client.Restore(ctx, file, &RestoreOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := installedNames(t, client); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(report.Items, []string{"kubernetes-ops", "@sre"}) {
+		t.Errorf("report lists %v", report.Items)
+	}
+
+	// The staging directories are gone
+	entries, err := filepath.Glob(filepath.Join(client.InstallDir(), ".restore-*"))
+	if err != nil || len(entries) > 0 {
+		t.Errorf("left staging directories behind: %v", entries)
+	}
+}
+
+func TestRestoreRejectsSymlinks(t *testing.T) {
+	client, _ := newMemoryClient(t, map[string]*Manifest{"kubernetes-ops": {Version: "1.2.0"}})
+
+	// A link whose target stays inside the archive gets past extraction, and
+	// reads as the file it points at
+	file := filepath.Join(t.TempDir(), "evil.tar.gz")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	usage := "version: 1.0.0\n"
+	sums := contentHash([]byte(usage)) + "  install/" + UsageFile + "\n" +
+		contentHash([]byte(usage)) + "  install/skills/evil/vega.yaml\n"
+	for _, entry := range []struct {
+		hdr     tar.Header
+		content string
+	}{
+		{tar.Header{Name: ChecksumsFile, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(sums))}, sums},
+		{tar.Header{Name: "install/" + UsageFile, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(usage))}, usage},
+		{tar.Header{Name: "install/skills/evil/vega.yaml", Typeflag: tar.TypeSymlink, Linkname: "../../" + UsageFile}, ""},
+	} {
+		if err := tw.WriteHeader(&entry.hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	_, err = client.Restore(context.Background(), file, nil)
+	if err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Fatalf("Restore = %v, want an error for the symlink", err)
+	}
+	if _, err := os.Lstat(filepath.Join(client.InstallDir(), "skills", "evil")); !os.IsNotExist(err) {
+		t.Errorf("restore left skills/evil behind: %v", err)
+	}
+}
+
+func TestRestoreSwapUndo(t *testing.T) {
+	root := t.TempDir()
+	src := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("old "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(src, name), []byte("new "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	swap := &restoreSwap{stages: make(map[string]string)}
+	defer swap.cleanup()
+	for _, name := range []string{"a", "b"} {
+		if err := swap.stage(root, name, filepath.Join(src, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Fail the second rename, after the first has been swapped in
+	os.Remove(swap.moves[1].staged)
+	if err := swap.swap(); err == nil {
+		t.Fatal("swap succeeded without the staged file")
+	}
+
+	for _, name := range []string{"a", "b"} {
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil || string(content) != "old "+name {
+			t.Errorf("%s = %q, %v after undo; want the old content", name, content, err)
+		}
+	}
+}
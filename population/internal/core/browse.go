@@ -0,0 +1,353 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// browser is the state of the browse screen: every item of the sources, the
+// filter typed so far, the items it matches, and the one selected.
+type browser struct {
+	ctx       context.Context
+	client    *Client
+	items     []SearchResult
+	filter    string
+	shown     []SearchResult
+	selected  int
+	top       int               // First item of shown on screen
+	manifests map[string]string // Fetched manifests, by item name
+}
+
+func (cl *cli) runBrowse(args []string) error {
+	fs := cl.flagSet("browse")
+	kindFlag := fs.String("kind", "", "Only browse one kind (skill, persona, profile, settings)")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	noCacheFlag := fs.Bool("no-cache", false, "Disable caching")
+	offlineFlag := fs.Bool("offline", false, "Use only the cache and installed items, never the network")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("browse takes at most one starting filter")
+	}
+	if *offlineFlag && *noCacheFlag {
+		return fmt.Errorf("--offline reads from the cache and can't be used with --no-cache")
+	}
+	if !cl.interactive() || !isTerminal(cl.stdout) {
+		return fmt.Errorf("browse needs a terminal; use search to list items from scripts")
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *noCacheFlag {
+		opts = append(opts, WithNoCache())
+	}
+	if *offlineFlag {
+		opts = append(opts, WithOffline())
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	// An empty query matches every item, in name order
+	items, err := client.Search(cl.ctx, "", &SearchOptions{Kind: ItemKind(*kindFlag)})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(cl.stdout, "No items to browse")
+		return nil
+	}
+
+	b := &browser{ctx: cl.ctx, client: client, items: items, filter: fs.Arg(0), manifests: make(map[string]string)}
+	b.applyFilter()
+	choice, err := cl.browse(b)
+	if err != nil || choice == nil {
+		return err
+	}
+
+	name := FormatItemName(choice.Kind, choice.Name)
+	if err := client.Install(cl.ctx, name, &InstallOptions{Progress: cl.stdout}); err != nil {
+		return err
+	}
+	fmt.Fprintf(cl.stdout, "Successfully installed %s to %s\n", name, client.itemDir(choice.Kind, choice.Name))
+	return nil
+}
+
+// browse runs the browse screen until the user picks an item to install,
+// returned, or quits, returning nil. The terminal is in raw mode meanwhile,
+// on its alternate screen, and restored however browse returns.
+func (cl *cli) browse(b *browser) (*SearchResult, error) {
+	tty := cl.stdin.(*os.File)
+	restore, err := rawMode(tty)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(cl.stdout, "\x1b[?1049h")
+	defer func() {
+		fmt.Fprint(cl.stdout, "\x1b[?1049l")
+		restore()
+	}()
+
+	buf := make([]byte, 64)
+	for {
+		rows, cols := terminalSize(tty)
+		b.draw(cl.stdout, rows, cols)
+
+		n, err := tty.Read(buf)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading key: %w", err)
+		}
+		if cl.ctx.Err() != nil {
+			return nil, cl.ctx.Err()
+		}
+
+		page := max(rows/2-3, 1)
+		for keys := buf[:n]; len(keys) > 0; {
+			key, size := readKey(keys)
+			keys = keys[size:]
+			switch key {
+			case "esc", "ctrl-c", "ctrl-d":
+				return nil, nil
+			case "enter":
+				if len(b.shown) == 0 {
+					continue
+				}
+				choice := b.shown[b.selected]
+				return &choice, nil
+			case "up", "ctrl-p":
+				b.move(-1)
+			case "down", "ctrl-n":
+				b.move(1)
+			case "pgup":
+				b.move(-page)
+			case "pgdown":
+				b.move(page)
+			case "backspace":
+				if b.filter != "" {
+					_, last := utf8.DecodeLastRuneInString(b.filter)
+					b.filter = b.filter[:len(b.filter)-last]
+					b.applyFilter()
+				}
+			case "ctrl-u":
+				b.filter = ""
+				b.applyFilter()
+			default:
+				if r, _ := utf8.DecodeRuneInString(key); utf8.RuneCountInString(key) == 1 && unicode.IsPrint(r) {
+					b.filter += key
+					b.applyFilter()
+				}
+			}
+		}
+	}
+}
+
+// applyFilter narrows the items to those whose name the filter fuzzily
+// matches (see fuzzyMatch), or whose description or tags contain each of
+// its words, and selects the first.
+func (b *browser) applyFilter() {
+	b.shown = b.shown[:0]
+	words := strings.Fields(strings.ToLower(b.filter))
+	for _, item := range b.items {
+		if fuzzyMatch(b.filter, FormatItemName(item.Kind, item.Name)) {
+			b.shown = append(b.shown, item)
+			continue
+		}
+		text := strings.ToLower(item.Description + " " + strings.Join(item.Tags, " "))
+		matched := len(words) > 0
+		for _, word := range words {
+			matched = matched && strings.Contains(text, word)
+		}
+		if matched {
+			b.shown = append(b.shown, item)
+		}
+	}
+	b.selected, b.top = 0, 0
+}
+
+// move moves the selection by delta items, within the shown items.
+func (b *browser) move(delta int) {
+	b.selected = max(0, min(b.selected+delta, len(b.shown)-1))
+}
+
+// manifest returns the manifest of an item for the detail pane, fetching
+// it the first time. A manifest that can't be fetched shows why instead.
+func (b *browser) manifest(item SearchResult) string {
+	name := FormatItemName(item.Kind, item.Name)
+	if m, ok := b.manifests[name]; ok {
+		return m
+	}
+	var text string
+	source, err := b.client.resolveSource(b.ctx, item.Kind, item.Name)
+	if err == nil {
+		var content []byte
+		if content, err = source.GetManifestRaw(b.ctx, item.Kind, item.Name); err == nil {
+			text = string(content)
+		}
+	}
+	if err != nil {
+		text = fmt.Sprintf("Can't show the manifest of %s: %v", name, err)
+	}
+	b.manifests[name] = text
+	return text
+}
+
+// draw redraws the screen: the filter, the shown items with the selected
+// one highlighted in the top half, and its manifest below.
+func (b *browser) draw(w io.Writer, rows, cols int) {
+	var screen bytes.Buffer
+	line := func(format string, args ...interface{}) {
+		screen.WriteString(truncate(fmt.Sprintf(format, args...), cols) + "\x1b[K\r\n")
+	}
+	screen.WriteString("\x1b[H")
+
+	listRows := max(rows/2-2, 1)
+	if b.selected < b.top {
+		b.top = b.selected
+	} else if b.selected >= b.top+listRows {
+		b.top = b.selected - listRows + 1
+	}
+
+	line("Filter: %s", b.filter)
+	for i := b.top; i < b.top+listRows; i++ {
+		if i >= len(b.shown) {
+			line("")
+			continue
+		}
+		item := b.shown[i]
+		entry := fmt.Sprintf("  %-30s  %s", FormatItemName(item.Kind, item.Name), item.Description)
+		if i == b.selected {
+			screen.WriteString("\x1b[7m" + truncate(entry, cols) + "\x1b[0m\x1b[K\r\n")
+			continue
+		}
+		line("%s", entry)
+	}
+	line("%d of %d items - up/down to move, Enter to install, Esc to quit", len(b.shown), len(b.items))
+
+	detailRows := rows - listRows - 2
+	if len(b.shown) > 0 {
+		item := b.shown[b.selected]
+		detail := strings.Split(strings.TrimRight(b.manifest(item), "\n"), "\n")
+		for i := 0; i < detailRows-1; i++ {
+			if i < len(detail) {
+				line("%s", strings.ReplaceAll(detail[i], "\t", "    "))
+			} else {
+				line("")
+			}
+		}
+	}
+	screen.WriteString("\x1b[J")
+	// Leave the cursor after the filter
+	screen.WriteString(fmt.Sprintf("\x1b[1;%dH", len("Filter: ")+utf8.RuneCountInString(b.filter)+1))
+	w.Write(screen.Bytes())
+}
+
+// truncate shortens s to fit in width columns.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+// readKey returns the name of the first key in input, as "up" or "enter",
+// or the character typed, and how many bytes it took.
+func readKey(input []byte) (string, int) {
+	switch input[0] {
+	case 3:
+		return "ctrl-c", 1
+	case 4:
+		return "ctrl-d", 1
+	case 14:
+		return "ctrl-n", 1
+	case 16:
+		return "ctrl-p", 1
+	case 21:
+		return "ctrl-u", 1
+	case '\r', '\n':
+		return "enter", 1
+	case 8, 127:
+		return "backspace", 1
+	case 27:
+		if len(input) >= 3 && (input[1] == '[' || input[1] == 'O') {
+			switch input[2] {
+			case 'A':
+				return "up", 3
+			case 'B':
+				return "down", 3
+			case '5', '6':
+				if len(input) >= 4 && input[3] == '~' {
+					if input[2] == '5' {
+						return "pgup", 4
+					}
+					return "pgdown", 4
+				}
+			}
+			return "", len(input) // Other keys are ignored
+		}
+		return "esc", 1
+	}
+	_, size := utf8.DecodeRune(input)
+	return string(input[:size]), size
+}
+
+// rawMode puts the terminal into raw mode with stty, so keys are read as
+// they are pressed, and returns a function restoring its settings.
+func rawMode(tty *os.File) (func(), error) {
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("reading terminal settings: %w", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("setting raw mode: %w", err)
+	}
+	return func() { stty(tty, strings.TrimSpace(saved)) }, nil
+}
+
+// terminalSize returns the rows and columns of the terminal, or 24 by 80 if
+// they can't be read.
+func terminalSize(tty *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err == nil {
+		if fields := strings.Fields(out); len(fields) == 2 {
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return rows, cols
+			}
+		}
+	}
+	return 24, 80
+}
+
+// stty runs stty on the terminal and returns its output.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
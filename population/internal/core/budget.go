@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// BudgetReport is the projected spend of a set of agents deployed together,
+// checked against the organization's caps.
+type BudgetReport struct {
+	Env      string        `json:"env,omitempty" yaml:"env,omitempty"`
+	Agents   []AgentBudget `json:"agents" yaml:"agents"`
+	Total    string        `json:"total" yaml:"total"`
+	AgentCap string        `json:"agent_cap,omitempty" yaml:"agent_cap,omitempty"` // The settings' max_budget
+	TotalCap string        `json:"total_cap,omitempty" yaml:"total_cap,omitempty"` // The settings' max_total_budget, or the cap requested
+	OverCap  bool          `json:"over_cap" yaml:"over_cap"`                       // An agent or the total exceeds its cap
+}
+
+// AgentBudget is the budget one persona or profile would be exported with.
+type AgentBudget struct {
+	Item    string   `json:"item" yaml:"item"` // e.g. "+platform-engineer"
+	Model   string   `json:"model" yaml:"model"`
+	Budget  string   `json:"budget" yaml:"budget"`
+	From    string   `json:"from" yaml:"from"` // Where the budget comes from: requested, profile, persona, settings, or default
+	Skills  []string `json:"skills,omitempty" yaml:"skills,omitempty"`
+	OverCap bool     `json:"over_cap" yaml:"over_cap"`
+}
+
+// BudgetOptions configures Budget. Empty fields fall back to the manifests,
+// installed settings, and export defaults, as for AgentOptions.
+type BudgetOptions struct {
+	Env    string // Deployment environment for profiles' conditional skills
+	Model  string // Model or model alias every agent would use
+	Budget string // Budget every agent would be given
+	Cap    string // Cap on the total instead of the settings' max_total_budget
+}
+
+// Budget projects the spend of personas and profiles deployed together: the
+// model and budget each would be exported with in opts.Env, as Agent resolves
+// them, and their total. Budgets over the settings'
+// max_budget, and a total over max_total_budget or opts.Cap, are reported as
+// over the cap rather than failing, so the whole set can be reviewed.
+func (c *Client) Budget(ctx context.Context, names []string, opts *BudgetOptions) (*BudgetReport, error) {
+	if opts == nil {
+		opts = &BudgetOptions{}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no personas or profiles to budget")
+	}
+
+	settings, err := c.Settings()
+	if err != nil {
+		return nil, err
+	}
+	report := &BudgetReport{
+		Env:      opts.Env,
+		Agents:   []AgentBudget{},
+		AgentCap: settings.MaxBudget,
+		TotalCap: settings.MaxTotalBudget,
+	}
+	if opts.Cap != "" {
+		report.TotalCap = opts.Cap
+	}
+	var agentCap, totalCap float64
+	if report.AgentCap != "" {
+		if agentCap, err = parseBudget(report.AgentCap); err != nil {
+			return nil, err
+		}
+	}
+	if report.TotalCap != "" {
+		if totalCap, err = parseBudget(report.TotalCap); err != nil {
+			return nil, err
+		}
+	}
+
+	var total float64
+	for _, name := range names {
+		agent, err := c.agentBudget(ctx, name, settings, opts)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseBudget(agent.Budget)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", agent.Item, err)
+		}
+		if report.AgentCap != "" && amount > agentCap {
+			agent.OverCap = true
+			report.OverCap = true
+		}
+		report.Agents = append(report.Agents, *agent)
+		total += amount
+	}
+	report.Total = formatBudget(total)
+	if report.TotalCap != "" && total > totalCap {
+		report.OverCap = true
+	}
+	return report, nil
+}
+
+// agentBudget resolves the model and budget a persona or profile would be
+// exported with, without rendering its prompt.
+func (c *Client) agentBudget(ctx context.Context, name string, settings *Settings, opts *BudgetOptions) (*AgentBudget, error) {
+	kind, itemName := ParseItemName(name)
+	if kind != KindPersona && kind != KindProfile {
+		return nil, fmt.Errorf("only personas and profiles have budgets (use @name or +name format): %s", name)
+	}
+
+	manifest, profile, err := c.agentManifests(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	agent := &AgentBudget{
+		Item:   FormatItemName(kind, itemName),
+		Model:  settings.Model(opts.Model, defaultExportModel),
+		Skills: agentSkillNames(manifest, profile, opts.Env),
+	}
+
+	switch agent.Budget = declaredBudget(opts.Budget, manifest, profile); {
+	case opts.Budget != "":
+		agent.From = "requested"
+	case profile != nil && profile.Budget != "":
+		agent.From = "profile"
+	case manifest.Budget != "":
+		agent.From = "persona"
+	case settings.DefaultBudget != "":
+		agent.Budget, agent.From = settings.DefaultBudget, "settings"
+	default:
+		agent.Budget, agent.From = defaultExportBudget, "default"
+	}
+	return agent, nil
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangelogEntry is one entry in a manifest's changelog.
+type ChangelogEntry struct {
+	Version string `json:"version" yaml:"version"`
+	Date    string `json:"date,omitempty" yaml:"date,omitempty"`
+	Changes string `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// BumpOptions configures a version bump.
+type BumpOptions struct {
+	Part   string // BumpMajor, BumpMinor, or BumpPatch (default)
+	Reason string // Changelog text for the new version (optional)
+	DryRun bool   // Report the new version without writing anything
+}
+
+// BumpResult describes a version bump.
+type BumpResult struct {
+	Path         string
+	OldVersion   string
+	NewVersion   string
+	IndexUpdated bool // The manifest lives in a registry checkout whose index was updated
+}
+
+// BumpManifest bumps the version of the manifest at path (a vega.yaml file or
+// the directory containing it), appends a changelog entry, and, when the
+// manifest lives in a registry checkout, updates its index entry too.
+func BumpManifest(path string, opts *BumpOptions) (*BumpResult, error) {
+	if opts == nil {
+		opts = &BumpOptions{}
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "vega.yaml")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if isEncrypted(content) {
+		return nil, fmt.Errorf("%s: %w; bump a registry checkout instead", path, ErrEncrypted)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	newVersion, err := BumpVersion(manifest.Version, opts.Part)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BumpResult{
+		Path:       path,
+		OldVersion: manifest.Version,
+		NewVersion: newVersion,
+	}
+
+	registry, kind, inRegistry := registryForManifest(path)
+	if opts.DryRun {
+		result.IndexUpdated = inRegistry
+		return result, nil
+	}
+
+	updated, err := bumpContent(content, newVersion, opts.Reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	if inRegistry {
+		manifest.Version = newVersion
+		if err := registry.UpdateIndex(kind, &manifest); err != nil {
+			return nil, err
+		}
+		result.IndexUpdated = true
+	}
+
+	return result, nil
+}
+
+// bumpContent sets the version of raw manifest content and appends a changelog
+// entry for it, leaving the rest of the file untouched.
+func bumpContent(content []byte, version, reason string, now time.Time) ([]byte, error) {
+	updated, err := setManifestVersion(content, version)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := ChangelogEntry{
+		Version: version,
+		Date:    now.Format("2006-01-02"),
+		Changes: reason,
+	}
+	return appendChangelog(updated, entry)
+}
+
+// appendChangelog adds an entry to the end of the top-level changelog list,
+// creating the list if the manifest doesn't have one yet.
+func appendChangelog(content []byte, entry ChangelogEntry) ([]byte, error) {
+	rendered, err := yaml.Marshal([]ChangelogEntry{entry})
+	if err != nil {
+		return nil, fmt.Errorf("encoding changelog entry: %w", err)
+	}
+
+	var entryLines []string
+	for _, line := range strings.Split(strings.TrimRight(string(rendered), "\n"), "\n") {
+		entryLines = append(entryLines, "  "+line)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if line == "changelog:" {
+			start = i
+			break
+		}
+	}
+
+	if start < 0 {
+		lines = append(lines, "", "changelog:")
+		lines = append(lines, entryLines...)
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	}
+
+	// The changelog ends at the next top-level key
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if lines[i] != "" && !strings.HasPrefix(lines[i], " ") && !strings.HasPrefix(lines[i], "-") && !strings.HasPrefix(lines[i], "#") {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	lines = append(lines[:end], append(entryLines, lines[end:]...)...)
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// registryForManifest reports whether a manifest path is laid out as
+// <registry>/<kind plural>/<name>/vega.yaml inside a registry checkout.
+func registryForManifest(path string) (*LocalRegistry, ItemKind, bool) {
+	itemDir := filepath.Dir(path)
+	kindDir := filepath.Dir(itemDir)
+
+	var kind ItemKind
+	for _, k := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		if filepath.Base(kindDir) == k.Plural() {
+			kind = k
+		}
+	}
+	if kind == "" {
+		return nil, "", false
+	}
+
+	registry, err := OpenLocalRegistry(filepath.Dir(kindDir))
+	if err != nil {
+		return nil, "", false
+	}
+	return registry, kind, true
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BundleFile is the file describing a bundle, at its root.
+const BundleFile = "bundle.yaml"
+
+// Bundle is a self-contained export of a persona or profile: the rendered
+// agent, its system prompt, and every item it is made of with their files,
+// so a runtime can run the agent without a vega home.
+type Bundle struct {
+	Name    string       `json:"name" yaml:"name"` // The exported item, e.g. "+sre-oncall"
+	Created time.Time    `json:"created" yaml:"created"`
+	Format  string       `json:"format" yaml:"format"` // Export format, or the template's file name
+	Agent   string       `json:"agent" yaml:"agent"`   // Rendered agent config, relative to the bundle
+	Prompt  string       `json:"prompt" yaml:"prompt"` // Rendered system prompt, relative to the bundle
+	Items   []BundleItem `json:"items" yaml:"items"`
+}
+
+// BundleItem is an item copied into a bundle.
+type BundleItem struct {
+	Kind    ItemKind          `json:"kind" yaml:"kind"`
+	Name    string            `json:"name" yaml:"name"`
+	Version string            `json:"version" yaml:"version"`
+	Path    string            `json:"path" yaml:"path"`                       // Item directory, relative to the bundle
+	SHA256  string            `json:"sha256" yaml:"sha256"`                   // Of its vega.yaml
+	Files   map[string]string `json:"files,omitempty" yaml:"files,omitempty"` // Hashes of its extra files by path
+}
+
+// ExportBundle exports a persona or profile into dir as a bundle. The bundle
+// is staged first, so dir is only created once it is complete; dir must not
+// exist or be empty.
+func (c *Client) ExportBundle(ctx context.Context, name, dir string, opts *ExportOptions) (*Bundle, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("%s is not empty", dir)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	tmpl, err := exportTemplate(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.exportData(ctx, name, &opts.AgentOptions)
+	if err != nil {
+		return nil, err
+	}
+	var agent bytes.Buffer
+	if err := tmpl.Execute(&agent, data); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", tmpl.Name(), err)
+	}
+
+	staging, err := newTempDir(c.tempDir, "bundle")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	kind, itemName := ParseItemName(name)
+	bundle := &Bundle{
+		Name:    FormatItemName(kind, itemName),
+		Created: time.Now().UTC(),
+		Format:  tmpl.Name(),
+		Agent:   "agent" + exportExtension(tmpl.Name()),
+		Prompt:  "system.md",
+		Items:   []BundleItem{},
+	}
+	if err := os.WriteFile(filepath.Join(staging, bundle.Agent), agent.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("writing agent: %w", err)
+	}
+	prompt := strings.TrimRight(data.Agent.System, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(staging, bundle.Prompt), []byte(prompt), 0644); err != nil {
+		return nil, fmt.Errorf("writing prompt: %w", err)
+	}
+
+	// The items the agent was rendered from, with their files
+	type part struct {
+		kind     ItemKind
+		manifest *Manifest
+	}
+	parts := []part{{KindProfile, data.Profile}, {KindPersona, data.Persona}}
+	for _, skill := range data.Skills {
+		parts = append(parts, part{KindSkill, skill})
+	}
+	for _, p := range parts {
+		if p.manifest == nil {
+			continue
+		}
+		item, err := c.bundleItem(ctx, staging, p.kind, p.manifest)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Items = append(bundle.Items, *item)
+	}
+
+	var content bytes.Buffer
+	if err := writeOutput(&content, OutputYAML, bundle); err != nil {
+		return nil, fmt.Errorf("encoding bundle: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, BundleFile), content.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("writing bundle: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := moveDir(staging, dir); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// bundleItem copies an item's manifest and files under items/ in a bundle.
+func (c *Client) bundleItem(ctx context.Context, bundleDir string, kind ItemKind, m *Manifest) (*BundleItem, error) {
+	name := m.Name
+	if err := checkItemName(name); err != nil {
+		return nil, err
+	}
+	source, err := c.resolveSource(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	content, err := source.GetManifestRaw(ctx, kind, name)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s %q: %w", kind, name, err)
+	}
+	files, err := source.fetchManifestFiles(ctx, kind, name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &BundleItem{
+		Kind:    kind,
+		Name:    name,
+		Version: m.Version,
+		Path:    "items/" + kind.Plural() + "/" + name,
+		SHA256:  contentHash(content),
+	}
+
+	dir := filepath.Join(bundleDir, filepath.FromSlash(item.Path))
+	if err := writeDeployed(filepath.Join(dir, "vega.yaml"), content); err != nil {
+		return nil, err
+	}
+	for rel, file := range files {
+		if err := writeDeployed(filepath.Join(dir, filepath.FromSlash(rel)), file); err != nil {
+			return nil, err
+		}
+		if item.Files == nil {
+			item.Files = make(map[string]string)
+		}
+		item.Files[rel] = contentHash(file)
+	}
+	return item, nil
+}
+
+// exportExtension returns the file extension for an export format, or for
+// a template named like "agent.json.tmpl".
+func exportExtension(format string) string {
+	switch format {
+	case "claude-code":
+		return ".md"
+	case "openai-assistants":
+		return ".json"
+	case "tron", "crewai":
+		return ".yaml"
+	}
+	if ext := filepath.Ext(strings.TrimSuffix(format, filepath.Ext(format))); ext != "" {
+		return ext
+	}
+	return ".txt"
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// CacheTTL is the default cache time-to-live for index files.
+	CacheTTL = 1 * time.Hour
+
+	// validatorsSuffix names the file holding a cached file's validators.
+	validatorsSuffix = ".validators"
+)
+
+// Validators are the HTTP validators a server sent with a file, used to
+// revalidate a cached copy with a conditional request once it expires.
+type Validators struct {
+	ETag         string `yaml:"etag,omitempty"`
+	LastModified string `yaml:"last_modified,omitempty"`
+}
+
+// IsZero reports whether there are no validators.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// Cache handles local caching of index files.
+type Cache struct {
+	dir      string
+	disabled bool
+	ttl      time.Duration
+	cipher   *contentCipher // Encrypts cached files (nil = plain)
+	logger   *slog.Logger   // Diagnostics (nil = warnings to stderr)
+}
+
+// NewCache creates a new Cache instance.
+func NewCache(dir string, disabled bool) *Cache {
+	return &Cache{
+		dir:      dir,
+		disabled: disabled,
+		ttl:      CacheTTL,
+	}
+}
+
+// Get retrieves a cached file if it exists and is not expired.
+// Returns the content and true if the cache is valid, nil and false otherwise.
+// A file that can't be decrypted counts as missing.
+func (c *Cache) Get(name string) ([]byte, bool) {
+	if c.disabled {
+		return nil, false
+	}
+
+	path := filepath.Join(c.dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		c.log().Debug("cache miss", "name", name)
+		return nil, false
+	}
+
+	// Check if cache is expired
+	if age := time.Since(info.ModTime()); age > c.ttl {
+		c.log().Debug("cache expired", "name", name, "age", age)
+		return nil, false
+	}
+
+	content, err := c.cipher.readFile(path)
+	if err != nil {
+		c.log().Debug("cache unreadable", "name", name, "error", err)
+		return nil, false
+	}
+
+	c.log().Debug("cache hit", "name", name)
+	return content, true
+}
+
+// GetStale retrieves a cached file whether or not it has expired.
+func (c *Cache) GetStale(name string) ([]byte, bool) {
+	if c.disabled {
+		return nil, false
+	}
+
+	content, err := c.cipher.readFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, false
+	}
+	c.log().Debug("cache read regardless of age", "name", name)
+	return content, true
+}
+
+// Validators returns the validators stored with a cached file, if any.
+func (c *Cache) Validators(name string) Validators {
+	var v Validators
+	if c.disabled {
+		return v
+	}
+	content, err := os.ReadFile(filepath.Join(c.dir, name+validatorsSuffix))
+	if err != nil {
+		return v
+	}
+	if err := yaml.Unmarshal(content, &v); err != nil {
+		return Validators{}
+	}
+	return v
+}
+
+// SetValidated stores content in the cache with the validators it was
+// served with.
+func (c *Cache) SetValidated(name string, content []byte, v Validators) error {
+	if err := c.Set(name, content); err != nil {
+		return err
+	}
+	if c.disabled || v.IsZero() {
+		return nil
+	}
+
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding cache validators: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, name+validatorsSuffix), data, 0644); err != nil {
+		return fmt.Errorf("writing cache validators: %w", err)
+	}
+	return nil
+}
+
+// Touch marks a cached file as fresh again, after the source confirmed it
+// is unchanged.
+func (c *Cache) Touch(name string) error {
+	if c.disabled {
+		return nil
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(c.dir, name), now, now); err != nil {
+		return fmt.Errorf("touching cache file: %w", err)
+	}
+	c.log().Debug("cache revalidated", "name", name)
+	return nil
+}
+
+// Set stores content in the cache, dropping any validators stored with the
+// previous content.
+func (c *Cache) Set(name string, content []byte) error {
+	if c.disabled {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	// Write beside the file and rename it into place, so that clients
+	// sharing the cache never read a partly written file
+	path := filepath.Join(c.dir, name)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := c.cipher.writeFile(tmp.Name(), content, 0644); err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := os.Remove(path + validatorsSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing cache validators: %w", err)
+	}
+
+	c.log().Debug("cache write", "name", name, "bytes", len(content))
+	return nil
+}
+
+// Invalidate removes a cached file and its validators.
+func (c *Cache) Invalidate(name string) error {
+	path := filepath.Join(c.dir, name)
+	for _, p := range []string{path, path + validatorsSuffix} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing cache file: %w", err)
+		}
+	}
+	return nil
+}
+
+// InvalidateAll removes all cached files.
+func (c *Cache) InvalidateAll() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("removing cache directory: %w", err)
+	}
+	return nil
+}
+
+// Dir returns the cache directory path.
+func (c *Cache) Dir() string {
+	return c.dir
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// InstallRecordFile is the name of the JSON install record written next to
+// each installed manifest.
+const InstallRecordFile = ".vega-install.json"
+
+// InstallRecord describes how an item was installed: its provenance, and the
+// hashes of what was written, which tell whether it was changed since.
+type InstallRecord struct {
+	Source      string            `json:"source" yaml:"source"`
+	Version     string            `json:"version" yaml:"version"`
+	SHA256      string            `json:"sha256" yaml:"sha256"`                     // Hash of the installed manifest
+	Files       map[string]string `json:"files,omitempty" yaml:"files,omitempty"`   // Hashes of the extra files, by path
+	Verified    bool              `json:"verified" yaml:"verified"`                 // The hash matched the one published in the index
+	Signed      bool              `json:"signed,omitempty" yaml:"signed,omitempty"` // A trusted signature was verified
+	InstalledAt time.Time         `json:"installed_at" yaml:"installed_at"`
+	InstalledBy string            `json:"installed_by,omitempty" yaml:"installed_by,omitempty"` // Module version of the installer, e.g. "vega-population v1.4.0"
+}
+
+// LoadInstallRecord reads the install record of an installed item directory.
+func LoadInstallRecord(dir string) (*InstallRecord, error) {
+	content, err := os.ReadFile(filepath.Join(dir, InstallRecordFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading install record: %w", err)
+	}
+
+	var record InstallRecord
+	if err := json.Unmarshal(content, &record); err != nil {
+		return nil, fmt.Errorf("parsing install record: %w", err)
+	}
+	return &record, nil
+}
+
+// writeInstallRecord writes the install record of an installed item directory.
+func writeInstallRecord(dir string, record *InstallRecord) error {
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding install record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, InstallRecordFile), content, 0644); err != nil {
+		return fmt.Errorf("writing install record: %w", err)
+	}
+	return nil
+}
+
+// installerVersion identifies this package's module and version for install
+// records. Builds from a checkout have the version "(devel)".
+func installerVersion() string {
+	version := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath {
+			version = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+			}
+		}
+	}
+	return path.Base(modulePath) + " " + version
+}
+
+// modulePath is the path of the module this package is in.
+const modulePath = "github.com/everydev1618/vega-population"
+
+// LocalChangesError reports an installed item that was changed since it was
+// installed, which replacing it would discard.
+type LocalChangesError struct {
+	Kind  ItemKind
+	Name  string
+	Files []string // Changed files, by path in the item directory
+}
+
+func (e *LocalChangesError) Error() string {
+	return fmt.Sprintf("%s %q has local changes to %s (push them first, or use --force to discard them)",
+		e.Kind, e.Name, strings.Join(e.Files, ", "))
+}
+
+// ChecksumMismatchError reports manifest content that doesn't match the hash
+// published in the source's index.
+type ChecksumMismatchError struct {
+	Kind     ItemKind
+	Name     string
+	Version  string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s %q %s: index publishes sha256 %s but the fetched manifest is %s (use --no-verify to install anyway)",
+		e.Kind, e.Name, e.Version, e.Expected, e.Actual)
+}
+
+// publishedChecksum returns the hash the index publishes for a version of an
+// item, or "" if it publishes none. An empty version means the current one,
+// which is returned alongside the hash. Items missing from the index have no
+// published hash.
+func (s *Source) publishedChecksum(ctx context.Context, kind ItemKind, name, version string) (string, string, error) {
+	entries, profiles, err := s.getIndex(ctx, kind)
+	if err != nil {
+		return "", "", err
+	}
+
+	var current string
+	var sums map[string]string
+	if kind == KindProfile {
+		entry := profiles[name]
+		current, sums = entry.Version, entry.SHA256
+	} else {
+		entry := entries[name]
+		current, sums = entry.Version, entry.SHA256
+	}
+
+	if version == "" {
+		version = current
+	}
+	return sums[version], version, nil
+}
@@ -0,0 +1,3541 @@
+package core
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RunCLI is the entry point for the CLI interface.
+func RunCLI(args []string) error {
+	return RunCLIWithOutput(args, os.Stdout, os.Stderr)
+}
+
+// RunCLIWithOutput runs the CLI like RunCLI, writing command output to stdout
+// and notices, progress, and flag errors to stderr, so embedders can capture
+// or redirect them.
+func RunCLIWithOutput(args []string, stdout, stderr io.Writer) error {
+	cl := &cli{stdin: os.Stdin, stdout: stdout, stderr: stderr}
+	err := cl.run(args)
+	if errors.Is(err, flag.ErrHelp) {
+		// The flag set has already printed its usage
+		return nil
+	}
+	return err
+}
+
+// cli runs CLI commands, writing to its own output streams.
+type cli struct {
+	stdin  io.Reader // Answers to questions, asked only when it is a terminal
+	stdout io.Writer
+	stderr io.Writer
+	ctx    context.Context // Cancelled by the first SIGINT or SIGTERM
+
+	session *Session // Set by a global --record or --replay
+
+	flagSets *[]*flag.FlagSet // Receives the flag sets commands create, when describing them (optional)
+}
+
+// flagSet creates a flag set for a command that reports errors rather than
+// exiting, and prints usage to stderr.
+func (cl *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(cl.stderr)
+	if cl.flagSets != nil {
+		*cl.flagSets = append(*cl.flagSets, fs)
+	}
+	return fs
+}
+
+// newClient creates a client for a command, recording or replaying its
+// reads when the run does, and logging to stderr.
+func (cl *cli) newClient(opts ...Option) (*Client, error) {
+	if cl.session != nil {
+		opts = append(opts, WithSession(cl.session))
+	}
+	logger, err := cl.logger()
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{WithLogger(logger)}, opts...)
+	key, err := encryptionKeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		opts = append([]Option{WithEncryptionKey(key)}, opts...)
+	}
+	return NewClient(opts...)
+}
+
+// logger returns the logger for the run: warnings to stderr as plain lines,
+// or everything at the level LogLevelEnv gives as slog text.
+func (cl *cli) logger() (*slog.Logger, error) {
+	value := os.Getenv(LogLevelEnv)
+	if value == "" {
+		return slog.New(newWarningHandler(cl.stderr)), nil
+	}
+	level, err := parseLogLevel(value)
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(slog.NewTextHandler(cl.stderr, &slog.HandlerOptions{Level: level})), nil
+}
+
+func (cl *cli) run(args []string) (err error) {
+	args, record, replay, err := splitGlobalSession(args)
+	if err != nil {
+		return err
+	}
+	args, output, err := splitGlobalOutput(args)
+	if err != nil {
+		return err
+	}
+
+	// A recording is saved however the command ends, failures being what
+	// it is for
+	switch {
+	case record != "":
+		cl.session = NewSession(args...)
+		defer func() {
+			if saveErr := cl.session.Save(record); saveErr != nil && err == nil {
+				err = saveErr
+			} else if saveErr == nil {
+				fmt.Fprintf(cl.stderr, "Recorded %d read(s) to %s\n", len(cl.session.Reads), record)
+			}
+		}()
+	case replay != "":
+		if cl.session, err = LoadSession(replay); err != nil {
+			return err
+		}
+	}
+
+	if len(args) == 0 {
+		return cl.printUsage()
+	}
+
+	cmd := args[0]
+	cmdArgs := args[1:]
+
+	// A global --output applies to the command, which must support it; the
+	// configured one applies to the commands supporting it
+	if output != "" {
+		if !outputCommands[cmd] {
+			return fmt.Errorf("--output is not supported by %s", cmd)
+		}
+		cmdArgs = append([]string{"--output", output}, cmdArgs...)
+	} else if outputCommands[cmd] {
+		if cfg, err := cl.config(); err == nil && cfg.Output != "" {
+			cmdArgs = append([]string{"--output", cfg.Output}, cmdArgs...)
+		}
+	}
+
+	// The first signal cancels the command, so it can clean up after itself
+	notice := "Interrupted; cleaning up (signal again to force)"
+	if cmd == "serve" || cmd == "daemon" {
+		notice = "Shutting down; waiting for work in progress (signal again to force)"
+	}
+	ctx, stop := cl.signalContext(notice)
+	defer stop()
+	cl.ctx = ctx
+
+	if cmd != "daemon" {
+		cl.printPendingNotifications()
+	}
+	if onboardingCommands[cmd] {
+		cl.offerInit()
+	}
+
+	return cl.dispatch(cmd, cmdArgs)
+}
+
+// dispatch runs a command.
+func (cl *cli) dispatch(cmd string, cmdArgs []string) error {
+	switch cmd {
+	case "init":
+		return cl.runInit(cmdArgs)
+	case "sync":
+		return cl.runSync(cmdArgs)
+	case "search":
+		return cl.runSearch(cmdArgs)
+	case "browse":
+		return cl.runBrowse(cmdArgs)
+	case "install":
+		return cl.runInstall(cmdArgs)
+	case "list", "ls":
+		return cl.runList(cmdArgs)
+	case "uninstall", "remove", "rm":
+		return cl.runUninstall(cmdArgs)
+	case "info":
+		return cl.runInfo(cmdArgs)
+	case "export":
+		return cl.runExport(cmdArgs)
+	case "deploy":
+		return cl.runDeploy(cmdArgs)
+	case "update":
+		return cl.runUpdate(cmdArgs)
+	case "mirror":
+		return cl.runMirror(cmdArgs)
+	case "daemon":
+		return cl.runDaemon(cmdArgs)
+	case "serve":
+		return cl.runServe(cmdArgs)
+	case "whatsnew":
+		return cl.runWhatsNew(cmdArgs)
+	case "outdated":
+		return cl.runOutdated(cmdArgs)
+	case "deps":
+		return cl.runDeps(cmdArgs)
+	case "why":
+		return cl.runWhy(cmdArgs)
+	case "upgrade":
+		return cl.runUpgrade(cmdArgs)
+	case "status":
+		return cl.runStatus(cmdArgs)
+	case "update-pr":
+		return cl.runUpdatePR(cmdArgs)
+	case "push":
+		return cl.runPush(cmdArgs)
+	case "publish":
+		return cl.runPublish(cmdArgs)
+	case "bump":
+		return cl.runBump(cmdArgs)
+	case "sign":
+		return cl.runSign(cmdArgs)
+	case "copy":
+		return cl.runCopy(cmdArgs)
+	case "check-owners":
+		return cl.runCheckOwners(cmdArgs)
+	case "preflight":
+		return cl.runPreflight(cmdArgs)
+	case "budget":
+		return cl.runBudget(cmdArgs)
+	case "demo":
+		return cl.runDemo(cmdArgs)
+	case "prompt":
+		return cl.runPrompt(cmdArgs)
+	case "validate":
+		return cl.runValidate(cmdArgs)
+	case "gc":
+		return cl.runGC(cmdArgs)
+	case "doctor":
+		return cl.runDoctor(cmdArgs)
+	case "backup":
+		return cl.runBackup(cmdArgs)
+	case "restore":
+		return cl.runRestore(cmdArgs)
+	case "config":
+		return cl.runConfig(cmdArgs)
+	case "completion":
+		return cl.runCompletion(cmdArgs)
+	case "__complete":
+		return cl.runComplete(cmdArgs)
+	case "__describe":
+		return cl.runDescribe(cmdArgs)
+	case "help", "-h", "--help":
+		return cl.printUsage()
+	default:
+		if suggestion := suggestCommand(cmd); suggestion != "" {
+			return fmt.Errorf("unknown command: %s (did you mean %s?)\nRun 'vega population help' for usage", cmd, suggestion)
+		}
+		return fmt.Errorf("unknown command: %s\nRun 'vega population help' for usage", cmd)
+	}
+}
+
+// commandNames are the commands run dispatches, for suggesting one when a
+// command is mistyped.
+var commandNames = []string{
+	"init", "sync", "search", "browse", "install", "list", "uninstall", "info", "export",
+	"deploy", "update", "mirror", "daemon", "serve", "whatsnew", "outdated",
+	"deps", "why", "upgrade", "status", "update-pr", "push", "publish", "bump", "sign",
+	"copy", "check-owners", "preflight", "budget", "demo", "prompt", "validate", "gc",
+	"doctor", "backup", "restore", "config", "completion", "help",
+}
+
+// suggestCommand returns the command closest to a mistyped one, or "" if
+// none is within a typo or two.
+func suggestCommand(cmd string) string {
+	best, bestDistance := "", len(cmd)/3+2
+	for _, name := range commandNames {
+		if d := editDistance(strings.ToLower(cmd), name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func (cl *cli) printUsage() error {
+	fmt.Fprintln(cl.stdout, `Usage: vega population [--record|--replay <session.json>] [--output json|yaml|table] <command> [options]
+
+Commands:
+  init               Set up the vega home, optionally with a starter profile
+  search <query>     Search for skills, personas, and profiles
+  browse [filter]    Browse items at a terminal: type to filter, Enter to install
+  install <name>     Install a skill, persona (@name), profile (+name), or settings (%name);
+                     append @<version> or @^<version> to pin a version
+  uninstall <name>   Remove an installed item and all of its files
+  list               List installed items (--unused --since 30d for unused ones)
+  info <name>        Show detailed information about an item
+  deps <name>        Show the dependency tree of a profile or persona
+  why <name>         Show which installed items depend on an item
+  export <name>      Export a persona or profile for tron.vega.yaml (--format for others)
+  deploy <@persona|+profile> <target>...
+                     Deploy a persona or profile to targets: file:<dir>, claude:<repo>,
+                     or tron:<file>[#agents.Name]; --lint checks the prompts for
+                     contradictions first, or only, without targets
+  demo <@persona>    Show a persona's example conversations
+  sync               Make the installed items match vega-population.yaml or ~/.vega/vega.deps.yaml
+                     (--prune also removes the rest)
+  update             Update the local cache
+  mirror             Copy the whole source to a directory, or verify a copy
+  whatsnew           Show items that changed upstream since the last check
+  outdated           List installed items with newer versions in the source
+  upgrade [name...]  Upgrade outdated installed items in place
+  status             Show installed files modified, missing, or added since they were
+                     installed, which an upgrade would discard
+  update-pr          Commit a project's lockfile updates to a branch, with changelogs,
+                     and optionally open a pull request (--github-token)
+  preflight [name]   Check this host meets a profile's or skill's requirements
+                     (default: every installed skill)
+  budget <@persona|+profile>...
+                     Total the budgets of agents deployed together and check them
+                     against the settings' caps (--env, --cap)
+  push <name>        Copy a locally modified item back into a registry checkout
+  publish <path>     Validate an item and publish it to a registry checkout or URL (--registry)
+  bump <path|name>   Bump an item's version and append to its changelog
+  sign <path|name>   Sign a registry manifest and list the signature in its index
+  copy <name>        Copy an item from a source into a registry checkout (--from, --to),
+                     verifying it and optionally re-signing it
+  check-owners       Check that index changes are made by the items' maintainers
+  prompt render|lint|diff|merge <manifest>...
+                     Work with a manifest's system prompt section by section
+  validate <path>... Check manifests against the item schemas (--all for a whole registry)
+  serve              Serve a registry checkout (or --installed items) as an HTTP source
+  gc --keep <n>      Remove older item versions from a registry checkout
+  doctor             Check the vega home, including that its content is encrypted
+                     consistently with $VEGA_POPULATION_ENCRYPTION_KEY (--fix to repair)
+  backup <file>      Archive the installed items, config, lockfile, and policy to a .tar.gz
+  restore <file>     Restore a backup, checking it against its checksums first (--force
+                     to replace installed items)
+  config get|set|unset|list [key] [value]
+                     Show or change the defaults in ~/.vega/config.yaml
+  completion <shell> Print the bash, zsh, or fish completion script
+  daemon             Refresh the cache (and sync a deps file) in the background
+  daemon status      Show daemon freshness metrics
+  daemon install-service
+                     Generate a systemd or launchd service for the daemon
+
+Examples:
+  vega population search kubernetes
+  vega population install kubernetes-ops
+  vega population install kubernetes-ops@^1.2
+  vega population install @incident-commander
+  vega population install +platform-engineer
+  vega population export @cmo
+  vega population deploy @cmo claude:. tron:./tron.vega.yaml#agents.Maya
+  vega population demo @cmo
+  vega population list
+  vega population deps +platform-engineer
+  vega population why kubernetes-ops
+  vega population mirror --publish ./public --sign-key mirror.pem
+  vega population --output json search kubernetes
+  vega population --record session.json install +platform-engineer`)
+	return nil
+}
+
+func (cl *cli) runInit(args []string) error {
+	fs := cl.flagSet("init")
+	profileFlag := fs.String("profile", "", "Starter profile to install, such as +sre-oncall")
+	layoutFlag := fs.String("layout", "", "Directories to install each kind into, such as persona=agents,skill=capabilities")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("init takes no arguments (use --profile to install a starter profile)")
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	initOpts := &InitOptions{Profile: *profileFlag, Progress: cl.stdout}
+	if *layoutFlag != "" {
+		layout, err := ParseLayout(*layoutFlag)
+		if err != nil {
+			return err
+		}
+		initOpts.Layout = layout
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Init(cl.ctx, initOpts)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Created) == 0 {
+		fmt.Fprintf(cl.stdout, "%s is already set up\n", result.Home)
+	} else {
+		fmt.Fprintf(cl.stdout, "Set up %s\n", result.Home)
+		for _, path := range result.Created {
+			fmt.Fprintf(cl.stdout, "  created %s\n", path)
+		}
+	}
+	if result.Installed != nil {
+		installed := 0
+		for _, item := range result.Installed.Items {
+			if item.Status == InstallStatusInstalled {
+				installed++
+			}
+		}
+		fmt.Fprintf(cl.stdout, "Installed +%s with %d item(s)\n", strings.TrimPrefix(*profileFlag, "+"), installed)
+	}
+
+	fmt.Fprintf(cl.stdout, `
+Next steps:
+  vega population search <query>      Find skills, personas, and profiles
+  vega population install +<profile>  Install a profile with its persona and skills
+  vega population list                See what is installed
+  Edit %s to require reviewed or signed items
+`, filepath.Join(client.InstallDir(), PolicyFile))
+	return nil
+}
+
+func (cl *cli) runSync(args []string) error {
+	fs := cl.flagSet("sync")
+	fileFlag := fs.String("file", "", "Spec to sync: a "+WorkspaceFile+" or a deps file (default: the workspace's, else ~/.vega/"+DefaultDepsFile+")")
+	pruneFlag := fs.Bool("prune", false, "Remove installed items the spec neither lists nor needs")
+	dryRunFlag := fs.Bool("dry-run", false, "Show the changes without making them")
+	noLockFlag := fs.Bool("no-lock", false, "Neither check nor write "+LockFile+" next to the spec")
+	recursiveFlag := fs.Bool("recursive", false, "Sync every "+WorkspaceFile+" and "+DefaultDepsFile+" under the current directory, each into .vega next to it")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (default: the workspace's source)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory (default: .vega next to the workspace file)")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("sync takes no arguments")
+	}
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var common []Option
+	if *sourceFlag != "" {
+		common = append(common, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		common = append(common, WithAuth(*tokenFlag))
+	}
+	syncOpts := &SyncOptions{Prune: *pruneFlag, DryRun: *dryRunFlag, Progress: cl.stdout}
+	if output.Structured() {
+		syncOpts.Progress = cl.stderr
+	}
+	if *recursiveFlag {
+		if *fileFlag != "" || *installDirFlag != "" {
+			return fmt.Errorf("--recursive finds the specs and install directories itself; it can't be used with --file or --install-dir")
+		}
+		return cl.syncRecursive(common, syncOpts, *noLockFlag, output)
+	}
+
+	// The spec is a workspace, found from here unless a file is given, or
+	// else the user's deps file for the vega home. Its lockfile is next to it.
+	var opts []Option
+	var deps *Deps
+	var lockPath string
+	ws, err := FindWorkspace(".")
+	if *fileFlag != "" {
+		if filepath.Base(*fileFlag) == WorkspaceFile {
+			ws, err = LoadWorkspace(*fileFlag)
+		} else {
+			ws, err = nil, ErrNoWorkspace
+			if deps, err = LoadDeps(*fileFlag); err != nil {
+				return err
+			}
+		}
+	}
+	switch {
+	case ws != nil:
+		opts = append(opts, WithWorkspace(ws))
+		deps = ws.Deps()
+		lockPath = filepath.Join(ws.Dir, LockFile)
+	case deps != nil:
+		lockPath = filepath.Join(filepath.Dir(*fileFlag), LockFile)
+	case errors.Is(err, ErrNoWorkspace):
+		path := filepath.Join(cl.vegaHome(), DefaultDepsFile)
+		lockPath = filepath.Join(cl.vegaHome(), LockFile)
+		if deps, err = LoadDeps(path); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w, and there is no %s; create one listing the skills, personas, and profiles to install", ErrNoWorkspace, path)
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	opts = append(opts, common...)
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	if !*noLockFlag {
+		syncOpts.Lock = lockPath
+	}
+	report, err := client.Sync(cl.ctx, deps, syncOpts)
+	if err != nil {
+		return err
+	}
+	if output.Structured() {
+		return writeOutput(cl.stdout, output, report)
+	}
+	cl.printSyncReport(report, client.InstallDir())
+	return nil
+}
+
+// syncResult is the outcome of syncing one directory with --recursive.
+type syncResult struct {
+	Dir    string      `json:"dir" yaml:"dir"`
+	Report *SyncReport `json:"report,omitempty" yaml:"report,omitempty"`
+	Error  string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// syncRecursive syncs every workspace under the working directory, going on
+// past failures, and summarizes each.
+func (cl *cli) syncRecursive(common []Option, syncOpts *SyncOptions, noLock bool, output OutputFormat) error {
+	workspaces, err := FindWorkspaces(".")
+	if err != nil {
+		return err
+	}
+	if len(workspaces) == 0 {
+		return fmt.Errorf("no %s or %s found under the current directory", WorkspaceFile, DefaultDepsFile)
+	}
+
+	results := make([]syncResult, 0, len(workspaces))
+	failed := 0
+	for i, ws := range workspaces {
+		result := syncResult{Dir: relDir(ws.Dir)}
+		if !output.Structured() {
+			if i > 0 {
+				fmt.Fprintln(cl.stdout)
+			}
+			fmt.Fprintf(cl.stdout, "== %s ==\n", result.Dir)
+		}
+
+		opts := *syncOpts
+		if !noLock {
+			opts.Lock = filepath.Join(ws.Dir, LockFile)
+		}
+		client, err := cl.newClient(append([]Option{WithWorkspace(ws)}, common...)...)
+		if err == nil {
+			result.Report, err = client.Sync(cl.ctx, ws.Deps(), &opts)
+		}
+		if err != nil {
+			if cl.ctx.Err() != nil {
+				return cl.ctx.Err()
+			}
+			failed++
+			result.Error = err.Error()
+			if !output.Structured() {
+				fmt.Fprintf(cl.stdout, "Failed: %v\n", err)
+			}
+		} else if !output.Structured() {
+			cl.printSyncReport(result.Report, ws.InstallDir())
+		}
+		results = append(results, result)
+	}
+
+	if output.Structured() {
+		if err := writeOutput(cl.stdout, output, results); err != nil {
+			return err
+		}
+	} else {
+		verb := "Synced"
+		if syncOpts.DryRun {
+			verb = "Checked"
+		}
+		fmt.Fprintf(cl.stdout, "\n%s %d of %d directories\n", verb, len(workspaces)-failed, len(workspaces))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d directories failed to sync", failed, len(workspaces))
+	}
+	return nil
+}
+
+// relDir returns dir relative to the working directory when it is below it.
+func relDir(dir string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return dir
+	}
+	rel, err := filepath.Rel(wd, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return dir
+	}
+	return rel
+}
+
+// printSyncReport prints the changes sync made to an install directory.
+func (cl *cli) printSyncReport(report *SyncReport, installDir string) {
+	if len(report.Changes) == 0 {
+		fmt.Fprintf(cl.stdout, "%s is in sync (%d item(s))\n", installDir, len(report.Unchanged))
+		return
+	}
+	if report.DryRun {
+		fmt.Fprintf(cl.stdout, "Changes to %s:\n", installDir)
+	} else {
+		fmt.Fprintf(cl.stdout, "\nChanged %s:\n", installDir)
+	}
+	counts := make(map[SyncAction]int)
+	for _, change := range report.Changes {
+		counts[change.Action]++
+		line := fmt.Sprintf("  %-8s %s", change.Action, FormatItemName(change.Kind, change.Name))
+		switch {
+		case change.From != "" && change.To != "" && change.From != change.To:
+			line += fmt.Sprintf(" %s -> %s", change.From, change.To)
+		case change.To != "":
+			line += " " + change.To
+		case change.From != "":
+			line += " " + change.From
+		}
+		if change.Want != "" && change.Action != SyncRemove {
+			line += fmt.Sprintf(" (wants %s)", change.Want)
+		}
+		fmt.Fprintln(cl.stdout, line)
+		cl.printFileChanges("      ", change.Files)
+	}
+	verb := "Synced"
+	if report.DryRun {
+		verb = "Would sync"
+	}
+	fmt.Fprintf(cl.stdout, "%s: %d installed, %d upgraded, %d removed, %d unchanged\n", verb, counts[SyncInstall], counts[SyncUpgrade], counts[SyncRemove], len(report.Unchanged))
+}
+
+// vegaHome returns the default vega home directory.
+func (cl *cli) vegaHome() string {
+	home, err := VegaHome()
+	if err != nil {
+		return DefaultVegaHome
+	}
+	return home
+}
+
+// onboardingCommands are the commands that suggest init when the vega home
+// hasn't been set up.
+var onboardingCommands = map[string]bool{
+	"search":   true,
+	"list":     true,
+	"ls":       true,
+	"info":     true,
+	"outdated": true,
+	"upgrade":  true,
+	"why":      true,
+}
+
+// offerInit suggests running init when the default vega home is missing or
+// unused.
+func (cl *cli) offerInit() {
+	client, err := cl.newClient()
+	if err != nil || !client.needsInit() {
+		return
+	}
+
+	// Use stderr so the hint never ends up in JSON output
+	fmt.Fprintf(cl.stderr, "Tip: %s isn't set up yet; run 'vega population init' to get started\n\n", client.home)
+}
+
+// maxSuggestions is how many similar items a search with no results
+// suggests.
+const maxSuggestions = 3
+
+func (cl *cli) runSearch(args []string) error {
+	fs := cl.flagSet("search")
+	kindFlag := fs.String("kind", "", "Filter by kind (skill, persona, profile)")
+	tagsFlag := fs.String("tags", "", "Filter by tags (comma-separated)")
+	statusFlag := fs.String("status", "", "Filter by review status (draft, reviewed, approved)")
+	limitFlag := fs.Int("limit", 0, "Maximum number of results")
+	fuzzyFlag := fs.Bool("fuzzy", false, "Also match names and tags within a few typos of the query")
+	deepFlag := fs.Bool("deep", false, "Also search manifest bodies: system prompts, skill prompts, tools, and examples")
+	explainFlag := fs.Bool("explain", false, "Show how each result's score was reached")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	noCacheFlag := fs.Bool("no-cache", false, "Disable caching")
+	offlineFlag := fs.Bool("offline", false, "Use only the cache and installed items, never the network")
+	pickFlag := fs.Bool("pick", false, "Pick a result interactively and install it")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory for --pick")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("search requires a query argument")
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	if *offlineFlag && *noCacheFlag {
+		return fmt.Errorf("--offline reads from the cache and can't be used with --no-cache")
+	}
+	if *pickFlag && (output.Structured() || !cl.interactive()) {
+		return fmt.Errorf("--pick needs a terminal and table output")
+	}
+
+	query := strings.Join(fs.Args(), " ")
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *noCacheFlag {
+		opts = append(opts, WithNoCache())
+	}
+	if *offlineFlag {
+		opts = append(opts, WithOffline())
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	searchOpts := &SearchOptions{
+		Limit:   *limitFlag,
+		Fuzzy:   *fuzzyFlag,
+		Deep:    *deepFlag,
+		Explain: *explainFlag,
+	}
+
+	if *kindFlag != "" {
+		searchOpts.Kind = ItemKind(*kindFlag)
+	}
+
+	if *tagsFlag != "" {
+		searchOpts.Tags = strings.Split(*tagsFlag, ",")
+		for i, t := range searchOpts.Tags {
+			searchOpts.Tags[i] = strings.TrimSpace(t)
+		}
+	}
+
+	if *statusFlag != "" {
+		if searchOpts.Status, err = ParseReviewStatus(*statusFlag); err != nil {
+			return err
+		}
+	}
+
+	results, err := client.Search(cl.ctx, query, searchOpts)
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		if results == nil {
+			results = []SearchResult{}
+		}
+		return writeOutput(cl.stdout, output, results)
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(cl.stdout, "No results found for %q\n", query)
+		if !searchOpts.Fuzzy {
+			// A typo is the likeliest reason
+			fuzzy := *searchOpts
+			fuzzy.Fuzzy, fuzzy.Deep, fuzzy.Explain, fuzzy.Limit = true, false, false, maxSuggestions
+			if similar, err := client.Search(cl.ctx, query, &fuzzy); err == nil && len(similar) > 0 {
+				names := make([]string, len(similar))
+				for i, r := range similar {
+					names[i] = FormatItemName(r.Kind, r.Name)
+				}
+				fmt.Fprintf(cl.stdout, "Did you mean %s?\n", strings.Join(names, ", "))
+			}
+		}
+		return nil
+	}
+
+	if *pickFlag {
+		if len(results) > maxPickCandidates {
+			results = results[:maxPickCandidates]
+		}
+		choice, err := cl.pick(fmt.Sprintf("Found %d result(s) for %q:", len(results), query), results)
+		if err != nil || choice == nil {
+			return err
+		}
+		name := FormatItemName(choice.Kind, choice.Name)
+		if err := client.Install(cl.ctx, name, &InstallOptions{Progress: cl.stdout}); err != nil {
+			return err
+		}
+		fmt.Fprintf(cl.stdout, "Successfully installed %s to %s\n", name, client.itemDir(choice.Kind, choice.Name))
+		return nil
+	}
+
+	fmt.Fprintf(cl.stdout, "Found %d result(s) for %q:\n\n", len(results), query)
+
+	for _, r := range results {
+		name := FormatItemName(r.Kind, r.Name)
+		if r.Status != StatusApproved {
+			name += " [" + string(r.Status) + "]"
+		}
+		fmt.Fprintf(cl.stdout, "  %-30s  %s\n", name, r.Description)
+		if len(r.Tags) > 0 {
+			fmt.Fprintf(cl.stdout, "  %-30s  tags: %s\n", "", strings.Join(r.Tags, ", "))
+		}
+		if r.Match != "" {
+			fmt.Fprintf(cl.stdout, "  %-30s  matched %s\n", "", r.Match)
+		}
+		if searchOpts.Explain {
+			fmt.Fprintf(cl.stdout, "  %-30s  score %.2f: %s\n", "", r.Score, r.Explain)
+		}
+		if len(client.Sources()) > 1 {
+			fmt.Fprintf(cl.stdout, "  %-30s  source: %s\n", "", r.Source)
+		}
+		for _, w := range r.Warnings {
+			fmt.Fprintf(cl.stdout, "  %-30s  warning: %s\n", "", w)
+		}
+		fmt.Fprintln(cl.stdout)
+	}
+
+	return nil
+}
+
+func (cl *cli) runInstall(args []string) error {
+	fs := cl.flagSet("install")
+	forceFlag := fs.Bool("force", false, "Overwrite existing installation")
+	noDepsFlag := fs.Bool("no-deps", false, "Skip dependencies (a profile's persona and skills, a skill's dependencies)")
+	onConflictFlag := fs.String("on-conflict", "fail", "How to settle items wanted at incompatible versions: fail, newest, or keep-existing")
+	allowIncompatibleFlag := fs.Bool("allow-incompatible", false, "Install profiles combining items their skills declare incompatible, with a warning")
+	concurrencyFlag := fs.Int("concurrency", DefaultConcurrency, "How many of a profile's dependencies to fetch at once")
+	offlineFlag := fs.Bool("offline", false, "Use only the cache and installed items, never the network")
+	dryRunFlag := fs.Bool("dry-run", false, "Show what would be installed")
+	envFlag := fs.String("env", "", "Deployment environment for conditional profile skills")
+	minStatusFlag := fs.String("min-status", "", "Lowest review status to install (default from policy.yaml)")
+	noVerifyFlag := fs.Bool("no-verify", false, "Skip checking manifests against the sha256 hashes published in the index")
+	requireSignedFlag := fs.Bool("require-signed", false, "Refuse items without a signature trusted by policy.yaml")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("install requires a name argument")
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var minStatus ReviewStatus
+	if *minStatusFlag != "" {
+		if minStatus, err = ParseReviewStatus(*minStatusFlag); err != nil {
+			return err
+		}
+	}
+	onConflict, err := ParseConflictStrategy(*onConflictFlag)
+	if err != nil {
+		return err
+	}
+	if *concurrencyFlag < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	opts := []Option{WithConcurrency(*concurrencyFlag)}
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+	if *offlineFlag {
+		opts = append(opts, WithOffline())
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	installOpts := &InstallOptions{
+		Force:      *forceFlag,
+		NoDeps:     *noDepsFlag,
+		DryRun:     *dryRunFlag,
+		Env:        *envFlag,
+		OnConflict: onConflict,
+
+		AllowIncompatible: *allowIncompatibleFlag,
+
+		MinStatus: minStatus,
+		NoVerify:  *noVerifyFlag,
+
+		RequireSigned: *requireSignedFlag,
+
+		Progress: cl.stdout,
+	}
+
+	// At a terminal, names no source has can be picked from what they match
+	names := make([]string, fs.NArg())
+	for i, name := range fs.Args() {
+		if names[i], err = cl.pickItem(client, name); err != nil {
+			return err
+		}
+	}
+
+	// Structured output goes to stdout alone, so progress moves to stderr
+	if output.Structured() {
+		installOpts.Progress = cl.stderr
+		report, err := client.InstallAll(cl.ctx, names, installOpts)
+		if err != nil {
+			return err
+		}
+		return writeOutput(cl.stdout, output, report)
+	}
+
+	report, err := client.InstallAll(cl.ctx, names, installOpts)
+	if err != nil {
+		return err
+	}
+	if *dryRunFlag {
+		for _, item := range report.Items {
+			cl.printFileChanges("  ", item.Files)
+		}
+	} else {
+		for _, name := range names {
+			base, _ := SplitVersion(name)
+			kind, itemName := ParseItemName(base)
+			fmt.Fprintf(cl.stdout, "Successfully installed %s to %s\n", FormatItemName(kind, itemName), client.itemDir(kind, itemName))
+		}
+	}
+	return nil
+}
+
+// printFileChanges lists the files a command changes, or would change, one a
+// line under what it reported, indented by indent.
+func (cl *cli) printFileChanges(indent string, changes []FileChange) {
+	for _, change := range changes {
+		fmt.Fprintf(cl.stdout, "%s%-6s  %s\n", indent, change.Action, change.Path)
+	}
+}
+
+func (cl *cli) runUninstall(args []string) error {
+	fs := cl.flagSet("uninstall")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	dryRunFlag := fs.Bool("dry-run", false, "Show the files that would be removed")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("uninstall requires a name argument")
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range fs.Args() {
+		kind, itemName := ParseItemName(name)
+		if *dryRunFlag {
+			changes, err := client.PlanUninstall(name)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cl.stdout, "Would uninstall %s\n", FormatItemName(kind, itemName))
+			cl.printFileChanges("  ", changes)
+			continue
+		}
+		if err := client.Uninstall(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(cl.stdout, "Uninstalled %s\n", FormatItemName(kind, itemName))
+	}
+
+	return nil
+}
+
+func (cl *cli) runList(args []string) error {
+	fs := cl.flagSet("list")
+	kindFlag := fs.String("kind", "", "Filter by kind (skill, persona, profile)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	unusedFlag := fs.Bool("unused", false, "Only list items not used recently (as reported by agent runtimes)")
+	sinceFlag := fs.String("since", "30d", "Window for --unused (e.g., 30d, 2w, 12h)")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	since, err := ParseAge(*sinceFlag)
+	if err != nil {
+		return err
+	}
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	var kind ItemKind
+	if *kindFlag != "" {
+		kind = ItemKind(*kindFlag)
+	}
+
+	var items []InstalledItem
+	if *unusedFlag {
+		items, err = client.Unused(kind, since)
+	} else {
+		items, err = client.List(kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		if items == nil {
+			items = []InstalledItem{}
+		}
+		return writeOutput(cl.stdout, output, items)
+	}
+
+	if len(items) == 0 {
+		if *unusedFlag {
+			fmt.Fprintf(cl.stdout, "No items unused in the last %s\n", *sinceFlag)
+		} else {
+			fmt.Fprintln(cl.stdout, "No items installed")
+		}
+		return nil
+	}
+
+	// Group by kind
+	byKind := make(map[ItemKind][]InstalledItem)
+	for _, item := range items {
+		byKind[item.Kind] = append(byKind[item.Kind], item)
+	}
+
+	for _, k := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		items, ok := byKind[k]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(cl.stdout, "%s:\n", titleCase(k.Plural()))
+		for _, item := range items {
+			line := fmt.Sprintf("  %-30s  v%s", FormatItemName(item.Kind, item.Name), item.Version)
+			if len(item.Files) > 0 {
+				line += fmt.Sprintf("  (+%d files)", len(item.Files))
+			}
+			if len(item.Modified) > 0 {
+				line += "  (modified)"
+			}
+			fmt.Fprintln(cl.stdout, line)
+		}
+		fmt.Fprintln(cl.stdout)
+	}
+
+	return nil
+}
+
+func (cl *cli) runInfo(args []string) error {
+	fs := cl.flagSet("info")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	offlineFlag := fs.Bool("offline", false, "Use only the cache and installed items, never the network")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("info requires a name argument")
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+	if *offlineFlag {
+		opts = append(opts, WithOffline())
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	name, err := cl.pickItem(client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	info, err := client.Info(cl.ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		return writeOutput(cl.stdout, output, info)
+	}
+
+	fmt.Fprintf(cl.stdout, "Name:        %s\n", FormatItemName(info.Kind, info.Name))
+	fmt.Fprintf(cl.stdout, "Kind:        %s\n", info.Kind)
+	fmt.Fprintf(cl.stdout, "Version:     %s\n", info.Version)
+	fmt.Fprintf(cl.stdout, "Description: %s\n", info.Description)
+	fmt.Fprintf(cl.stdout, "Author:      %s\n", info.Author)
+
+	if len(info.Maintainers) > 0 {
+		fmt.Fprintf(cl.stdout, "Maintainers: %s\n", strings.Join(info.Maintainers, ", "))
+	}
+	fmt.Fprintf(cl.stdout, "Review:      %s\n", info.Status)
+	fmt.Fprintf(cl.stdout, "Source:      %s\n", info.Source)
+
+	if len(info.Tags) > 0 {
+		fmt.Fprintf(cl.stdout, "Tags:        %s\n", strings.Join(info.Tags, ", "))
+	}
+
+	if info.Persona != "" {
+		fmt.Fprintf(cl.stdout, "Persona:     @%s\n", info.Persona)
+	}
+
+	if len(info.Skills) > 0 {
+		fmt.Fprintf(cl.stdout, "Skills:      %s\n", strings.Join(info.Skills, ", "))
+	}
+
+	if len(info.RecommendedSkills) > 0 {
+		fmt.Fprintf(cl.stdout, "Recommended: %s\n", strings.Join(info.RecommendedSkills, ", "))
+	}
+
+	for _, w := range info.Warnings {
+		fmt.Fprintf(cl.stdout, "Warning:     %s\n", w)
+	}
+
+	fmt.Fprintln(cl.stdout)
+	if info.Installed {
+		fmt.Fprintf(cl.stdout, "Status:      Installed at %s\n", info.InstalledPath)
+		if record := info.Install; record != nil {
+			fmt.Fprintf(cl.stdout, "Installed:   v%s from %s on %s", record.Version, record.Source, record.InstalledAt.Local().Format("2006-01-02 15:04"))
+			if record.InstalledBy != "" {
+				fmt.Fprintf(cl.stdout, " by %s", record.InstalledBy)
+			}
+			fmt.Fprintln(cl.stdout)
+			fmt.Fprintf(cl.stdout, "SHA256:      %s", record.SHA256)
+			if record.Verified {
+				fmt.Fprint(cl.stdout, " (verified)")
+			}
+			fmt.Fprintln(cl.stdout)
+		}
+		if len(info.Modified) > 0 {
+			fmt.Fprintf(cl.stdout, "Modified:    %s\n", strings.Join(info.Modified, ", "))
+		}
+	} else {
+		fmt.Fprintf(cl.stdout, "Status:      Not installed\n")
+	}
+
+	return nil
+}
+
+// Export defaults used when no installed settings provide them.
+const (
+	defaultExportModel  = "claude-sonnet-4-20250514"
+	defaultExportBudget = "$3.00"
+)
+
+// agentFlags are the flags that shape a persona rendered as an agent.
+type agentFlags struct {
+	name, model, budget, secrets *string
+	temperature                  *float64
+	examples, noTools            *bool
+	env, tools                   *string
+}
+
+func addAgentFlags(fs *flag.FlagSet) *agentFlags {
+	return &agentFlags{
+		name:        fs.String("name", "", "Agent name to use (default: extracted from persona or capitalized ID)"),
+		model:       fs.String("model", "", "Model or model alias to use (default from installed settings, else "+defaultExportModel+")"),
+		temperature: fs.Float64("temperature", defaultExportTemperature, "Temperature setting"),
+		budget:      fs.String("budget", "", "Budget limit (default from installed settings, else "+defaultExportBudget+")"),
+		secrets:     fs.String("secrets", "", "Provider for {{secret \"NAME\"}} references: env[:<prefix>] (default prefix "+DefaultSecretEnvPrefix+"), file:<dir>, or vault:<path>"),
+		examples:    fs.Bool("examples", false, "Include the persona's example conversations as few-shot examples in the system prompt"),
+		env:         fs.String("env", "", "Deployment environment for a profile's conditional skills (e.g., prod, staging)"),
+		tools:       fs.String("tools", "", "Comma-separated tools to give the agent instead of its skills' tools"),
+		noTools:     fs.Bool("no-tools", false, "Give the agent no tools"),
+	}
+}
+
+func (f *agentFlags) options() (*AgentOptions, error) {
+	if *f.tools != "" && *f.noTools {
+		return nil, fmt.Errorf("--tools and --no-tools cannot be combined")
+	}
+	secrets, err := NewSecretProvider(*f.secrets)
+	if err != nil {
+		return nil, err
+	}
+	var tools []string
+	for _, tool := range strings.Split(*f.tools, ",") {
+		if tool = strings.TrimSpace(tool); tool != "" {
+			tools = append(tools, tool)
+		}
+	}
+	return &AgentOptions{
+		Name:        *f.name,
+		Model:       *f.model,
+		Temperature: *f.temperature,
+		Budget:      *f.budget,
+		Secrets:     secrets,
+		Examples:    *f.examples,
+		Env:         *f.env,
+		Tools:       tools,
+		NoTools:     *f.noTools,
+	}, nil
+}
+
+func (cl *cli) runExport(args []string) error {
+	fs := cl.flagSet("export")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	agentFlags := addAgentFlags(fs)
+	formatFlag := fs.String("format", "", "Output format: "+strings.Join(ExportFormats(), ", ")+" (default "+DefaultExportFormat+")")
+	templateFlag := fs.String("template", "", "Path of a Go text/template to render instead of a built-in format")
+	bundleFlag := fs.String("bundle", "", "Write a self-contained bundle to this directory: the agent, its prompt, and its items' files")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *templateFlag != "" && *formatFlag != "" {
+		return fmt.Errorf("--format and --template cannot be combined")
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("export requires a persona or profile name (e.g., @cmo or +platform-engineer)")
+	}
+
+	name := fs.Arg(0)
+	if kind, _ := ParseItemName(name); kind != KindPersona && kind != KindProfile {
+		return fmt.Errorf("export only works with personas and profiles (use @name or +name format)")
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	agentOpts, err := agentFlags.options()
+	if err != nil {
+		return err
+	}
+	exportOpts := &ExportOptions{
+		AgentOptions: *agentOpts,
+		Format:       *formatFlag,
+		Template:     *templateFlag,
+	}
+	if *bundleFlag != "" {
+		bundle, err := client.ExportBundle(cl.ctx, name, *bundleFlag, exportOpts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cl.stdout, "Bundled %s into %s:\n", bundle.Name, *bundleFlag)
+		fmt.Fprintf(cl.stdout, "  %-12s %s\n", "agent", bundle.Agent)
+		fmt.Fprintf(cl.stdout, "  %-12s %s\n", "prompt", bundle.Prompt)
+		for _, item := range bundle.Items {
+			fmt.Fprintf(cl.stdout, "  %-12s %s %s\n", item.Path, FormatItemName(item.Kind, item.Name), item.Version)
+		}
+		return nil
+	}
+
+	content, err := client.Export(cl.ctx, name, exportOpts)
+	if err != nil {
+		return err
+	}
+	_, err = cl.stdout.Write(content)
+	return err
+}
+
+func (cl *cli) runDeploy(args []string) error {
+	fs := cl.flagSet("deploy")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	promptsOnlyFlag := fs.Bool("refresh-prompts-only", false, "Update only the system prompt of agents already in tron targets, keeping their model, budget, and tools")
+	lintFlag := fs.Bool("lint", false, "Check the combined prompts for contradictory instructions first, and don't deploy if there are any")
+	agentFlags := addAgentFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 || (fs.NArg() < 2 && !*lintFlag) {
+		return fmt.Errorf("deploy requires a persona or profile name and at least one target (e.g., @cmo claude:.)")
+	}
+
+	// Check every address before deploying anywhere
+	var deployTargets []Target
+	for _, address := range fs.Args()[1:] {
+		target, err := NewTarget(address)
+		if err != nil {
+			return err
+		}
+		if *promptsOnlyFlag {
+			tron, ok := target.(TronTarget)
+			if !ok {
+				return fmt.Errorf("--refresh-prompts-only only applies to tron targets, not %s", address)
+			}
+			tron.PromptsOnly = true
+			target = tron
+		}
+		deployTargets = append(deployTargets, target)
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	agentOpts, err := agentFlags.options()
+	if err != nil {
+		return err
+	}
+
+	// Contradictions are reported before anything is written
+	if *lintFlag {
+		contradictions, err := client.Contradictions(cl.ctx, fs.Arg(0), agentOpts)
+		if err != nil {
+			return err
+		}
+		for _, c := range contradictions {
+			fmt.Fprintf(cl.stdout, "  %s\n", c)
+		}
+		if len(contradictions) > 0 && len(deployTargets) == 0 {
+			return fmt.Errorf("%d contradiction(s) found in the prompts of %s", len(contradictions), fs.Arg(0))
+		}
+		if len(contradictions) > 0 {
+			return fmt.Errorf("%d contradiction(s) found in the prompts of %s; not deploying", len(contradictions), fs.Arg(0))
+		}
+		fmt.Fprintf(cl.stdout, "No contradictions found in the prompts of %s\n", fs.Arg(0))
+		if len(deployTargets) == 0 {
+			return nil
+		}
+	}
+
+	agent, err := client.Agent(cl.ctx, fs.Arg(0), agentOpts)
+	if err != nil {
+		return err
+	}
+
+	for i, target := range deployTargets {
+		address := fs.Arg(i + 1)
+		if err := target.Deploy(cl.ctx, agent); err != nil {
+			return fmt.Errorf("deploying to %s: %w", address, err)
+		}
+		fmt.Fprintf(cl.stdout, "Deployed %s to %s\n", FormatItemName(ParseItemName(fs.Arg(0))), address)
+	}
+	return nil
+}
+
+func (cl *cli) runPreflight(args []string) error {
+	fs := cl.flagSet("preflight")
+	envFlag := fs.String("env", "", "Deployment environment for conditional profile skills (e.g., prod)")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	gaps, err := client.Preflight(cl.ctx, fs.Arg(0), *envFlag)
+	if err != nil {
+		return err
+	}
+
+	if len(gaps) == 0 {
+		fmt.Fprintf(cl.stdout, "Preflight passed on %s\n", CurrentPlatform(*envFlag))
+		return nil
+	}
+
+	for _, gap := range gaps {
+		fmt.Fprintf(cl.stdout, "%-24s missing %s %s\n", FormatItemName(gap.Kind, gap.Name), gap.Type, gap.Need)
+		fmt.Fprintf(cl.stdout, "%-24s   %s\n", "", gap.Hint)
+	}
+	return fmt.Errorf("%d preflight gap(s) found", len(gaps))
+}
+
+func (cl *cli) runBudget(args []string) error {
+	fs := cl.flagSet("budget")
+	envFlag := fs.String("env", "", "Deployment environment for conditional profile skills (e.g., prod)")
+	modelFlag := fs.String("model", "", "Model or model alias every agent would use (default from installed settings, else "+defaultExportModel+")")
+	budgetFlag := fs.String("budget", "", "Budget every agent would be given (default from the manifests or installed settings, else "+defaultExportBudget+")")
+	capFlag := fs.String("cap", "", "Cap on the total budget (default the installed settings' max_total_budget)")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("budget requires the personas and profiles deployed together (e.g., +platform-engineer @cmo)")
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	report, err := client.Budget(cl.ctx, fs.Args(), &BudgetOptions{
+		Env:    *envFlag,
+		Model:  *modelFlag,
+		Budget: *budgetFlag,
+		Cap:    *capFlag,
+	})
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		if err := writeOutput(cl.stdout, output, report); err != nil {
+			return err
+		}
+	} else {
+		for _, agent := range report.Agents {
+			over := ""
+			if agent.OverCap {
+				over = "  over the " + report.AgentCap + " cap"
+			}
+			fmt.Fprintf(cl.stdout, "  %-30s  %-28s  %8s  (%s)%s\n", agent.Item, agent.Model, agent.Budget, agent.From, over)
+		}
+		fmt.Fprintln(cl.stdout)
+		if report.TotalCap != "" {
+			fmt.Fprintf(cl.stdout, "Total: %s (cap %s)\n", report.Total, report.TotalCap)
+		} else {
+			fmt.Fprintf(cl.stdout, "Total: %s\n", report.Total)
+		}
+	}
+
+	if !report.OverCap {
+		return nil
+	}
+	var over int
+	for _, agent := range report.Agents {
+		if agent.OverCap {
+			over++
+		}
+	}
+	if over > 0 {
+		return fmt.Errorf("%d agent budget(s) exceed the cap of %s", over, report.AgentCap)
+	}
+	return fmt.Errorf("projected total of %s exceeds the cap of %s", report.Total, report.TotalCap)
+}
+
+func (cl *cli) runDemo(args []string) error {
+	fs := cl.flagSet("demo")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("demo requires a persona name (e.g., @cmo)")
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	examples, err := client.Examples(cl.ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if len(examples) == 0 {
+		fmt.Fprintf(cl.stdout, "%s has no example conversations\n", name)
+		return nil
+	}
+
+	for i, ex := range examples {
+		if i > 0 {
+			fmt.Fprintln(cl.stdout)
+		}
+		title := ex.Title
+		if title == "" {
+			title = fmt.Sprintf("Example %d", i+1)
+		}
+		fmt.Fprintf(cl.stdout, "=== %s ===\n", title)
+		for _, m := range ex.Messages {
+			fmt.Fprintf(cl.stdout, "\n%s:\n", titleCase(m.Role))
+			cl.printPrefixed("  ", strings.TrimSpace(m.Content))
+		}
+	}
+
+	return nil
+}
+
+func (cl *cli) runUpdate(args []string) error {
+	fs := cl.flagSet("update")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cl.stdout, "Updating cache...")
+	if err := client.UpdateCache(cl.ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cl.stdout, "Cache updated successfully")
+	return nil
+}
+
+func (cl *cli) runMirror(args []string) error {
+	fs := cl.flagSet("mirror")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	publishFlag := fs.String("publish", "", "Write a static mirror to this directory")
+	verifyFlag := fs.String("verify", "", "Verify that the mirror in this directory matches the source")
+	signKeyFlag := fs.String("sign-key", "", "Ed25519 private key (PEM) used to sign the checksums")
+	publicKeyFlag := fs.String("public-key", "", "Ed25519 public key (PEM) used to verify the checksums signature")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// A bare directory is where to publish, like --publish
+	if fs.NArg() > 1 || (fs.NArg() == 1 && *publishFlag != "") {
+		return fmt.Errorf("mirror takes one destination directory")
+	}
+	if fs.NArg() == 1 {
+		*publishFlag = fs.Arg(0)
+	}
+	if (*publishFlag == "") == (*verifyFlag == "") {
+		return fmt.Errorf("mirror requires a destination directory or --verify")
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	if *publishFlag != "" {
+		mirrorOpts := &MirrorOptions{}
+		if *signKeyFlag != "" {
+			key, err := LoadPrivateKey(*signKeyFlag)
+			if err != nil {
+				return err
+			}
+			mirrorOpts.SigningKey = key
+		}
+
+		report, err := client.Mirror(cl.ctx, *publishFlag, mirrorOpts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cl.stdout, "Mirrored %d file(s) to %s\n", report.Files, report.Dir)
+		if len(report.Missing) > 0 {
+			fmt.Fprintf(cl.stdout, "Skipped %d indexed item(s) without a manifest: %s\n", len(report.Missing), strings.Join(report.Missing, ", "))
+		}
+		if report.Signed {
+			fmt.Fprintf(cl.stdout, "Signed %s\n", ChecksumsFile)
+		}
+		return nil
+	}
+
+	var key ed25519.PublicKey
+	if *publicKeyFlag != "" {
+		key, err = LoadPublicKey(*publicKeyFlag)
+		if err != nil {
+			return err
+		}
+	}
+
+	mismatches, err := client.VerifyMirror(cl.ctx, *verifyFlag, key)
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Fprintf(cl.stdout, "Mirror %s matches %s\n", *verifyFlag, client.Source())
+		return nil
+	}
+
+	for _, m := range mismatches {
+		fmt.Fprintf(cl.stdout, "  %-50s  %s\n", m.Path, m.Reason)
+	}
+	return fmt.Errorf("mirror %s has %d mismatch(es)", *verifyFlag, len(mismatches))
+}
+
+func (cl *cli) runDaemon(args []string) error {
+	if len(args) > 0 && args[0] == "status" {
+		return cl.runDaemonStatus(args[1:])
+	}
+	if len(args) > 0 && args[0] == "install-service" {
+		return cl.runDaemonInstallService(args[1:])
+	}
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+
+	fs := cl.flagSet("daemon")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	intervalFlag := fs.Duration("interval", DefaultDaemonInterval, "Refresh interval")
+	prefetchFlag := fs.String("prefetch", "", "Kinds whose manifests are prefetched (comma-separated: skill, persona, profile)")
+	syncFlag := fs.String("sync", "", "Deps file to sync after every refresh")
+	notifyFlag := fs.Bool("notify", false, "Announce upstream updates to installed items on the next CLI run")
+	webhookFlag := fs.String("notify-webhook", "", "Also POST upstream updates to this URL")
+	desktopFlag := fs.Bool("notify-desktop", false, "Also show upstream updates as desktop notifications")
+	debugFlag := fs.String("debug-addr", "", "Serve pprof and runtime metrics on this loopback address (e.g. 127.0.0.1:6060)")
+	shutdownFlag := fs.Duration("shutdown-timeout", DefaultShutdownTimeout, "How long to drain in-flight work on SIGINT or SIGTERM")
+	accessLogFlag := fs.String("access-log", "", "Log each request as a line of JSON to this file (- for stdout)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	accessLog, closeLog, err := cl.openAccessLog(*accessLogFlag)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	daemonOpts := &DaemonOptions{
+		Interval:  *intervalFlag,
+		Sync:      *syncFlag,
+		Debug:     *debugFlag,
+		AccessLog: accessLog,
+
+		ShutdownTimeout: *shutdownFlag,
+	}
+	if daemonOpts.Debug != "" {
+		if err := checkLoopback(daemonOpts.Debug); err != nil {
+			return err
+		}
+	}
+	if *notifyFlag || *webhookFlag != "" || *desktopFlag {
+		daemonOpts.Notify = &NotifyOptions{
+			Webhook: *webhookFlag,
+			Desktop: *desktopFlag,
+		}
+	}
+	if *prefetchFlag != "" {
+		for _, k := range strings.Split(*prefetchFlag, ",") {
+			daemonOpts.Prefetch = append(daemonOpts.Prefetch, ItemKind(strings.TrimSpace(k)))
+		}
+	}
+
+	daemon := client.NewDaemon(daemonOpts)
+	fmt.Fprintf(cl.stdout, "Refreshing %s every %s (socket: %s)\n", client.Source(), daemonOpts.Interval, client.daemonSocket())
+	if daemonOpts.Debug != "" {
+		fmt.Fprintf(cl.stdout, "Serving pprof and runtime metrics on http://%s/debug/\n", daemonOpts.Debug)
+	}
+	return daemon.Run(cl.ctx)
+}
+
+// openAccessLog opens the access log a server writes to: nothing for "",
+// stdout for "-", or else a file, appended to.
+func (cl *cli) openAccessLog(path string) (io.Writer, func(), error) {
+	switch path {
+	case "":
+		return nil, func() {}, nil
+	case "-":
+		return cl.stdout, func() {}, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening access log: %w", err)
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// signalContext returns a context cancelled by SIGINT or SIGTERM, printing
+// notice. A second signal kills the process.
+func (cl *cli) signalContext(notice string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			// Restore the default handling, so a second signal exits immediately
+			signal.Stop(signals)
+			fmt.Fprintln(cl.stderr, notice)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+func (cl *cli) runServe(args []string) error {
+	fs := cl.flagSet("serve")
+	addrFlag := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	dirFlag := fs.String("dir", ".", "Registry checkout to serve")
+	installedFlag := fs.Bool("installed", false, "Serve the installed items instead of a registry checkout")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory (with --installed)")
+	tokenFlag := fs.String("token", "", "Require this token from clients (bearer token or basic auth password)")
+	tokensFlag := fs.String("tokens", "", "YAML file of further tokens with read or publish roles")
+	writableFlag := fs.Bool("writable", false, "Accept uploads from clients with a publish token")
+	uiFlag := fs.Bool("ui", false, "Serve a web UI for browsing the items at /ui/")
+	debugFlag := fs.String("debug-addr", "", "Serve pprof and runtime metrics on this loopback address (e.g. 127.0.0.1:6060)")
+	shutdownFlag := fs.Duration("shutdown-timeout", DefaultShutdownTimeout, "How long to drain in-flight work on SIGINT or SIGTERM")
+	accessLogFlag := fs.String("access-log", "", "Log each request as a line of JSON to this file (- for stdout)")
+	rateFlag := fs.Float64("rate-limit", 0, "Requests per second allowed per client IP (0 for no limit)")
+	burstFlag := fs.Int("rate-burst", 0, "Requests a client may make in a burst (default the rate limit)")
+	concurrentFlag := fs.Int("max-concurrent", 0, "Requests served at once (0 for no limit)")
+	keepFlag := fs.Int("keep-versions", 0, "With --writable, versions of each item to keep when collecting garbage (0 to keep all)")
+	keepReferencedFlag := fs.Bool("keep-referenced", true, "Also keep skill versions pinned by kept profile versions")
+	gcIntervalFlag := fs.Duration("gc-interval", DefaultGCInterval, "How often to collect garbage with --keep-versions")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dir := *dirFlag
+	var encryptionKey []byte
+	if *installedFlag {
+		var opts []Option
+		if *installDirFlag != "" {
+			opts = append(opts, WithInstallDir(*installDirFlag))
+		}
+		client, err := cl.newClient(opts...)
+		if err != nil {
+			return err
+		}
+		dir = client.InstallDir()
+		encryptionKey = client.encryptionKey
+	}
+
+	var tokens []ServerToken
+	if *tokensFlag != "" {
+		var err error
+		if tokens, err = LoadServerTokens(*tokensFlag); err != nil {
+			return err
+		}
+	}
+
+	var retention *RetentionPolicy
+	if *keepFlag > 0 {
+		retention = &RetentionPolicy{KeepLast: *keepFlag, KeepReferenced: *keepReferencedFlag}
+	}
+
+	accessLog, closeLog, err := cl.openAccessLog(*accessLogFlag)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	server, err := NewServer(dir, &ServerOptions{
+		Token:           *tokenFlag,
+		Tokens:          tokens,
+		Writable:        *writableFlag,
+		Retention:       retention,
+		GCInterval:      *gcIntervalFlag,
+		Log:             cl.stderr,
+		AccessLog:       accessLog,
+		UI:              *uiFlag,
+		Installed:       *installedFlag,
+		EncryptionKey:   encryptionKey,
+		RateLimit:       *rateFlag,
+		RateBurst:       *burstFlag,
+		MaxConcurrent:   *concurrentFlag,
+		ShutdownTimeout: *shutdownFlag,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *debugFlag != "" {
+		shutdown, err := startDebugServer(*debugFlag)
+		if err != nil {
+			return err
+		}
+		defer shutdown(context.Background())
+	}
+
+	fmt.Fprintf(cl.stdout, "Serving %s on http://%s/\n", dir, *addrFlag)
+	if *uiFlag {
+		fmt.Fprintf(cl.stdout, "Web UI at http://%s/ui/\n", *addrFlag)
+	}
+	if *writableFlag {
+		fmt.Fprintln(cl.stdout, "Accepting uploads from publish tokens")
+	}
+	if !server.readsNeedToken() {
+		fmt.Fprintln(cl.stdout, "Warning: no --token set; anyone who can reach the address can read the registry")
+	}
+	return server.Run(cl.ctx, *addrFlag)
+}
+
+func (cl *cli) runDaemonStatus(args []string) error {
+	fs := cl.flagSet("daemon status")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := cl.newClient()
+	if err != nil {
+		return err
+	}
+
+	status, running, err := client.DaemonStatus(cl.ctx)
+	if err != nil {
+		return err
+	}
+
+	if running {
+		fmt.Fprintln(cl.stdout, "Daemon:       running")
+	} else {
+		fmt.Fprintln(cl.stdout, "Daemon:       not running (showing last recorded status)")
+	}
+	fmt.Fprintf(cl.stdout, "Source:       %s\n", status.Source)
+	fmt.Fprintf(cl.stdout, "Last refresh: %s (%dms)\n", status.LastRefresh.Format(time.RFC3339), status.DurationMS)
+	fmt.Fprintf(cl.stdout, "Next refresh: %s\n", status.NextRefresh.Format(time.RFC3339))
+	fmt.Fprintf(cl.stdout, "Cached:       %d index(es), %d manifest(s)\n", status.Indexes, status.Manifests)
+	fmt.Fprintf(cl.stdout, "Refreshes:    %d (%d failed)\n", status.Refreshes, status.Failures)
+	if status.LastError != "" {
+		fmt.Fprintf(cl.stdout, "Last error:   %s\n", status.LastError)
+	}
+	if !status.LastSync.IsZero() {
+		fmt.Fprintf(cl.stdout, "Last sync:    %s\n", status.LastSync.Format(time.RFC3339))
+	}
+	if status.SyncError != "" {
+		fmt.Fprintf(cl.stdout, "Sync error:   %s\n", status.SyncError)
+	}
+
+	return nil
+}
+
+func (cl *cli) runDaemonInstallService(args []string) error {
+	fs := cl.flagSet("daemon install-service")
+	managerFlag := fs.String("manager", "", "Service manager (systemd, launchd; default: detected)")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	intervalFlag := fs.Duration("interval", DefaultDaemonInterval, "Refresh and sync interval")
+	depsFlag := fs.String("deps", "", "Deps file to sync periodically")
+	printFlag := fs.Bool("print", false, "Print the service file instead of writing it")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	file, err := GenerateService(ServiceOptions{
+		Manager:  *managerFlag,
+		Source:   *sourceFlag,
+		Interval: *intervalFlag,
+		Deps:     *depsFlag,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *printFlag {
+		fmt.Fprint(cl.stdout, string(file.Content))
+		return nil
+	}
+
+	for _, dir := range append([]string{filepath.Dir(file.Path)}, file.Dirs...) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(file.Path, file.Content, 0644); err != nil {
+		return fmt.Errorf("writing service file: %w", err)
+	}
+
+	fmt.Fprintf(cl.stdout, "Wrote %s service to %s\n", file.Manager, file.Path)
+	fmt.Fprintf(cl.stdout, "Enable it with:\n  %s\n", file.Enable)
+	return nil
+}
+
+func (cl *cli) runWhatsNew(args []string) error {
+	fs := cl.flagSet("whatsnew")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	installedFlag := fs.Bool("installed", false, "Only show installed items with newer upstream versions")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	if *installedFlag {
+		outdated, err := client.Outdated(cl.ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(outdated) == 0 {
+			fmt.Fprintln(cl.stdout, "All installed items are up to date")
+			return nil
+		}
+
+		for _, item := range outdated {
+			fmt.Fprintf(cl.stdout, "  %-30s  %s -> %s\n", FormatItemName(item.Kind, item.Name), item.Installed, item.Latest)
+		}
+		return nil
+	}
+
+	changed, err := client.WhatsNew(cl.ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		fmt.Fprintln(cl.stdout, "Nothing new since the last check")
+		return nil
+	}
+
+	for _, r := range changed {
+		fmt.Fprintf(cl.stdout, "  %-30s  v%-8s  %s\n", FormatItemName(r.Kind, r.Name), r.Version, r.Description)
+	}
+	return nil
+}
+
+func (cl *cli) runUpdatePR(args []string) error {
+	fs := cl.flagSet("update-pr")
+	repoFlag := fs.String("repo", ".", "Project directory holding "+WorkspaceFile+" or "+DefaultDepsFile+", and "+LockFile)
+	branchFlag := fs.String("branch", DefaultUpdateBranch, "Branch to commit the update to")
+	baseFlag := fs.String("base", "", "Branch the pull request targets (default: the current branch)")
+	dryRunFlag := fs.Bool("dry-run", false, "Show the updates and message without committing anything")
+	githubTokenFlag := fs.String("github-token", "", "GitHub token to push the branch and open a pull request with")
+	apiFlag := fs.String("api", DefaultGitHubAPI, "GitHub API URL, for GitHub Enterprise")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (default: the workspace's source)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("update-pr takes no arguments (use --repo for the project directory)")
+	}
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	// The spec is the project's workspace file, else its deps file
+	var opts []Option
+	var deps *Deps
+	if ws, err := LoadWorkspace(filepath.Join(*repoFlag, WorkspaceFile)); err == nil {
+		opts = append(opts, WithWorkspace(ws))
+		deps = ws.Deps()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	} else if deps, err = LoadDeps(filepath.Join(*repoFlag, DefaultDepsFile)); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s has neither a %s nor a %s", *repoFlag, WorkspaceFile, DefaultDepsFile)
+	} else if err != nil {
+		return err
+	}
+
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.UpdatePR(cl.ctx, deps, filepath.Join(*repoFlag, LockFile), &UpdatePROptions{
+		Branch: *branchFlag,
+		Base:   *baseFlag,
+		DryRun: *dryRunFlag,
+		Token:  *githubTokenFlag,
+		API:    *apiFlag,
+	})
+	if err != nil {
+		return err
+	}
+	if output.Structured() {
+		return writeOutput(cl.stdout, output, result)
+	}
+
+	if len(result.Updates) == 0 {
+		fmt.Fprintln(cl.stdout, "The lockfile is up to date")
+		return nil
+	}
+	if result.DryRun {
+		fmt.Fprintf(cl.stdout, "%s\n\n%s", result.Title, result.Body)
+		return nil
+	}
+	fmt.Fprintf(cl.stdout, "Committed %s to %s (%s)\n", result.Title, result.Branch, result.Commit[:12])
+	if result.PullRequest != "" {
+		fmt.Fprintf(cl.stdout, "Pull request: %s\n", result.PullRequest)
+	} else {
+		fmt.Fprintf(cl.stdout, "Push it and open a pull request, or pass --github-token to have it done\n")
+	}
+	return nil
+}
+
+func (cl *cli) runOutdated(args []string) error {
+	fs := cl.flagSet("outdated")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	jsonFlag := fs.Bool("json", false, "Print the outdated items as JSON (same as --output json)")
+	exitCodeFlag := fs.Bool("exit-code", false, "Exit with an error if any item is outdated")
+	recursiveFlag := fs.Bool("recursive", false, "Check every "+WorkspaceFile+" and "+DefaultDepsFile+" under the current directory")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+	if *jsonFlag {
+		output = OutputJSON
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *recursiveFlag {
+		if *installDirFlag != "" {
+			return fmt.Errorf("--recursive checks the install directory next to each spec; it can't be used with --install-dir")
+		}
+		return cl.outdatedRecursive(opts, *exitCodeFlag, output)
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	outdated, err := client.Outdated(cl.ctx)
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		if outdated == nil {
+			outdated = []OutdatedItem{}
+		}
+		if err := writeOutput(cl.stdout, output, outdated); err != nil {
+			return err
+		}
+	} else if len(outdated) == 0 {
+		fmt.Fprintln(cl.stdout, "All installed items are up to date")
+	} else {
+		for _, item := range outdated {
+			fmt.Fprintf(cl.stdout, "  %-30s  %s -> %s\n", FormatItemName(item.Kind, item.Name), item.Installed, item.Latest)
+		}
+	}
+
+	if *exitCodeFlag && len(outdated) > 0 {
+		return fmt.Errorf("%d installed item(s) are outdated", len(outdated))
+	}
+	return nil
+}
+
+// outdatedResult is the outdated items of one directory with --recursive.
+type outdatedResult struct {
+	Dir      string         `json:"dir" yaml:"dir"`
+	Outdated []OutdatedItem `json:"outdated" yaml:"outdated"`
+	Error    string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// outdatedRecursive lists the outdated items of every workspace under the
+// working directory, going on past failures.
+func (cl *cli) outdatedRecursive(common []Option, exitCode bool, output OutputFormat) error {
+	workspaces, err := FindWorkspaces(".")
+	if err != nil {
+		return err
+	}
+	if len(workspaces) == 0 {
+		return fmt.Errorf("no %s or %s found under the current directory", WorkspaceFile, DefaultDepsFile)
+	}
+
+	results := make([]outdatedResult, 0, len(workspaces))
+	failed, outdated, stale := 0, 0, 0
+	for _, ws := range workspaces {
+		result := outdatedResult{Dir: relDir(ws.Dir), Outdated: []OutdatedItem{}}
+		client, err := cl.newClient(append([]Option{WithWorkspace(ws)}, common...)...)
+		if err == nil {
+			var items []OutdatedItem
+			if items, err = client.Outdated(cl.ctx); items != nil {
+				result.Outdated = items
+			}
+		}
+		if err != nil {
+			if cl.ctx.Err() != nil {
+				return cl.ctx.Err()
+			}
+			failed++
+			result.Error = err.Error()
+		} else if len(result.Outdated) > 0 {
+			stale++
+			outdated += len(result.Outdated)
+		}
+		results = append(results, result)
+	}
+
+	if output.Structured() {
+		if err := writeOutput(cl.stdout, output, results); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range results {
+			switch {
+			case result.Error != "":
+				fmt.Fprintf(cl.stdout, "%s: failed: %s\n", result.Dir, result.Error)
+			case len(result.Outdated) == 0:
+				fmt.Fprintf(cl.stdout, "%s: up to date\n", result.Dir)
+			default:
+				fmt.Fprintf(cl.stdout, "%s: %d outdated\n", result.Dir, len(result.Outdated))
+				for _, item := range result.Outdated {
+					fmt.Fprintf(cl.stdout, "  %-30s  %s -> %s\n", FormatItemName(item.Kind, item.Name), item.Installed, item.Latest)
+				}
+			}
+		}
+		fmt.Fprintf(cl.stdout, "\n%d of %d directories have outdated items\n", stale, len(workspaces))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d directories failed to check", failed, len(workspaces))
+	}
+	if exitCode && outdated > 0 {
+		return fmt.Errorf("%d installed item(s) are outdated in %d directories", outdated, stale)
+	}
+	return nil
+}
+
+func (cl *cli) runDeps(args []string) error {
+	fs := cl.flagSet("deps")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: vega population deps <name>")
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	graph, err := client.DependencyGraph(cl.ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if output.Structured() {
+		return writeOutput(cl.stdout, output, graph)
+	}
+
+	fmt.Fprintf(cl.stdout, "%s %s\n", FormatItemName(graph.Kind, graph.Name), graph.Version)
+	cl.printDependencies(graph.Dependencies, "")
+	return nil
+}
+
+// printDependencies prints dependency nodes as a tree below their parent.
+func (cl *cli) printDependencies(nodes []*DependencyNode, indent string) {
+	for i, node := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		line := FormatItemName(node.Kind, node.Name)
+		if node.Version != "" {
+			line += " " + node.Version
+		}
+		if node.Via != DependsOnSkill {
+			line += " (" + string(node.Via) + ")"
+		}
+		if node.Constraint != "" {
+			line += " [" + node.Constraint + "]"
+		}
+		if node.Condition != "" {
+			line += " [" + node.Condition + "]"
+		}
+		if node.Error != "" {
+			line += " - " + node.Error
+		}
+		fmt.Fprintf(cl.stdout, "%s%s%s\n", indent, branch, line)
+		cl.printDependencies(node.Dependencies, indent+next)
+	}
+}
+
+func (cl *cli) runWhy(args []string) error {
+	fs := cl.flagSet("why")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: vega population why <name>")
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	dependents, err := client.Dependents(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		if dependents == nil {
+			dependents = []Dependent{}
+		}
+		return writeOutput(cl.stdout, output, dependents)
+	}
+	if len(dependents) == 0 {
+		fmt.Fprintf(cl.stdout, "No installed items depend on %s\n", fs.Arg(0))
+		return nil
+	}
+	for _, d := range dependents {
+		fmt.Fprintf(cl.stdout, "  %s (%s)\n", strings.Join(d.Path, " → "), d.Via)
+	}
+	return nil
+}
+
+func (cl *cli) runUpgrade(args []string) error {
+	fs := cl.flagSet("upgrade")
+	dryRunFlag := fs.Bool("dry-run", false, "Show what would be upgraded")
+	envFlag := fs.String("env", "", "Deployment environment for conditional profile skills")
+	forceFlag := fs.Bool("force", false, "Upgrade items changed since they were installed, discarding the changes")
+	sourceFlag := fs.String("source", "", "Custom source URL or path (comma-separated for several, highest priority first)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	upgraded, err := client.Upgrade(cl.ctx, fs.Args(), &UpgradeOptions{
+		DryRun: *dryRunFlag,
+		Env:    *envFlag,
+		Force:  *forceFlag,
+	})
+	for _, item := range upgraded {
+		verb := "Upgraded"
+		if *dryRunFlag {
+			verb = "Would upgrade"
+		}
+		fmt.Fprintf(cl.stdout, "%s %s (%s -> %s)\n", verb, FormatItemName(item.Kind, item.Name), item.Installed, item.Latest)
+		cl.printFileChanges("  ", item.Files)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(upgraded) == 0 {
+		fmt.Fprintln(cl.stdout, "Nothing to upgrade")
+	}
+	return nil
+}
+
+func (cl *cli) runPush(args []string) error {
+	fs := cl.flagSet("push")
+	registryFlag := fs.String("registry", "", "Registry checkout to push into")
+	remoteFlag := fs.String("remote", "", "URL of a writable registry server to upload to instead of a checkout")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	majorFlag := fs.Bool("major", false, "Bump the major version")
+	minorFlag := fs.Bool("minor", false, "Bump the minor version")
+	reasonFlag := fs.String("reason", "", "Changelog entry for the new version")
+	dryRunFlag := fs.Bool("dry-run", false, "Show what would be pushed")
+	allowShadowFlag := fs.Bool("allow-shadow", false, "Push a new item even if a source already has one of the same name")
+	sourceFlag := fs.String("source", "", "Sources checked for name collisions (comma-separated; default: the public source)")
+	tokenFlag := fs.String("token", "", "Bearer token for the registry and sources (default $VEGA_POPULATION_TOKEN)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("push requires a name argument")
+	}
+	registry, err := registryOrRemote("push", *registryFlag, *remoteFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	pushOpts := &PushOptions{
+		Bump:   BumpPatch,
+		Reason: *reasonFlag,
+		DryRun: *dryRunFlag,
+
+		AllowShadow: *allowShadowFlag,
+	}
+	if *tokenFlag != "" {
+		pushOpts.Auth = &SourceAuth{Token: *tokenFlag}
+	}
+	if *minorFlag {
+		pushOpts.Bump = BumpMinor
+	}
+	if *majorFlag {
+		pushOpts.Bump = BumpMajor
+	}
+
+	for _, name := range fs.Args() {
+		result, err := client.Push(cl.ctx, name, registry, pushOpts)
+		if err != nil {
+			return err
+		}
+
+		display := FormatItemName(result.Kind, result.Name)
+		from := result.OldVersion
+		if from == "" {
+			from = "new"
+		}
+		if *dryRunFlag {
+			fmt.Fprintf(cl.stdout, "Would push %s (%s -> %s) to %s\n", display, from, result.NewVersion, result.Path)
+		} else {
+			fmt.Fprintf(cl.stdout, "Pushed %s (%s -> %s) to %s\n", display, from, result.NewVersion, result.Path)
+		}
+	}
+
+	return nil
+}
+
+func (cl *cli) runGC(args []string) error {
+	fs := cl.flagSet("gc")
+	registryFlag := fs.String("registry", ".", "Registry checkout to collect")
+	keepFlag := fs.Int("keep", 0, "Versions of each item to keep, counting the current one (required)")
+	keepReferencedFlag := fs.Bool("keep-referenced", true, "Also keep skill versions pinned by kept profile versions")
+	dryRunFlag := fs.Bool("dry-run", false, "Show what would be removed")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keepFlag < 1 {
+		return fmt.Errorf("gc requires --keep of at least 1")
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	registry, err := OpenLocalRegistry(*registryFlag)
+	if err != nil {
+		return err
+	}
+	result, err := registry.CollectGarbage(RetentionPolicy{KeepLast: *keepFlag, KeepReferenced: *keepReferencedFlag}, *dryRunFlag)
+	if err != nil {
+		return err
+	}
+	if output.Structured() {
+		return writeOutput(cl.stdout, output, result)
+	}
+
+	verb := "Removed"
+	if *dryRunFlag {
+		verb = "Would remove"
+	}
+	for _, v := range result.Removed {
+		fmt.Fprintf(cl.stdout, "%s %s %s\n", verb, FormatItemName(v.Kind, v.Name), v.Version)
+	}
+	if result.Uploads > 0 {
+		fmt.Fprintf(cl.stdout, "%s %d stale upload(s)\n", verb, result.Uploads)
+	}
+	if *dryRunFlag && len(result.Files) > 0 {
+		fmt.Fprintln(cl.stdout, "Files:")
+		cl.printFileChanges("  ", result.Files)
+	}
+	if len(result.Removed) == 0 && result.Uploads == 0 {
+		fmt.Fprintln(cl.stdout, "Nothing to collect")
+	}
+	return nil
+}
+
+func (cl *cli) runConfig(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	sub := args[0]
+	want, ok := map[string]int{"get": 1, "set": 2, "unset": 1, "list": 0}[sub]
+	if !ok {
+		return fmt.Errorf("unknown config subcommand: %s (want get, set, unset, or list)", sub)
+	}
+	if len(args)-1 != want {
+		return fmt.Errorf("usage: config get <key> | set <key> <value> | unset <key> | list")
+	}
+
+	home, err := VegaHome()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(home, ConfigFile)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if sub == "list" {
+		effective := *cfg
+		if cfg.Auth != nil {
+			auth := *cfg.Auth
+			effective.Auth = &auth
+		}
+		if _, err := effective.ApplyEnv(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cl.stdout, "# %s\n", path)
+		for _, key := range ConfigKeys {
+			value := key.Get(&effective)
+			if value == "" {
+				continue
+			}
+			if key.Name == "auth.token" || key.Name == "auth.password" {
+				value = "(hidden)"
+			}
+			if env, _, set := key.LookupEnv(); set {
+				value += "  (from $" + env + ")"
+			}
+			fmt.Fprintf(cl.stdout, "%s = %s\n", key.Name, value)
+		}
+		return nil
+	}
+
+	key, err := LookupConfigKey(args[1])
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "get":
+		if _, err := cfg.ApplyEnv(); err != nil {
+			return err
+		}
+		if value := key.Get(cfg); value != "" {
+			fmt.Fprintln(cl.stdout, value)
+		}
+		return nil
+	case "set":
+		err = key.Set(cfg, args[2])
+	case "unset":
+		err = key.Set(cfg, "")
+	}
+	if err != nil {
+		return err
+	}
+	if err := cfg.Save(path); err != nil {
+		return err
+	}
+	if env, _, set := key.LookupEnv(); set {
+		fmt.Fprintf(cl.stderr, "Note: $%s overrides %s\n", env, key.Name)
+	}
+	return nil
+}
+
+// config returns the defaults of the config file, with the environment's
+// overrides applied.
+func (cl *cli) config() (*Config, error) {
+	home, err := VegaHome()
+	if err != nil {
+		return nil, err
+	}
+	return loadUserConfig(home)
+}
+
+func (cl *cli) runStatus(args []string) error {
+	fs := cl.flagSet("status")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	exitCodeFlag := fs.Bool("exit-code", false, "Exit with an error if any item has local changes")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	drifted, err := client.Drift()
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		if err := writeOutput(cl.stdout, output, drifted); err != nil {
+			return err
+		}
+	} else if len(drifted) == 0 {
+		fmt.Fprintln(cl.stdout, "All installed items match their install records")
+	} else {
+		for i, item := range drifted {
+			if i > 0 {
+				fmt.Fprintln(cl.stdout)
+			}
+			fmt.Fprintf(cl.stdout, "%s %s:\n", FormatItemName(item.Kind, item.Name), item.Version)
+			if item.Unrecorded {
+				fmt.Fprintf(cl.stdout, "  (no install record; installed by hand or by an older vega)\n")
+			}
+			for _, file := range item.Modified {
+				fmt.Fprintf(cl.stdout, "  modified:   %s\n", file)
+			}
+			for _, file := range item.Missing {
+				fmt.Fprintf(cl.stdout, "  missing:    %s\n", file)
+			}
+			for _, file := range item.Untracked {
+				fmt.Fprintf(cl.stdout, "  untracked:  %s\n", file)
+			}
+		}
+		fmt.Fprintf(cl.stdout, "\nUpgrading these items discards the changes; run 'vega population push' to keep them\n")
+	}
+
+	if *exitCodeFlag && len(drifted) > 0 {
+		return fmt.Errorf("%d installed item(s) have local changes", len(drifted))
+	}
+	return nil
+}
+
+func (cl *cli) runBackup(args []string) error {
+	fs := cl.flagSet("backup")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("backup requires the archive to write (e.g., vega-home.tar.gz)")
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	report, err := client.Backup(cl.ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cl.stdout, "Backed up %d item(s), %d file(s) in all, to %s\n", len(report.Items), report.Files, report.File)
+	return nil
+}
+
+func (cl *cli) runRestore(args []string) error {
+	fs := cl.flagSet("restore")
+	forceFlag := fs.Bool("force", false, "Replace the items already installed")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("restore requires the archive written by backup")
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	report, err := client.Restore(cl.ctx, fs.Arg(0), &RestoreOptions{Force: *forceFlag})
+	if err != nil {
+		return err
+	}
+	for _, item := range report.Items {
+		fmt.Fprintf(cl.stdout, "  %s\n", item)
+	}
+	fmt.Fprintf(cl.stdout, "Restored %d item(s), %d file(s) in all, from %s\n", len(report.Items), report.Files, report.File)
+	return nil
+}
+
+func (cl *cli) runDoctor(args []string) error {
+	fs := cl.flagSet("doctor")
+	fixFlag := fs.Bool("fix", false, "Encrypt plain content when a key is set, and drop cached content encrypted with another key")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	outputFlag := fs.String("output", "table", "Output format: table, json, or yaml")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	output, err := ParseOutputFormat(*outputFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	checks, err := client.Doctor(&DoctorOptions{Fix: *fixFlag})
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, check := range checks {
+		if !check.OK {
+			failed++
+		}
+	}
+	if output.Structured() {
+		if err := writeOutput(cl.stdout, output, checks); err != nil {
+			return err
+		}
+	} else {
+		for _, check := range checks {
+			status := "ok"
+			if !check.OK {
+				status = "FAIL"
+			}
+			detail := check.Detail
+			if check.Fixed > 0 {
+				detail = fmt.Sprintf("%s (fixed %d)", detail, check.Fixed)
+			}
+			fmt.Fprintf(cl.stdout, "%-4s  %-26s %s\n", status, check.Name, detail)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// registryOrRemote returns the registry a command writes to, given as a
+// checkout or URL with --registry or as a server URL with --remote.
+func registryOrRemote(cmd, registry, remote string) (string, error) {
+	switch {
+	case registry != "" && remote != "":
+		return "", fmt.Errorf("%s takes --registry or --remote, not both", cmd)
+	case remote != "":
+		if !isRemoteRegistry(remote) {
+			return "", fmt.Errorf("--remote must be an http:// or https:// URL")
+		}
+		return remote, nil
+	case registry != "":
+		return registry, nil
+	}
+	return "", fmt.Errorf("%s requires --registry or --remote", cmd)
+}
+
+func (cl *cli) runPublish(args []string) error {
+	fs := cl.flagSet("publish")
+	registryFlag := fs.String("registry", "", "Registry checkout or URL to publish to")
+	remoteFlag := fs.String("remote", "", "URL of a writable registry server to publish to (same as a URL --registry)")
+	majorFlag := fs.Bool("major", false, "Bump the major version if the registry already has this version")
+	minorFlag := fs.Bool("minor", false, "Bump the minor version if the registry already has this version")
+	reasonFlag := fs.String("reason", "", "Changelog entry for a bumped version")
+	dryRunFlag := fs.Bool("dry-run", false, "Show what would be published")
+	allowShadowFlag := fs.Bool("allow-shadow", false, "Publish a new item even if a source already has one of the same name")
+	sourceFlag := fs.String("source", "", "Sources checked for name collisions (comma-separated; default: the public source)")
+	tokenFlag := fs.String("token", "", "Bearer token for the registry and sources (default $VEGA_POPULATION_TOKEN)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("publish requires a path argument")
+	}
+	registry, err := registryOrRemote("publish", *registryFlag, *remoteFlag)
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *sourceFlag != "" {
+		opts = append(opts, sourceOption(*sourceFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	publishOpts := &PublishOptions{
+		Bump:   BumpPatch,
+		Reason: *reasonFlag,
+		DryRun: *dryRunFlag,
+
+		AllowShadow: *allowShadowFlag,
+	}
+	if *tokenFlag != "" {
+		publishOpts.Auth = &SourceAuth{Token: *tokenFlag}
+	}
+	if *minorFlag {
+		publishOpts.Bump = BumpMinor
+	}
+	if *majorFlag {
+		publishOpts.Bump = BumpMajor
+	}
+
+	for _, path := range fs.Args() {
+		result, err := client.Publish(cl.ctx, path, registry, publishOpts)
+		var invalid *InvalidManifestError
+		if errors.As(err, &invalid) {
+			fmt.Fprintf(cl.stdout, "%s:\n", path)
+			for _, e := range invalid.Errors {
+				fmt.Fprintf(cl.stdout, "  %s\n", e)
+			}
+			return fmt.Errorf("%d validation error(s) found", len(invalid.Errors))
+		}
+		if err != nil {
+			return err
+		}
+
+		display := FormatItemName(result.Kind, result.Name)
+		from := result.OldVersion
+		if from == "" {
+			from = "new"
+		}
+		verb := "Published"
+		if *dryRunFlag {
+			verb = "Would publish"
+		}
+		fmt.Fprintf(cl.stdout, "%s %s (%s -> %s) to %s\n", verb, display, from, result.Version, registry)
+		fmt.Fprintf(cl.stdout, "  sha256: %s\n", result.SHA256)
+		if len(result.Files) > 0 {
+			fmt.Fprintf(cl.stdout, "  files:  %s\n", strings.Join(result.Files, ", "))
+		}
+		if result.Bumped && !*dryRunFlag {
+			fmt.Fprintf(cl.stdout, "  Bumped %s to %s\n", path, result.Version)
+		}
+	}
+
+	return nil
+}
+
+// signFlags are the flags that choose how manifests are signed.
+type signFlags struct {
+	key, gpgKey   *string
+	gpg, sigstore *bool
+}
+
+func addSignFlags(fs *flag.FlagSet) *signFlags {
+	return &signFlags{
+		key:      fs.String("key", "", "Ed25519 private key (PEM) to sign with"),
+		gpg:      fs.Bool("gpg", false, "Sign with gpg instead of an Ed25519 key"),
+		gpgKey:   fs.String("gpg-key", "", "GPG key to sign with (default: gpg's default key)"),
+		sigstore: fs.Bool("sigstore", false, "Sign keylessly with cosign (sigstore) instead of an Ed25519 key"),
+	}
+}
+
+// options returns the chosen signing options, or nil if no signing flag was given.
+func (f *signFlags) options() (*SignOptions, error) {
+	signOpts := &SignOptions{Type: SignatureEd25519, GPGKey: *f.gpgKey}
+	switch {
+	case *f.gpg && *f.sigstore:
+		return nil, fmt.Errorf("use only one of --gpg and --sigstore")
+	case *f.gpg:
+		signOpts.Type = SignatureGPG
+	case *f.sigstore:
+		signOpts.Type = SignatureSigstore
+	case *f.key == "":
+		return nil, nil
+	default:
+		key, err := LoadPrivateKey(*f.key)
+		if err != nil {
+			return nil, err
+		}
+		signOpts.Key = key
+	}
+	return signOpts, nil
+}
+
+func (cl *cli) runSign(args []string) error {
+	fs := cl.flagSet("sign")
+	registryFlag := fs.String("registry", ".", "Registry checkout used to resolve names")
+	signFlags := addSignFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("sign requires a path or name argument")
+	}
+
+	signOpts, err := signFlags.options()
+	if err != nil {
+		return err
+	}
+	if signOpts == nil {
+		return fmt.Errorf("sign requires --key, --gpg, or --sigstore")
+	}
+
+	for _, arg := range fs.Args() {
+		path := arg
+		if _, err := os.Stat(arg); err != nil {
+			kind, name := ParseItemName(arg)
+			path = filepath.Join(*registryFlag, kind.Plural(), name, "vega.yaml")
+		}
+
+		result, err := SignManifest(cl.ctx, path, signOpts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cl.stdout, "Signed %s %s (%s)\n", arg, result.Version, result.Path)
+		if result.IndexUpdated {
+			fmt.Fprintf(cl.stdout, "  index entry updated\n")
+		}
+	}
+
+	return nil
+}
+
+func (cl *cli) runCopy(args []string) error {
+	fs := cl.flagSet("copy")
+	fromFlag := fs.String("from", "", "Source to copy from (default: the configured source; comma-separated for several)")
+	toFlag := fs.String("to", "", "Registry checkout to copy into (required)")
+	tokenFlag := fs.String("token", "", "Bearer token for remote sources (default $VEGA_POPULATION_TOKEN)")
+	noDepsFlag := fs.Bool("no-deps", false, "Copy profiles without their persona and skills")
+	noVerifyFlag := fs.Bool("no-verify", false, "Skip checking manifests against the sha256 hashes published in the source index")
+	dryRunFlag := fs.Bool("dry-run", false, "Show what would be copied")
+	signFlags := addSignFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("copy requires a name argument")
+	}
+	if *toFlag == "" {
+		return fmt.Errorf("copy requires --to <registry checkout>")
+	}
+
+	signOpts, err := signFlags.options()
+	if err != nil {
+		return err
+	}
+
+	var opts []Option
+	if *fromFlag != "" {
+		opts = append(opts, sourceOption(*fromFlag))
+	}
+	if *tokenFlag != "" {
+		opts = append(opts, WithAuth(*tokenFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	copyOpts := &CopyOptions{
+		NoDeps:   *noDepsFlag,
+		NoVerify: *noVerifyFlag,
+		Sign:     signOpts,
+		DryRun:   *dryRunFlag,
+	}
+
+	for _, name := range fs.Args() {
+		results, err := client.Copy(cl.ctx, name, *toFlag, copyOpts)
+		for _, r := range results {
+			verb := "Copied"
+			switch {
+			case *dryRunFlag:
+				verb = "Would copy"
+			case r.Unchanged:
+				verb = "Already copied"
+			}
+			fmt.Fprintf(cl.stdout, "%s %s %s to %s\n", verb, FormatItemName(r.Kind, r.Name), r.Version, r.Path)
+			if !r.Verified {
+				fmt.Fprintf(cl.stdout, "  warning: the source publishes no checksum for this version\n")
+			}
+			if r.Signature != "" {
+				fmt.Fprintf(cl.stdout, "  signed (%s)\n", r.Signature)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cl *cli) runBump(args []string) error {
+	fs := cl.flagSet("bump")
+	registryFlag := fs.String("registry", "", "Resolve names in this registry checkout instead of the install dir")
+	installDirFlag := fs.String("install-dir", "", "Custom installation directory")
+	majorFlag := fs.Bool("major", false, "Bump the major version")
+	minorFlag := fs.Bool("minor", false, "Bump the minor version")
+	reasonFlag := fs.String("reason", "", "Changelog entry for the new version")
+	dryRunFlag := fs.Bool("dry-run", false, "Show the new version without writing it")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("bump requires a path or name argument")
+	}
+
+	var opts []Option
+	if *installDirFlag != "" {
+		opts = append(opts, WithInstallDir(*installDirFlag))
+	}
+
+	client, err := cl.newClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	bumpOpts := &BumpOptions{
+		Part:   BumpPatch,
+		Reason: *reasonFlag,
+		DryRun: *dryRunFlag,
+	}
+	if *minorFlag {
+		bumpOpts.Part = BumpMinor
+	}
+	if *majorFlag {
+		bumpOpts.Part = BumpMajor
+	}
+
+	for _, arg := range fs.Args() {
+		path := arg
+		if _, err := os.Stat(arg); err != nil {
+			kind, name := ParseItemName(arg)
+			if *registryFlag != "" {
+				path = filepath.Join(*registryFlag, kind.Plural(), name, "vega.yaml")
+			} else {
+				path = filepath.Join(client.itemDir(kind, name), "vega.yaml")
+			}
+		}
+
+		result, err := BumpManifest(path, bumpOpts)
+		if err != nil {
+			return err
+		}
+
+		verb := "Bumped"
+		if *dryRunFlag {
+			verb = "Would bump"
+		}
+		fmt.Fprintf(cl.stdout, "%s %s (%s -> %s)\n", verb, result.Path, result.OldVersion, result.NewVersion)
+		if result.IndexUpdated {
+			fmt.Fprintf(cl.stdout, "  index entry updated\n")
+		}
+	}
+
+	return nil
+}
+
+func (cl *cli) runCheckOwners(args []string) error {
+	fs := cl.flagSet("check-owners")
+	registryFlag := fs.String("registry", ".", "Registry checkout to check")
+	baseFlag := fs.String("base", "origin/main", "Git revision or directory to compare against")
+	authorFlag := fs.String("author", "", "Identity making the change (default $VEGA_AUTHOR or $GITHUB_ACTOR)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	author := *authorFlag
+	if author == "" {
+		author = os.Getenv("VEGA_AUTHOR")
+	}
+	if author == "" {
+		author = os.Getenv("GITHUB_ACTOR")
+	}
+	if author == "" {
+		fmt.Fprintln(cl.stdout, "No author identity given; skipping ownership check.")
+		return nil
+	}
+
+	registry, err := OpenLocalRegistry(*registryFlag)
+	if err != nil {
+		return err
+	}
+
+	violations, err := registry.CheckOwnership(*baseFlag, author)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) == 0 {
+		fmt.Fprintf(cl.stdout, "All index changes are attributed to a maintainer (%s).\n", author)
+		return nil
+	}
+
+	for _, v := range violations {
+		owners := "no maintainers listed"
+		if len(v.Maintainers) > 0 {
+			owners = "maintained by " + strings.Join(v.Maintainers, ", ")
+		}
+		fmt.Fprintf(cl.stdout, "  %-30s  %-8s  %s\n", FormatItemName(v.Kind, v.Name), v.Change, owners)
+	}
+
+	return fmt.Errorf("%d item(s) changed by %s, who is not a listed maintainer", len(violations), author)
+}
+
+func (cl *cli) runPrompt(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("prompt requires a subcommand: render, lint, diff, or merge")
+	}
+
+	sub := args[0]
+	want := map[string]int{"render": 1, "lint": 1, "diff": 2, "merge": 3}[sub]
+	if want == 0 {
+		return fmt.Errorf("unknown prompt subcommand: %s", sub)
+	}
+
+	if sub == "lint" {
+		return cl.runPromptLint(args[1:])
+	}
+
+	files := args[1:]
+	if len(files) != want {
+		return fmt.Errorf("prompt %s requires %d manifest path(s)", sub, want)
+	}
+
+	prompts := make([]Prompt, len(files))
+	for i, path := range files {
+		manifest, err := LoadManifest(path)
+		if err != nil {
+			return err
+		}
+		prompts[i] = manifest.SystemPrompt
+	}
+
+	switch sub {
+	case "render":
+		fmt.Fprint(cl.stdout, prompts[0].String())
+
+	case "diff":
+		changes := DiffPrompts(prompts[0], prompts[1])
+		if len(changes) == 0 {
+			fmt.Fprintln(cl.stdout, "Prompts are identical")
+		}
+		for _, c := range changes {
+			fmt.Fprintf(cl.stdout, "%s section %q\n", titleCase(c.Change), c.Section)
+			cl.printPrefixed("  - ", c.Old)
+			cl.printPrefixed("  + ", c.New)
+		}
+
+	case "merge":
+		merged, conflicts := MergePrompts(prompts[0], prompts[1], prompts[2])
+		enc := yaml.NewEncoder(cl.stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(map[string]Prompt{"system_prompt": merged}); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("conflicting edits to section(s) %s; kept the text from %s", strings.Join(conflicts, ", "), files[1])
+		}
+	}
+
+	return nil
+}
+
+func (cl *cli) runPromptLint(args []string) error {
+	fs := cl.flagSet("prompt lint")
+	styleFlag := fs.String("style", "", "Style guide to enforce (default: the enclosing registry's "+StyleFile+")")
+	allFlag := fs.Bool("all", false, "Lint every item in the registry")
+	registryFlag := fs.String("registry", ".", "Registry checkout used with --all")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var explicit *StyleGuide
+	if *styleFlag != "" {
+		var err error
+		if explicit, err = LoadStyleGuide(*styleFlag); err != nil {
+			return err
+		}
+	}
+
+	paths := fs.Args()
+	if *allFlag {
+		registry, err := OpenLocalRegistry(*registryFlag)
+		if err != nil {
+			return err
+		}
+		if paths, err = registry.Manifests(); err != nil {
+			return err
+		}
+	} else if len(paths) != 1 {
+		return fmt.Errorf("prompt lint requires a manifest path or --all")
+	}
+
+	total := 0
+	for _, path := range paths {
+		manifest, err := LoadManifest(path)
+		if err != nil {
+			return err
+		}
+		// Only personas carry a system prompt
+		if *allFlag && manifest.SystemPrompt.IsZero() {
+			continue
+		}
+
+		guide := explicit
+		if guide == nil {
+			if registry, _, ok := registryForManifest(path); ok {
+				if guide, err = registry.StyleGuide(); err != nil {
+					return err
+				}
+			}
+		}
+
+		problems := LintPrompt(manifest.SystemPrompt)
+		problems = append(problems, LintExamples(manifest.Examples)...)
+		if guide != nil {
+			problems = append(problems, guide.Check(manifest.SystemPrompt)...)
+		}
+		if len(problems) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(cl.stdout, "%s:\n", path)
+		for _, p := range problems {
+			fmt.Fprintf(cl.stdout, "  %s\n", p)
+		}
+		total += len(problems)
+	}
+
+	if total > 0 {
+		return fmt.Errorf("%d prompt problem(s) found", total)
+	}
+	fmt.Fprintln(cl.stdout, "Prompts look good")
+	return nil
+}
+
+func (cl *cli) runValidate(args []string) error {
+	fs := cl.flagSet("validate")
+	allFlag := fs.Bool("all", false, "Validate every item in the registry")
+	registryFlag := fs.String("registry", ".", "Registry checkout used with --all")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths := fs.Args()
+	if *allFlag {
+		registry, err := OpenLocalRegistry(*registryFlag)
+		if err != nil {
+			return err
+		}
+		if paths, err = registry.Manifests(); err != nil {
+			return err
+		}
+	} else if len(paths) == 0 {
+		return fmt.Errorf("validate requires a manifest path or --all")
+	}
+
+	total := 0
+	for _, path := range paths {
+		// An item directory stands for its manifest
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, "vega.yaml")
+		}
+
+		manifest, err := LoadManifest(path)
+		if err != nil {
+			return err
+		}
+
+		errs := ValidateManifest(manifest)
+		if registry, _, ok := registryForManifest(path); ok {
+			refErrs, err := registry.ValidateReferences(path, manifest)
+			if err != nil {
+				return err
+			}
+			errs = append(errs, refErrs...)
+
+			guide, err := registry.StyleGuide()
+			if err != nil {
+				return err
+			}
+			if guide != nil && !manifest.SystemPrompt.IsZero() {
+				for _, problem := range guide.Check(manifest.SystemPrompt) {
+					errs = append(errs, ValidationError{Field: "system_prompt", Message: problem})
+				}
+			}
+		}
+		if len(errs) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(cl.stdout, "%s:\n", path)
+		for _, e := range errs {
+			fmt.Fprintf(cl.stdout, "  %s\n", e)
+		}
+		total += len(errs)
+	}
+
+	if total > 0 {
+		return fmt.Errorf("%d validation error(s) found", total)
+	}
+	fmt.Fprintln(cl.stdout, "All manifests are valid")
+	return nil
+}
+
+// sourceOption configures the client from a --source flag, which may list
+// several comma-separated sources in priority order.
+func sourceOption(value string) Option {
+	urls := strings.Split(value, ",")
+	if len(urls) == 1 {
+		return WithSource(value)
+	}
+
+	var trimmed []string
+	for _, url := range urls {
+		if url = strings.TrimSpace(url); url != "" {
+			trimmed = append(trimmed, url)
+		}
+	}
+	return sourcesOption(trimmed)
+}
+
+// printPrefixed prints each line of text with a prefix.
+func (cl *cli) printPrefixed(prefix, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(cl.stdout, "%s%s\n", prefix, line)
+	}
+}
+
+// printPendingNotifications shows notifications queued by the daemon since the last run.
+func (cl *cli) printPendingNotifications() {
+	client, err := cl.newClient()
+	if err != nil {
+		return
+	}
+
+	pending, err := client.PendingNotifications()
+	if err != nil || len(pending) == 0 {
+		return
+	}
+
+	// Use stderr so notices never end up in exported or JSON output
+	for _, n := range pending {
+		fmt.Fprintf(cl.stderr, "Notice: %s\n", n.Message)
+	}
+	fmt.Fprintln(cl.stderr)
+}
+
+// titleCase returns the string with the first letter capitalized.
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// extractAgentName tries to extract a name from "You are X" in the system prompt.
+func extractAgentName(systemPrompt string) string {
+	// Look for patterns like "You are Maya" or "You are Maya,"
+	lines := strings.Split(systemPrompt, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "You are ") {
+			// Extract the name
+			rest := strings.TrimPrefix(line, "You are ")
+			// Take the first word (the name)
+			parts := strings.FieldsFunc(rest, func(r rune) bool {
+				return r == ' ' || r == ',' || r == '.' || r == '-' || r == ':'
+			})
+			if len(parts) > 0 {
+				name := parts[0]
+				// Skip articles
+				if name == "a" || name == "an" || name == "the" {
+					if len(parts) > 1 {
+						return ""
+					}
+				}
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,500 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultSource is the default URL for the vega-population repository.
+	DefaultSource = "https://raw.githubusercontent.com/martellcode/vega-population/main/"
+
+	// DefaultCacheDir is the default cache directory relative to vega home.
+	DefaultCacheDir = "cache/population"
+
+	// DefaultVegaHome is the default vega home directory.
+	DefaultVegaHome = ".vega"
+
+	// DefaultTempDir is where temporary files are kept, relative to vega home.
+	DefaultTempDir = "tmp"
+
+	// DefaultConcurrency is how many of a profile's dependencies are fetched
+	// at once.
+	DefaultConcurrency = 4
+)
+
+// Client is the main entry point for library users.
+type Client struct {
+	home       string
+	source     string
+	sources    []SourceConfig   // Set by WithSources; source is then the primary
+	auth       *SourceAuth      // Default credentials for remote sources
+	signatures *SignaturePolicy // Set by WithSignatureVerification
+	cacheDir   string
+	installDir string
+	layout     Layout // Directories of each kind in the install directory
+	layoutSet  bool   // Set by WithLayout rather than read from the install directory
+	tempDir    string
+	workspace  *Workspace // Project installing its own items (optional)
+	untrusted  bool       // The primary source was set by a workspace, not the user
+	noCache    bool
+	cacheTTL   time.Duration // How long cached indexes are fresh (default CacheTTL)
+	offline    bool
+	session    *Session // Records or replays source reads (optional)
+	cache      *Cache
+
+	encryptionKey []byte         // Set by WithEncryptionKey
+	cipher        *contentCipher // Encrypts the cache and installed items (nil = plain)
+	logger        *slog.Logger   // Set by WithLogger
+
+	versionControl VersionControl // Records changes to the install directory (optional)
+
+	concurrency int        // Dependencies fetched at once
+	http        httpPolicy // How remote sources are fetched
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSource sets a custom source URL or local path.
+func WithSource(url string) Option {
+	return func(c *Client) {
+		c.source = url
+		c.sources = nil
+		c.untrusted = false
+	}
+}
+
+// WithCacheDir sets a custom cache directory.
+func WithCacheDir(path string) Option {
+	return func(c *Client) {
+		c.cacheDir = path
+	}
+}
+
+// WithInstallDir sets a custom installation directory.
+func WithInstallDir(path string) Option {
+	return func(c *Client) {
+		c.installDir = path
+	}
+}
+
+// WithNoCache disables caching of index files.
+func WithNoCache() Option {
+	return func(c *Client) {
+		c.noCache = true
+	}
+}
+
+// WithOffline keeps the client off the network. Indexes and manifests are read
+// from the cache however old, the installed items stand in for an index that
+// was never cached, and anything else fails with an error wrapping
+// ErrOffline. Local directory sources are read as usual.
+func WithOffline() Option {
+	return func(c *Client) {
+		c.offline = true
+	}
+}
+
+// WithConcurrency sets how many of a profile's dependencies are fetched at
+// once while installing it (default DefaultConcurrency; 1 fetches them one
+// after another). They are still installed, and reported, in order.
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		c.concurrency = n
+	}
+}
+
+// WithHTTPClient sets the HTTP client remote sources are fetched with
+// (default http.DefaultClient), e.g. to use a proxy or custom TLS settings.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http.client = hc
+	}
+}
+
+// WithRetry sets how many times a remote fetch is retried after a connection
+// failure, a server error, or 429 Too Many Requests (default DefaultRetries;
+// 0 never retries), and how long to wait before the first retry, doubling
+// each time (default DefaultBackoff). A Retry-After header from the server
+// overrides the wait.
+func WithRetry(max int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.http.retries = max
+		c.http.backoff = backoff
+	}
+}
+
+// WithTimeout bounds each remote request, including reading the response
+// (default DefaultTimeout; 0 for no timeout). A download cut off by the
+// timeout is resumed by the retry.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.http.timeout = d
+	}
+}
+
+// NewClient creates a new population Client with the given options. The
+// defaults come from the vega home's ConfigFile, if there is one, and the
+// environment variables overriding it.
+func NewClient(opts ...Option) (*Client, error) {
+	vegaHome, err := VegaHome()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		home:       vegaHome,
+		source:     DefaultSource,
+		cacheDir:   defaultCacheDir(vegaHome),
+		installDir: vegaHome,
+		tempDir:    filepath.Join(vegaHome, DefaultTempDir),
+
+		concurrency: DefaultConcurrency,
+		http:        defaultHTTPPolicy(),
+	}
+
+	cfg, err := loadUserConfig(vegaHome)
+	if err != nil {
+		return nil, err
+	}
+	WithConfig(cfg)(c)
+
+	// A project's workspace comes next, so options override it
+	ws, err := FindWorkspace(".")
+	if err == nil {
+		WithWorkspace(ws)(c)
+	} else if !errors.Is(err, ErrNoWorkspace) {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	if !c.layoutSet {
+		if c.layout, err = LoadLayout(c.installDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.cipher, err = newContentCipher(c.encryptionKey); err != nil {
+		return nil, err
+	}
+
+	// Initialize cache
+	c.cache = NewCache(c.cacheDir, c.noCache)
+	c.cache.cipher = c.cipher
+	c.cache.logger = c.logger
+	if c.cacheTTL > 0 {
+		c.cache.ttl = c.cacheTTL
+	}
+
+	// Clear away what interrupted runs left behind
+	if _, err := cleanTemp(c.tempDir); err != nil {
+		c.log().Warn("cleaning up temporary files", "error", err)
+	}
+
+	return c, nil
+}
+
+// newSource creates a Source for the primary source.
+func (c *Client) newSource() *Source {
+	return c.newSourceFor(c.Sources()[0])
+}
+
+// newSourceFor creates a Source configured with the client's settings.
+func (c *Client) newSourceFor(cfg SourceConfig) *Source {
+	source := c.directSource(cfg)
+	if !c.noCache && !source.isLocal && source.registry == nil {
+		source.daemon = newDaemonConn(c.daemonSocket())
+	}
+	return source
+}
+
+// directSource creates a Source that always reaches the source itself,
+// bypassing the daemon.
+func (c *Client) directSource(cfg SourceConfig) *Source {
+	var source *Source
+	if cfg.Registry != nil {
+		source = newRegistrySource(registryName(cfg.Registry), cfg.Registry, c.cache)
+	} else {
+		source = NewSource(cfg.URL, c.cache)
+	}
+	if cfg.Name != "" {
+		source.name = cfg.Name
+	}
+	source.auth = c.authFor(cfg)
+	source.concurrency = c.concurrency
+	source.http = c.http
+	source.offline = c.offline
+	source.installDir = c.installDir
+	source.layout = c.layout
+	source.tempDir = c.tempDir
+	source.session = c.session
+	source.cipher = c.cipher
+	source.logger = c.logger
+	return source
+}
+
+// daemonSocket returns the path of the background daemon socket.
+func (c *Client) daemonSocket() string {
+	return filepath.Join(c.home, DaemonSocketName)
+}
+
+// Search returns matching items across all types. The query is parsed by
+// ParseQuery, so it can match fields, negate terms, and combine them with OR.
+func (c *Client) Search(ctx context.Context, query string, opts *SearchOptions) ([]SearchResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return c.SearchQuery(ctx, q, opts)
+}
+
+// SearchQuery returns items matching a parsed or programmatically built query
+// across all types.
+func (c *Client) SearchQuery(ctx context.Context, query *Query, opts *SearchOptions) ([]SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	return c.searchSources(ctx, query, opts)
+}
+
+// Install installs an item by name.
+// The name can be prefixed with @ for personas or + for profiles.
+func (c *Client) Install(ctx context.Context, name string, opts *InstallOptions) error {
+	_, err := c.InstallWithReport(ctx, name, opts)
+	return err
+}
+
+// InstallWithReport installs an item like Install and reports every item it
+// installed, skipped, or found already installed, dependencies first. On
+// error the report covers the items handled before it.
+func (c *Client) InstallWithReport(ctx context.Context, name string, opts *InstallOptions) (*InstallReport, error) {
+	return c.InstallAll(ctx, []string{name}, opts)
+}
+
+// InstallAll installs several items, and reports them as InstallWithReport
+// does. Before installing anything it checks the version requirements of
+// all of them and their dependencies together: items wanted at versions no
+// single version satisfies are settled by opts.OnConflict, and listed in the
+// report's Conflicts. With ConflictFail, a *ConflictError is returned and
+// nothing is installed. Likewise, requested profiles combining items their
+// skills declare incompatible return an *IncompatibilityError unless
+// opts.AllowIncompatible is set, and the report lists them otherwise.
+func (c *Client) InstallAll(ctx context.Context, names []string, opts *InstallOptions) (*InstallReport, error) {
+	report := &InstallReport{Items: []InstallResult{}}
+	if opts == nil {
+		opts = &InstallOptions{}
+	}
+
+	if opts.MinStatus == "" {
+		policy, err := c.Policy()
+		if err != nil {
+			return report, err
+		}
+		withPolicy := *opts
+		withPolicy.MinStatus = policy.MinStatus
+		opts = &withPolicy
+	}
+	for _, name := range names {
+		if err := ValidateItemName(name); err != nil {
+			return report, err
+		}
+	}
+	if !opts.DryRun && !opts.unrecorded {
+		defer func() {
+			var installed []string
+			for _, item := range report.Items {
+				if item.Status == InstallStatusInstalled {
+					installed = append(installed, FormatItemName(item.Kind, item.Name)+" "+item.Version)
+				}
+			}
+			if len(installed) > 0 {
+				c.recordChange(ctx, changeMessage("Install", installed))
+			}
+		}()
+	}
+
+	pins, conflicts, incompatible, err := c.resolveConflicts(ctx, names, opts)
+	report.Conflicts = conflicts
+	if err != nil {
+		return report, err
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(opts.progress(), "Conflict: %s; using %s\n", conflict, conflict.Resolved)
+	}
+	if len(incompatible) > 0 {
+		if !opts.AllowIncompatible {
+			return report, &IncompatibilityError{Incompatibilities: incompatible}
+		}
+		report.Incompatibilities = incompatible
+		for _, i := range incompatible {
+			fmt.Fprintf(opts.progress(), "Warning: %s\n", i)
+		}
+	}
+
+	for _, name := range names {
+		itemOpts := *opts
+		itemOpts.pins = pins
+		name, itemOpts.Version = SplitVersion(name)
+		if itemOpts.Version == "" {
+			itemOpts.Version = opts.Version
+		}
+
+		kind, itemName := ParseItemName(name)
+		source, err := c.resolveSource(ctx, kind, itemName)
+		if err != nil {
+			return report, err
+		}
+		if source.signatures, err = c.signaturePolicy(opts.RequireSigned); err != nil {
+			return report, err
+		}
+
+		if !opts.DryRun {
+			fmt.Fprintf(opts.progress(), "Installing %s %q...\n", kind, itemName)
+		}
+		if err := source.install(ctx, kind, itemName, c.installDir, &itemOpts, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// List returns installed items of the given kind.
+// If kind is empty, returns all installed items.
+func (c *Client) List(kind ItemKind) ([]InstalledItem, error) {
+	var items []InstalledItem
+
+	kinds := []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings}
+	if kind != "" {
+		kinds = []ItemKind{kind}
+	}
+
+	for _, k := range kinds {
+		dir := filepath.Join(c.installDir, c.layout.Dir(k))
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s directory: %w", k.Plural(), err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			manifestPath := filepath.Join(dir, entry.Name(), "vega.yaml")
+			if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+				continue
+			}
+
+			manifest, err := loadManifest(manifestPath, c.cipher)
+			if errors.Is(err, ErrEncrypted) {
+				// Listing nothing would hide that the items are there
+				return nil, err
+			}
+			if err != nil {
+				// Skip items with invalid manifests
+				continue
+			}
+
+			item := InstalledItem{
+				Kind:    k,
+				Name:    entry.Name(),
+				Version: manifest.Version,
+				Path:    filepath.Join(dir, entry.Name()),
+				Files:   manifest.FilePaths(),
+			}
+			item.Install, item.Modified = installState(item.Path, c.cipher)
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// Info returns detailed information about an item.
+func (c *Client) Info(ctx context.Context, name string) (*ItemInfo, error) {
+	if err := ValidateItemName(name); err != nil {
+		return nil, err
+	}
+	kind, itemName := ParseItemName(name)
+	source, err := c.resolveSource(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := source.Info(ctx, kind, itemName, c.installDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Installed {
+		info.Install, info.Modified = installState(info.InstalledPath, c.cipher)
+	}
+
+	// Installs verify signatures when there is a policy, which an unsigned
+	// item passes only while signatures aren't required
+	if policy, err := c.signaturePolicy(false); err == nil && policy != nil {
+		if ref, err := source.publishedSignature(ctx, kind, itemName, info.Version); err == nil && ref == nil {
+			info.Warnings = append(info.Warnings, Warning{Code: WarnUnsigned, Message: "not signed, so its signature can't be verified"})
+		}
+	}
+	return info, nil
+}
+
+// UpdateCache refreshes the cached index files of every source.
+func (c *Client) UpdateCache(ctx context.Context) error {
+	for _, cfg := range c.Sources() {
+		// Bypass the daemon so an explicit update always reaches the source
+		source := c.directSource(cfg)
+		if err := source.UpdateCache(ctx); err != nil {
+			return fmt.Errorf("updating %s: %w", cfg.URL, err)
+		}
+	}
+	return nil
+}
+
+// Source returns the configured (primary) source URL.
+func (c *Client) Source() string {
+	return c.source
+}
+
+// InstallDir returns the configured installation directory.
+func (c *Client) InstallDir() string {
+	return c.installDir
+}
+
+// WithInstallDir returns a client like c that installs into dir, such as one
+// per tenant of a service managing many populations. It shares c's sources,
+// credentials, cache, and other settings, so deriving one is cheap and
+// indexes fetched for one tenant serve them all, but its installed items,
+// usage log, and layout are dir's own; c's layout is kept if it was set with
+// WithLayout, and a workspace c found is not. c and the clients derived
+// from it may be used concurrently.
+func (c *Client) WithInstallDir(dir string) (*Client, error) {
+	derived := *c
+	derived.installDir = dir
+	derived.workspace = nil
+	if !derived.layoutSet {
+		layout, err := LoadLayout(dir)
+		if err != nil {
+			return nil, err
+		}
+		derived.layout = layout
+	}
+	return &derived, nil
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compatibility is what a skill declares about the items it can be combined
+// with in a profile:
+//
+//	compatibility:
+//	  personas: [incident-commander, devops-lead]
+//	  incompatible_skills: [cautious-deploys]
+//	  incompatible_tools: [force_push]
+//	  reason: tells the agent to deploy without waiting for approval
+type Compatibility struct {
+	Personas             []string `yaml:"personas,omitempty"`              // Personas the skill works with (empty = any)
+	IncompatiblePersonas []string `yaml:"incompatible_personas,omitempty"` // Personas the skill doesn't work with
+	IncompatibleSkills   []string `yaml:"incompatible_skills,omitempty"`   // Skills it contradicts, such as by giving opposite instructions
+	IncompatibleTools    []string `yaml:"incompatible_tools,omitempty"`    // Tools no other skill of the profile may provide
+	Reason               string   `yaml:"reason,omitempty"`                // Why, shown with each incompatibility
+}
+
+// Incompatibility is a pair of items a profile combines although one of
+// them declares it doesn't work with the other.
+type Incompatibility struct {
+	Profile string `json:"profile" yaml:"profile"` // Display name of the profile
+	Item    string `json:"item" yaml:"item"`       // Display name of the skill declaring it
+	With    string `json:"with" yaml:"with"`       // Display name of the other item, and the tool for a tool
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func (i Incompatibility) String() string {
+	s := fmt.Sprintf("%s: %s is incompatible with %s", i.Profile, i.Item, i.With)
+	if i.Reason != "" {
+		s += ": " + i.Reason
+	}
+	return s
+}
+
+// IncompatibilityError is returned by an install of profiles combining
+// items one of their skills declares it doesn't work with, unless
+// InstallOptions.AllowIncompatible is set.
+type IncompatibilityError struct {
+	Incompatibilities []Incompatibility
+}
+
+func (e *IncompatibilityError) Error() string {
+	lines := []string{fmt.Sprintf("%d incompatible combination(s) (use --allow-incompatible to install anyway):", len(e.Incompatibilities))}
+	for _, i := range e.Incompatibilities {
+		lines = append(lines, "  "+i.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkCompatibility returns the incompatibilities among a persona (which
+// may be "") and skills combined in a profile, as the skills declare them,
+// in the order of the skills' names.
+func checkCompatibility(persona string, skills map[string]*Manifest) []Incompatibility {
+	names := make([]string, 0, len(skills))
+	for name := range skills {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var found []Incompatibility
+	for _, name := range names {
+		compat := skills[name].Compatibility
+		if compat == nil {
+			continue
+		}
+		item := FormatItemName(KindSkill, name)
+		add := func(with string) {
+			found = append(found, Incompatibility{Item: item, With: with, Reason: compat.Reason})
+		}
+
+		if persona != "" {
+			if len(compat.Personas) > 0 && !containsFold(compat.Personas, persona) {
+				add(FormatItemName(KindPersona, persona) + " (it works only with " + formatNames(KindPersona, compat.Personas) + ")")
+			} else if containsFold(compat.IncompatiblePersonas, persona) {
+				add(FormatItemName(KindPersona, persona))
+			}
+		}
+		for _, other := range names {
+			if other == name {
+				continue
+			}
+			if containsFold(compat.IncompatibleSkills, other) {
+				add(FormatItemName(KindSkill, other))
+			}
+			for _, tool := range skills[other].Tools {
+				if containsFold(compat.IncompatibleTools, tool.Name) {
+					add(fmt.Sprintf("tool %s of %s", tool.Name, FormatItemName(KindSkill, other)))
+				}
+			}
+		}
+	}
+	return found
+}
+
+// formatNames formats item names of a kind as a comma-separated list of
+// display names.
+func formatNames(kind ItemKind, names []string) string {
+	formatted := make([]string, len(names))
+	for i, name := range names {
+		formatted[i] = FormatItemName(kind, name)
+	}
+	return strings.Join(formatted, ", ")
+}
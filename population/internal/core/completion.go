@@ -0,0 +1,284 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// completionScripts are the shell completion scripts, by shell. Each asks
+// the hidden __complete command for the candidates of the word at the
+// cursor, given the words up to it.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for vega
+_vega() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ "$line" == *[[:space:]] ]] && words+=("")
+    local cur="${words[${#words[@]}-1]}"
+
+    local IFS=$'\n'
+    local -a candidates=($(vega population __complete "${words[@]}" 2>/dev/null))
+    if [[ ${#candidates[@]} -eq 0 ]]; then
+        compopt -o default 2>/dev/null
+        COMPREPLY=()
+        return
+    fi
+    # Bash splits words at @, :, and =, so complete what follows the last one
+    local prefix="${cur%"${cur##*[@:=]}"}"
+    COMPREPLY=("${candidates[@]#"$prefix"}")
+    # Deploy targets such as tron: go on with a path
+    [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == *: ]] && compopt -o nospace 2>/dev/null
+}
+complete -F _vega vega
+`,
+	"zsh": `#compdef vega
+# zsh completion for vega
+_vega() {
+    local -a candidates
+    candidates=(${(f)"$(vega population __complete "${(@)words[1,CURRENT]}" 2>/dev/null)"})
+    if (( ${#candidates} )); then
+        # Deploy targets such as tron: go on with a path
+        compadd -Q -S '' -- ${(M)candidates:#*:}
+        compadd -Q -- ${candidates:#*:}
+    else
+        _files
+    fi
+}
+compdef _vega vega
+`,
+	"fish": `# fish completion for vega
+function __vega_complete
+    vega population __complete (commandline -opc) (commandline -ct) 2>/dev/null
+end
+function __vega_has_candidates
+    set -l candidates (__vega_complete)
+    test (count $candidates) -gt 0
+end
+complete -c vega -f -n __vega_has_candidates -a '(__vega_complete)'
+complete -c vega -F -n 'not __vega_has_candidates'
+`,
+}
+
+// WriteCompletion writes the completion script for a shell: bash, zsh, or
+// fish.
+func WriteCompletion(w io.Writer, shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unknown shell %q (want bash, zsh, or fish)", shell)
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+func (cl *cli) runCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("completion requires a shell: bash, zsh, or fish")
+	}
+	return WriteCompletion(cl.stdout, args[0])
+}
+
+// itemCommands are the commands taking item names, and the kinds of items
+// they take (nil = any).
+var itemCommands = map[string][]ItemKind{
+	"install":   nil,
+	"info":      nil,
+	"uninstall": nil,
+	"remove":    nil,
+	"rm":        nil,
+	"upgrade":   nil,
+	"why":       nil,
+	"copy":      nil,
+	"deps":      {KindPersona, KindProfile},
+	"export":    {KindPersona, KindProfile},
+	"deploy":    {KindPersona, KindProfile},
+	"budget":    {KindPersona, KindProfile},
+	"demo":      {KindPersona},
+	"preflight": {KindProfile},
+}
+
+// installedCommands are the item commands that take installed items only.
+var installedCommands = map[string]bool{
+	"uninstall": true, "remove": true, "rm": true, "upgrade": true, "why": true,
+}
+
+// flagValues are the values of flags that take one of a few, by name.
+var flagValues = map[string][]string{
+	"output":      {"table", "json", "yaml"},
+	"kind":        {"skill", "persona", "profile", "settings"},
+	"status":      {"draft", "reviewed", "approved"},
+	"min-status":  {"draft", "reviewed", "approved"},
+	"on-conflict": {"fail", "newest", "keep-existing"},
+}
+
+// runComplete prints the completions of the last of args, the words of a
+// command line up to the cursor starting with the program name, one a line.
+// It is what the completion scripts run.
+func (cl *cli) runComplete(args []string) error {
+	if len(args) < 2 {
+		return nil
+	}
+	words, current := args[1:len(args)-1], args[len(args)-1]
+	for _, candidate := range cl.completions(words, current) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Fprintln(cl.stdout, candidate)
+		}
+	}
+	return nil
+}
+
+// completions returns the candidates for the word after words.
+func (cl *cli) completions(words []string, current string) []string {
+	if len(words) == 0 {
+		return []string{"population", "pop", "completion", "help", "version"}
+	}
+	switch words[0] {
+	case "completion":
+		if len(words) == 1 {
+			return []string{"bash", "zsh", "fish"}
+		}
+		return nil
+	case "population", "pop":
+	default:
+		return nil
+	}
+
+	// Skip global flags and their values
+	rest := words[1:]
+	for len(rest) > 0 && strings.HasPrefix(rest[0], "--") {
+		if flag := rest[0]; !strings.Contains(flag, "=") && len(rest) > 1 {
+			rest = rest[1:]
+		}
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		if strings.HasPrefix(current, "-") {
+			return []string{"--output", "--record", "--replay"}
+		}
+		if len(words) > 1 && words[len(words)-1] == "--output" {
+			return flagValues["output"]
+		}
+		return commandNames
+	}
+
+	cmd, cmdWords := rest[0], rest[1:]
+	flags := cl.commandFlags(cmd)
+	if strings.HasPrefix(current, "-") {
+		names := make([]string, 0, len(flags))
+		for name := range flags {
+			names = append(names, "--"+name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// The value of a flag
+	if len(cmdWords) > 0 {
+		prev := strings.TrimLeft(cmdWords[len(cmdWords)-1], "-")
+		if takesValue, ok := flags[prev]; ok && takesValue && !strings.Contains(prev, "=") {
+			return flagValues[prev]
+		}
+	}
+
+	kinds, ok := itemCommands[cmd]
+	if !ok {
+		return nil
+	}
+	if cmd == "deploy" && countArgs(cmdWords, flags) > 0 {
+		return []string{"file:", "claude:", "tron:"}
+	}
+	// Items come from the sources and install directory the line names
+	var opts []Option
+	for i, word := range cmdWords {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+		if !hasValue && i+1 < len(cmdWords) {
+			value = cmdWords[i+1]
+		}
+		switch {
+		case !strings.HasPrefix(word, "-") || value == "":
+		case name == "source":
+			opts = append(opts, sourceOption(value))
+		case name == "install-dir":
+			opts = append(opts, WithInstallDir(value))
+		}
+	}
+	return cl.itemNames(kinds, installedCommands[cmd], current, opts...)
+}
+
+// commandFlags returns the flags of a command, and whether each takes a
+// value.
+func (cl *cli) commandFlags(cmd string) map[string]bool {
+	flags := make(map[string]bool)
+	for _, f := range cl.describeFlags(cmd) {
+		flags[f.Name] = f.Type != "bool"
+	}
+	return flags
+}
+
+// countArgs counts the positional arguments among a command's words.
+func countArgs(words []string, flags map[string]bool) int {
+	n := 0
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "-") {
+			n++
+			continue
+		}
+		if name := strings.TrimLeft(word, "-"); flags[name] && !strings.Contains(name, "=") {
+			i++ // Its value
+		}
+	}
+	return n
+}
+
+// itemNames returns the display names of the items of the given kinds (nil
+// = any) from the cached indexes and the installed items, never the
+// network, or only installed ones. A prefix of current limits the kind.
+func (cl *cli) itemNames(kinds []ItemKind, installedOnly bool, current string, opts ...Option) []string {
+	if kinds == nil {
+		kinds = []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings}
+	}
+	if kind, _ := ParseItemName(current); current != "" && kind != KindSkill {
+		allowed := false
+		for _, k := range kinds {
+			allowed = allowed || k == kind
+		}
+		if !allowed {
+			return nil
+		}
+		kinds = []ItemKind{kind}
+	}
+
+	client, err := cl.newClient(append(opts, WithOffline())...)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	addName := func(kind ItemKind, name string) {
+		if display := FormatItemName(kind, name); !seen[display] {
+			seen[display] = true
+			names = append(names, display)
+		}
+	}
+	for _, kind := range kinds {
+		if items, err := client.List(kind); err == nil {
+			for _, item := range items {
+				addName(item.Kind, item.Name)
+			}
+		}
+		if installedOnly {
+			continue
+		}
+		if results, err := client.Search(context.Background(), "", &SearchOptions{Kind: kind}); err == nil {
+			for _, r := range results {
+				addName(r.Kind, r.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
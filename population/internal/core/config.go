@@ -0,0 +1,416 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFile is the user's configuration in the vega home, setting the
+	// client's defaults, for example:
+	//
+	//	sources:
+	//	  - https://registry.internal.example/
+	//	  - https://raw.githubusercontent.com/martellcode/vega-population/main/
+	//	install_dir: ~/agents
+	//	cache_dir: /var/cache/vega
+	//	cache_ttl: 6h
+	//	output: json
+	//	auth:
+	//	  keychain: registry.internal.example
+	ConfigFile = "config.yaml"
+
+	// HomeEnv is the environment variable overriding the vega home, where
+	// ConfigFile is read from and items are installed. By default it is
+	// ~/.vega or, on Linux with $XDG_DATA_HOME set and no ~/.vega yet,
+	// $XDG_DATA_HOME/vega.
+	HomeEnv = "VEGA_HOME"
+)
+
+// Config holds the defaults NewClient reads from ConfigFile. Environment
+// variables override it (see ConfigKeys), a workspace overrides both, and
+// options override everything.
+type Config struct {
+	Sources    []string `yaml:"sources,omitempty"`     // Sources, highest priority first
+	InstallDir string   `yaml:"install_dir,omitempty"` // A leading ~/ is the user's home
+	CacheDir   string   `yaml:"cache_dir,omitempty"`   // A leading ~/ is the user's home
+	CacheTTL   string   `yaml:"cache_ttl,omitempty"`   // How long cached indexes are fresh, e.g. "6h"
+	NoCache    bool     `yaml:"no_cache,omitempty"`
+	Output     string   `yaml:"output,omitempty"` // Default CLI output format: table, json, or yaml
+
+	VersionControl string      `yaml:"version_control,omitempty"` // Keep the install directory's history: "git", or empty for none
+	Auth           *SourceAuth `yaml:"auth,omitempty"`            // Default credentials for remote sources
+}
+
+// ConfigKey is a setting of Config, as read and written by the CLI's config
+// command.
+type ConfigKey struct {
+	Name       string   // As in ConfigFile, e.g. "install_dir" or "auth.token"
+	Env        string   // Environment variable overriding it (optional)
+	EnvAliases []string // Older names of Env, used when it is unset
+
+	get func(*Config) string
+	set func(*Config, string) error // "" unsets
+}
+
+// ConfigKeys are the settings of Config.
+var ConfigKeys = []ConfigKey{
+	{
+		Name: "sources", Env: "VEGA_POPULATION_SOURCE", EnvAliases: []string{"VEGA_SOURCE"},
+		get: func(c *Config) string { return strings.Join(c.Sources, ",") },
+		set: func(c *Config, v string) error {
+			c.Sources = nil
+			for _, url := range strings.Split(v, ",") {
+				if url = strings.TrimSpace(url); url != "" {
+					c.Sources = append(c.Sources, url)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Name: "install_dir", Env: "VEGA_INSTALL_DIR",
+		get: func(c *Config) string { return c.InstallDir },
+		set: func(c *Config, v string) error { c.InstallDir = v; return nil },
+	},
+	{
+		Name: "cache_dir", Env: "VEGA_POPULATION_CACHE_DIR",
+		get: func(c *Config) string { return c.CacheDir },
+		set: func(c *Config, v string) error { c.CacheDir = v; return nil },
+	},
+	{
+		Name: "cache_ttl", Env: "VEGA_CACHE_TTL",
+		get: func(c *Config) string { return c.CacheTTL },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+					return fmt.Errorf("cache_ttl must be a positive duration such as 30m or 6h, not %q", v)
+				}
+			}
+			c.CacheTTL = v
+			return nil
+		},
+	},
+	{
+		Name: "no_cache", Env: "VEGA_NO_CACHE",
+		get: func(c *Config) string { return formatConfigBool(c.NoCache) },
+		set: func(c *Config, v string) (err error) { c.NoCache, err = parseConfigBool("no_cache", v); return err },
+	},
+	{
+		Name: "output", Env: "VEGA_OUTPUT",
+		get: func(c *Config) string { return c.Output },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if _, err := ParseOutputFormat(v); err != nil {
+					return err
+				}
+			}
+			c.Output = v
+			return nil
+		},
+	},
+	{
+		Name: "version_control", Env: "VEGA_POPULATION_VERSION_CONTROL",
+		get: func(c *Config) string { return c.VersionControl },
+		set: func(c *Config, v string) error {
+			if v != "" && v != "git" {
+				return fmt.Errorf("version_control must be git, or empty for none, not %q", v)
+			}
+			c.VersionControl = v
+			return nil
+		},
+	},
+	configAuthKey("auth.token", func(a *SourceAuth) *string { return &a.Token }),
+	configAuthKey("auth.token_env", func(a *SourceAuth) *string { return &a.TokenEnv }),
+	configAuthKey("auth.keychain", func(a *SourceAuth) *string { return &a.Keychain }),
+	configAuthKey("auth.username", func(a *SourceAuth) *string { return &a.Username }),
+	configAuthKey("auth.password", func(a *SourceAuth) *string { return &a.Password }),
+	{
+		Name: "auth.netrc",
+		get: func(c *Config) string {
+			return formatConfigBool(c.Auth != nil && c.Auth.Netrc)
+		},
+		set: func(c *Config, v string) error {
+			netrc, err := parseConfigBool("auth.netrc", v)
+			if err != nil {
+				return err
+			}
+			if c.Auth == nil {
+				c.Auth = &SourceAuth{}
+			}
+			c.Auth.Netrc = netrc
+			c.dropEmptyAuth()
+			return nil
+		},
+	},
+	{
+		Name: "auth.hosts",
+		get: func(c *Config) string {
+			if c.Auth == nil {
+				return ""
+			}
+			return strings.Join(c.Auth.Hosts, ",")
+		},
+		set: func(c *Config, v string) error {
+			if c.Auth == nil {
+				c.Auth = &SourceAuth{}
+			}
+			c.Auth.Hosts = nil
+			for _, host := range strings.Split(v, ",") {
+				if host = strings.TrimSpace(host); host != "" {
+					c.Auth.Hosts = append(c.Auth.Hosts, host)
+				}
+			}
+			c.dropEmptyAuth()
+			return nil
+		},
+	},
+}
+
+// configAuthKey is the ConfigKey of a string field of Config.Auth.
+func configAuthKey(name string, field func(*SourceAuth) *string) ConfigKey {
+	return ConfigKey{
+		Name: name,
+		get: func(c *Config) string {
+			if c.Auth == nil {
+				return ""
+			}
+			return *field(c.Auth)
+		},
+		set: func(c *Config, v string) error {
+			if c.Auth == nil {
+				c.Auth = &SourceAuth{}
+			}
+			*field(c.Auth) = v
+			c.dropEmptyAuth()
+			return nil
+		},
+	}
+}
+
+// dropEmptyAuth removes Auth once none of its settings are left.
+func (c *Config) dropEmptyAuth() {
+	if a := c.Auth; a != nil && a.Token == "" && a.TokenEnv == "" && a.Keychain == "" &&
+		a.Username == "" && a.Password == "" && !a.Netrc && len(a.Headers) == 0 && len(a.Hosts) == 0 {
+		c.Auth = nil
+	}
+}
+
+func formatConfigBool(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func parseConfigBool(name, v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, not %q", name, v)
+	}
+	return b, nil
+}
+
+// LookupConfigKey returns the setting named name.
+func LookupConfigKey(name string) (ConfigKey, error) {
+	for _, key := range ConfigKeys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+	names := make([]string, len(ConfigKeys))
+	for i, key := range ConfigKeys {
+		names[i] = key.Name
+	}
+	sort.Strings(names)
+	return ConfigKey{}, fmt.Errorf("unknown setting %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// Get returns the value of the setting in c, or "" if it is unset.
+func (k ConfigKey) Get(c *Config) string {
+	return k.get(c)
+}
+
+// Set sets the setting in c, or unsets it given "".
+func (k ConfigKey) Set(c *Config, value string) error {
+	return k.set(c, value)
+}
+
+// LookupEnv returns the environment variable overriding the setting and its
+// value, if one is set.
+func (k ConfigKey) LookupEnv() (string, string, bool) {
+	if k.Env == "" {
+		return "", "", false
+	}
+	for _, name := range append([]string{k.Env}, k.EnvAliases...) {
+		if value, ok := os.LookupEnv(name); ok {
+			return name, value, true
+		}
+	}
+	return "", "", false
+}
+
+// VegaHome returns the vega home: $VEGA_HOME, or ~/.vega. On Linux, with
+// $XDG_DATA_HOME set and no ~/.vega, it is $XDG_DATA_HOME/vega instead.
+func VegaHome() (string, error) {
+	if home := os.Getenv(HomeEnv); home != "" {
+		return expandHome(home), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	vegaHome := filepath.Join(home, DefaultVegaHome)
+	if data := os.Getenv("XDG_DATA_HOME"); runtime.GOOS == "linux" && filepath.IsAbs(data) {
+		if _, err := os.Stat(vegaHome); errors.Is(err, os.ErrNotExist) {
+			return filepath.Join(data, "vega"), nil
+		}
+	}
+	return vegaHome, nil
+}
+
+// defaultCacheDir returns the cache directory of a vega home: in it, or on
+// Linux under $XDG_CACHE_HOME when it is set and $VEGA_HOME isn't.
+func defaultCacheDir(home string) string {
+	if cache := os.Getenv("XDG_CACHE_HOME"); runtime.GOOS == "linux" && filepath.IsAbs(cache) && os.Getenv(HomeEnv) == "" {
+		return filepath.Join(cache, "vega", "population")
+	}
+	return filepath.Join(home, DefaultCacheDir)
+}
+
+// LoadConfig reads a config file. A missing file yields an empty config.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// validate checks the settings by setting each to its own value.
+func (c *Config) validate() error {
+	for _, key := range ConfigKeys {
+		if err := key.set(c, key.get(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save writes the config to a file, readable only by the user as it may
+// hold credentials.
+func (c *Config) Save(path string) error {
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// ApplyEnv overrides the settings with the environment variables of
+// ConfigKeys that are set, returning the names of those variables.
+func (c *Config) ApplyEnv() ([]string, error) {
+	var applied []string
+	for _, key := range ConfigKeys {
+		name, value, ok := key.LookupEnv()
+		if !ok {
+			continue
+		}
+		if err := key.set(c, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		applied = append(applied, name)
+	}
+	return applied, nil
+}
+
+// loadUserConfig reads the config file in the vega home, with the
+// environment's overrides applied.
+func loadUserConfig(home string) (*Config, error) {
+	cfg, err := LoadConfig(filepath.Join(home, ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// WithConfig applies a config's defaults. NewClient already does this for
+// the config file in the vega home; options given after this one still
+// override it.
+func WithConfig(cfg *Config) Option {
+	return func(c *Client) {
+		if len(cfg.Sources) > 0 {
+			sourcesOption(cfg.Sources)(c)
+		}
+		if cfg.InstallDir != "" {
+			c.installDir = expandHome(cfg.InstallDir)
+		}
+		if cfg.CacheDir != "" {
+			c.cacheDir = expandHome(cfg.CacheDir)
+		}
+		if ttl, err := time.ParseDuration(cfg.CacheTTL); err == nil && ttl > 0 {
+			c.cacheTTL = ttl
+		}
+		if cfg.NoCache {
+			c.noCache = true
+		}
+		if cfg.Auth != nil {
+			c.auth = cfg.Auth
+		}
+		if cfg.VersionControl == "git" {
+			c.versionControl = GitVersionControl{}
+		}
+	}
+}
+
+// WithCacheTTL sets how long cached indexes are used before they are
+// fetched again (default CacheTTL).
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cacheTTL = ttl
+	}
+}
+
+// sourcesOption configures sources given highest priority first, or the
+// one source.
+func sourcesOption(urls []string) Option {
+	if len(urls) == 1 {
+		return WithSource(urls[0])
+	}
+	var sources []SourceConfig
+	for i, url := range urls {
+		sources = append(sources, SourceConfig{URL: url, Priority: len(urls) - i})
+	}
+	return WithSources(sources)
+}
@@ -0,0 +1,310 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConflictStrategy says how an install settles items wanted at versions no
+// single version satisfies.
+type ConflictStrategy string
+
+const (
+	ConflictFail         ConflictStrategy = "fail"          // Report the conflicts and install nothing
+	ConflictNewest       ConflictStrategy = "newest"        // Install the newest version any requirement wants
+	ConflictKeepExisting ConflictStrategy = "keep-existing" // Keep the installed version, or install the newest
+)
+
+// ParseConflictStrategy parses a conflict strategy name.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	switch strategy := ConflictStrategy(s); strategy {
+	case ConflictFail, ConflictNewest, ConflictKeepExisting:
+		return strategy, nil
+	case "":
+		return ConflictFail, nil
+	default:
+		return "", fmt.Errorf("unknown conflict strategy %q (want fail, newest, or keep-existing)", s)
+	}
+}
+
+// VersionRequirement is a version constraint an item places on another.
+type VersionRequirement struct {
+	By         string `json:"by" yaml:"by"` // Display name of the requiring item ("" when requested directly)
+	Constraint string `json:"constraint" yaml:"constraint"`
+}
+
+// VersionConflict is an item whose requirements no single version satisfies,
+// counting the installed version unless the install is forced.
+type VersionConflict struct {
+	Kind         ItemKind             `json:"kind" yaml:"kind"`
+	Name         string               `json:"name" yaml:"name"`
+	Requirements []VersionRequirement `json:"requirements" yaml:"requirements"`
+	Installed    string               `json:"installed,omitempty" yaml:"installed,omitempty"`
+	Resolved     string               `json:"resolved,omitempty" yaml:"resolved,omitempty"` // Version the strategy chose ("" when failing)
+}
+
+func (c VersionConflict) String() string {
+	var wants []string
+	for _, r := range c.Requirements {
+		by := r.By
+		if by == "" {
+			by = "requested"
+		}
+		wants = append(wants, fmt.Sprintf("%s (%s)", r.Constraint, by))
+	}
+	if c.Installed != "" {
+		wants = append(wants, c.Installed+" (installed)")
+	}
+	return fmt.Sprintf("%s wanted at %s", FormatItemName(c.Kind, c.Name), strings.Join(wants, ", "))
+}
+
+// ConflictError is returned by an install with the ConflictFail strategy
+// when requested items want the same item at incompatible versions.
+type ConflictError struct {
+	Conflicts []VersionConflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := []string{fmt.Sprintf("%d version conflict(s) (use --on-conflict newest or keep-existing to resolve):", len(e.Conflicts))}
+	for _, c := range e.Conflicts {
+		lines = append(lines, "  "+c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// requirementKey identifies an item in a resolution.
+type requirementKey struct {
+	kind ItemKind
+	name string
+}
+
+// resolution collects the version requirements of the items an install
+// would touch.
+type resolution struct {
+	source   *Source
+	opts     *InstallOptions
+	platform Platform
+
+	order     []requirementKey
+	wants     map[requirementKey][]VersionRequirement
+	visited   map[string]bool              // kind/name@constraint already walked
+	manifests map[requirementKey]*Manifest // First manifest walked of each item
+}
+
+// resolveConflicts walks the requested items and their dependencies, as an
+// install would, and returns the versions to pin items at so that every
+// requirement is met, settling conflicts with the install's strategy. It
+// also returns the incompatibilities within each requested profile.
+func (c *Client) resolveConflicts(ctx context.Context, names []string, opts *InstallOptions) (map[string]string, []VersionConflict, []Incompatibility, error) {
+	strategy := opts.OnConflict
+	if strategy == "" {
+		strategy = ConflictFail
+	}
+
+	var resolutions []*resolution
+	var incompatible []Incompatibility
+	for _, name := range names {
+		base, constraint := SplitVersion(name)
+		kind, itemName := ParseItemName(base)
+		source, err := c.resolveSource(ctx, kind, itemName)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		r := &resolution{
+			source:    source,
+			opts:      opts,
+			platform:  CurrentPlatform(opts.Env),
+			wants:     make(map[requirementKey][]VersionRequirement),
+			visited:   make(map[string]bool),
+			manifests: make(map[requirementKey]*Manifest),
+		}
+		if err := r.walk(ctx, kind, itemName, constraint, ""); err != nil {
+			return nil, nil, nil, err
+		}
+		if kind == KindProfile {
+			incompatible = append(incompatible, r.incompatibilities(itemName)...)
+		}
+		resolutions = append(resolutions, r)
+	}
+
+	// Merge the requirements, keeping the source of the first request for each item
+	var order []requirementKey
+	wants := make(map[requirementKey][]VersionRequirement)
+	sources := make(map[requirementKey]*Source)
+	for _, r := range resolutions {
+		for _, key := range r.order {
+			if _, ok := sources[key]; !ok {
+				order = append(order, key)
+				sources[key] = r.source
+			}
+			wants[key] = append(wants[key], r.wants[key]...)
+		}
+	}
+
+	pins := make(map[string]string)
+	var conflicts []VersionConflict
+	for _, key := range order {
+		reqs := wants[key]
+		if len(reqs) == 0 {
+			continue
+		}
+
+		versions, _, err := sources[key].Versions(ctx, key.kind, key.name)
+		if err != nil {
+			continue // The install reports items it can't fetch
+		}
+		constraints := make([]*VersionConstraint, 0, len(reqs))
+		for _, req := range reqs {
+			vc, err := ParseConstraint(req.Constraint)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("%s: %w", FormatItemName(key.kind, key.name), err)
+			}
+			constraints = append(constraints, vc)
+		}
+		matchesAll := func(v string) bool {
+			for _, vc := range constraints {
+				if !vc.Match(v) {
+					return false
+				}
+			}
+			return true
+		}
+
+		installed := ""
+		if !opts.Force {
+			installed = installedVersion(c.itemDir(key.kind, key.name), c.cipher)
+		}
+		if installed != "" && matchesAll(installed) {
+			continue // Already installed at a version everything accepts
+		}
+
+		var best string
+		for _, v := range versions {
+			if matchesAll(v) && (best == "" || CompareVersions(v, best) > 0) {
+				best = v
+			}
+		}
+		if best != "" && installed == "" {
+			pins[FormatItemName(key.kind, key.name)] = best
+			continue
+		}
+
+		conflict := VersionConflict{Kind: key.kind, Name: key.name, Requirements: reqs, Installed: installed}
+		switch {
+		case strategy == ConflictKeepExisting && installed != "":
+			conflict.Resolved = installed
+		case strategy != ConflictFail:
+			for _, vc := range constraints {
+				if v, ok := vc.Best(versions); ok && (conflict.Resolved == "" || CompareVersions(v, conflict.Resolved) > 0) {
+					conflict.Resolved = v
+				}
+			}
+		}
+		if conflict.Resolved != "" {
+			pins[FormatItemName(key.kind, key.name)] = conflict.Resolved
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	if strategy == ConflictFail && len(conflicts) > 0 {
+		return nil, conflicts, incompatible, &ConflictError{Conflicts: conflicts}
+	}
+	return pins, conflicts, incompatible, nil
+}
+
+// walk records the requirement on an item and walks its dependencies at the
+// version the requirement resolves to.
+func (r *resolution) walk(ctx context.Context, kind ItemKind, name, constraint, by string) error {
+	key := requirementKey{kind, name}
+	if _, ok := r.wants[key]; !ok {
+		r.order = append(r.order, key)
+		r.wants[key] = nil
+	}
+	if constraint != "" {
+		r.wants[key] = append(r.wants[key], VersionRequirement{By: by, Constraint: constraint})
+	}
+
+	visit := FormatItemName(kind, name) + "@" + constraint
+	if r.visited[visit] || r.opts.NoDeps {
+		return nil
+	}
+	r.visited[visit] = true
+
+	content, _, err := r.source.resolveManifest(ctx, kind, name, constraint)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return nil // The install reports items it can't fetch
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil
+	}
+	if r.manifests[key] == nil {
+		r.manifests[key] = &manifest
+	}
+
+	self := FormatItemName(kind, name)
+	switch kind {
+	case KindProfile:
+		if manifest.Persona != "" {
+			persona, version := SplitVersion(manifest.Persona)
+			if err := r.walk(ctx, KindPersona, persona, version, self); err != nil {
+				return err
+			}
+		}
+		for _, ref := range manifest.Skills {
+			if !ref.Applies(r.platform) {
+				continue
+			}
+			if err := r.walk(ctx, KindSkill, ref.Name, ref.Version, self); err != nil {
+				return err
+			}
+		}
+	case KindSkill:
+		for _, dep := range manifest.Dependencies {
+			depName, version := SplitVersion(dep)
+			if err := r.walk(ctx, KindSkill, depName, version, self); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// incompatibilities returns the incompatibilities among a profile's persona
+// and the skills it installs, its skills' dependencies included.
+func (r *resolution) incompatibilities(profile string) []Incompatibility {
+	m := r.manifests[requirementKey{KindProfile, profile}]
+	if m == nil {
+		return nil // Not fetched, or walked without dependencies
+	}
+	persona, _ := SplitVersion(m.Persona)
+	skills := make(map[string]*Manifest)
+	for key, manifest := range r.manifests {
+		if key.kind == KindSkill {
+			skills[key.name] = manifest
+		}
+	}
+	found := checkCompatibility(persona, skills)
+	for i := range found {
+		found[i].Profile = FormatItemName(KindProfile, profile)
+	}
+	return found
+}
+
+// installedVersion returns the version of the item installed in dir, or ""
+// if there is none.
+func installedVersion(dir string, cipher *contentCipher) string {
+	m, err := loadManifest(filepath.Join(dir, "vega.yaml"), cipher)
+	if err != nil {
+		return ""
+	}
+	return m.Version
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Contradiction is an instruction in an agent's combined prompts forbidding
+// what another part of them asks for, or what one of its skills needs.
+type Contradiction struct {
+	Rule      string `json:"rule" yaml:"rule"`             // The prohibition, e.g. "Never use web search."
+	RuleFrom  string `json:"rule_from" yaml:"rule_from"`   // Where it is, e.g. "@analyst system_prompt"
+	Other     string `json:"other" yaml:"other"`           // The instruction, tool, or binary it forbids
+	OtherFrom string `json:"other_from" yaml:"other_from"` // Where that is, e.g. "web-research tools"
+}
+
+func (c Contradiction) String() string {
+	return fmt.Sprintf("%s: %q\n    contradicts %s: %q", c.RuleFrom, c.Rule, c.OtherFrom, c.Other)
+}
+
+// Contradictions checks the combined prompts of a persona or profile, as
+// rendered for deployment with opts, for instructions that contradict each
+// other: a prohibition such as "never use web search" in one part against
+// an instruction to do it in another, or against a skill's web_search tool
+// or required binary. The check is a heuristic matching the words after
+// never, do not, avoid, and the like, so it can miss contradictions phrased
+// differently and flag prohibitions with exceptions spelled out.
+func (c *Client) Contradictions(ctx context.Context, name string, opts *AgentOptions) ([]Contradiction, error) {
+	// The check reads the manifests' prompts, so secrets needn't resolve
+	checkOpts := AgentOptions{}
+	if opts != nil {
+		checkOpts = *opts
+	}
+	checkOpts.Secrets = unresolvedSecrets{}
+
+	data, err := c.exportData(ctx, name, &checkOpts)
+	if err != nil {
+		return nil, err
+	}
+	return findContradictions(data), nil
+}
+
+// unresolvedSecrets leaves secret references as they are.
+type unresolvedSecrets struct{}
+
+func (unresolvedSecrets) Secret(ctx context.Context, name string) (string, error) {
+	return fmt.Sprintf("{{secret %q}}", name), nil
+}
+
+// instruction is a sentence of a prompt and where it is.
+type instruction struct {
+	text, from string
+}
+
+var (
+	// prohibitionPattern finds a prohibition and captures what it forbids
+	prohibitionPattern = regexp.MustCompile(`(?i)\b(?:never|do not|don't|must not|mustn't|should not|shouldn't|avoid|refrain from|under no circumstances)\b\s+(.+)`)
+
+	// mandatePattern finds an instruction to do something and captures it
+	mandatePattern = regexp.MustCompile(`(?i)^(?:always\s+|you must\s+|must\s+|make sure to\s+|be sure to\s+)?((?:use|run|call|rely on|prefer|check|search|query|execute)\b.+)`)
+
+	sentenceEnd = regexp.MustCompile(`[.!?;]\s+|\n+`)
+)
+
+// genericWords are left out when comparing instructions, as saying nothing
+// about what is forbidden or asked for.
+var genericWords = map[string]bool{
+	"use": true, "using": true, "run": true, "call": true, "rely": true, "prefer": true,
+	"ever": true, "any": true, "it": true, "them": true, "your": true, "you": true,
+	"be": true, "is": true, "are": true, "make": true, "do": true, "tool": true,
+	"command": true,
+}
+
+// boundaryWords end the object of an instruction: what follows says when or
+// how rather than what.
+var boundaryWords = map[string]bool{
+	"after": true, "before": true, "directly": true, "during": true, "except": true,
+	"if": true, "unless": true, "until": true, "when": true, "while": true,
+	"without": true, "yourself": true,
+}
+
+// findContradictions checks the manifests an agent was rendered from.
+func findContradictions(data *ExportData) []Contradiction {
+	var parts []instruction
+	add := func(text, from string) {
+		for _, s := range sentenceEnd.Split(text, -1) {
+			if s = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(s), "-*•0123456789.) ")); s != "" {
+				parts = append(parts, instruction{s, from})
+			}
+		}
+	}
+
+	persona := FormatItemName(KindPersona, data.Persona.Name)
+	add(data.Persona.SystemPrompt.String(), persona+" system_prompt")
+	if data.Profile != nil {
+		add(data.Profile.SystemPromptAppend, FormatItemName(KindProfile, data.Profile.Name)+" system_prompt_append")
+	}
+	for _, skill := range data.Skills {
+		names := make([]string, 0, len(skill.Prompts))
+		for name := range skill.Prompts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(skill.Prompts[name], skill.Name+" prompts."+name)
+		}
+	}
+
+	// What each skill gives the agent to work with
+	type capability struct {
+		what, from string
+		words      []string
+	}
+	var capabilities []capability
+	for _, skill := range data.Skills {
+		for _, tool := range skill.Tools {
+			if containsTool(data.Tools, tool.Name) {
+				capabilities = append(capabilities, capability{tool.Name, skill.Name + " tools", tokenize(tool.Name)})
+			}
+		}
+		if skill.Requires != nil {
+			for _, binary := range skill.Requires.Binaries {
+				capabilities = append(capabilities, capability{binary, skill.Name + " requires.binaries", tokenize(binary)})
+			}
+		}
+	}
+
+	var found []Contradiction
+	seen := make(map[string]bool)
+	report := func(c Contradiction) {
+		key := c.RuleFrom + "\x00" + c.Rule + "\x00" + c.OtherFrom + "\x00" + c.Other
+		if !seen[key] {
+			seen[key] = true
+			found = append(found, c)
+		}
+	}
+	for _, rule := range parts {
+		m := prohibitionPattern.FindStringSubmatch(rule.text)
+		if m == nil {
+			continue
+		}
+		forbidden := objectWords(m[1])
+		if len(forbidden) == 0 {
+			continue
+		}
+
+		for _, other := range parts {
+			if other.from == rule.from {
+				continue
+			}
+			mandate := mandatePattern.FindStringSubmatch(other.text)
+			if mandate == nil || prohibitionPattern.MatchString(other.text) {
+				continue
+			}
+			if hasWords(contentWords(mandate[1]), forbidden) {
+				report(Contradiction{Rule: rule.text, RuleFrom: rule.from, Other: other.text, OtherFrom: other.from})
+			}
+		}
+		for _, c := range capabilities {
+			if len(c.words) > 0 && hasWords(forbidden, c.words) && hasWords(c.words, forbidden) {
+				report(Contradiction{Rule: rule.text, RuleFrom: rule.from, Other: c.what, OtherFrom: c.from})
+			}
+		}
+	}
+	return found
+}
+
+// contentWords returns the words of text that say what it is about,
+// without stop words and generic verbs.
+func contentWords(text string) []string {
+	var words []string
+	for _, word := range tokenize(text) {
+		if !stopWords[word] && !genericWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// objectWords returns the words of what an instruction is about: its
+// leading content words, up to a stop word or one saying when or how, as
+// "docker logs" of "use docker logs during an incident".
+func objectWords(text string) []string {
+	var words []string
+	for _, word := range tokenize(text) {
+		if genericWords[word] && len(words) == 0 {
+			continue
+		}
+		if stopWords[word] || genericWords[word] || boundaryWords[word] {
+			break
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// hasWords reports whether words include every one of want.
+func hasWords(words, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, word := range words {
+			if word == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// containsTool reports whether tools include one named name.
+func containsTool(tools []Tool, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
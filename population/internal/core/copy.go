@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CopyOptions configures copying items from a source into a registry checkout.
+type CopyOptions struct {
+	Version  string       // Version constraint, as for InstallOptions
+	NoDeps   bool         // Copy a profile without its persona and skills
+	NoVerify bool         // Skip checking manifests against the hashes published in the source index
+	Sign     *SignOptions // Re-sign copied manifests (nil leaves them unsigned)
+	DryRun   bool         // Report what would be copied without writing anything
+}
+
+// CopyResult describes one copied item.
+type CopyResult struct {
+	Kind      ItemKind
+	Name      string
+	Version   string
+	Path      string // Manifest path in the registry
+	Verified  bool   // The manifest matched the hash published in the source index
+	Unchanged bool   // The registry already had this exact manifest
+	Signature string // Signature file written, if re-signed
+}
+
+// Copy fetches an item from the client's source, verifying it as Install
+// does, and adds it to the registry checkout at registryDir, updating its
+// index and optionally re-signing it. This curates internal registries from
+// public ones. A profile is copied together with its persona and skills.
+func (c *Client) Copy(ctx context.Context, name, registryDir string, opts *CopyOptions) ([]CopyResult, error) {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+
+	registry, err := OpenLocalRegistry(registryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	name, version := SplitVersion(name)
+	if version == "" {
+		version = opts.Version
+	}
+	kind, itemName := ParseItemName(name)
+
+	var results []CopyResult
+	if kind == KindProfile && !opts.NoDeps {
+		source, err := c.resolveSource(ctx, kind, itemName)
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := source.GetManifest(ctx, kind, itemName)
+		if err != nil {
+			return nil, fmt.Errorf("fetching profile %q: %w", itemName, err)
+		}
+
+		var deps []string
+		if manifest.Persona != "" {
+			persona, _ := SplitVersion(manifest.Persona)
+			deps = append(deps, FormatItemName(KindPersona, persona))
+		}
+		for _, skill := range manifest.Skills.Ordered() {
+			deps = append(deps, skill.Name)
+		}
+		for _, dep := range deps {
+			depKind, depName := ParseItemName(dep)
+			result, err := c.copyItem(ctx, registry, depKind, depName, "", opts)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, *result)
+		}
+	}
+
+	result, err := c.copyItem(ctx, registry, kind, itemName, version, opts)
+	if err != nil {
+		return results, err
+	}
+	return append(results, *result), nil
+}
+
+// copyItem copies one item into a registry.
+func (c *Client) copyItem(ctx context.Context, registry *LocalRegistry, kind ItemKind, name, constraint string, opts *CopyOptions) (*CopyResult, error) {
+	source, err := c.resolveSource(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if source.signatures, err = c.signaturePolicy(false); err != nil {
+		return nil, err
+	}
+
+	fetched, err := source.fetchVerified(ctx, kind, name, constraint, opts.NoVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(fetched.content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing %s %q: %w", kind, name, err)
+	}
+	manifest.Name = name
+
+	files, err := source.fetchFiles(ctx, kind, name, manifest.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CopyResult{
+		Kind:     kind,
+		Name:     name,
+		Version:  fetched.version,
+		Path:     registry.ManifestPath(kind, name),
+		Verified: fetched.verified,
+	}
+	if existing, err := registry.ReadManifest(kind, name); err == nil && bytes.Equal(existing, fetched.content) {
+		result.Unchanged = true
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if !result.Unchanged {
+		if err := registry.WriteManifest(kind, name, fetched.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeItemFiles(filepath.Dir(result.Path), files, nil); err != nil {
+		return nil, err
+	}
+	if err := registry.UpdateIndex(kind, &manifest); err != nil {
+		return nil, err
+	}
+
+	if opts.Sign != nil {
+		signed, err := SignManifest(ctx, result.Path, opts.Sign)
+		if err != nil {
+			return nil, fmt.Errorf("signing %s %q: %w", kind, name, err)
+		}
+		result.Signature = signed.Path
+	}
+	return result, nil
+}
@@ -0,0 +1,544 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDaemonInterval is how often the daemon refreshes indexes by default.
+	DefaultDaemonInterval = 15 * time.Minute
+
+	// DaemonSocketName is the daemon socket file name, relative to the vega home.
+	DaemonSocketName = "population.sock"
+
+	// DaemonStatusFile is the freshness metrics file, relative to the cache directory.
+	DaemonStatusFile = "daemon-status.json"
+
+	// daemonDialTimeout bounds how long the CLI waits for a daemon before falling back.
+	daemonDialTimeout = 200 * time.Millisecond
+
+	// DefaultShutdownTimeout is how long servers drain in-flight work on shutdown.
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// DaemonOptions configures the background refresh daemon.
+type DaemonOptions struct {
+	Interval time.Duration  // Refresh interval (default DefaultDaemonInterval)
+	Prefetch []ItemKind     // Kinds whose manifests are prefetched along with the indexes
+	Socket   string         // Socket path (default <vega home>/population.sock)
+	Sync     string         // Deps file to sync after every refresh (optional)
+	Notify   *NotifyOptions // Announce upstream updates to installed items (optional)
+	Debug    string         // Loopback address serving pprof and runtime metrics (optional)
+
+	AccessLog io.Writer // Where each socket request is logged as a line of JSON (optional)
+
+	// ShutdownTimeout bounds how long Run waits, once ctx is cancelled, for a
+	// refresh and socket requests in progress (default DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
+}
+
+// DaemonStatus reports the freshness of the daemon's data.
+type DaemonStatus struct {
+	Source      string    `json:"source"`
+	LastRefresh time.Time `json:"last_refresh"`
+	NextRefresh time.Time `json:"next_refresh"`
+	DurationMS  int64     `json:"duration_ms"`
+	Indexes     int       `json:"indexes"`
+	Manifests   int       `json:"manifests"`
+	Refreshes   int       `json:"refreshes"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastSync    time.Time `json:"last_sync,omitempty"`
+	SyncError   string    `json:"sync_error,omitempty"`
+}
+
+// Daemon periodically refreshes the cached indexes, prefetches manifests, and
+// serves them over a local socket so interactive commands never wait on the network.
+type Daemon struct {
+	client *Client
+	source *Source
+	opts   DaemonOptions
+
+	mu     sync.RWMutex
+	files  map[string][]byte
+	status DaemonStatus
+
+	// Set while running
+	server     *http.Server
+	cancelWork context.CancelFunc
+	quit       chan struct{} // Closed to stop refreshing
+	idle       chan struct{} // Closed once the refresh loop has stopped
+	stopOnce   sync.Once
+}
+
+// NewDaemon creates a daemon for the client's source.
+func (c *Client) NewDaemon(opts *DaemonOptions) *Daemon {
+	d := &Daemon{
+		client: c,
+		files:  make(map[string][]byte),
+	}
+	if opts != nil {
+		d.opts = *opts
+	}
+	if d.opts.Interval <= 0 {
+		d.opts.Interval = DefaultDaemonInterval
+	}
+	if d.opts.Socket == "" {
+		d.opts.Socket = c.daemonSocket()
+	}
+	if d.opts.ShutdownTimeout <= 0 {
+		d.opts.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	// The daemon always talks to the real source, never to itself
+	d.source = c.directSource(c.Sources()[0])
+	d.status.Source = d.source.baseURL
+
+	return d
+}
+
+// Run refreshes immediately, then on every interval, while serving the socket.
+// When ctx is cancelled it shuts down as Shutdown does, waiting at most
+// ShutdownTimeout.
+func (d *Daemon) Run(ctx context.Context) error {
+	listener, err := d.listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(d.opts.Socket)
+
+	if d.opts.Debug != "" {
+		shutdown, err := startDebugServer(d.opts.Debug)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		defer shutdown(context.Background())
+	}
+
+	// Refreshes outlive ctx so a shutdown lets the one in progress finish
+	work, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	d.mu.Lock()
+	d.server = &http.Server{Handler: d.handler()}
+	d.cancelWork = cancel
+	d.quit = make(chan struct{})
+	d.idle = make(chan struct{})
+	server, quit, idle := d.server, d.quit, d.idle
+	d.mu.Unlock()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	go func() {
+		defer close(idle)
+		d.loop(work, quit)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), d.opts.ShutdownTimeout)
+		defer cancelShutdown()
+		return d.Shutdown(shutdownCtx)
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			// Shut down by a call to Shutdown, which finishes the work
+			<-idle
+			return nil
+		}
+		d.stop()
+		cancel()
+		<-idle
+		return fmt.Errorf("serving daemon socket: %w", err)
+	}
+}
+
+// loop refreshes immediately, then on every interval, until quit is closed.
+func (d *Daemon) loop(ctx context.Context, quit <-chan struct{}) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	// Errors are recorded in the status; the daemon keeps running
+	d.tick(ctx)
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			d.tick(ctx)
+		}
+	}
+}
+
+// Shutdown stops refreshing and serving the socket. A refresh in progress and
+// requests being served are allowed to finish until ctx is done, when they are
+// cancelled. The final status is written before it returns.
+func (d *Daemon) Shutdown(ctx context.Context) error {
+	d.mu.RLock()
+	server, cancel, idle := d.server, d.cancelWork, d.idle
+	d.mu.RUnlock()
+	if server == nil {
+		return nil
+	}
+
+	d.stop()
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		cancel()
+		<-idle
+	}
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		server.Close()
+		err = fmt.Errorf("draining daemon socket: %w", err)
+	}
+	if status := d.Status(); status.Refreshes > 0 {
+		if werr := d.writeStatus(status); err == nil && werr != nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// stop ends the refresh loop.
+func (d *Daemon) stop() {
+	d.stopOnce.Do(func() {
+		d.mu.RLock()
+		close(d.quit)
+		d.mu.RUnlock()
+	})
+}
+
+// tick runs one refresh and, if configured, one sync and update check.
+func (d *Daemon) tick(ctx context.Context) {
+	if err := d.Refresh(ctx); err != nil {
+		return
+	}
+	if d.opts.Sync != "" {
+		d.sync(ctx)
+	}
+	if d.opts.Notify != nil {
+		// Notification errors must not stop the daemon
+		d.client.NotifyUpdates(ctx, d.opts.Notify)
+	}
+}
+
+func (d *Daemon) sync(ctx context.Context) {
+	var syncErr string
+	deps, err := LoadDeps(d.opts.Sync)
+	if err == nil {
+		_, err = d.client.Sync(ctx, deps, nil)
+	}
+	if err != nil {
+		syncErr = err.Error()
+	}
+
+	d.mu.Lock()
+	d.status.LastSync = time.Now()
+	d.status.SyncError = syncErr
+	status := d.status
+	d.mu.Unlock()
+
+	d.writeStatus(status)
+}
+
+// Refresh fetches all indexes and the configured manifests, updates the cache,
+// and writes the freshness metrics file.
+func (d *Daemon) Refresh(ctx context.Context) error {
+	start := time.Now()
+	files := make(map[string][]byte)
+
+	err := d.fetchAll(ctx, files)
+
+	d.mu.Lock()
+	if err == nil {
+		d.files = files
+		d.status.LastRefresh = start
+		d.status.Indexes = 0
+		d.status.Manifests = 0
+		for path := range files {
+			if strings.HasSuffix(path, "/index.yaml") {
+				d.status.Indexes++
+			} else {
+				d.status.Manifests++
+			}
+		}
+		d.status.LastError = ""
+	} else {
+		d.status.Failures++
+		d.status.LastError = err.Error()
+	}
+	d.status.Refreshes++
+	d.status.DurationMS = time.Since(start).Milliseconds()
+	d.status.NextRefresh = start.Add(d.opts.Interval)
+	status := d.status
+	d.mu.Unlock()
+
+	if writeErr := d.writeStatus(status); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// Status returns the current freshness metrics.
+func (d *Daemon) Status() DaemonStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.status
+}
+
+func (d *Daemon) fetchAll(ctx context.Context, files map[string][]byte) error {
+	prefetch := make(map[ItemKind]bool)
+	for _, kind := range d.opts.Prefetch {
+		prefetch[kind] = true
+	}
+
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		indexPath := kind.Plural() + "/index.yaml"
+		// Refreshing the on-disk cache keeps it warm for clients that don't
+		// use the socket, and lets unchanged indexes be revalidated cheaply
+		content, err := d.source.refreshIndex(ctx, kind)
+		if err != nil {
+			return fmt.Errorf("fetching %s index: %w", kind.Plural(), err)
+		}
+		files[indexPath] = content
+
+		if !prefetch[kind] {
+			continue
+		}
+
+		entries, profiles, err := d.source.parseIndex(content, kind)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		for name := range entries {
+			names = append(names, name)
+		}
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := fmt.Sprintf("%s/%s/vega.yaml", kind.Plural(), name)
+			content, err := d.source.fetch(ctx, path)
+			if err != nil {
+				// Items without a manifest are simply not prefetched
+				continue
+			}
+			files[path] = content
+		}
+	}
+
+	return nil
+}
+
+func (d *Daemon) writeStatus(status DaemonStatus) error {
+	content, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding daemon status: %w", err)
+	}
+	return d.source.cache.Set(DaemonStatusFile, content)
+}
+
+// listen opens the daemon socket, replacing a stale socket file left by a crashed daemon.
+func (d *Daemon) listen() (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(d.opts.Socket), 0755); err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(d.opts.Socket); err == nil {
+		conn, err := net.DialTimeout("unix", d.opts.Socket, daemonDialTimeout)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", d.opts.Socket)
+		}
+		if err := os.Remove(d.opts.Socket); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", d.opts.Socket)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", d.opts.Socket, err)
+	}
+	return listener, nil
+}
+
+// handler serves /status and /files/<path> for the daemon's source, and the
+// /healthz and /readyz probes. Requests for a different source get a 404 so
+// clients fall back to fetching directly.
+func (d *Daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d.Status())
+	})
+
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("source") != d.source.baseURL {
+			http.NotFound(w, r)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/files/")
+
+		d.mu.RLock()
+		content, ok := d.files[path]
+		d.mu.RUnlock()
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	})
+
+	h := withHealth(mux, d.ready)
+	if d.opts.AccessLog != nil {
+		h = accessLog(h, d.opts.AccessLog)
+	}
+	return h
+}
+
+// ready reports why the daemon can't serve content: it hasn't fetched the
+// indexes yet, or is shutting down.
+func (d *Daemon) ready() error {
+	d.mu.RLock()
+	quit, loaded, lastError := d.quit, len(d.files) > 0, d.status.LastError
+	d.mu.RUnlock()
+
+	select {
+	case <-quit:
+		return errors.New("shutting down")
+	default:
+	}
+	if !loaded {
+		if lastError != "" {
+			return errors.New(lastError)
+		}
+		return errors.New("waiting for the first refresh")
+	}
+	return nil
+}
+
+// daemonConn is the client side of the daemon socket.
+type daemonConn struct {
+	socket string
+	client *http.Client
+}
+
+// newDaemonConn returns a connection to the daemon socket, or nil if no daemon is running.
+func newDaemonConn(socket string) *daemonConn {
+	if socket == "" {
+		return nil
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return nil
+	}
+
+	return &daemonConn{
+		socket: socket,
+		client: &http.Client{
+			Timeout: 2 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					dialer := net.Dialer{Timeout: daemonDialTimeout}
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// get asks the daemon for a source file. ok is false if the daemon doesn't have it.
+func (d *daemonConn) get(ctx context.Context, baseURL, path string) ([]byte, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://daemon/files/"+path, nil)
+	if err != nil {
+		return nil, false
+	}
+	q := req.URL.Query()
+	q.Set("source", baseURL)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// status fetches the running daemon's freshness metrics.
+func (d *daemonConn) status(ctx context.Context) (*DaemonStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://daemon/status", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var status DaemonStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decoding daemon status: %w", err)
+	}
+	return &status, nil
+}
+
+// DaemonStatus returns the freshness metrics of the running daemon, or of the
+// last daemon run recorded in the cache if none is running.
+func (c *Client) DaemonStatus(ctx context.Context) (*DaemonStatus, bool, error) {
+	if conn := newDaemonConn(c.daemonSocket()); conn != nil {
+		if status, err := conn.status(ctx); err == nil {
+			return status, true, nil
+		}
+	}
+
+	// Stored in the cache, so encrypted with it
+	content, err := c.cipher.readFile(filepath.Join(c.cacheDir, DaemonStatusFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, fmt.Errorf("no daemon status recorded (is the daemon running?)")
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("reading daemon status: %w", err)
+	}
+
+	var status DaemonStatus
+	if err := json.Unmarshal(content, &status); err != nil {
+		return nil, false, fmt.Errorf("decoding daemon status: %w", err)
+	}
+	return &status, false, nil
+}
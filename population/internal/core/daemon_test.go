@@ -0,0 +1,38 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDaemonStatusEncrypted(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(HomeEnv, home)
+	client, err := NewClient(WithSource(t.TempDir()), WithEncryptionKey(bytes.Repeat([]byte{7}, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := client.NewDaemon(nil)
+	if err := d.writeStatus(DaemonStatus{Source: "test", Refreshes: 3}); err != nil {
+		t.Fatalf("writeStatus: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(client.cacheDir, DaemonStatusFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncrypted(raw) {
+		t.Fatal("daemon status was stored in plain text")
+	}
+
+	status, running, err := client.DaemonStatus(context.Background())
+	if err != nil {
+		t.Fatalf("DaemonStatus: %v", err)
+	}
+	if running || status.Source != "test" || status.Refreshes != 3 {
+		t.Errorf("DaemonStatus = %+v (running %v), want the recorded status", status, running)
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+)
+
+var publishRuntimeVars sync.Once
+
+// debugHandler serves the pprof profiles under /debug/pprof/ and runtime
+// metrics (memory stats, goroutines, GC) as JSON under /debug/vars.
+func debugHandler() http.Handler {
+	publishRuntimeVars.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} { return runtime.NumGoroutine() }))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// checkLoopback rejects debug addresses that aren't bound to the loopback
+// interface; profiles expose memory contents and command lines.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid debug address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("debug address %q must be on the loopback interface (e.g. 127.0.0.1:6060)", addr)
+}
+
+// startDebugServer serves debugHandler on a loopback address until the
+// returned function shuts it down.
+func startDebugServer(addr string) (func(context.Context) error, error) {
+	if err := checkLoopback(addr); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: debugHandler()}
+	go server.Serve(listener)
+	return server.Shutdown, nil
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDeepScore ranks deep matches below index matches on the description,
+// and above fuzzy ones.
+const maxDeepScore = 0.45
+
+// deepSearch scores the manifest bodies of the candidates the index didn't
+// match against the text of the query for each: their system prompts, a
+// skill's prompts and tools, and a persona's examples. Manifests are read from the install directory or the cache when
+// they hold the indexed version, and fetched up to s.concurrency at a time
+// otherwise. Candidates whose manifest can't be read are skipped, with a
+// warning unless the source simply lacks it.
+func (s *Source) deepSearch(ctx context.Context, candidates []SearchResult, queries []string) []SearchResult {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	matched := make([]bool, len(candidates))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	workers := s.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers && w < len(candidates); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				r := &candidates[i]
+				m, err := s.searchManifest(ctx, r.Kind, r.Name, r.Version)
+				if err != nil {
+					if ctx.Err() == nil && !isNotFoundError(err) {
+						s.log().Warn("skipping "+FormatItemName(r.Kind, r.Name)+" in deep search", "source", s.name, "error", err)
+					}
+					continue
+				}
+				r.Score, r.Match = deepScore(queries[i], strings.Fields(queries[i]), m)
+				matched[i] = r.Score > 0
+			}
+		}()
+	}
+	for i := range candidates {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var results []SearchResult
+	for i, r := range candidates {
+		if matched[i] {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// searchManifest returns a version of an item's manifest for searching,
+// preferring an installed or cached copy of it to fetching it.
+func (s *Source) searchManifest(ctx context.Context, kind ItemKind, name, version string) (*Manifest, error) {
+	if err := checkItemName(name); err != nil {
+		return nil, err
+	}
+	if s.installDir != "" {
+		m, err := loadManifest(filepath.Join(s.installDir, s.layout.Dir(kind), name, "vega.yaml"), s.cipher)
+		if err == nil && m.Version == version {
+			return m, nil
+		}
+	}
+
+	// A version's manifest doesn't change, so a cached one is good however
+	// old; local sources are read directly
+	cacheKey := s.cacheKey(fmt.Sprintf("manifests-%s-%s@%s.yaml", kind.Plural(), name, url.PathEscape(version)))
+	var content []byte
+	cached := false
+	if !s.isLocal {
+		content, cached = s.cache.GetStale(cacheKey)
+	}
+	if !cached {
+		var err error
+		if content, err = s.GetManifestRaw(ctx, kind, name); err != nil {
+			return nil, err
+		}
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if !cached && !s.isLocal && m.Version == version {
+		if err := s.cache.Set(cacheKey, content); err != nil {
+			s.log().Warn("failed to cache "+FormatItemName(kind, name)+" manifest", "source", s.name, "error", err)
+		}
+	}
+	return &m, nil
+}
+
+// deepScore scores a manifest body against a query, whose words must all
+// appear in one part of it, and returns the part and line they were found
+// in. The whole query appearing as a phrase scores highest.
+func deepScore(query string, words []string, m *Manifest) (float64, string) {
+	type part struct{ name, text string }
+	parts := []part{
+		{"system_prompt", m.SystemPrompt.String()},
+		{"system_prompt_append", m.SystemPromptAppend},
+	}
+	prompts := make([]string, 0, len(m.Prompts))
+	for name := range m.Prompts {
+		prompts = append(prompts, name)
+	}
+	sort.Strings(prompts)
+	for _, name := range prompts {
+		parts = append(parts, part{"prompts." + name, m.Prompts[name]})
+	}
+	for _, tool := range m.Tools {
+		parts = append(parts, part{"tools." + tool.Name, tool.Name + "\n" + tool.Description})
+	}
+	for i, example := range m.Examples {
+		var text strings.Builder
+		for _, message := range example.Messages {
+			text.WriteString(message.Content + "\n")
+		}
+		parts = append(parts, part{fmt.Sprintf("examples[%d]", i), text.String()})
+	}
+
+	query = strings.ToLower(query)
+	var best float64
+	var match string
+	for _, p := range parts {
+		text := strings.ToLower(p.text)
+		found := true
+		for _, word := range words {
+			if !strings.Contains(text, strings.ToLower(word)) {
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		score := maxDeepScore - 0.05
+		if strings.Contains(text, query) {
+			score = maxDeepScore
+		}
+		if score > best {
+			best, match = score, p.name+": "+matchLine(p.text, strings.ToLower(words[0]))
+		}
+	}
+	return best, match
+}
+
+// matchLine returns the trimmed line of text containing word, shortened
+// around it.
+func matchLine(text, word string) string {
+	const width = 80
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		i := strings.Index(strings.ToLower(line), word)
+		if i < 0 {
+			continue
+		}
+		runes := []rune(line)
+		if len(runes) <= width {
+			return line
+		}
+		start := utf8.RuneCountInString(line[:min(i, len(line))]) - width/3
+		if start < 0 {
+			start = 0
+		}
+		end := start + width
+		if end > len(runes) {
+			end, start = len(runes), len(runes)-width
+		}
+		excerpt := string(runes[start:end])
+		if start > 0 {
+			excerpt = "..." + excerpt
+		}
+		if end < len(runes) {
+			excerpt += "..."
+		}
+		return excerpt
+	}
+	return ""
+}
@@ -0,0 +1,501 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Agent is a persona or profile rendered for deployment, with organization
+// settings applied.
+type Agent struct {
+	ID          string // Persona or profile name, e.g. "cmo"
+	Name        string // Agent name, e.g. "Maya"
+	Description string
+	Model       string
+	Temperature float64
+	Budget      string
+	System      string   // System prompt, with secrets resolved
+	Tools       []string // Tools of the agent's skills, unless overridden
+	Skills      []string // A profile's skills, or a persona's recommended skills
+}
+
+// AgentOptions configures how a persona is rendered as an Agent. Empty fields
+// fall back to installed settings, then to the export defaults.
+type AgentOptions struct {
+	Name        string         // Agent name (default: from "You are X" in the prompt, else the capitalized ID)
+	Model       string         // Model or model alias
+	Temperature float64        // Sampling temperature (default 0.7)
+	Budget      string         // Budget limit, e.g. "$5.00"
+	Secrets     SecretProvider // Resolves {{secret "NAME"}} references (default: none, failing on any)
+	Examples    bool           // Append the persona's example conversations to the system prompt
+	Env         string         // Deployment environment for a profile's conditional skills
+	Tools       []string       // Tools to give the agent instead of its skills' tools
+	NoTools     bool           // Give the agent no tools
+}
+
+// defaultExportTemperature is the temperature used when none is given.
+const defaultExportTemperature = 0.7
+
+// Agent renders a persona or profile for deployment, with the tools of its
+// skills. A profile is rendered as its persona with the profile's prompt
+// appended, and brings the skills that apply in opts.Env; a persona brings
+// its recommended skills.
+func (c *Client) Agent(ctx context.Context, name string, opts *AgentOptions) (*Agent, error) {
+	data, err := c.exportData(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return data.Agent, nil
+}
+
+// exportData renders a persona or profile as an Agent, keeping the manifests
+// it was rendered from.
+func (c *Client) exportData(ctx context.Context, name string, opts *AgentOptions) (*ExportData, error) {
+	if opts == nil {
+		opts = &AgentOptions{}
+	}
+
+	kind, itemName := ParseItemName(name)
+	if kind != KindPersona && kind != KindProfile {
+		return nil, fmt.Errorf("only personas and profiles can be deployed as agents (use @name or +name format)")
+	}
+
+	// Apply installed organization settings
+	settings, err := c.Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, profile, err := c.agentManifests(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	var skills []*Manifest
+	for _, skillName := range agentSkillNames(manifest, profile, opts.Env) {
+		skill, err := c.manifest(ctx, KindSkill, skillName)
+		if err != nil {
+			return nil, fmt.Errorf("fetching skill %q of %s: %w", skillName, FormatItemName(kind, itemName), err)
+		}
+		skills = append(skills, skill)
+	}
+
+	return renderExport(ctx, itemName, manifest, profile, skills, settings, opts)
+}
+
+// agentManifests fetches the persona an agent is rendered from, and the
+// profile when the agent is one.
+func (c *Client) agentManifests(ctx context.Context, kind ItemKind, name string) (manifest, profile *Manifest, err error) {
+	personaName, personaVersion := name, ""
+	if kind == KindProfile {
+		if profile, err = c.manifest(ctx, KindProfile, name); err != nil {
+			return nil, nil, fmt.Errorf("fetching profile: %w", err)
+		}
+		if profile.Persona == "" {
+			return nil, nil, fmt.Errorf("profile %q has no persona to deploy", name)
+		}
+		personaName, personaVersion = SplitVersion(profile.Persona)
+	}
+	if manifest, err = c.manifestAt(ctx, KindPersona, personaName, personaVersion); err != nil {
+		return nil, nil, fmt.Errorf("fetching persona: %w", err)
+	}
+	return manifest, profile, nil
+}
+
+// agentSkillNames returns the skills of an agent: a profile's skills that
+// apply here in env, or a persona's recommended skills.
+func agentSkillNames(manifest, profile *Manifest, env string) []string {
+	if profile == nil {
+		return manifest.RecommendedSkills
+	}
+	var names []string
+	for _, ref := range profile.Skills.Select(CurrentPlatform(env)) {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+// declaredBudget returns the budget requested for an agent, else the one its
+// profile or persona declares, or "" to fall back to the settings.
+func declaredBudget(requested string, manifest, profile *Manifest) string {
+	if requested != "" {
+		return requested
+	}
+	if profile != nil && profile.Budget != "" {
+		return profile.Budget
+	}
+	return manifest.Budget
+}
+
+// renderExport renders a persona, or a profile of it, as an agent with the
+// tools of the given skills, applying settings.
+func renderExport(ctx context.Context, id string, manifest, profile *Manifest, skills []*Manifest, settings *Settings, opts *AgentOptions) (*ExportData, error) {
+	budget, err := settings.Budget(declaredBudget(opts.Budget, manifest, profile), defaultExportBudget)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := manifest.SystemPrompt.String()
+	if profile != nil && strings.TrimSpace(profile.SystemPromptAppend) != "" {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n" + strings.Trim(profile.SystemPromptAppend, "\n") + "\n"
+	}
+
+	system, err := RenderSecrets(ctx, prompt, opts.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tron takes few-shot examples as part of the system prompt
+	if opts.Examples && len(manifest.Examples) > 0 {
+		system = strings.TrimRight(system, "\n") + "\n\n" + FormatExamples(manifest.Examples)
+	}
+
+	agent := &Agent{
+		ID:          id,
+		Name:        opts.Name,
+		Description: manifest.Description,
+		Model:       settings.Model(opts.Model, defaultExportModel),
+		Temperature: opts.Temperature,
+		Budget:      budget,
+		System:      system,
+	}
+	if agent.Name == "" {
+		if agent.Name = extractAgentName(system); agent.Name == "" {
+			agent.Name = titleCase(id)
+		}
+	}
+	if agent.Temperature == 0 {
+		agent.Temperature = defaultExportTemperature
+	}
+	if profile != nil {
+		agent.Description = profile.Description
+	}
+
+	data := &ExportData{Agent: agent, Persona: manifest, Profile: profile, Skills: skills}
+	var tools []string
+	for _, skill := range skills {
+		agent.Skills = append(agent.Skills, skill.Name)
+		for _, tool := range skill.Tools {
+			if !containsFold(tools, tool.Name) {
+				tools = append(tools, tool.Name)
+				data.Tools = append(data.Tools, tool)
+			}
+		}
+	}
+
+	switch {
+	case opts.NoTools:
+		tools = nil
+	case opts.Tools != nil:
+		tools = opts.Tools
+	}
+	agent.Tools = settings.AllowedTools(tools)
+	data.Tools = allowedSkillTools(data.Tools, agent.Tools)
+	return data, nil
+}
+
+// manifest fetches an item's manifest from the source that has it.
+func (c *Client) manifest(ctx context.Context, kind ItemKind, name string) (*Manifest, error) {
+	return c.manifestAt(ctx, kind, name, "")
+}
+
+// manifestAt fetches the manifest of the newest version of an item a
+// constraint allows, or of its current version without one.
+func (c *Client) manifestAt(ctx context.Context, kind ItemKind, name, constraint string) (*Manifest, error) {
+	source, err := c.resolveSource(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if constraint == "" {
+		return source.GetManifest(ctx, kind, name)
+	}
+	content, _, err := source.resolveManifest(ctx, kind, name, constraint)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Target is a deploy destination for agents.
+type Target interface {
+	Deploy(ctx context.Context, agent *Agent) error
+}
+
+// TargetHandler creates a Target from the part of an address after its
+// scheme, e.g. "./prompts" for "file:./prompts".
+type TargetHandler func(arg string) (Target, error)
+
+var (
+	targetsMu sync.RWMutex
+	targets   = map[string]TargetHandler{
+		"file":   newFileTarget,
+		"claude": newClaudeTarget,
+		"tron":   newTronTarget,
+	}
+)
+
+// RegisterTarget makes a target scheme available to NewTarget, replacing any
+// handler already registered for it.
+func RegisterTarget(scheme string, handler TargetHandler) {
+	targetsMu.Lock()
+	defer targetsMu.Unlock()
+	targets[scheme] = handler
+}
+
+// TargetSchemes returns the registered target schemes, sorted.
+func TargetSchemes() []string {
+	targetsMu.RLock()
+	defer targetsMu.RUnlock()
+
+	schemes := make([]string, 0, len(targets))
+	for scheme := range targets {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// NewTarget creates a Target from an address such as "file:./prompts",
+// "claude:./repo", or "tron:./tron.vega.yaml#agents.Maya".
+func NewTarget(address string) (Target, error) {
+	scheme, arg, ok := strings.Cut(address, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid target %q (want <scheme>:<destination>, with scheme one of %s)", address, strings.Join(TargetSchemes(), ", "))
+	}
+
+	targetsMu.RLock()
+	handler, ok := targets[scheme]
+	targetsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown target scheme %q (use %s)", scheme, strings.Join(TargetSchemes(), ", "))
+	}
+	return handler(arg)
+}
+
+// FileTarget writes each agent's system prompt to <Dir>/<id>.md.
+type FileTarget struct {
+	Dir string
+}
+
+func newFileTarget(arg string) (Target, error) {
+	if arg == "" {
+		return nil, fmt.Errorf("file targets need a directory (file:<dir>)")
+	}
+	return FileTarget{Dir: arg}, nil
+}
+
+// Deploy writes the agent's system prompt.
+func (t FileTarget) Deploy(ctx context.Context, agent *Agent) error {
+	return writeDeployed(filepath.Join(t.Dir, agent.ID+".md"), []byte(strings.TrimRight(agent.System, "\n")+"\n"))
+}
+
+// ClaudeTarget writes each agent as a Claude subagent definition in a
+// repository, at <Dir>/.claude/agents/<id>.md.
+type ClaudeTarget struct {
+	Dir string
+}
+
+func newClaudeTarget(arg string) (Target, error) {
+	if arg == "" {
+		arg = "."
+	}
+	return ClaudeTarget{Dir: arg}, nil
+}
+
+// Deploy writes the agent's subagent definition.
+func (t ClaudeTarget) Deploy(ctx context.Context, agent *Agent) error {
+	front, err := yaml.Marshal(struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+	}{agent.ID, agent.Description})
+	if err != nil {
+		return fmt.Errorf("encoding agent: %w", err)
+	}
+
+	content := "---\n" + string(front) + "---\n\n" + strings.TrimRight(agent.System, "\n") + "\n"
+	return writeDeployed(filepath.Join(t.Dir, ".claude", "agents", agent.ID+".md"), []byte(content))
+}
+
+// TronTarget writes each agent into a tron.vega.yaml file at a dotted key
+// path, keeping the rest of the file.
+type TronTarget struct {
+	Path string
+	Key  string // Dotted key path, e.g. "agents.Maya" (default: agents.<agent name>)
+
+	// PromptsOnly updates just the system prompt of an agent already in the
+	// file, keeping its model, budget, tools, and the rest as they are, and
+	// leaves the file untouched if the prompt hasn't changed.
+	PromptsOnly bool
+}
+
+func newTronTarget(arg string) (Target, error) {
+	path, key, _ := strings.Cut(arg, "#")
+	if path == "" {
+		path = "tron.vega.yaml"
+	}
+	return TronTarget{Path: path, Key: key}, nil
+}
+
+// AgentConfig is an agent as configured in tron.vega.yaml, ready to marshal
+// as YAML or JSON. It goes under agents.<Name>.
+type AgentConfig struct {
+	Name        string      `json:"-" yaml:"-"` // Key under agents, not part of the config
+	Model       string      `json:"model" yaml:"model"`
+	Temperature float64     `json:"temperature" yaml:"temperature"`
+	Budget      string      `json:"budget" yaml:"budget"`
+	System      string      `json:"system" yaml:"system"`
+	Tools       []string    `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Skills      []string    `json:"skills,omitempty" yaml:"skills,omitempty"`
+	Supervision Supervision `json:"supervision" yaml:"supervision"`
+}
+
+// Supervision is how tron restarts a failed agent.
+type Supervision struct {
+	Strategy    string `json:"strategy" yaml:"strategy"`
+	MaxRestarts int    `json:"max_restarts" yaml:"max_restarts"`
+}
+
+// Config returns the agent as configured in tron.vega.yaml, supervised to
+// restart twice.
+func (a *Agent) Config() *AgentConfig {
+	return &AgentConfig{
+		Name:        a.Name,
+		Model:       a.Model,
+		Temperature: a.Temperature,
+		Budget:      a.Budget,
+		System:      strings.TrimRight(a.System, "\n") + "\n",
+		Tools:       a.Tools,
+		Skills:      a.Skills,
+		Supervision: Supervision{Strategy: "restart", MaxRestarts: 2},
+	}
+}
+
+// Deploy sets the agent's configuration at the target key.
+func (t TronTarget) Deploy(ctx context.Context, agent *Agent) error {
+	key := t.Key
+	if key == "" {
+		key = "agents." + agent.Name
+	}
+
+	var doc yaml.Node
+	content, err := os.ReadFile(t.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", t.Path, err)
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", t.Path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	path := strings.Split(key, ".")
+	config := agent.Config()
+	if t.PromptsOnly {
+		existing := getYAMLPath(doc.Content[0], path)
+		if existing == nil || existing.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s has no agent at %s to refresh; deploy it in full first", t.Path, key)
+		}
+		if system := getYAMLPath(existing, []string{"system"}); system != nil && system.Value == config.System {
+			return nil
+		}
+		var value yaml.Node
+		if err := value.Encode(config.System); err != nil {
+			return fmt.Errorf("encoding system prompt: %w", err)
+		}
+		if err := setYAMLPath(existing, []string{"system"}, &value); err != nil {
+			return fmt.Errorf("updating %s: %w", t.Path, err)
+		}
+	} else {
+		var value yaml.Node
+		if err := value.Encode(config); err != nil {
+			return fmt.Errorf("encoding agent: %w", err)
+		}
+		if err := setYAMLPath(doc.Content[0], path, &value); err != nil {
+			return fmt.Errorf("updating %s: %w", t.Path, err)
+		}
+	}
+
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encoding %s: %w", t.Path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encoding %s: %w", t.Path, err)
+	}
+	return writeDeployed(t.Path, []byte(out.String()))
+}
+
+// getYAMLPath returns the value at a key path in a mapping, or nil.
+func getYAMLPath(node *yaml.Node, path []string) *yaml.Node {
+	for ; len(path) > 0; path = path[1:] {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == path[0] {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// setYAMLPath sets the value at a key path in a mapping, creating
+// intermediate mappings as needed.
+func setYAMLPath(node *yaml.Node, path []string, value *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping", path[0])
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			node.Content[i+1] = value
+			return nil
+		}
+		return setYAMLPath(node.Content[i+1], path[1:], value)
+	}
+
+	child := value
+	if len(path) > 1 {
+		child = &yaml.Node{Kind: yaml.MappingNode}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}, child)
+	if len(path) > 1 {
+		return setYAMLPath(child, path[1:], value)
+	}
+	return nil
+}
+
+// writeDeployed writes a deployed file, creating its directory.
+func writeDeployed(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
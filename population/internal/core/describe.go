@@ -0,0 +1,264 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cliDescription is what `vega population __describe` prints: every command
+// with its arguments and flags, for shells, TUIs, and API layers that build
+// their own interfaces from the CLI.
+type cliDescription struct {
+	Program     string               `json:"program"`
+	GlobalFlags []flagDescription    `json:"global_flags"`
+	Commands    []commandDescription `json:"commands"`
+}
+
+// commandDescription describes a command, or a subcommand of one.
+type commandDescription struct {
+	Name        string               `json:"name"`
+	Aliases     []string             `json:"aliases,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Args        []argDescription     `json:"args"`
+	Flags       []flagDescription    `json:"flags"`
+	Output      bool                 `json:"output"` // Takes --output table|json|yaml
+	Subcommands []commandDescription `json:"subcommands,omitempty"`
+}
+
+// argDescription describes a positional argument. Its type is one of:
+//
+//	string          free text
+//	path            a file or directory
+//	item            an item name (name, @persona, +profile, %settings), optionally @version
+//	installed-item  an item name, of an installed item
+//	item-or-path    an item name or the path of a manifest
+//	target          a deploy target (file:<dir>, claude:<repo>, or tron:<file>)
+//	enum            one of values
+type argDescription struct {
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Kinds    []ItemKind `json:"kinds,omitempty"`  // Item kinds taken (default: any)
+	Values   []string   `json:"values,omitempty"` // With type enum
+	Optional bool       `json:"optional,omitempty"`
+	Repeated bool       `json:"repeated,omitempty"`
+}
+
+// flagDescription describes a flag. Its type is bool, string, int, float,
+// or duration.
+type flagDescription struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Default string   `json:"default,omitempty"`
+	Usage   string   `json:"usage"`
+	Values  []string `json:"values,omitempty"` // The values it takes, when only a few
+}
+
+// commandAliases are the other names dispatch accepts for a command.
+var commandAliases = map[string][]string{
+	"list":      {"ls"},
+	"uninstall": {"remove", "rm"},
+}
+
+// commandArgs are the positional arguments of each command.
+var commandArgs = map[string][]argDescription{
+	"search":     {{Name: "query", Type: "string", Repeated: true}},
+	"browse":     {{Name: "filter", Type: "string", Optional: true}},
+	"install":    {{Name: "name", Type: "item", Repeated: true}},
+	"uninstall":  {{Name: "name", Type: "installed-item", Repeated: true}},
+	"info":       {{Name: "name", Type: "item", Optional: true}},
+	"deps":       {{Name: "name", Type: "item", Kinds: []ItemKind{KindPersona, KindProfile}}},
+	"why":        {{Name: "name", Type: "installed-item"}},
+	"export":     {{Name: "name", Type: "item", Kinds: []ItemKind{KindPersona, KindProfile}}},
+	"deploy":     {{Name: "name", Type: "item", Kinds: []ItemKind{KindPersona, KindProfile}}, {Name: "target", Type: "target", Optional: true, Repeated: true}},
+	"budget":     {{Name: "name", Type: "item", Kinds: []ItemKind{KindPersona, KindProfile}, Repeated: true}},
+	"demo":       {{Name: "persona", Type: "item", Kinds: []ItemKind{KindPersona}}},
+	"mirror":     {{Name: "dir", Type: "path", Optional: true}},
+	"upgrade":    {{Name: "name", Type: "installed-item", Optional: true, Repeated: true}},
+	"preflight":  {{Name: "name", Type: "item", Kinds: []ItemKind{KindProfile, KindSkill}, Optional: true}},
+	"push":       {{Name: "name", Type: "installed-item", Repeated: true}},
+	"publish":    {{Name: "path", Type: "path", Repeated: true}},
+	"bump":       {{Name: "item", Type: "item-or-path", Repeated: true}},
+	"sign":       {{Name: "item", Type: "item-or-path", Repeated: true}},
+	"copy":       {{Name: "name", Type: "item", Repeated: true}},
+	"validate":   {{Name: "path", Type: "path", Optional: true, Repeated: true}},
+	"backup":     {{Name: "file", Type: "path"}},
+	"restore":    {{Name: "file", Type: "path"}},
+	"completion": {{Name: "shell", Type: "enum", Values: []string{"bash", "zsh", "fish"}}},
+}
+
+// subcommandArgs are the positional arguments of subcommands, by command
+// and subcommand.
+var subcommandArgs = map[string]map[string][]argDescription{
+	"daemon": {"status": nil, "install-service": nil},
+	"prompt": {
+		"render": {{Name: "manifest", Type: "path"}},
+		"lint":   {{Name: "manifest", Type: "path", Optional: true, Repeated: true}},
+		"diff":   {{Name: "old", Type: "path"}, {Name: "new", Type: "path"}},
+		"merge":  {{Name: "base", Type: "path"}, {Name: "ours", Type: "path"}, {Name: "theirs", Type: "path"}},
+	},
+	"config": {
+		"get":   {{Name: "key", Type: "enum"}},
+		"set":   {{Name: "key", Type: "enum"}, {Name: "value", Type: "string"}},
+		"unset": {{Name: "key", Type: "enum"}},
+		"list":  nil,
+	},
+}
+
+// describeCLI describes every command run dispatches.
+func (cl *cli) describeCLI() *cliDescription {
+	summaries := cl.commandSummaries()
+	d := &cliDescription{
+		Program: "vega population",
+		GlobalFlags: []flagDescription{
+			{Name: "output", Type: "string", Usage: "Output format of the command, which must take --output", Values: flagValues["output"]},
+			{Name: "record", Type: "string", Usage: "Record the command's source reads to a session file"},
+			{Name: "replay", Type: "string", Usage: "Replay source reads from a session file instead of the network"},
+		},
+		Commands: []commandDescription{},
+	}
+	for _, name := range commandNames {
+		if name == "help" {
+			continue
+		}
+		cmd := commandDescription{
+			Name:    name,
+			Aliases: commandAliases[name],
+			Summary: summaries[name],
+			Args:    describedArgs(commandArgs[name]),
+			Flags:   cl.describeFlags(name),
+			Output:  outputCommands[name],
+		}
+		subs := subcommandArgs[name]
+		for _, sub := range sortedKeys(subs) {
+			args := describedArgs(subs[sub])
+			if name == "config" && len(args) > 0 {
+				args[0].Values = configKeyNames()
+			}
+			cmd.Subcommands = append(cmd.Subcommands, commandDescription{
+				Name:    sub,
+				Summary: summaries[name+" "+sub],
+				Args:    args,
+				Flags:   cl.describeFlags(name, sub),
+			})
+		}
+		d.Commands = append(d.Commands, cmd)
+	}
+	return d
+}
+
+// configKeyNames returns the names of ConfigKeys, the keys config takes.
+func configKeyNames() []string {
+	names := make([]string, len(ConfigKeys))
+	for i, key := range ConfigKeys {
+		names[i] = key.Name
+	}
+	return names
+}
+
+// describedArgs returns args as described, never nil.
+func describedArgs(args []argDescription) []argDescription {
+	described := make([]argDescription, len(args))
+	copy(described, args)
+	return described
+}
+
+// commandFlagSet returns the flag set a command, or a subcommand given after
+// it, parses its arguments with, or nil if it takes no flags. The command is
+// run with -h, which stops it at parsing.
+func (cl *cli) commandFlagSet(cmd ...string) *flag.FlagSet {
+	var sets []*flag.FlagSet
+	help := &cli{stdout: io.Discard, stderr: io.Discard, ctx: context.Background(), flagSets: &sets}
+	help.dispatch(cmd[0], append(cmd[1:len(cmd):len(cmd)], "-h"))
+	for _, fs := range sets {
+		if fs.Name() == strings.Join(cmd, " ") {
+			return fs
+		}
+	}
+	return nil
+}
+
+// describeFlags describes the flags of a command, or of a subcommand given
+// after it, in name order.
+func (cl *cli) describeFlags(cmd ...string) []flagDescription {
+	flags := []flagDescription{}
+	fs := cl.commandFlagSet(cmd...)
+	if fs == nil {
+		return flags
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		typ, usage := flag.UnquoteUsage(f)
+		switch {
+		case typ == "":
+			typ = "bool"
+		case typ == "value":
+			typ = "string"
+		}
+		flags = append(flags, flagDescription{
+			Name:    f.Name,
+			Type:    typ,
+			Default: f.DefValue,
+			Usage:   usage,
+			Values:  flagValues[f.Name],
+		})
+	})
+	return flags
+}
+
+// commandSummaries returns the description of each command in the usage,
+// and of subcommands listed there as "command subcommand", with wrapped
+// lines joined. Descriptions start in the column of the first command's.
+func (cl *cli) commandSummaries() map[string]string {
+	var usage bytes.Buffer
+	(&cli{stdout: &usage, stderr: io.Discard}).printUsage()
+
+	summaries := make(map[string]string)
+	var current string
+	column := -1
+	scanner := bufio.NewScanner(&usage)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "Commands:" {
+			column = 0
+			continue
+		}
+		if column < 0 {
+			continue
+		}
+		if line == "" {
+			break
+		}
+
+		if fields := strings.Fields(line); !strings.HasPrefix(line, "   ") {
+			current = fields[0]
+			if len(fields) > 1 && isSubcommandName(current, fields[1]) {
+				current += " " + fields[1]
+			}
+			if column == 0 {
+				column = strings.Index(line, fields[1])
+			}
+		}
+		if len(line) <= column || line[column-1] != ' ' || line[column] == ' ' {
+			continue // Arguments running into the description column
+		}
+		summaries[current] = strings.TrimSpace(summaries[current] + " " + line[column:])
+	}
+	return summaries
+}
+
+// isSubcommandName reports whether word names a subcommand of cmd.
+func isSubcommandName(cmd, word string) bool {
+	_, ok := subcommandArgs[cmd][word]
+	return ok
+}
+
+func (cl *cli) runDescribe(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("__describe takes no arguments")
+	}
+	return writeOutput(cl.stdout, OutputJSON, cl.describeCLI())
+}
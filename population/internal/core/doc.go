@@ -0,0 +1,4 @@
+// Package core implements the population client, registries, server, and
+// CLI. Programs use it through the population package, the stable API, and
+// the experimental packages under population/x.
+package core
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DoctorCheck is the outcome of one of Doctor's checks.
+type DoctorCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	OK     bool   `json:"ok" yaml:"ok"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Fixed  int    `json:"fixed,omitempty" yaml:"fixed,omitempty"` // Files Doctor fixed
+}
+
+// DoctorOptions configures Doctor.
+type DoctorOptions struct {
+	// Fix encrypts the plain files found while an encryption key is set,
+	// and removes cached files encrypted with another key, which are fetched
+	// again when needed. Installed files encrypted with another key are left
+	// for reinstalling.
+	Fix bool
+}
+
+// Doctor checks the vega home: that the install and cache directories are
+// there, and that their content is all encrypted with the client's key, or
+// all plain without one. A mix comes from turning encryption on or off, or
+// changing the key, with content already cached or installed.
+func (c *Client) Doctor(opts *DoctorOptions) ([]DoctorCheck, error) {
+	if opts == nil {
+		opts = &DoctorOptions{}
+	}
+
+	var checks []DoctorCheck
+	for _, dir := range []struct{ name, path string }{
+		{"install directory", c.installDir},
+		{"cache directory", c.cacheDir},
+	} {
+		check := DoctorCheck{Name: dir.name, OK: true, Detail: dir.path}
+		if info, err := os.Stat(dir.path); err != nil || !info.IsDir() {
+			check.OK = false
+			check.Detail = dir.path + " is missing; run vega population init"
+		}
+		checks = append(checks, check)
+	}
+
+	cached, err := c.cachedFiles()
+	if err != nil {
+		return nil, err
+	}
+	installed, err := c.installedFiles()
+	if err != nil {
+		return nil, err
+	}
+	cacheCheck, err := c.checkEncryption("cache encryption", cached, opts.Fix, true)
+	if err != nil {
+		return nil, err
+	}
+	installCheck, err := c.checkEncryption("installed item encryption", installed, opts.Fix, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(checks, cacheCheck, installCheck), nil
+}
+
+// checkEncryption checks that files are all encrypted with the client's key,
+// or all plain without one, fixing them if fix is set. Files encrypted with
+// another key are removed if they are disposable.
+func (c *Client) checkEncryption(name string, files []string, fix, disposable bool) (DoctorCheck, error) {
+	var encrypted, plain, foreign []string
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return DoctorCheck{}, fmt.Errorf("reading %s: %w", path, err)
+		}
+		switch {
+		case !isEncrypted(content):
+			plain = append(plain, path)
+		case c.cipher == nil:
+			encrypted = append(encrypted, path)
+		default:
+			if _, err := c.cipher.open(content); err != nil {
+				foreign = append(foreign, path)
+			} else {
+				encrypted = append(encrypted, path)
+			}
+		}
+	}
+
+	check := DoctorCheck{Name: name, OK: true}
+	switch {
+	case len(files) == 0:
+		check.Detail = "nothing stored"
+		return check, nil
+	case c.cipher == nil && len(encrypted) > 0:
+		check.OK = false
+		check.Detail = fmt.Sprintf("%d of %d file(s) are encrypted and can't be read without the key; set %s", len(encrypted), len(files), EncryptionKeyEnv)
+		return check, nil
+	case c.cipher == nil:
+		check.Detail = fmt.Sprintf("%d file(s), not encrypted", len(files))
+		return check, nil
+	}
+
+	var problems []string
+	if len(plain) > 0 {
+		problems = append(problems, fmt.Sprintf("%d plain", len(plain)))
+	}
+	if len(foreign) > 0 {
+		problems = append(problems, fmt.Sprintf("%d encrypted with another key", len(foreign)))
+	}
+	if len(problems) == 0 {
+		check.Detail = fmt.Sprintf("%d file(s), all encrypted", len(files))
+		return check, nil
+	}
+	check.OK = false
+	check.Detail = fmt.Sprintf("%s of %d file(s)", strings.Join(problems, " and "), len(files))
+	if !fix {
+		if len(plain) > 0 || disposable {
+			check.Detail += "; run doctor --fix"
+		} else {
+			check.Detail += "; reinstall their items"
+		}
+		return check, nil
+	}
+
+	for _, path := range plain {
+		info, err := os.Stat(path)
+		if err != nil {
+			return check, fmt.Errorf("encrypting %s: %w", path, err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return check, fmt.Errorf("encrypting %s: %w", path, err)
+		}
+		if err := c.cipher.writeFile(path, content, info.Mode().Perm()); err != nil {
+			return check, fmt.Errorf("encrypting %s: %w", path, err)
+		}
+		check.Fixed++
+	}
+	kept := len(files)
+	if disposable {
+		for _, path := range foreign {
+			if err := os.Remove(path); err != nil {
+				return check, fmt.Errorf("removing %s: %w", path, err)
+			}
+			check.Fixed++
+			kept--
+		}
+		foreign = nil
+	}
+	if len(foreign) > 0 {
+		check.Detail = fmt.Sprintf("%d of %d file(s) encrypted with another key; reinstall their items", len(foreign), kept)
+		return check, nil
+	}
+	check.OK = true
+	check.Detail = fmt.Sprintf("%d file(s), all encrypted", kept)
+	return check, nil
+}
+
+// cachedFiles returns the paths of the files cached from sources, leaving
+// out git and archive checkouts, partial downloads, and bookkeeping.
+func (c *Client) cachedFiles() ([]string, error) {
+	var paths []string
+	for _, dir := range []string{c.cacheDir, filepath.Join(c.cacheDir, "files")} {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading cache directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") || name == DaemonStatusFile ||
+				strings.HasSuffix(name, validatorsSuffix) || strings.HasSuffix(name, ".part") {
+				continue
+			}
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths, nil
+}
+
+// installedFiles returns the paths of the installed items' manifests and
+// extra files, leaving out their install records.
+func (c *Client) installedFiles() ([]string, error) {
+	var paths []string
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		dir := filepath.Join(c.installDir, c.layout.Dir(kind))
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() && d.Name() != InstallRecordFile && filepath.Dir(path) != dir {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s directory: %w", kind.Plural(), err)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ItemDrift is how an installed item's files differ from what its install
+// record says was installed: the local edits an upgrade or a forced install
+// would discard.
+type ItemDrift struct {
+	Kind    ItemKind `json:"kind" yaml:"kind"`
+	Name    string   `json:"name" yaml:"name"`
+	Version string   `json:"version" yaml:"version"`
+
+	Unrecorded bool     `json:"unrecorded,omitempty" yaml:"unrecorded,omitempty"` // No install record, so drift can't be told
+	Modified   []string `json:"modified,omitempty" yaml:"modified,omitempty"`     // Changed since installed
+	Missing    []string `json:"missing,omitempty" yaml:"missing,omitempty"`       // Installed, and since removed
+	Untracked  []string `json:"untracked,omitempty" yaml:"untracked,omitempty"`   // Added since installed
+}
+
+// Clean reports whether the item is as it was installed.
+func (d *ItemDrift) Clean() bool {
+	return !d.Unrecorded && len(d.Modified)+len(d.Missing)+len(d.Untracked) == 0
+}
+
+// changed returns every drifted file, sorted.
+func (d *ItemDrift) changed() []string {
+	changed := append(append(append([]string(nil), d.Modified...), d.Missing...), d.Untracked...)
+	sort.Strings(changed)
+	return changed
+}
+
+// Drift hashes the files of every installed item against its install record
+// and returns the items that drifted from it, including ones without a
+// record, in kind and name order.
+func (c *Client) Drift() ([]ItemDrift, error) {
+	items, err := c.List("")
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool { return kindOrder(items[i].Kind) < kindOrder(items[j].Kind) })
+
+	drifted := []ItemDrift{}
+	for _, item := range items {
+		drift, err := itemDrift(item.Path, c.cipher)
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", FormatItemName(item.Kind, item.Name), err)
+		}
+		if drift.Clean() {
+			continue
+		}
+		drift.Kind, drift.Name, drift.Version = item.Kind, item.Name, item.Version
+		drifted = append(drifted, *drift)
+	}
+	return drifted, nil
+}
+
+// itemDrift compares an installed item directory with its install record.
+// Records without file hashes, written before they were kept, take the files
+// the manifest lists as installed, unchanged.
+func itemDrift(dir string, cipher *contentCipher) (*ItemDrift, error) {
+	record, err := LoadInstallRecord(dir)
+	if err != nil {
+		return &ItemDrift{Unrecorded: true}, nil
+	}
+
+	drift := &ItemDrift{}
+	if content, err := cipher.readFile(filepath.Join(dir, "vega.yaml")); err != nil {
+		drift.Missing = append(drift.Missing, "vega.yaml")
+	} else if !strings.EqualFold(contentHash(content), record.SHA256) {
+		drift.Modified = append(drift.Modified, "vega.yaml")
+	}
+
+	tracked := map[string]bool{"vega.yaml": true, InstallRecordFile: true}
+	for _, rel := range sortedKeys(record.Files) {
+		tracked[rel] = true
+		content, err := cipher.readFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if errors.Is(err, fs.ErrNotExist) {
+			drift.Missing = append(drift.Missing, rel)
+		} else if err != nil || !strings.EqualFold(contentHash(content), record.Files[rel]) {
+			drift.Modified = append(drift.Modified, rel)
+		}
+	}
+	if record.Files == nil {
+		if manifest, err := loadManifest(filepath.Join(dir, "vega.yaml"), cipher); err == nil {
+			for _, rel := range manifest.FilePaths() {
+				tracked[rel] = true
+			}
+		}
+	}
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); !tracked[rel] {
+			drift.Untracked = append(drift.Untracked, rel)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// installState returns the install record of an installed item directory and
+// the files changed since it was installed, or nothing for an item installed
+// by hand.
+func installState(dir string, cipher *contentCipher) (*InstallRecord, []string) {
+	record, err := LoadInstallRecord(dir)
+	if err != nil {
+		return nil, nil
+	}
+	drift, err := itemDrift(dir, cipher)
+	if err != nil {
+		return record, nil
+	}
+	return record, drift.changed()
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// EncryptionKeyEnv is the environment variable holding the key the CLI
+	// encrypts the cache and installed items with: 32 bytes, base64 or hex
+	// encoded, or "keychain" to read it from the OS keychain entry
+	// EncryptionKeychainService.
+	EncryptionKeyEnv = "VEGA_POPULATION_ENCRYPTION_KEY"
+
+	// EncryptionKeychainService is the OS keychain entry holding the key.
+	EncryptionKeychainService = "vega-population-encryption"
+)
+
+// ErrEncrypted is wrapped by errors reading encrypted content without the
+// key it was encrypted with.
+var ErrEncrypted = errors.New("content is encrypted")
+
+// encryptedMagic starts every encrypted file, ahead of the nonce and the
+// sealed content.
+var encryptedMagic = []byte("vega-population-aes256gcm\x00")
+
+// contentCipher encrypts and decrypts cached and installed content with
+// AES-GCM. A nil contentCipher leaves content as it is.
+type contentCipher struct {
+	aead cipher.AEAD
+}
+
+// WithEncryptionKey encrypts the cache and installed items at rest with
+// AES-256-GCM under key, which must be 32 bytes. Content is decrypted
+// transparently on read, and content written before encryption was turned
+// on is still read as it is; Client.Doctor finds and fixes such a mix.
+func WithEncryptionKey(key []byte) Option {
+	return func(c *Client) {
+		c.encryptionKey = key
+	}
+}
+
+// newContentCipher returns the cipher for a key, or nil for no key.
+func newContentCipher(key []byte) (*contentCipher, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, not %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return &contentCipher{aead: aead}, nil
+}
+
+// seal encrypts content, unless the cipher is nil.
+func (c *contentCipher) seal(content []byte) ([]byte, error) {
+	if c == nil {
+		return content, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	sealed := append(append([]byte{}, encryptedMagic...), nonce...)
+	return c.aead.Seal(sealed, nonce, content, nil), nil
+}
+
+// open decrypts encrypted content and returns plain content as it is.
+// Encrypted content fails with an error wrapping ErrEncrypted if the cipher
+// is nil or has another key.
+func (c *contentCipher) open(content []byte) ([]byte, error) {
+	if !isEncrypted(content) {
+		return content, nil
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w; set %s to read it", ErrEncrypted, EncryptionKeyEnv)
+	}
+	rest := content[len(encryptedMagic):]
+	if len(rest) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("decrypting: content is truncated")
+	}
+	nonce, sealed := rest[:c.aead.NonceSize()], rest[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w with another key, or corrupted", ErrEncrypted)
+	}
+	return plain, nil
+}
+
+// readFile reads a file and decrypts it if it is encrypted.
+func (c *contentCipher) readFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := c.open(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// writeFile encrypts content, unless the cipher is nil, and writes it to a
+// file.
+func (c *contentCipher) writeFile(path string, content []byte, perm os.FileMode) error {
+	sealed, err := c.seal(content)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, perm)
+}
+
+// isEncrypted reports whether content was encrypted by a contentCipher.
+func isEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, encryptedMagic)
+}
+
+// ParseEncryptionKey decodes a key given as base64 or hex, as in
+// EncryptionKeyEnv.
+func ParseEncryptionKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(s); err == nil {
+			if len(key) != 32 {
+				return nil, fmt.Errorf("encryption key must be 32 bytes, not %d", len(key))
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("encryption key must be 32 bytes encoded as base64 or hex")
+}
+
+// encryptionKeyFromEnv returns the key EncryptionKeyEnv gives, or nil if it
+// is unset.
+func encryptionKeyFromEnv() ([]byte, error) {
+	value := os.Getenv(EncryptionKeyEnv)
+	if value == "" {
+		return nil, nil
+	}
+	if value == "keychain" {
+		var err error
+		if value, err = keychainToken(EncryptionKeychainService); err != nil {
+			return nil, fmt.Errorf("%s: %w", EncryptionKeyEnv, err)
+		}
+	}
+	key, err := ParseEncryptionKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EncryptionKeyEnv, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Example is a sample conversation shipped with a persona.
+type Example struct {
+	Title    string           `yaml:"title,omitempty"`
+	Messages []ExampleMessage `yaml:"messages"`
+}
+
+// ExampleMessage is one turn of an example conversation.
+type ExampleMessage struct {
+	Role    string `yaml:"role"` // "user" or "assistant"
+	Content string `yaml:"content"`
+}
+
+// Examples returns the example conversations shipped with a persona.
+func (c *Client) Examples(ctx context.Context, name string) ([]Example, error) {
+	kind, itemName := ParseItemName(name)
+	if kind != KindPersona {
+		return nil, fmt.Errorf("only personas have examples (use @name format)")
+	}
+
+	source, err := c.resolveSource(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := source.GetManifest(ctx, kind, itemName)
+	if err != nil {
+		return nil, fmt.Errorf("fetching persona: %w", err)
+	}
+	return manifest.Examples, nil
+}
+
+// LintExamples reports problems with a persona's example conversations.
+func LintExamples(examples []Example) []string {
+	var problems []string
+	for i, ex := range examples {
+		label := fmt.Sprintf("example %d", i+1)
+		if ex.Title != "" {
+			label = fmt.Sprintf("example %q", ex.Title)
+		}
+
+		if len(ex.Messages) == 0 {
+			problems = append(problems, label+" has no messages")
+			continue
+		}
+		for j, m := range ex.Messages {
+			if m.Role != "user" && m.Role != "assistant" {
+				problems = append(problems, fmt.Sprintf("%s message %d has role %q (want user or assistant)", label, j+1, m.Role))
+			}
+			if strings.TrimSpace(m.Content) == "" {
+				problems = append(problems, fmt.Sprintf("%s message %d is empty", label, j+1))
+			}
+		}
+		if ex.Messages[0].Role != "user" {
+			problems = append(problems, label+" should start with a user message")
+		}
+	}
+	return problems
+}
+
+// FormatExamples renders examples as few-shot transcripts for runtimes that
+// take them as part of the system prompt.
+func FormatExamples(examples []Example) string {
+	if len(examples) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Example Conversations\n")
+	for i, ex := range examples {
+		title := ex.Title
+		if title == "" {
+			title = fmt.Sprintf("Example %d", i+1)
+		}
+		fmt.Fprintf(&b, "\n### %s\n", title)
+		for _, m := range ex.Messages {
+			fmt.Fprintf(&b, "\n%s: %s\n", titleCase(m.Role), strings.TrimSpace(m.Content))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,242 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultExportFormat is the format export uses unless told otherwise.
+const DefaultExportFormat = "tron"
+
+// ExportData is what an export template is executed with.
+type ExportData struct {
+	Agent   *Agent
+	Persona *Manifest   // The persona's manifest
+	Profile *Manifest   // The profile's manifest, when exporting a profile
+	Skills  []*Manifest // The agent's skills: a profile's that apply, or a persona's recommended ones
+	Tools   []Tool      // The skills' tools the agent may use
+}
+
+// ExportOptions configures how a persona or profile is exported.
+type ExportOptions struct {
+	AgentOptions
+
+	Format   string // Built-in format (default DefaultExportFormat); see ExportFormats
+	Template string // Path of a text/template to use instead of a built-in format
+}
+
+// exportFormats are the built-in export templates by format name.
+var exportFormats = map[string]string{
+	"tron": `  {{.Agent.Name}}:
+    model: {{.Agent.Model}}
+    temperature: {{.Agent.Temperature}}
+    budget: "{{.Agent.Budget}}"
+    system: |
+{{indent 6 .Agent.System}}
+{{- with .Agent.Tools}}
+    tools:
+{{- range .}}
+      - {{.}}
+{{- end}}
+{{- end}}
+{{- with .Agent.Skills}}
+    skills:
+{{- range .}}
+      - {{.}}
+{{- end}}
+{{- end}}
+    supervision:
+      strategy: restart
+      max_restarts: 2
+`,
+
+	"claude-code": `---
+name: {{.Agent.ID}}
+description: {{json .Agent.Description}}
+---
+
+{{trim .Agent.System}}
+`,
+
+	"openai-assistants": `{
+  "name": {{json .Agent.Name}},
+  "description": {{json .Agent.Description}},
+  "model": {{json .Agent.Model}},
+  "temperature": {{.Agent.Temperature}},
+  "instructions": {{json (trim .Agent.System)}},
+  "tools": [
+{{- range $i, $tool := .Tools}}{{if $i}},{{end}}
+    {
+      "type": "function",
+      "function": {
+        "name": {{json $tool.Name}},
+        "description": {{json $tool.Description}},
+        "parameters": {{json (schema $tool)}}
+      }
+    }
+{{- end}}
+  ]
+}
+`,
+
+	"crewai": `{{.Agent.ID}}:
+  role: {{json .Agent.Name}}
+  goal: {{json .Agent.Description}}
+  backstory: |
+{{indent 4 .Agent.System}}
+  llm: {{.Agent.Model}}
+{{- with .Tools}}
+  # Tools to bind in code:{{range .}} {{.Name}}{{end}}
+{{- end}}
+`,
+}
+
+// ExportFormats returns the names of the built-in export formats, sorted.
+func ExportFormats() []string {
+	formats := make([]string, 0, len(exportFormats))
+	for format := range exportFormats {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Export renders a persona or profile in a built-in format or through a
+// custom template.
+func (c *Client) Export(ctx context.Context, name string, opts *ExportOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	tmpl, err := exportTemplate(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.exportData(ctx, name, &opts.AgentOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", tmpl.Name(), err)
+	}
+	return out.Bytes(), nil
+}
+
+// ExportAgentConfig renders a persona manifest as a tron agent configuration
+// with the tools of the given skill manifests, without a client: no sources
+// are read and no installed settings are applied. opts.Format and
+// opts.Template are ignored.
+func ExportAgentConfig(manifest *Manifest, skills []*Manifest, opts ExportOptions) (*AgentConfig, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("no persona manifest to export")
+	}
+	data, err := renderExport(context.Background(), manifest.Name, manifest, nil, skills, &Settings{}, &opts.AgentOptions)
+	if err != nil {
+		return nil, err
+	}
+	return data.Agent.Config(), nil
+}
+
+// exportTemplate parses the template an export uses.
+func exportTemplate(opts *ExportOptions) (*template.Template, error) {
+	if opts.Template != "" {
+		content, err := os.ReadFile(opts.Template)
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		tmpl, err := template.New(filepath.Base(opts.Template)).Funcs(exportFuncs).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsing template: %w", err)
+		}
+		return tmpl, nil
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = DefaultExportFormat
+	}
+	text, ok := exportFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q (use %s)", format, strings.Join(ExportFormats(), ", "))
+	}
+	return template.Must(template.New(format).Funcs(exportFuncs).Parse(text)), nil
+}
+
+// exportFuncs are the functions export templates may call, besides the
+// text/template built-ins.
+var exportFuncs = template.FuncMap{
+	"indent": indentLines,
+	"json":   toJSON,
+	"trim":   func(s string) string { return strings.TrimSpace(s) },
+	"join":   strings.Join,
+	"schema": toolSchema,
+}
+
+// indentLines indents each line of s by n spaces, without a final newline.
+func indentLines(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = pad + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toJSON encodes v as compact JSON, which is also a valid YAML scalar.
+func toJSON(v interface{}) (string, error) {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// toolSchema describes a tool's parameters as a JSON Schema object.
+func toolSchema(tool Tool) map[string]interface{} {
+	properties := make(map[string]interface{}, len(tool.Params))
+	required := []string{}
+	for name, param := range tool.Params {
+		property := map[string]interface{}{"type": param.Type}
+		if param.Type == "" {
+			property["type"] = "string"
+		}
+		if param.Description != "" {
+			property["description"] = param.Description
+		}
+		if param.Default != nil {
+			property["default"] = param.Default
+		}
+		properties[name] = property
+		if param.Required {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// allowedSkillTools returns the tools whose names are allowed.
+func allowedSkillTools(tools []Tool, allowed []string) []Tool {
+	var kept []Tool
+	for _, tool := range tools {
+		if containsFold(allowed, tool.Name) {
+			kept = append(kept, tool)
+		}
+	}
+	return kept
+}
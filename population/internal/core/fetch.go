@@ -0,0 +1,415 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetries is how many times a remote fetch is retried after
+	// failing without receiving any data.
+	DefaultRetries = 3
+
+	// DefaultBackoff is how long a remote fetch waits before its first retry;
+	// each further retry waits twice as long.
+	DefaultBackoff = 500 * time.Millisecond
+
+	// DefaultTimeout bounds each remote request, including reading the response.
+	DefaultTimeout = time.Minute
+
+	// maxRetryAfter caps how long a server's Retry-After can make a fetch wait.
+	maxRetryAfter = time.Minute
+)
+
+// httpPolicy says how a source makes remote requests.
+type httpPolicy struct {
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	timeout time.Duration // Per request; 0 for none
+}
+
+// defaultHTTPPolicy returns the policy sources use unless configured otherwise.
+func defaultHTTPPolicy() httpPolicy {
+	return httpPolicy{
+		client:  http.DefaultClient,
+		retries: DefaultRetries,
+		backoff: DefaultBackoff,
+		timeout: DefaultTimeout,
+	}
+}
+
+// do sends a request, bounded by the policy's timeout. The returned function
+// must be called once the response body has been read.
+func (p httpPolicy) do(req *http.Request) (*http.Response, context.CancelFunc, error) {
+	cancel := context.CancelFunc(func() {})
+	if p.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), p.timeout)
+		req = req.WithContext(ctx)
+	}
+	client := p.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return resp, cancel, nil
+}
+
+// wait sleeps before retrying after the given number of earlier failures:
+// as long as the server asked with Retry-After, or else the backoff doubled
+// for each earlier failure.
+func (p httpPolicy) wait(ctx context.Context, failures int, err error) error {
+	delay := p.backoff << failures
+	var busy *retryAfterError
+	if errors.As(err, &busy) {
+		delay = busy.wait
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfterError is a response asking the client to wait before retrying.
+type retryAfterError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// statusError describes a failed response, and whether it is worth retrying.
+// Responses with a Retry-After header return a *retryAfterError.
+func statusError(url string, resp *http.Response) (bool, error) {
+	if resp.StatusCode == http.StatusNotAcceptable && isManifestPath(url) {
+		return false, fmt.Errorf("fetching %s: %w: the registry serves none of the schemas this client reads (up to %d); upgrade vega", url, ErrManifestSchema, ManifestSchemaVersion)
+	}
+	err := fmt.Errorf("fetching %s: status %d", url, resp.StatusCode)
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	if wait, ok := retryAfter(resp); ok && retry {
+		return true, &retryAfterError{err: err, wait: wait}
+	}
+	return retry, err
+}
+
+// retryAfter parses a response's Retry-After header, in seconds or as a date,
+// capped at maxRetryAfter.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	var wait time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if when, err := http.ParseTime(value); err == nil {
+		wait = time.Until(when)
+	} else {
+		return 0, false
+	}
+	return min(max(wait, 0), maxRetryAfter), true
+}
+
+// partial is a download in progress that can be resumed.
+type partial interface {
+	io.Writer
+	Size() (int64, error)
+	Restart() error // Discard what was written, when the server can't resume
+}
+
+// memoryPartial is a download kept in memory.
+type memoryPartial struct {
+	bytes.Buffer
+}
+
+func (p *memoryPartial) Size() (int64, error) { return int64(p.Len()), nil }
+func (p *memoryPartial) Restart() error       { p.Reset(); return nil }
+
+// filePartial is a download kept in a file, so it can be resumed by a later run.
+type filePartial struct {
+	*os.File
+}
+
+func (p filePartial) Size() (int64, error) {
+	return p.Seek(0, io.SeekEnd)
+}
+
+func (p filePartial) Restart() error {
+	if err := p.Truncate(0); err != nil {
+		return err
+	}
+	_, err := p.Seek(0, io.SeekStart)
+	return err
+}
+
+// fetchRemote retrieves content from a remote source.
+func (s *Source) fetchRemote(ctx context.Context, path string) ([]byte, error) {
+	var p memoryPartial
+	if err := s.download(ctx, path, &p); err != nil {
+		return nil, err
+	}
+	return p.Bytes(), nil
+}
+
+// download fetches a remote path into p, continuing from what p already
+// holds. Connection failures, server errors, and bodies cut short are
+// retried, resuming with a Range request where the server supports it.
+func (s *Source) download(ctx context.Context, path string, p partial) error {
+	url := s.baseURL + path
+	if s.offline {
+		return fmt.Errorf("fetching %s: %w", url, ErrOffline)
+	}
+
+	for failures := 0; ; {
+		offset, err := p.Size()
+		if err != nil {
+			return fmt.Errorf("resuming %s: %w", url, err)
+		}
+
+		received, retry, err := s.downloadOnce(ctx, url, offset, p)
+		if err == nil {
+			return nil
+		}
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+
+		// Only attempts that made no progress count against the retries
+		if received == 0 {
+			if failures >= s.http.retries {
+				return err
+			}
+			s.log().Debug("retrying", "url", url, "attempt", failures+1, "error", err)
+			if err := s.http.wait(ctx, failures, err); err != nil {
+				return err
+			}
+			failures++
+		}
+	}
+}
+
+// downloadOnce makes one request for url, from offset if it is non-zero. It
+// returns how many bytes were received and whether a failure is worth retrying.
+func (s *Source) downloadOnce(ctx context.Context, url string, offset int64, p partial) (int64, bool, error) {
+	req, err := s.newRequest(ctx, url)
+	if err != nil {
+		return 0, false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	start := time.Now()
+	resp, done, err := s.http.do(req)
+	if err != nil {
+		s.log().Debug("fetch failed", "url", url, "duration", time.Since(start), "error", err)
+		return 0, true, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer done()
+	defer resp.Body.Close()
+	s.log().Debug("fetched", "url", url, "status", resp.StatusCode, "offset", offset, "duration", time.Since(start))
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && rangeStart(resp) == offset:
+		// Resuming
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent ||
+		resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Start over when the server sent the whole body or can't serve the range
+		if offset > 0 {
+			if err := p.Restart(); err != nil {
+				return 0, false, fmt.Errorf("restarting %s: %w", url, err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return 0, true, fmt.Errorf("fetching %s: status %d", url, resp.StatusCode)
+			}
+		}
+	default:
+		retry, err := statusError(url, resp)
+		return 0, retry, err
+	}
+	if isManifestPath(url) {
+		if err := checkManifestResponse(url, resp); err != nil {
+			return 0, false, err
+		}
+	}
+
+	n, err := io.Copy(p, resp.Body)
+	if err != nil {
+		return n, true, fmt.Errorf("reading %s: %w", url, err)
+	}
+	return n, false, nil
+}
+
+// newRequest creates an authenticated GET request for url, asking for the
+// manifest schemas this client reads if url is a manifest.
+func (s *Source) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if isManifestPath(url) {
+		req.Header.Set("Accept", manifestAccept)
+	}
+	if s.auth != nil {
+		if err := s.auth.apply(req); err != nil {
+			return nil, fmt.Errorf("authenticating to %s: %w", s.baseURL, err)
+		}
+	}
+	return req, nil
+}
+
+// fetchConditional fetches a remote path unless it still matches the
+// validators of a cached copy, in which case it reports notModified. It
+// returns the validators the content was served with. Failures are retried
+// as in download.
+func (s *Source) fetchConditional(ctx context.Context, path string, cached Validators) (content []byte, v Validators, notModified bool, err error) {
+	url := s.baseURL + path
+	if s.offline {
+		return nil, Validators{}, false, fmt.Errorf("fetching %s: %w", url, ErrOffline)
+	}
+
+	for failures := 0; ; failures++ {
+		content, v, notModified, retry, err := s.fetchConditionalOnce(ctx, url, cached)
+		if err == nil {
+			return content, v, notModified, nil
+		}
+		if !retry || ctx.Err() != nil || failures >= s.http.retries {
+			return nil, Validators{}, false, err
+		}
+		s.log().Debug("retrying", "url", url, "attempt", failures+1, "error", err)
+		if err := s.http.wait(ctx, failures, err); err != nil {
+			return nil, Validators{}, false, err
+		}
+	}
+}
+
+// fetchConditionalOnce makes one conditional request for url. It returns
+// whether a failure is worth retrying.
+func (s *Source) fetchConditionalOnce(ctx context.Context, url string, cached Validators) ([]byte, Validators, bool, bool, error) {
+	req, err := s.newRequest(ctx, url)
+	if err != nil {
+		return nil, Validators{}, false, false, err
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+
+	start := time.Now()
+	resp, done, err := s.http.do(req)
+	if err != nil {
+		s.log().Debug("fetch failed", "url", url, "duration", time.Since(start), "error", err)
+		return nil, Validators{}, false, true, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer done()
+	defer resp.Body.Close()
+	s.log().Debug("fetched", "url", url, "status", resp.StatusCode, "conditional", !cached.IsZero(), "duration", time.Since(start))
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && !cached.IsZero():
+		return nil, cached, true, false, nil
+	case resp.StatusCode != http.StatusOK:
+		retry, err := statusError(url, resp)
+		return nil, Validators{}, false, retry, err
+	}
+	if isManifestPath(url) {
+		if err := checkManifestResponse(url, resp); err != nil {
+			return nil, Validators{}, false, false, err
+		}
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Validators{}, false, true, fmt.Errorf("reading %s: %w", url, err)
+	}
+	v := Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	return content, v, false, false, nil
+}
+
+// rangeStart returns the first byte of a partial response's Content-Range.
+func rangeStart(resp *http.Response) int64 {
+	spec, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, _ := strings.Cut(spec, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// fetchPinned fetches a remote file whose sha256 is known. Completed files
+// are kept in the cache by hash and partial downloads are resumed, so an
+// interrupted install doesn't fetch large files again from the start. With
+// encryption on, files are fetched whole instead, as partial downloads
+// would be kept in the clear.
+func (s *Source) fetchPinned(ctx context.Context, path, sum string) ([]byte, error) {
+	if s.isLocal || s.git != nil || s.archive != nil || s.registry != nil || s.cache.disabled || s.session != nil || s.cipher != nil {
+		return s.fetch(ctx, path)
+	}
+
+	dir := filepath.Join(s.cache.dir, "files")
+	done := filepath.Join(dir, strings.ToLower(sum))
+	if content, err := os.ReadFile(done); err == nil && strings.EqualFold(contentHash(content), sum) {
+		return content, nil
+	}
+	if s.daemon != nil {
+		if content, ok := s.daemon.get(ctx, s.baseURL, path); ok {
+			return content, nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	f, err := os.OpenFile(done+".part", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening partial download: %w", err)
+	}
+	defer f.Close()
+
+	if err := s.download(ctx, path, filePartial{f}); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading partial download: %w", err)
+	}
+	f.Close()
+
+	// A corrupt download must not be resumed; the caller reports the mismatch
+	if !strings.EqualFold(contentHash(content), sum) {
+		os.Remove(done + ".part")
+		return content, nil
+	}
+	if err := os.Rename(done+".part", done); err != nil {
+		return nil, fmt.Errorf("caching %s: %w", path, err)
+	}
+	return content, nil
+}
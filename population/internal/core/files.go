@@ -0,0 +1,161 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ItemFile is an extra file shipped with an item, such as a prompt template,
+// script, or reference doc, stored next to its vega.yaml. It can be written as
+// a bare path or as a mapping pinning the file's hash:
+//
+//	files:
+//	  - templates/incident.md
+//	  - path: scripts/rollout.sh
+//	    sha256: 9f86d081884c7d65...
+type ItemFile struct {
+	Path   string `yaml:"path"`             // Slash-separated, relative to the item directory
+	SHA256 string `yaml:"sha256,omitempty"` // Expected hash of the content (optional)
+}
+
+// UnmarshalYAML accepts either a scalar path or a mapping.
+func (f *ItemFile) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Path = node.Value
+		return nil
+	}
+
+	type plain ItemFile
+	return node.Decode((*plain)(f))
+}
+
+// MarshalYAML writes files without a hash back as bare paths.
+func (f ItemFile) MarshalYAML() (interface{}, error) {
+	if f.SHA256 == "" {
+		return f.Path, nil
+	}
+
+	type plain ItemFile
+	return plain(f), nil
+}
+
+// checkItemFilePath rejects file paths that would escape the item directory
+// or replace its manifest, its install record, or the older versions a
+// registry archives under versions/.
+func checkItemFilePath(p string) error {
+	clean := path.Clean(p)
+	switch {
+	case p == "" || clean == "." || strings.Contains(p, `\`) || path.IsAbs(p) || filepath.IsAbs(p):
+		return fmt.Errorf("invalid file path %q", p)
+	case clean == ".." || strings.HasPrefix(clean, "../"):
+		return fmt.Errorf("file path %q leaves the item directory", p)
+	case clean == "vega.yaml" || clean == InstallRecordFile || clean == "versions" || strings.HasPrefix(clean, "versions/"):
+		return fmt.Errorf("file path %q is reserved", p)
+	}
+	return nil
+}
+
+// sha256Pattern matches a hex sha256 hash.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// fetchFiles fetches the extra files listed by an item's manifest, keyed by
+// their cleaned relative paths, checking any pinned hashes.
+func (s *Source) fetchFiles(ctx context.Context, kind ItemKind, name string, files []ItemFile) (map[string][]byte, error) {
+	fetched := make(map[string][]byte, len(files))
+	for _, f := range files {
+		if err := checkItemFilePath(f.Path); err != nil {
+			return nil, fmt.Errorf("%s %q: %w", kind, name, err)
+		}
+		rel := path.Clean(f.Path)
+
+		source := fmt.Sprintf("%s/%s/%s", kind.Plural(), name, rel)
+		var content []byte
+		var err error
+		if f.SHA256 != "" {
+			if !sha256Pattern.MatchString(f.SHA256) {
+				return nil, fmt.Errorf("%s %q file %s has an invalid sha256 %q", kind, name, rel, f.SHA256)
+			}
+			content, err = s.fetchPinned(ctx, source, f.SHA256)
+		} else {
+			content, err = s.fetch(ctx, source)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s %q file %s: %w", kind, name, rel, err)
+		}
+		if f.SHA256 != "" && !strings.EqualFold(f.SHA256, contentHash(content)) {
+			return nil, fmt.Errorf("%s %q file %s does not match its sha256 (expected %s, got %s)", kind, name, rel, f.SHA256, contentHash(content))
+		}
+		fetched[rel] = content
+	}
+	return fetched, nil
+}
+
+// writeItemFiles writes an item's extra files under its install directory,
+// encrypted with cipher unless it is nil.
+func writeItemFiles(dir string, files map[string][]byte, cipher *contentCipher) error {
+	for rel, content := range files {
+		dest := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+		if err := cipher.writeFile(dest, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// Uninstall removes an installed item and all of its files.
+func (c *Client) Uninstall(name string) error {
+	if err := c.uninstall(name); err != nil {
+		return err
+	}
+	kind, itemName := ParseItemName(name)
+	c.recordChange(context.Background(), "Uninstall "+FormatItemName(kind, itemName))
+	return nil
+}
+
+// uninstall removes an installed item, leaving recording the change to the
+// caller.
+func (c *Client) uninstall(name string) error {
+	if err := ValidateItemName(name); err != nil {
+		return err
+	}
+	kind, itemName := ParseItemName(name)
+
+	dir := c.itemDir(kind, itemName)
+	if _, err := os.Stat(filepath.Join(dir, "vega.yaml")); os.IsNotExist(err) {
+		return fmt.Errorf("%s %q is not installed", kind, itemName)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing %s %q: %w", kind, itemName, err)
+	}
+	return nil
+}
+
+// fetchManifestFiles fetches the extra files listed in manifest content.
+func (s *Source) fetchManifestFiles(ctx context.Context, kind ItemKind, name string, content []byte) (map[string][]byte, error) {
+	var manifest struct {
+		Files []ItemFile `yaml:"files"`
+	}
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing %s %q: %w", kind, name, err)
+	}
+	return s.fetchFiles(ctx, kind, name, manifest.Files)
+}
+
+// FilePaths returns the relative paths of the manifest's extra files.
+func (m *Manifest) FilePaths() []string {
+	var paths []string
+	for _, f := range m.Files {
+		paths = append(paths, path.Clean(f.Path))
+	}
+	return paths
+}
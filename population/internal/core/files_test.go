@@ -0,0 +1,35 @@
+package core
+
+import "testing"
+
+func TestCheckItemFilePath(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		ok   bool
+	}{
+		{"prompts/system.md", true},
+		{"./scripts/run.sh", true},
+		{"versionsfile.txt", true},
+		{"docs/versions/1.0.0.md", true},
+		{"", false},
+		{".", false},
+		{"./", false},
+		{"a/..", false},
+		{"..", false},
+		{"../x", false},
+		{"a/../../x", false},
+		{"/etc/passwd", false},
+		{`a\b`, false},
+		{"vega.yaml", false},
+		{"./vega.yaml", false},
+		{InstallRecordFile, false},
+		{"versions", false},
+		{"versions/1.0.0/vega.yaml", false},
+		{"./versions/1.0.0/prompt.md", false},
+		{"a/../versions/1.0.0/vega.yaml", false},
+	} {
+		if err := checkItemFilePath(tc.path); (err == nil) != tc.ok {
+			t.Errorf("checkItemFilePath(%q) = %v, want ok %v", tc.path, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitSyncKey is the cache entry recording when a git source was last pulled.
+const gitSyncKey = "git-synced"
+
+// gitRepo is a git source, read from a sparse checkout in the cache directory.
+type gitRepo struct {
+	url string
+	ref string // Branch or tag (default: the remote's HEAD)
+	dir string
+
+	mu     sync.Mutex
+	synced bool // Pulled during this process
+}
+
+// isGitSource reports whether a source URL names a git repository rather
+// than a directory or raw HTTP tree:
+//
+//	git@github.com:org/private-population.git
+//	ssh://git@github.com/org/private-population.git
+//	https://github.com/org/private-population.git#release
+func isGitSource(url string) bool {
+	url, _, _ = strings.Cut(url, "#")
+	url = strings.TrimSuffix(url, "/")
+	return strings.HasPrefix(url, "git@") ||
+		strings.HasPrefix(url, "ssh://") ||
+		strings.HasPrefix(url, "git://") ||
+		strings.HasSuffix(url, ".git")
+}
+
+// newGitRepo returns the repository for a git source URL, checked out under cacheDir.
+func newGitRepo(url, cacheDir string) *gitRepo {
+	url, ref, _ := strings.Cut(strings.TrimSuffix(url, "/"), "#")
+	sum := sha256.Sum256([]byte(url + "#" + ref))
+	return &gitRepo{
+		url: url,
+		ref: ref,
+		dir: filepath.Join(cacheDir, "git", hex.EncodeToString(sum[:8])),
+	}
+}
+
+// fetchGit reads a file from the source's checkout, cloning or pulling it first
+// when the checkout is missing or older than the cache TTL.
+func (s *Source) fetchGit(ctx context.Context, path string) ([]byte, error) {
+	if err := s.syncGit(ctx); err != nil {
+		return nil, err
+	}
+
+	fullPath := filepath.Join(s.git.dir, filepath.FromSlash(path))
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s from %s: %w", path, s.git.url, err)
+	}
+	return content, nil
+}
+
+// syncGit brings the checkout up to date, at most once per process and cache TTL.
+func (s *Source) syncGit(ctx context.Context) error {
+	repo := s.git
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.synced {
+		return nil
+	}
+	_, err := os.Stat(filepath.Join(repo.dir, ".git"))
+	cloned := err == nil
+	if cloned {
+		if _, fresh := s.cache.Get(s.cacheKey(gitSyncKey)); fresh || s.offline {
+			repo.synced = true
+			return nil
+		}
+	}
+	if s.offline {
+		return fmt.Errorf("cloning %s: %w", repo.url, ErrOffline)
+	}
+
+	if cloned {
+		err = repo.pull(ctx)
+	} else {
+		err = repo.clone(ctx, s.tempDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	repo.synced = true
+	if err := s.cache.Set(s.cacheKey(gitSyncKey), []byte(time.Now().UTC().Format(time.RFC3339))); err != nil {
+		s.log().Warn("failed to cache "+gitSyncKey, "source", s.name, "error", err)
+	}
+	return nil
+}
+
+// check rejects a URL or ref git would read as an option, such as
+// "--upload-pack=<command>.git", which would run a command.
+func (r *gitRepo) check() error {
+	if strings.HasPrefix(r.url, "-") {
+		return fmt.Errorf("invalid git source %q: starts with a dash", r.url)
+	}
+	if strings.HasPrefix(r.ref, "-") {
+		return fmt.Errorf("invalid git ref %q in %s: starts with a dash", r.ref, r.url)
+	}
+	return nil
+}
+
+// resync makes the next read pull the checkout again.
+func (r *gitRepo) resync() {
+	r.mu.Lock()
+	r.synced = false
+	r.mu.Unlock()
+}
+
+// clone makes a shallow, sparse checkout of the population directories,
+// staging it in a temporary directory under tempRoot.
+func (r *gitRepo) clone(ctx context.Context, tempRoot string) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+
+	// Clone into a temporary directory so an interrupted clone isn't mistaken for a checkout
+	stage, err := newTempDir(tempRoot, "clone")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stage)
+	tmp := filepath.Join(stage, "checkout")
+
+	args := []string{"clone", "--quiet", "--depth", "1", "--filter=blob:none", "--sparse"}
+	if r.ref != "" {
+		args = append(args, "--branch", r.ref)
+	}
+	args = append(args, "--", r.url, tmp)
+	if err := runGit(ctx, "", args...); err != nil {
+		return fmt.Errorf("cloning %s: %w", r.url, err)
+	}
+
+	dirs := []string{"sparse-checkout", "set"}
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		dirs = append(dirs, kind.Plural())
+	}
+	if err := runGit(ctx, tmp, dirs...); err != nil {
+		return fmt.Errorf("sparse checkout of %s: %w", r.url, err)
+	}
+	return moveDir(tmp, r.dir)
+}
+
+// pull updates the checkout to the latest commit of its ref.
+func (r *gitRepo) pull(ctx context.Context) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	ref := r.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := runGit(ctx, r.dir, "fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+		return fmt.Errorf("fetching %s: %w", r.url, err)
+	}
+	if err := runGit(ctx, r.dir, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("updating checkout of %s: %w", r.url, err)
+	}
+	return nil
+}
+
+// runGit runs a git command, returning its stderr in the error.
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Fail instead of prompting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// gitOutput runs a git command and returns its trimmed stdout.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitCloneRejectsOptions(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "pwned")
+	for _, url := range []string{
+		"--upload-pack=touch " + marker + ".git",
+		"https://github.com/org/population.git#--upload-pack=touch " + marker,
+		"-c core.sshCommand=touch " + marker + ".git",
+	} {
+		if !isGitSource(url) {
+			t.Fatalf("%q is not a git source", url)
+		}
+		repo := newGitRepo(url, filepath.Join(dir, "cache"))
+		if err := repo.clone(context.Background(), filepath.Join(dir, "tmp")); err == nil {
+			t.Errorf("clone of %q succeeded", url)
+		}
+		if err := repo.pull(context.Background()); err == nil {
+			t.Errorf("pull of %q succeeded", url)
+		}
+		if _, err := os.Stat(marker); err == nil {
+			t.Fatalf("clone of %q ran a command", url)
+		}
+	}
+}
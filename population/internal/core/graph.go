@@ -0,0 +1,242 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DependencyType says how an item depends on another.
+type DependencyType string
+
+const (
+	DependsOnPersona     DependencyType = "persona"     // A profile's persona
+	DependsOnSkill       DependencyType = "skill"       // A profile's skill
+	DependsOnRecommended DependencyType = "recommended" // A persona's recommended skill (not installed with it)
+	DependsOnDependency  DependencyType = "dependency"  // A skill's dependency
+)
+
+// DependencyNode is an item in a dependency graph, with the items it
+// depends on.
+type DependencyNode struct {
+	Kind         ItemKind          `json:"kind" yaml:"kind"`
+	Name         string            `json:"name" yaml:"name"`
+	Version      string            `json:"version,omitempty" yaml:"version,omitempty"`
+	Via          DependencyType    `json:"via,omitempty" yaml:"via,omitempty"`               // How the parent depends on it (empty for the root)
+	Condition    string            `json:"condition,omitempty" yaml:"condition,omitempty"`   // Conditions gating a profile's skill
+	Constraint   string            `json:"constraint,omitempty" yaml:"constraint,omitempty"` // Version constraint on a skill's dependency or a profile's persona
+	Error        string            `json:"error,omitempty" yaml:"error,omitempty"`           // Why the item could not be fetched
+	Dependencies []*DependencyNode `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// DependencyGraph fetches an item and everything it depends on: a profile's
+// persona and skills, a persona's recommended skills, and skills' dependencies. Dependencies that
+// can't be fetched are kept in the graph with an Error instead of failing it.
+func (c *Client) DependencyGraph(ctx context.Context, name string) (*DependencyNode, error) {
+	if err := ValidateItemName(name); err != nil {
+		return nil, err
+	}
+	kind, itemName := ParseItemName(name)
+	manifest, err := c.fetchDependency(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &DependencyNode{Kind: kind, Name: itemName}
+	if err := c.expandDependencies(ctx, root, manifest, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// resolveDependencies fetches a node's manifest and expands it.
+func (c *Client) resolveDependencies(ctx context.Context, node *DependencyNode, seen map[string]bool) error {
+	manifest, err := c.fetchDependency(ctx, node.Kind, node.Name)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		node.Error = err.Error()
+		return nil
+	}
+	return c.expandDependencies(ctx, node, manifest, seen)
+}
+
+// expandDependencies fills in a node's version and dependencies from its
+// manifest. seen holds the items on the path from the root, so a cycle ends
+// the walk.
+func (c *Client) expandDependencies(ctx context.Context, node *DependencyNode, manifest *Manifest, seen map[string]bool) error {
+	key := FormatItemName(node.Kind, node.Name)
+	if seen[key] {
+		return nil
+	}
+	seen[key] = true
+	defer delete(seen, key)
+
+	node.Version = manifest.Version
+
+	if node.Kind == KindProfile && manifest.Persona != "" {
+		persona, version := SplitVersion(manifest.Persona)
+		node.Dependencies = append(node.Dependencies, &DependencyNode{Kind: KindPersona, Name: persona, Via: DependsOnPersona, Constraint: version})
+	}
+	if node.Kind == KindProfile {
+		for _, ref := range manifest.Skills.Ordered() {
+			node.Dependencies = append(node.Dependencies, &DependencyNode{
+				Kind:      KindSkill,
+				Name:      ref.Name,
+				Via:       DependsOnSkill,
+				Condition: ref.conditionString(),
+			})
+		}
+	}
+	if node.Kind == KindPersona {
+		for _, skill := range manifest.RecommendedSkills {
+			node.Dependencies = append(node.Dependencies, &DependencyNode{Kind: KindSkill, Name: skill, Via: DependsOnRecommended})
+		}
+	}
+	if node.Kind == KindSkill {
+		for _, dep := range manifest.Dependencies {
+			name, version := SplitVersion(dep)
+			node.Dependencies = append(node.Dependencies, &DependencyNode{Kind: KindSkill, Name: name, Via: DependsOnDependency, Constraint: version})
+		}
+	}
+
+	for _, dep := range node.Dependencies {
+		if seen[FormatItemName(dep.Kind, dep.Name)] {
+			continue
+		}
+		if err := c.resolveDependencies(ctx, dep, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchDependency fetches the latest manifest of an item from the sources.
+func (c *Client) fetchDependency(ctx context.Context, kind ItemKind, name string) (*Manifest, error) {
+	source, err := c.resolveSource(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return source.GetManifest(ctx, kind, name)
+}
+
+// conditionString describes the conditions on a skill reference, such as
+// "only=linux env=prod", or returns "" if there are none.
+func (r SkillRef) conditionString() string {
+	var conditions []string
+	if r.Only != "" {
+		conditions = append(conditions, "only="+r.Only)
+	}
+	if r.Env != "" {
+		conditions = append(conditions, "env="+r.Env)
+	}
+	if r.RequiresTool != "" {
+		conditions = append(conditions, "requires_tool="+r.RequiresTool)
+	}
+	return strings.Join(conditions, " ")
+}
+
+// Dependent is an installed item that depends on another, directly or
+// through its persona.
+type Dependent struct {
+	Kind    ItemKind       `json:"kind" yaml:"kind"`
+	Name    string         `json:"name" yaml:"name"`
+	Version string         `json:"version" yaml:"version"`
+	Via     DependencyType `json:"via" yaml:"via"`   // How the last item on the path depends on the target
+	Path    []string       `json:"path" yaml:"path"` // Display names from the dependent to the target
+}
+
+// Dependents returns the installed items that depend on another: profiles
+// using it as their persona or a skill, or whose persona recommends it,
+// personas recommending it, and skills depending on it. It reads only
+// installed manifests.
+func (c *Client) Dependents(name string) ([]Dependent, error) {
+	kind, itemName := ParseItemName(name)
+	if err := checkItemName(itemName); err != nil {
+		return nil, err
+	}
+	target := FormatItemName(kind, itemName)
+
+	installed := func(k ItemKind, n string) *Manifest {
+		m, err := loadManifest(filepath.Join(c.itemDir(k, n), "vega.yaml"), c.cipher)
+		if err != nil {
+			return nil
+		}
+		return m
+	}
+	recommends := func(m *Manifest) bool {
+		if kind != KindSkill || m == nil {
+			return false
+		}
+		for _, skill := range m.RecommendedSkills {
+			if skill == itemName {
+				return true
+			}
+		}
+		return false
+	}
+
+	var dependents []Dependent
+	for _, k := range []ItemKind{KindProfile, KindPersona, KindSkill} {
+		entries, err := os.ReadDir(filepath.Join(c.installDir, c.layout.Dir(k)))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			m := installed(k, entry.Name())
+			if m == nil {
+				continue
+			}
+			display := FormatItemName(k, entry.Name())
+			add := func(via DependencyType, path ...string) {
+				dependents = append(dependents, Dependent{
+					Kind:    k,
+					Name:    entry.Name(),
+					Version: m.Version,
+					Via:     via,
+					Path:    append([]string{display}, path...),
+				})
+			}
+
+			if k == KindPersona {
+				if recommends(m) {
+					add(DependsOnRecommended, target)
+				}
+				continue
+			}
+			if k == KindSkill {
+				for _, dep := range m.Dependencies {
+					if name, _ := SplitVersion(dep); kind == KindSkill && name == itemName {
+						add(DependsOnDependency, target)
+						break
+					}
+				}
+				continue
+			}
+
+			persona, _ := SplitVersion(m.Persona)
+			if kind == KindPersona && persona == itemName {
+				add(DependsOnPersona, target)
+			}
+			if kind == KindSkill {
+				for _, skill := range m.Skills.Names() {
+					if skill == itemName {
+						add(DependsOnSkill, target)
+						break
+					}
+				}
+				if persona != "" && recommends(installed(KindPersona, persona)) {
+					add(DependsOnRecommended, FormatItemName(KindPersona, persona), target)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(dependents, func(i, j int) bool {
+		return strings.Join(dependents[i].Path, " ") < strings.Join(dependents[j].Path, " ")
+	})
+	return dependents, nil
+}
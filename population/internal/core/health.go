@@ -0,0 +1,137 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestIDHeader carries a request's ID. A server keeps the ID a client or
+// proxy sends, or makes one up, and returns it in the response and its
+// access log.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs a server accepts from clients.
+const maxRequestIDLength = 128
+
+// withHealth serves the liveness probe /healthz and the readiness probe
+// /readyz ahead of h, without authentication. ready returns why the server
+// can't take requests, or nil.
+func withHealth(h http.Handler, ready func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, "ok\n")
+		case "/readyz":
+			if err := ready(); err != nil {
+				http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, "ok\n")
+		default:
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// accessEntry is an access log line.
+type accessEntry struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id"`
+	Remote     string    `json:"remote"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMS float64   `json:"duration_ms"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// accessLog gives every request handled by h an ID and logs it to out as a
+// line of JSON once it has been served.
+func accessLog(h http.Handler, out io.Writer) http.Handler {
+	var mu sync.Mutex
+	enc := json.NewEncoder(out)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := requestID(r)
+		w.Header().Set(RequestIDHeader, id)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		entry := accessEntry{
+			Time:       start.UTC(),
+			RequestID:  id,
+			Remote:     clientIP(r),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			UserAgent:  r.UserAgent(),
+		}
+		mu.Lock()
+		enc.Encode(entry)
+		mu.Unlock()
+	})
+}
+
+// requestID returns the ID a request was sent with, if it is short and
+// printable, or a new random one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" && len(id) <= maxRequestIDLength {
+		printable := true
+		for _, c := range id {
+			if c <= ' ' || c > '~' {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return id
+		}
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder records the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// starterPolicy is the policy file written by Init, with every setting at its
+// default and commented out.
+const starterPolicy = `# Workspace policy for vega population, applied to every install here.
+# Uncomment a setting to change its default.
+
+# Lowest review status that may be installed: draft, reviewed, or approved.
+# min_status: reviewed
+
+# Verify manifest signatures against trusted publisher keys.
+# signatures:
+#   require: true          # Refuse unsigned items too
+#   keys:
+#     - ~/.vega/keys/registry.pem
+`
+
+// InitOptions configures setting up a vega home.
+type InitOptions struct {
+	Profile  string    // Starter profile to install, with or without its "+" (optional)
+	Progress io.Writer // Receives install progress (default: discarded)
+
+	// Layout names the directories of each kind in the install directory,
+	// written to its LayoutFile. It can only be set before anything is
+	// installed (optional).
+	Layout Layout
+}
+
+// InitResult describes a vega home that was set up.
+type InitResult struct {
+	Home      string         `json:"home"`
+	Created   []string       `json:"created"`             // Directories and files that didn't exist yet
+	Installed *InstallReport `json:"installed,omitempty"` // The starter profile's install, if one was installed
+}
+
+// Init sets up the vega home: the install and cache directories and a
+// starter policy file, then the starter profile if one is given. Anything
+// that already exists is left alone, so it is safe to run again.
+func (c *Client) Init(ctx context.Context, opts *InitOptions) (*InitResult, error) {
+	if opts == nil {
+		opts = &InitOptions{}
+	}
+	result := &InitResult{Home: c.home, Created: []string{}}
+
+	if opts.Layout != nil {
+		created, err := c.initLayout(opts.Layout)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.Created = append(result.Created, filepath.Join(c.installDir, LayoutFile))
+		}
+	}
+
+	dirs := []string{c.home, c.installDir, c.cacheDir}
+	for _, kind := range []ItemKind{KindPersona, KindSkill, KindProfile} {
+		dirs = append(dirs, filepath.Join(c.installDir, c.layout.Dir(kind)))
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating %s: %w", dir, err)
+		}
+		result.Created = append(result.Created, dir)
+	}
+
+	policy := filepath.Join(c.installDir, PolicyFile)
+	if _, err := os.Stat(policy); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(policy, []byte(starterPolicy), 0644); err != nil {
+			return nil, fmt.Errorf("writing policy: %w", err)
+		}
+		result.Created = append(result.Created, policy)
+	}
+
+	if opts.Profile == "" {
+		return result, nil
+	}
+	name := "+" + strings.TrimPrefix(opts.Profile, "+")
+	if _, err := os.Stat(filepath.Join(c.itemDir(KindProfile, name[1:]), "vega.yaml")); err == nil {
+		return result, nil // Installed by an earlier init
+	}
+	report, err := c.InstallWithReport(ctx, name, &InstallOptions{Progress: opts.Progress})
+	if err != nil {
+		return nil, err
+	}
+	result.Installed = report
+	return result, nil
+}
+
+// initLayout sets the layout of a new install directory, and reports whether
+// it wrote the layout file. An existing layout must be the same.
+func (c *Client) initLayout(layout Layout) (bool, error) {
+	if err := layout.validate(); err != nil {
+		return false, err
+	}
+	existing, err := LoadLayout(c.installDir)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+			if existing.Dir(kind) != layout.Dir(kind) {
+				return false, fmt.Errorf("%s already has a different layout in %s", c.installDir, LayoutFile)
+			}
+		}
+		return false, nil
+	}
+
+	// Installed items would be lost to the new directories
+	c.layout = nil
+	items, err := c.List("")
+	if err != nil {
+		return false, err
+	}
+	if len(items) > 0 {
+		return false, fmt.Errorf("%s already has items installed in the default layout; a layout must be set before installing", c.installDir)
+	}
+	if err := layout.Save(c.installDir); err != nil {
+		return false, err
+	}
+	c.layout = layout
+	return true, nil
+}
+
+// needsInit reports whether the vega home looks unused: it doesn't exist, or
+// has neither a policy file nor any installed items.
+func (c *Client) needsInit() bool {
+	if c.workspace != nil {
+		return false // Set up by sync instead
+	}
+	if _, err := os.Stat(c.home); err != nil {
+		return true
+	}
+	if _, err := os.Stat(filepath.Join(c.installDir, PolicyFile)); err == nil {
+		return false
+	}
+	items, err := c.List("")
+	return err == nil && len(items) == 0
+}
@@ -0,0 +1,547 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Install statuses reported in an InstallResult.
+const (
+	InstallStatusInstalled        = "installed"
+	InstallStatusWouldInstall     = "would-install"
+	InstallStatusAlreadyInstalled = "already-installed"
+	InstallStatusSkipped          = "skipped" // A conditional profile skill that doesn't apply here
+)
+
+// InstallReport lists the items an install touched, dependencies first.
+type InstallReport struct {
+	Items     []InstallResult   `json:"items" yaml:"items"`
+	Conflicts []VersionConflict `json:"conflicts,omitempty" yaml:"conflicts,omitempty"` // Version conflicts and how they were settled
+
+	Incompatibilities []Incompatibility `json:"incompatibilities,omitempty" yaml:"incompatibilities,omitempty"` // Incompatible items installed together
+}
+
+// InstallResult describes one item of an install.
+type InstallResult struct {
+	Kind     ItemKind `json:"kind" yaml:"kind"`
+	Name     string   `json:"name" yaml:"name"`
+	Version  string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Status   string   `json:"status" yaml:"status"`
+	Path     string   `json:"path,omitempty" yaml:"path,omitempty"`
+	SHA256   string   `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Verified bool     `json:"verified" yaml:"verified"` // The manifest matched the hash published in the index
+	Signed   bool     `json:"signed" yaml:"signed"`     // The manifest matched a trusted signature
+
+	Files []FileChange `json:"files,omitempty" yaml:"files,omitempty"` // With DryRun, the files installing would change
+}
+
+// add appends a result to the report.
+func (r *InstallReport) add(result InstallResult) {
+	r.Items = append(r.Items, result)
+}
+
+// has reports whether the report already covers an item.
+func (r *InstallReport) has(kind ItemKind, name string) bool {
+	for _, item := range r.Items {
+		if item.Kind == kind && item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DependencyCycleError is returned when skills depend on each other in a loop.
+type DependencyCycleError struct {
+	Cycle []string // Skill names around the loop, starting and ending with the same one
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("skill dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// progress returns where install progress messages are written.
+func (opts *InstallOptions) progress() io.Writer {
+	if opts.Progress != nil {
+		return opts.Progress
+	}
+	return io.Discard
+}
+
+// Install installs an item from the source to the install directory.
+func (s *Source) Install(ctx context.Context, kind ItemKind, name string, installDir string, opts *InstallOptions) error {
+	return s.install(ctx, kind, name, installDir, opts, &InstallReport{})
+}
+
+// install installs an item, recording what it did in report.
+func (s *Source) install(ctx context.Context, kind ItemKind, name string, installDir string, opts *InstallOptions, report *InstallReport) error {
+	if err := checkItemName(name); err != nil {
+		return err
+	}
+
+	// Check if already installed
+	destDir := filepath.Join(installDir, s.layout.Dir(kind), name)
+	destPath := filepath.Join(destDir, "vega.yaml")
+
+	// A pin resolving a conflict may replace the installed version
+	constraint := opts.Version
+	pin, pinned := opts.pins[FormatItemName(kind, name)]
+	if pinned {
+		constraint = pin
+	}
+	if _, err := os.Stat(destPath); err == nil && !opts.Force && (!pinned || installedVersion(destDir, s.cipher) == pin) {
+		return fmt.Errorf("%s %q is already installed (use --force to overwrite)", kind, name)
+	}
+
+	if err := s.checkStatus(ctx, kind, name, opts.MinStatus); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		fmt.Fprintf(opts.progress(), "Would install %s %q to %s\n", kind, name, destDir)
+	}
+
+	// Fetch the manifest, resolving the requested version if there is one
+	fetched, files, err := s.fetchItem(ctx, kind, name, constraint, opts)
+	if err != nil {
+		return err
+	}
+
+	// For profiles, install the dependencies of the version being installed first
+	if kind == KindProfile && !opts.NoDeps {
+		if err := s.installProfileDeps(ctx, name, fetched.content, installDir, opts, report); err != nil {
+			return err
+		}
+	}
+
+	if kind == KindSkill && !opts.NoDeps {
+		if err := s.installSkillDeps(ctx, name, fetched.content, installDir, opts, report); err != nil {
+			return err
+		}
+	}
+
+	result := InstallResult{
+		Kind:     kind,
+		Name:     name,
+		Version:  fetched.version,
+		Status:   InstallStatusInstalled,
+		Path:     destDir,
+		SHA256:   fetched.sha256,
+		Verified: fetched.verified,
+		Signed:   fetched.signed,
+	}
+
+	if opts.DryRun {
+		if constraint != "" {
+			fmt.Fprintf(opts.progress(), "  resolved %s to %s\n", constraint, fetched.version)
+		}
+		result.Status = InstallStatusWouldInstall
+		written := map[string][]byte{filepath.Base(destPath): fetched.content}
+		for rel, content := range files {
+			written[rel] = content
+		}
+		if result.Files, err = planItemFiles(destDir, written, s.cipher); err != nil {
+			return err
+		}
+		report.add(result)
+		return nil
+	}
+
+	// Write the item to a temporary directory and move it into place, so an
+	// interrupted install leaves the previous installation, and replacing it
+	// leaves none of its files behind
+	stage, err := newTempDir(s.tempDir, "install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stage)
+	staged := filepath.Join(stage, name)
+	if err := os.MkdirAll(staged, 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	if err := s.cipher.writeFile(filepath.Join(staged, filepath.Base(destPath)), fetched.content, 0644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := writeItemFiles(staged, files, s.cipher); err != nil {
+		return err
+	}
+
+	record := &InstallRecord{
+		Source:      s.baseURL,
+		Version:     fetched.version,
+		SHA256:      fetched.sha256,
+		Verified:    fetched.verified,
+		Signed:      fetched.signed,
+		InstalledAt: time.Now().UTC(),
+		InstalledBy: installerVersion(),
+	}
+	for rel, content := range files {
+		if record.Files == nil {
+			record.Files = make(map[string]string)
+		}
+		record.Files[filepath.ToSlash(rel)] = contentHash(content)
+	}
+	if err := writeInstallRecord(staged, record); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := moveDir(staged, destDir); err != nil {
+		return fmt.Errorf("installing %s %q: %w", kind, name, err)
+	}
+
+	report.add(result)
+	return nil
+}
+
+// prefetchedItem is an item's manifest and files fetched ahead of installing it.
+type prefetchedItem struct {
+	fetched *fetchedManifest
+	files   map[string][]byte
+}
+
+// prefetchKey identifies a prefetched item and the constraint it was fetched for.
+func prefetchKey(kind ItemKind, name, constraint string) string {
+	return FormatItemName(kind, name) + "@" + constraint
+}
+
+// fetchItem fetches an item's verified manifest and extra files, unless they
+// were prefetched.
+func (s *Source) fetchItem(ctx context.Context, kind ItemKind, name, constraint string, opts *InstallOptions) (*fetchedManifest, map[string][]byte, error) {
+	if item, ok := opts.prefetched[prefetchKey(kind, name, constraint)]; ok {
+		return item.fetched, item.files, nil
+	}
+
+	fetched, err := s.fetchVerified(ctx, kind, name, constraint, opts.NoVerify)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := s.fetchManifestFiles(ctx, kind, name, fetched.content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fetched, files, nil
+}
+
+// fetchedManifest is manifest content fetched by fetchVerified.
+type fetchedManifest struct {
+	content  []byte
+	version  string // The version fetched
+	sha256   string
+	verified bool // The content matched the hash published in the index
+	signed   bool // The content matched a trusted signature
+}
+
+// fetchVerified fetches the manifest of the best version matching a
+// constraint and checks it against the hash published in the index and,
+// with a signature policy, its signature.
+func (s *Source) fetchVerified(ctx context.Context, kind ItemKind, name, constraint string, noVerify bool) (*fetchedManifest, error) {
+	content, version, err := s.resolveManifest(ctx, kind, name, constraint)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s %q: %w", kind, name, err)
+	}
+
+	sum := contentHash(content)
+	expected, version, err := s.publishedChecksum(ctx, kind, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s %q: %w", kind, name, err)
+	}
+
+	fetched := &fetchedManifest{content: content, version: version, sha256: sum}
+	if expected != "" && !noVerify {
+		if !strings.EqualFold(expected, sum) {
+			return nil, &ChecksumMismatchError{Kind: kind, Name: name, Version: version, Expected: expected, Actual: sum}
+		}
+		fetched.verified = true
+	}
+
+	if fetched.signed, err = s.verifySignature(ctx, kind, name, version, content); err != nil {
+		return nil, err
+	}
+	return fetched, nil
+}
+
+// installProfileDeps installs the dependencies (persona and skills) named by
+// the manifest content of the profile version being installed.
+func (s *Source) installProfileDeps(ctx context.Context, profileName string, content []byte, installDir string, opts *InstallOptions, report *InstallReport) error {
+	var profile Manifest
+	if err := yaml.Unmarshal(content, &profile); err != nil {
+		return fmt.Errorf("parsing profile %q: %w", profileName, err)
+	}
+
+	prefetched, err := s.prefetchProfileDeps(ctx, &profile, installDir, opts, report)
+	if err != nil {
+		return fmt.Errorf("installing profile %q: %w", profileName, err)
+	}
+
+	// Install persona, at a version the profile allows
+	if profile.Persona != "" {
+		persona, version := SplitVersion(profile.Persona)
+		if opts.DryRun {
+			fmt.Fprintf(opts.progress(), "Would install persona %q (dependency of profile %q)\n", persona, profileName)
+		} else {
+			fmt.Fprintf(opts.progress(), "Installing persona %q...\n", persona)
+		}
+
+		depOpts := &InstallOptions{
+			Force:   opts.Force,
+			NoDeps:  true, // Don't recurse for personas
+			DryRun:  opts.DryRun,
+			Env:     opts.Env,
+			Version: version,
+
+			MinStatus: opts.MinStatus,
+			NoVerify:  opts.NoVerify,
+			Progress:  opts.Progress,
+
+			pins:       opts.pins,
+			prefetched: prefetched,
+		}
+
+		if err := s.install(ctx, KindPersona, persona, installDir, depOpts, report); err != nil {
+			// Don't fail on "already installed" errors for dependencies
+			if !opts.Force && isAlreadyInstalledError(err) {
+				if !opts.DryRun {
+					fmt.Fprintf(opts.progress(), "  Persona %q already installed\n", persona)
+				}
+				report.add(InstallResult{Kind: KindPersona, Name: persona, Status: InstallStatusAlreadyInstalled})
+			} else {
+				return fmt.Errorf("installing persona %q: %w", persona, err)
+			}
+		}
+	}
+
+	// Install skills in priority order, skipping those whose conditions don't apply here
+	platform := CurrentPlatform(opts.Env)
+	for _, skill := range profile.Skills.Ordered() {
+		skillName := skill.Name
+		if report.has(KindSkill, skillName) {
+			continue // Already handled as another skill's dependency
+		}
+		if !skill.Applies(platform) {
+			fmt.Fprintf(opts.progress(), "Skipping skill %q (conditions not met on %s)\n", skillName, platform)
+			report.add(InstallResult{Kind: KindSkill, Name: skillName, Status: InstallStatusSkipped})
+			continue
+		}
+
+		if opts.DryRun {
+			fmt.Fprintf(opts.progress(), "Would install skill %q (dependency of profile %q)\n", skillName, profileName)
+		} else {
+			fmt.Fprintf(opts.progress(), "Installing skill %q...\n", skillName)
+		}
+
+		depOpts := &InstallOptions{
+			Force:   opts.Force,
+			NoDeps:  opts.NoDeps,
+			DryRun:  opts.DryRun,
+			Env:     opts.Env,
+			Version: skill.Version,
+
+			MinStatus: opts.MinStatus,
+			NoVerify:  opts.NoVerify,
+			Progress:  opts.Progress,
+
+			pins:       opts.pins,
+			prefetched: prefetched,
+		}
+
+		if err := s.install(ctx, KindSkill, skillName, installDir, depOpts, report); err != nil {
+			if !opts.Force && isAlreadyInstalledError(err) {
+				if !opts.DryRun {
+					fmt.Fprintf(opts.progress(), "  Skill %q already installed\n", skillName)
+				}
+				report.add(InstallResult{Kind: KindSkill, Name: skillName, Status: InstallStatusAlreadyInstalled})
+			} else {
+				return fmt.Errorf("installing skill %q: %w", skillName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// prefetchProfileDeps fetches a profile's persona and the skills that apply
+// here, up to s.concurrency at a time, so they can then be installed in order
+// without waiting on each fetch in turn. Items this install won't fetch, such
+// as those already installed, are left out. Every failed fetch is reported.
+func (s *Source) prefetchProfileDeps(ctx context.Context, profile *Manifest, installDir string, opts *InstallOptions, report *InstallReport) (map[string]*prefetchedItem, error) {
+	type job struct {
+		kind             ItemKind
+		name, constraint string
+	}
+	var jobs []job
+	add := func(kind ItemKind, name, constraint string) {
+		pin, pinned := opts.pins[FormatItemName(kind, name)]
+		if pinned {
+			constraint = pin
+		}
+		if report.has(kind, name) {
+			return
+		}
+		dir := filepath.Join(installDir, s.layout.Dir(kind), name)
+		if _, err := os.Stat(filepath.Join(dir, "vega.yaml")); err == nil && !opts.Force && (!pinned || installedVersion(dir, s.cipher) == pin) {
+			return
+		}
+		jobs = append(jobs, job{kind, name, constraint})
+	}
+
+	if profile.Persona != "" {
+		persona, version := SplitVersion(profile.Persona)
+		add(KindPersona, persona, version)
+	}
+	platform := CurrentPlatform(opts.Env)
+	for _, skill := range profile.Skills.Ordered() {
+		if skill.Applies(platform) {
+			add(KindSkill, skill.Name, skill.Version)
+		}
+	}
+
+	workers := s.concurrency
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	if workers < 2 {
+		return nil, nil
+	}
+
+	// Load the indexes up front, so the workers read them from the cache
+	for _, kind := range []ItemKind{KindPersona, KindSkill} {
+		if _, _, err := s.getIndex(ctx, kind); err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]*prefetchedItem, len(jobs))
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				j := jobs[i]
+				fetched, err := s.fetchVerified(ctx, j.kind, j.name, j.constraint, opts.NoVerify)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				files, err := s.fetchManifestFiles(ctx, j.kind, j.name, fetched.content)
+				if err != nil {
+					errs[i] = fmt.Errorf("fetching files of %s %q: %w", j.kind, j.name, err)
+					continue
+				}
+				items[i] = &prefetchedItem{fetched: fetched, files: files}
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	prefetched := make(map[string]*prefetchedItem, len(jobs))
+	var failures []error
+	for i, j := range jobs {
+		if errs[i] != nil {
+			failures = append(failures, errs[i])
+			continue
+		}
+		prefetched[prefetchKey(j.kind, j.name, j.constraint)] = items[i]
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("%d dependencies could not be fetched:\n%w", len(failures), errors.Join(failures...))
+	}
+	return prefetched, nil
+}
+
+// installSkillDeps installs the skills a skill depends on, and theirs, before
+// the skill itself. Skills already handled by this install are skipped, and a
+// skill that depends on itself through others is a *DependencyCycleError.
+func (s *Source) installSkillDeps(ctx context.Context, skillName string, content []byte, installDir string, opts *InstallOptions, report *InstallReport) error {
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("parsing skill %q: %w", skillName, err)
+	}
+
+	path := append(append([]string(nil), opts.dependencyPath...), skillName)
+	for _, dep := range manifest.Dependencies {
+		depName, version := SplitVersion(dep)
+		for i, name := range path {
+			if name == depName {
+				return &DependencyCycleError{Cycle: append(append([]string(nil), path[i:]...), depName)}
+			}
+		}
+		if report.has(KindSkill, depName) {
+			continue
+		}
+
+		if opts.DryRun {
+			fmt.Fprintf(opts.progress(), "Would install skill %q (dependency of skill %q)\n", depName, skillName)
+		} else {
+			fmt.Fprintf(opts.progress(), "Installing skill %q (dependency of skill %q)...\n", depName, skillName)
+		}
+
+		depOpts := &InstallOptions{
+			Force:   opts.Force,
+			DryRun:  opts.DryRun,
+			Env:     opts.Env,
+			Version: version,
+
+			MinStatus: opts.MinStatus,
+			NoVerify:  opts.NoVerify,
+			Progress:  opts.Progress,
+
+			dependencyPath: path,
+			pins:           opts.pins,
+		}
+
+		if err := s.install(ctx, KindSkill, depName, installDir, depOpts, report); err != nil {
+			if !opts.Force && isAlreadyInstalledError(err) {
+				if !opts.DryRun {
+					fmt.Fprintf(opts.progress(), "  Skill %q already installed\n", depName)
+				}
+				report.add(InstallResult{Kind: KindSkill, Name: depName, Status: InstallStatusAlreadyInstalled})
+			} else {
+				var cycle *DependencyCycleError
+				if errors.As(err, &cycle) {
+					return err
+				}
+				return fmt.Errorf("installing skill %q (dependency of %q): %w", depName, skillName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isAlreadyInstalledError checks if the error is an "already installed" error.
+func isAlreadyInstalledError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return containsString(err.Error(), "already installed")
+}
+
+func containsString(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsStringHelper(s, substr))
+}
+
+func containsStringHelper(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutFile is the file in an install directory that names the directory
+// each kind of item is installed in, for runtimes expecting other names:
+//
+//	persona: agents
+//	skill: capabilities
+const LayoutFile = "layout.yaml"
+
+// Layout maps kinds to the directories of an install directory their items
+// are installed in. Kinds it doesn't name use their plural, e.g. "skills".
+type Layout map[ItemKind]string
+
+// Dir returns the directory items of a kind are installed in, relative to
+// the install directory.
+func (l Layout) Dir(kind ItemKind) string {
+	if dir := l[kind]; dir != "" {
+		return dir
+	}
+	return kind.Plural()
+}
+
+// ParseLayout parses a layout given as comma-separated kind=dir pairs, such
+// as "persona=agents,skill=capabilities".
+func ParseLayout(s string) (Layout, error) {
+	layout := make(Layout)
+	for _, pair := range strings.Split(s, ",") {
+		kind, dir, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid layout entry %q (want kind=dir)", pair)
+		}
+		layout[ItemKind(strings.TrimSpace(kind))] = strings.TrimSpace(dir)
+	}
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// LoadLayout loads the layout of an install directory. An install directory
+// without a LayoutFile has the default layout, which is nil.
+func LoadLayout(installDir string) (Layout, error) {
+	path := filepath.Join(installDir, LayoutFile)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading layout: %w", err)
+	}
+
+	var layout Layout
+	if err := yaml.Unmarshal(content, &layout); err != nil {
+		return nil, fmt.Errorf("parsing layout %s: %w", path, err)
+	}
+	if err := layout.validate(); err != nil {
+		return nil, fmt.Errorf("layout %s: %w", path, err)
+	}
+	return layout, nil
+}
+
+// Save writes the layout to an install directory's LayoutFile.
+func (l Layout) Save(installDir string) error {
+	if err := l.validate(); err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("encoding layout: %w", err)
+	}
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		return fmt.Errorf("creating install directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(installDir, LayoutFile), content, 0644); err != nil {
+		return fmt.Errorf("writing layout: %w", err)
+	}
+	return nil
+}
+
+// validate checks that the layout names known kinds, and gives each its own
+// directory directly in the install directory.
+func (l Layout) validate() error {
+	kinds := make([]string, 0, len(l))
+	for kind := range l {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+
+	for _, name := range kinds {
+		kind := ItemKind(name)
+		switch kind {
+		case KindSkill, KindPersona, KindProfile, KindSettings:
+		default:
+			return fmt.Errorf("unknown kind %q in layout (use skill, persona, profile, or settings)", kind)
+		}
+		dir := l[kind]
+		if dir == "" || dir == "." || dir == ".." || strings.ContainsAny(dir, `/\`) || strings.HasPrefix(dir, ".") {
+			return fmt.Errorf("invalid layout directory %q for %s: it must be a plain directory name", dir, kind)
+		}
+	}
+
+	// Two kinds sharing a directory would see each other's items
+	dirs := make(map[string]ItemKind)
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		dir := l.Dir(kind)
+		if other, ok := dirs[dir]; ok {
+			return fmt.Errorf("layout puts both %s and %s items in %s", other, kind, dir)
+		}
+		dirs[dir] = kind
+	}
+	return nil
+}
+
+// WithLayout installs items into the given directories of the install
+// directory instead of those in its LayoutFile.
+func WithLayout(layout Layout) Option {
+	return func(c *Client) {
+		c.layout = layout
+		c.layoutSet = true
+	}
+}
+
+// itemDir returns the directory an item is installed in.
+func (c *Client) itemDir(kind ItemKind, name string) string {
+	return filepath.Join(c.installDir, c.layout.Dir(kind), name)
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// limitSweepInterval is how often idle clients are dropped from a limiter.
+const limitSweepInterval = time.Minute
+
+// limiter rate limits requests per client IP with a token bucket, and caps
+// how many requests are served at once. Requests over either limit are
+// rejected with a Retry-After header, which sources back off and retry on.
+type limiter struct {
+	rate  float64 // Tokens added per second; 0 for no rate limit
+	burst float64
+	slots chan struct{} // nil for no concurrency limit
+
+	mu        sync.Mutex
+	clients   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket is a client's token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter creates a limiter allowing rate requests per second per client,
+// in bursts of up to burst, and maxConcurrent requests at once. It returns
+// nil if there are no limits.
+func newLimiter(rate float64, burst, maxConcurrent int) *limiter {
+	if rate <= 0 && maxConcurrent <= 0 {
+		return nil
+	}
+
+	l := &limiter{rate: rate, burst: float64(burst), clients: make(map[string]*bucket)}
+	if l.rate > 0 && l.burst < 1 {
+		l.burst = math.Max(1, math.Ceil(l.rate))
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// wrap applies the limits to a handler.
+func (l *limiter) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait, ok := l.allow(clientIP(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+				defer func() { <-l.slots }()
+			default:
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "server busy", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from a client's bucket, or reports how long until one
+// is available.
+func (l *limiter) allow(client string, now time.Time) (time.Duration, bool) {
+	if l.rate <= 0 {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= limitSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.clients[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep drops the clients whose buckets have refilled, since a new bucket
+// would be the same. It must be called with l.mu held.
+func (l *limiter) sweep(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientIP returns the IP address a request came from. Forwarding headers
+// are ignored, as any client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
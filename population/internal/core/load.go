@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOptions configures loading an item into memory.
+type LoadOptions struct {
+	Env       string       // Deployment environment used to select conditional profile skills
+	MinStatus ReviewStatus // Lowest review status to load (default: the install directory's policy, if any)
+	NoVerify  bool         // Skip checking manifests against the hashes published in the index
+
+	RequireSigned bool // Refuse items without a trusted signature
+}
+
+// LoadedItem is an item resolved in memory by Load.
+type LoadedItem struct {
+	Kind     ItemKind
+	Name     string
+	Version  string
+	Source   string
+	SHA256   string
+	Verified bool // The manifest matched the hash published in the index
+	Signed   bool // The manifest matched a trusted signature
+
+	Manifest *Manifest
+	Raw      []byte            // The manifest as published
+	Files    map[string][]byte // Extra files listed by the manifest, by relative path
+
+	// Prompt is the rendered system prompt: a persona's own, or a profile's persona's.
+	Prompt string
+
+	// For profiles, the persona and the skills that apply on this platform, in priority order.
+	Persona *LoadedItem
+	Skills  []*LoadedItem
+}
+
+// Load resolves an item and its dependencies entirely in memory, for runtimes
+// that hydrate agents on demand, such as containers with read-only
+// filesystems. Nothing is written to disk: the index cache is bypassed and
+// nothing is installed. The name takes the same forms as Install, including
+// a version constraint (e.g. "+sre-oncall@^1.2").
+func (c *Client) Load(ctx context.Context, name string, opts *LoadOptions) (*LoadedItem, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	minStatus := opts.MinStatus
+	if minStatus == "" {
+		policy, err := c.Policy()
+		if err != nil {
+			return nil, err
+		}
+		minStatus = policy.MinStatus
+	}
+
+	// Read through a client whose cache never touches disk
+	mem := *c
+	mem.cache = NewCache(c.cacheDir, true)
+
+	if err := ValidateItemName(name); err != nil {
+		return nil, err
+	}
+	name, constraint := SplitVersion(name)
+	kind, itemName := ParseItemName(name)
+	source, err := mem.resolveSource(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+	if source.signatures, err = c.signaturePolicy(opts.RequireSigned); err != nil {
+		return nil, err
+	}
+
+	item, err := source.load(ctx, kind, itemName, constraint, minStatus, opts.NoVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case KindPersona:
+		item.Prompt = item.Manifest.SystemPrompt.String()
+
+	case KindProfile:
+		// Dependencies come from the profile's source, as with Install
+		if persona, version := SplitVersion(item.Manifest.Persona); persona != "" {
+			if item.Persona, err = source.load(ctx, KindPersona, persona, version, minStatus, opts.NoVerify); err != nil {
+				return nil, fmt.Errorf("loading persona %q: %w", persona, err)
+			}
+			item.Persona.Prompt = item.Persona.Manifest.SystemPrompt.String()
+			item.Prompt = item.Persona.Prompt
+		}
+		for _, ref := range item.Manifest.Skills.Select(CurrentPlatform(opts.Env)) {
+			skill, err := source.load(ctx, KindSkill, ref.Name, "", minStatus, opts.NoVerify)
+			if err != nil {
+				return nil, fmt.Errorf("loading skill %q: %w", ref.Name, err)
+			}
+			item.Skills = append(item.Skills, skill)
+		}
+	}
+
+	return item, nil
+}
+
+// load fetches, verifies, and parses one item's manifest.
+func (s *Source) load(ctx context.Context, kind ItemKind, name, constraint string, minStatus ReviewStatus, noVerify bool) (*LoadedItem, error) {
+	if err := s.checkStatus(ctx, kind, name, minStatus); err != nil {
+		return nil, err
+	}
+
+	fetched, err := s.fetchVerified(ctx, kind, name, constraint, noVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(fetched.content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing %s %q: %w", kind, name, err)
+	}
+
+	version := fetched.version
+	if version == "" {
+		version = manifest.Version
+	}
+
+	files, err := s.fetchFiles(ctx, kind, name, manifest.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadedItem{
+		Kind:     kind,
+		Name:     name,
+		Version:  version,
+		Source:   s.name,
+		SHA256:   fetched.sha256,
+		Verified: fetched.verified,
+		Signed:   fetched.signed,
+		Manifest: &manifest,
+		Raw:      fetched.content,
+		Files:    files,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile is the conventional name of a lockfile, kept next to the spec it
+// locks.
+const LockFile = "vega.lock"
+
+// Lock records the exact content each item was synced with. The hashes are
+// of the manifests themselves, so they are the same from any mirror of a
+// source, and a later sync from a different mirror can check that it serves
+// identical content before installing anything from it.
+type Lock struct {
+	Items []LockEntry `yaml:"items"`
+}
+
+// LockEntry pins the content of an item.
+type LockEntry struct {
+	Kind    ItemKind `yaml:"kind"`
+	Name    string   `yaml:"name"`
+	Version string   `yaml:"version"`
+	Source  string   `yaml:"source"` // Where it was installed from, such as a mirror
+	SHA256  string   `yaml:"sha256"` // Hash of its manifest, whichever mirror serves it
+}
+
+// LockMismatchError reports a source serving different content for a locked
+// version of an item than the lockfile recorded.
+type LockMismatchError struct {
+	Kind       ItemKind
+	Name       string
+	Version    string
+	Source     string // The source serving the different content
+	Locked     string // Hash in the lockfile
+	LockedFrom string // Source the locked content came from
+	Actual     string
+}
+
+func (e *LockMismatchError) Error() string {
+	return fmt.Sprintf("%s %q %s from %s does not match the lockfile: locked sha256 %s (from %s), got %s",
+		e.Kind, e.Name, e.Version, e.Source, e.Locked, e.LockedFrom, e.Actual)
+}
+
+// LoadLock loads a lockfile. A missing lockfile is an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lockfile: %w", err)
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("parsing lockfile %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// Save writes the lockfile to path, leaving it untouched if it is unchanged.
+func (l *Lock) Save(path string) error {
+	var content bytes.Buffer
+	content.WriteString("# Written by vega population sync; do not edit.\n")
+	if err := writeOutput(&content, OutputYAML, l); err != nil {
+		return fmt.Errorf("encoding lockfile: %w", err)
+	}
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content.Bytes()) {
+		return nil
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing lockfile: %w", err)
+	}
+	return nil
+}
+
+// entry returns the lock entry of an item, or nil.
+func (l *Lock) entry(kind ItemKind, name string) *LockEntry {
+	for i := range l.Items {
+		if l.Items[i].Kind == kind && l.Items[i].Name == name {
+			return &l.Items[i]
+		}
+	}
+	return nil
+}
+
+// verifyLock checks that the sources serve the locked content of every
+// locked item that isn't already installed with it. Items or versions a
+// source no longer offers are skipped; installing them fails anyway.
+func (c *Client) verifyLock(ctx context.Context, lock *Lock) error {
+	for _, entry := range lock.Items {
+		if err := checkItemName(entry.Name); err != nil {
+			return fmt.Errorf("lockfile: %w", err)
+		}
+		dir := c.itemDir(entry.Kind, entry.Name)
+		if record, err := LoadInstallRecord(dir); err == nil && strings.EqualFold(record.SHA256, entry.SHA256) {
+			continue
+		}
+
+		source, err := c.resolveSource(ctx, entry.Kind, entry.Name)
+		if err != nil {
+			continue
+		}
+		sum, err := source.lockedSum(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("verifying locked %s %q: %w", entry.Kind, entry.Name, err)
+		}
+		if sum != "" && !strings.EqualFold(sum, entry.SHA256) {
+			return &LockMismatchError{
+				Kind:       entry.Kind,
+				Name:       entry.Name,
+				Version:    entry.Version,
+				Source:     source.baseURL,
+				Locked:     entry.SHA256,
+				LockedFrom: entry.Source,
+				Actual:     sum,
+			}
+		}
+	}
+	return nil
+}
+
+// lockedSum returns the hash of the manifest the source serves for a locked
+// version of an item, or "" if it doesn't offer that version. The hash the
+// index publishes is used when there is one, since installing checks the
+// content against it.
+func (s *Source) lockedSum(ctx context.Context, entry LockEntry) (string, error) {
+	if _, ok, err := s.latestVersion(ctx, entry.Kind, entry.Name); err != nil || !ok {
+		return "", err
+	}
+	versions, current, err := s.Versions(ctx, entry.Kind, entry.Name)
+	if err != nil {
+		return "", err
+	}
+	offered := false
+	for _, v := range versions {
+		offered = offered || v == entry.Version
+	}
+	if !offered {
+		return "", nil
+	}
+
+	if published, _, err := s.publishedChecksum(ctx, entry.Kind, entry.Name, entry.Version); err != nil || published != "" {
+		return published, err
+	}
+	var content []byte
+	if entry.Version == current {
+		content, err = s.GetManifestRaw(ctx, entry.Kind, entry.Name)
+	} else {
+		content, err = s.GetVersionedManifestRaw(ctx, entry.Kind, entry.Name, entry.Version)
+	}
+	if err != nil {
+		return "", err
+	}
+	return contentHash(content), nil
+}
+
+// lockInstalled returns a lock of the installed items among needed, from
+// their install records. An item installed at a locked version with
+// different content is an error, so the old lock is kept.
+func (c *Client) lockInstalled(needed map[string]bool, old *Lock) (*Lock, error) {
+	names := make([]string, 0, len(needed))
+	for name := range needed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lock := &Lock{Items: []LockEntry{}}
+	for _, name := range names {
+		kind, itemName := ParseItemName(name)
+		if err := checkItemName(itemName); err != nil {
+			return nil, err
+		}
+		record, err := LoadInstallRecord(c.itemDir(kind, itemName))
+		if err != nil {
+			continue // Not installed, or installed by hand
+		}
+		if entry := old.entry(kind, itemName); entry != nil && entry.Version == record.Version && !strings.EqualFold(entry.SHA256, record.SHA256) {
+			return nil, &LockMismatchError{
+				Kind:       kind,
+				Name:       itemName,
+				Version:    record.Version,
+				Source:     record.Source,
+				Locked:     entry.SHA256,
+				LockedFrom: entry.Source,
+				Actual:     record.SHA256,
+			}
+		}
+		lock.Items = append(lock.Items, LockEntry{
+			Kind:    kind,
+			Name:    itemName,
+			Version: record.Version,
+			Source:  record.Source,
+			SHA256:  record.SHA256,
+		})
+	}
+	return lock, nil
+}
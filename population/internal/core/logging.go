@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LogLevelEnv is the environment variable the CLI reads a log level from
+// (debug, info, warn, or error). When set, diagnostics are written to stderr
+// as slog text at that level, instead of only warnings as plain lines.
+const LogLevelEnv = "VEGA_POPULATION_LOG"
+
+// WithLogger sends the client's diagnostics to logger: warnings, such as a
+// source being skipped or the cache failing to write, carry the error as an
+// "error" attribute; HTTP fetches and cache hits, misses, and writes are
+// logged at debug level. By default warnings are written to stderr and the
+// rest is dropped. A logger whose handler discards everything silences the
+// client.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// defaultLogger writes warnings and errors to stderr as plain lines.
+var defaultLogger = slog.New(newWarningHandler(os.Stderr))
+
+// warningHandler writes records of warning level and above as a line,
+// "Warning: <message>: <error>", with the "error" attribute if there is one,
+// leaving out the other attributes.
+type warningHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	attrs []slog.Attr
+}
+
+func newWarningHandler(w io.Writer) *warningHandler {
+	return &warningHandler{mu: &sync.Mutex{}, w: w}
+}
+
+func (h *warningHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn
+}
+
+func (h *warningHandler) Handle(_ context.Context, r slog.Record) error {
+	var line strings.Builder
+	if r.Level >= slog.LevelError {
+		line.WriteString("Error: ")
+	} else {
+		line.WriteString("Warning: ")
+	}
+	line.WriteString(r.Message)
+
+	var errValue any
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "error" {
+			errValue = a.Value.Any()
+		}
+		return errValue == nil
+	})
+	for _, a := range h.attrs {
+		if errValue == nil && a.Key == "error" {
+			errValue = a.Value.Any()
+		}
+	}
+	if errValue != nil {
+		fmt.Fprintf(&line, ": %v", errValue)
+	}
+	line.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line.String())
+	return err
+}
+
+func (h *warningHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &warningHandler{mu: h.mu, w: h.w, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *warningHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// parseLogLevel parses a level as LogLevelEnv gives it.
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("%s: unknown log level %q (want debug, info, warn, or error)", LogLevelEnv, s)
+	}
+	return level, nil
+}
+
+// log returns the source's logger.
+func (s *Source) log() *slog.Logger {
+	if s.logger == nil {
+		return defaultLogger
+	}
+	return s.logger
+}
+
+// log returns the cache's logger.
+func (c *Cache) log() *slog.Logger {
+	if c.logger == nil {
+		return defaultLogger
+	}
+	return c.logger
+}
+
+// log returns the client's logger.
+func (c *Client) log() *slog.Logger {
+	if c.logger == nil {
+		return defaultLogger
+	}
+	return c.logger
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestSchemaVersion is the newest manifest schema this client reads.
+// Manifests without a schema_version are version 1.
+const ManifestSchemaVersion = 1
+
+// manifestMediaTypePrefix and manifestMediaTypeSuffix surround the schema
+// version in the media type of a manifest, as in
+// application/vnd.vega-population.manifest.v1+yaml.
+const (
+	manifestMediaTypePrefix = "application/vnd.vega-population.manifest.v"
+	manifestMediaTypeSuffix = "+yaml"
+)
+
+// ErrManifestSchema is wrapped by the errors for manifests in a schema newer
+// than ManifestSchemaVersion, and for registries that no longer serve one
+// this client reads.
+var ErrManifestSchema = errors.New("unsupported manifest schema")
+
+// ManifestMediaType returns the media type of a manifest schema version.
+func ManifestMediaType(version int) string {
+	return manifestMediaTypePrefix + strconv.Itoa(version) + manifestMediaTypeSuffix
+}
+
+// manifestAccept is the Accept header sent for manifests: every schema this
+// client reads, newest first, then plain YAML from registries that don't
+// negotiate.
+var manifestAccept = func() string {
+	var types []string
+	for v := ManifestSchemaVersion; v >= 1; v-- {
+		types = append(types, ManifestMediaType(v))
+	}
+	return strings.Join(append(types, "application/yaml;q=0.9", "*/*;q=0.1"), ", ")
+}()
+
+// isManifestPath reports whether a source path or URL names a manifest.
+func isManifestPath(p string) bool {
+	return path.Base(p) == "vega.yaml"
+}
+
+// manifestSchemaOf parses the schema version of a media type, reporting
+// false for anything but a manifest media type.
+func manifestSchemaOf(mediaType string) (int, bool) {
+	mediaType, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return 0, false
+	}
+	rest, ok := strings.CutPrefix(mediaType, manifestMediaTypePrefix)
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, manifestMediaTypeSuffix)
+	if !ok {
+		return 0, false
+	}
+	version, err := strconv.Atoi(rest)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// checkManifestResponse checks that a manifest response is in a schema this
+// client reads, going by its Content-Type. Registries that don't negotiate
+// serve plain YAML, which checkManifestSchema checks once it is read.
+func checkManifestResponse(url string, resp *http.Response) error {
+	version, ok := manifestSchemaOf(resp.Header.Get("Content-Type"))
+	if ok && version > ManifestSchemaVersion {
+		return fmt.Errorf("fetching %s: %w: schema version %d is newer than this client reads (%d); upgrade vega", url, ErrManifestSchema, version, ManifestSchemaVersion)
+	}
+	return nil
+}
+
+// checkManifestSchema checks that a manifest's schema_version is one this
+// client reads. Content that isn't YAML is left for the caller to report.
+func checkManifestSchema(content []byte) error {
+	var header struct {
+		SchemaVersion int `yaml:"schema_version"`
+	}
+	if err := yaml.Unmarshal(content, &header); err != nil {
+		return nil
+	}
+	if header.SchemaVersion > ManifestSchemaVersion {
+		return fmt.Errorf("%w: schema version %d is newer than this client reads (%d); upgrade vega", ErrManifestSchema, header.SchemaVersion, ManifestSchemaVersion)
+	}
+	return nil
+}
+
+// negotiateManifest returns the Content-Type to serve a manifest with, given
+// the request's Accept header, or false if the client asks for manifest
+// schemas only and not the manifest's. Clients that don't name a manifest
+// schema get plain YAML, so older clients keep working.
+func negotiateManifest(accept string, content []byte) (string, bool) {
+	var header struct {
+		SchemaVersion int `yaml:"schema_version"`
+	}
+	yaml.Unmarshal(content, &header)
+	version := max(header.SchemaVersion, 1)
+
+	named := false
+	for _, part := range strings.Split(accept, ",") {
+		accepted, ok := manifestSchemaOf(strings.TrimSpace(part))
+		if !ok {
+			continue
+		}
+		named = true
+		if accepted == version {
+			return ManifestMediaType(version), true
+		}
+	}
+	if named {
+		return "", false
+	}
+	return "application/yaml", true
+}
@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MemorySource is a Registry holding its items in memory, for tests and
+// for programs that build their items at run time. Its indexes are derived
+// from the manifests, with the sha256 of each, so installs verify as they
+// would from a published registry. It is safe for concurrent use, and may be
+// changed while clients read it; use WithNoCache so they see changes at once.
+//
+//	src, err := registry.NewMemorySource(map[string]*population.Manifest{
+//	    "kubernetes-ops": {Version: "1.2.0", Description: "Kubernetes helpers"},
+//	    "@sre":           {Version: "2.0.0", SystemPrompt: population.Prompt{Text: "You are an SRE."}},
+//	})
+//	client, err := population.NewClient(registry.WithRegistry(src), population.WithNoCache())
+type MemorySource struct {
+	mu    sync.RWMutex
+	items map[requirementKey]*memoryItem
+	files map[string][]byte // By path from the registry root
+}
+
+// memoryItem is an item in a MemorySource.
+type memoryItem struct {
+	manifest *Manifest
+	content  []byte            // The current manifest, encoded
+	archived map[string][]byte // Older manifests, by version
+}
+
+// MemoryFile is a file a MemorySource serves besides indexes and manifests,
+// such as one listed in a manifest's files.
+type MemoryFile struct {
+	Path    string // From the registry root, e.g. "skills/kubernetes-ops/scripts/drain.sh"
+	Content []byte
+}
+
+// NewMemorySource returns a MemorySource serving manifests, keyed by item
+// name (name, @persona, +profile, or %settings), and files. A manifest's
+// kind and name are filled in from its key when empty.
+func NewMemorySource(manifests map[string]*Manifest, files ...MemoryFile) (*MemorySource, error) {
+	m := &MemorySource{
+		items: make(map[requirementKey]*memoryItem),
+		files: make(map[string][]byte),
+	}
+	for _, name := range sortedKeys(manifests) {
+		if err := m.Put(name, manifests[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range files {
+		if err := m.PutFile(f.Path, f.Content); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Put adds an item, or publishes a new version of it. The version it
+// replaces stays available as an older version, as in a registry.
+func (m *MemorySource) Put(name string, manifest *Manifest) error {
+	if err := ValidateItemName(name); err != nil {
+		return err
+	}
+	kind, itemName := ParseItemName(name)
+	if manifest == nil {
+		return fmt.Errorf("%s %q has no manifest", kind, itemName)
+	}
+	if manifest.Version == "" {
+		return fmt.Errorf("%s %q has no version", kind, itemName)
+	}
+
+	stored := *manifest
+	if stored.Kind == "" {
+		stored.Kind = string(kind)
+	}
+	if stored.Name == "" {
+		stored.Name = itemName
+	}
+	content, err := yaml.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("encoding %s %q: %w", kind, itemName, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := requirementKey{kind, itemName}
+	item := m.items[key]
+	if item == nil {
+		item = &memoryItem{archived: make(map[string][]byte)}
+		m.items[key] = item
+	} else if item.manifest.Version != stored.Version {
+		item.archived[item.manifest.Version] = item.content
+	}
+	delete(item.archived, stored.Version)
+	item.manifest, item.content = &stored, content
+	return nil
+}
+
+// PutFile adds or replaces a file, by its slash-separated path from the
+// registry root.
+func (m *MemorySource) PutFile(path string, content []byte) error {
+	if !fs.ValidPath(path) {
+		return fmt.Errorf("invalid file path %q", path)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path] = append([]byte(nil), content...)
+	return nil
+}
+
+// Remove removes an item and its older versions.
+func (m *MemorySource) Remove(name string) {
+	kind, itemName := ParseItemName(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, requirementKey{kind, itemName})
+}
+
+// GetIndex implements Registry.
+func (m *MemorySource) GetIndex(_ context.Context, kind ItemKind) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make(map[string]IndexEntry)
+	profiles := make(map[string]ProfileIndexEntry)
+	for key, item := range m.items {
+		if key.kind != kind {
+			continue
+		}
+		man := item.manifest
+		sums := map[string]string{man.Version: contentHash(item.content)}
+		var versions []string
+		for version, content := range item.archived {
+			sums[version] = contentHash(content)
+			versions = append(versions, version)
+		}
+		sort.SliceStable(versions, func(i, j int) bool { return CompareVersions(versions[i], versions[j]) < 0 })
+
+		if kind == KindProfile {
+			profiles[key.name] = ProfileIndexEntry{
+				Version:     man.Version,
+				Description: man.Description,
+				Author:      man.Author,
+				Maintainers: man.Maintainers,
+				Status:      man.Status,
+				Deprecated:  man.Deprecated,
+				Versions:    versions,
+				SHA256:      sums,
+				Persona:     man.Persona,
+				Skills:      man.Skills,
+			}
+			continue
+		}
+		var tools []string
+		for _, tool := range man.Tools {
+			tools = append(tools, tool.Name)
+		}
+		entries[key.name] = IndexEntry{
+			Version:     man.Version,
+			Description: man.Description,
+			Author:      man.Author,
+			Maintainers: man.Maintainers,
+			Status:      man.Status,
+			Deprecated:  man.Deprecated,
+			Versions:    versions,
+			SHA256:      sums,
+			Tags:        man.Tags,
+			Tools:       tools,
+		}
+	}
+
+	var index interface{} = map[string]map[string]IndexEntry{kind.Plural(): entries}
+	if kind == KindProfile {
+		index = map[string]map[string]ProfileIndexEntry{kind.Plural(): profiles}
+	}
+	content, err := yaml.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s index: %w", kind.Plural(), err)
+	}
+	return content, nil
+}
+
+// GetManifest implements Registry.
+func (m *MemorySource) GetManifest(_ context.Context, kind ItemKind, name, version string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item := m.items[requirementKey{kind, name}]
+	switch {
+	case item == nil:
+		return nil, fmt.Errorf("%s %q: %w", kind, name, fs.ErrNotExist)
+	case version == "" || version == item.manifest.Version:
+		return item.content, nil
+	}
+	content, ok := item.archived[version]
+	if !ok {
+		return nil, fmt.Errorf("%s %q version %s: %w", kind, name, version, fs.ErrNotExist)
+	}
+	return content, nil
+}
+
+// GetFile implements Registry.
+func (m *MemorySource) GetFile(_ context.Context, path string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	content, ok := m.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+	return content, nil
+}
+
+// String labels the source, uniquely in the process so that sources never
+// share cached content.
+func (m *MemorySource) String() string {
+	return fmt.Sprintf("memory:%p", m)
+}
@@ -0,0 +1,247 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// newMemoryClient returns a client installing into a fresh vega home from a
+// MemorySource serving manifests and files.
+func newMemoryClient(t *testing.T, manifests map[string]*Manifest, files ...MemoryFile) (*Client, *MemorySource) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv(HomeEnv, home)
+	src, err := NewMemorySource(manifests, files...)
+	if err != nil {
+		t.Fatalf("NewMemorySource: %v", err)
+	}
+	client, err := NewClient(WithRegistry(src), WithNoCache(), WithInstallDir(home))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client, src
+}
+
+// installedNames returns the names of the installed items, in List order.
+func installedNames(t *testing.T, client *Client) []string {
+	t.Helper()
+	items, err := client.List("")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, FormatItemName(item.Kind, item.Name)+"@"+item.Version)
+	}
+	return names
+}
+
+func TestMemorySourceIndex(t *testing.T) {
+	src, err := NewMemorySource(map[string]*Manifest{
+		"kubernetes-ops": {Version: "1.2.0", Description: "Kubernetes helpers", Tools: []Tool{{Name: "kubectl_get"}}},
+		"@sre":           {Version: "2.0.0", SystemPrompt: Prompt{Text: "You are an SRE."}},
+		"+platform":      {Version: "1.0.0", Persona: "sre", Skills: SkillRefs{{Name: "kubernetes-ops"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Put("kubernetes-ops", &Manifest{Version: "1.3.0", Description: "Kubernetes helpers"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	content, err := src.GetIndex(ctx, KindSkill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index struct {
+		Skills map[string]IndexEntry `yaml:"skills"`
+	}
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := index.Skills["kubernetes-ops"]
+	if !ok {
+		t.Fatalf("index has no kubernetes-ops: %s", content)
+	}
+	if entry.Version != "1.3.0" || !reflect.DeepEqual(entry.Versions, []string{"1.2.0"}) {
+		t.Errorf("kubernetes-ops is at %s with older versions %v, want 1.3.0 with [1.2.0]", entry.Version, entry.Versions)
+	}
+
+	// The published hashes are those of the manifests served
+	for version, want := range entry.SHA256 {
+		manifest, err := src.GetManifest(ctx, KindSkill, "kubernetes-ops", version)
+		if err != nil {
+			t.Fatalf("GetManifest %s: %v", version, err)
+		}
+		if got := contentHash(manifest); got != want {
+			t.Errorf("version %s hashes to %s, index says %s", version, got, want)
+		}
+	}
+
+	content, err = src.GetIndex(ctx, KindProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profiles struct {
+		Profiles map[string]ProfileIndexEntry `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(content, &profiles); err != nil {
+		t.Fatal(err)
+	}
+	if p := profiles.Profiles["platform"]; p.Persona != "sre" || len(p.Skills) != 1 {
+		t.Errorf("profile index entry = %+v, want persona sre and one skill", p)
+	}
+
+	src.Remove("kubernetes-ops")
+	if _, err := src.GetManifest(ctx, KindSkill, "kubernetes-ops", ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetManifest after Remove = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := src.GetManifest(ctx, KindPersona, "sre", "9.9.9"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetManifest of an unknown version = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestMemorySourcePutErrors(t *testing.T) {
+	src, err := NewMemorySource(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Put("kubernetes-ops", &Manifest{}); err == nil {
+		t.Error("Put without a version succeeded")
+	}
+	if err := src.Put("../escape", &Manifest{Version: "1.0.0"}); err == nil {
+		t.Error("Put with an invalid name succeeded")
+	}
+	if err := src.PutFile("../outside", []byte("x")); err == nil {
+		t.Error("PutFile with a path leaving the registry succeeded")
+	}
+	if _, err := src.GetFile(context.Background(), "skills/none/file"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetFile of a missing file = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestInstallFromMemorySource(t *testing.T) {
+	client, _ := newMemoryClient(t, map[string]*Manifest{
+		"kubernetes-ops": {Version: "1.2.0", Files: []ItemFile{{Path: "scripts/drain.sh"}}},
+		"@sre":           {Version: "2.0.0", SystemPrompt: Prompt{Text: "You are an SRE."}},
+		"+platform":      {Version: "1.0.0", Persona: "sre", Skills: SkillRefs{{Name: "kubernetes-ops"}}},
+	}, MemoryFile{Path: "skills/kubernetes-ops/scripts/drain.sh", Content: []byte("#!/bin/sh\n")})
+
+	if err := client.Install(context.Background(), "+platform", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	got := installedNames(t, client)
+	want := []string{"kubernetes-ops@1.2.0", "@sre@2.0.0", "+platform@1.0.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("installed %v, want %v", got, want)
+	}
+
+	dir := client.itemDir(KindSkill, "kubernetes-ops")
+	if content, err := os.ReadFile(filepath.Join(dir, "scripts", "drain.sh")); err != nil || string(content) != "#!/bin/sh\n" {
+		t.Errorf("extra file = %q, %v", content, err)
+	}
+	record, err := LoadInstallRecord(dir)
+	if err != nil {
+		t.Fatalf("LoadInstallRecord: %v", err)
+	}
+	if !record.Verified || record.Version != "1.2.0" {
+		t.Errorf("install record = %+v, want version 1.2.0 verified against the index", record)
+	}
+	if record.Files["scripts/drain.sh"] != contentHash([]byte("#!/bin/sh\n")) {
+		t.Errorf("install record files = %v, want the hash of scripts/drain.sh", record.Files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ".vega-install.json"))
+	if err != nil {
+		t.Fatalf("reading install record: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(content, &fields); err != nil {
+		t.Fatalf("install record is not JSON: %v", err)
+	}
+	for _, key := range []string{"source", "version", "sha256", "installed_at", "installed_by"} {
+		if fields[key] == nil || fields[key] == "" {
+			t.Errorf("install record has no %s: %s", key, content)
+		}
+	}
+}
+
+func TestInstallProfileVersionDeps(t *testing.T) {
+	client, src := newMemoryClient(t, map[string]*Manifest{
+		"@alpha":         {Version: "1.0.0"},
+		"@beta":          {Version: "1.0.0"},
+		"kubernetes-ops": {Version: "1.0.0"},
+		"docker-ops":     {Version: "1.0.0"},
+		"+platform":      {Version: "1.0.0", Persona: "alpha", Skills: SkillRefs{{Name: "kubernetes-ops"}}},
+	})
+	if err := src.Put("+platform", &Manifest{Version: "2.0.0", Persona: "beta", Skills: SkillRefs{{Name: "docker-ops"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Install(context.Background(), "+platform", &InstallOptions{Version: "1.0.0"}); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	got := installedNames(t, client)
+	want := []string{"kubernetes-ops@1.0.0", "@alpha@1.0.0", "+platform@1.0.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("installed %v, want the dependencies of +platform 1.0.0: %v", got, want)
+	}
+}
+
+func TestUpgradeFromMemorySource(t *testing.T) {
+	client, src := newMemoryClient(t, map[string]*Manifest{
+		"kubernetes-ops": {Version: "1.2.0"},
+	})
+	ctx := context.Background()
+	if err := client.Install(ctx, "kubernetes-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if err := src.Put("kubernetes-ops", &Manifest{Version: "1.3.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	outdated, err := client.Outdated(ctx)
+	if err != nil {
+		t.Fatalf("Outdated: %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].Installed != "1.2.0" || outdated[0].Latest != "1.3.0" {
+		t.Fatalf("Outdated = %+v, want kubernetes-ops 1.2.0 -> 1.3.0", outdated)
+	}
+
+	// A pinned install of the replaced version is served from the archive
+	if err := client.Install(ctx, "kubernetes-ops", &InstallOptions{Version: "1.2.0", Force: true}); err != nil {
+		t.Fatalf("Install 1.2.0: %v", err)
+	}
+	if got := installedNames(t, client); !reflect.DeepEqual(got, []string{"kubernetes-ops@1.2.0"}) {
+		t.Errorf("installed %v, want kubernetes-ops@1.2.0", got)
+	}
+}
+
+func TestSearchMemorySource(t *testing.T) {
+	client, _ := newMemoryClient(t, map[string]*Manifest{
+		"kubernetes-ops": {Version: "1.2.0", Description: "Kubernetes cluster operations"},
+		"docker-ops":     {Version: "1.0.0", Description: "Docker container management"},
+		"@sre":           {Version: "2.0.0", Description: "Site reliability engineer for Kubernetes"},
+	})
+
+	results, err := client.Search(context.Background(), "kubernetes", nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	var names []string
+	for _, r := range results {
+		names = append(names, FormatItemName(r.Kind, r.Name))
+	}
+	if len(names) != 2 || names[0] != "kubernetes-ops" || names[1] != "@sre" {
+		t.Errorf("Search found %v, want kubernetes-ops then @sre", names)
+	}
+}
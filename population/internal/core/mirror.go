@@ -0,0 +1,380 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ChecksumsFile is the integrity file written at the root of a published mirror.
+	// It uses the sha256sum format, so mirrors can also be checked with `sha256sum -c`.
+	ChecksumsFile = "SHA256SUMS"
+
+	// SignatureFile holds the optional Ed25519 signature of ChecksumsFile.
+	SignatureFile = ChecksumsFile + ".sig"
+)
+
+// MirrorOptions configures mirror publishing.
+type MirrorOptions struct {
+	SigningKey ed25519.PrivateKey // Sign the checksums file (optional)
+}
+
+// MirrorReport summarizes a published mirror.
+type MirrorReport struct {
+	Dir     string
+	Files   int
+	Signed  bool
+	Missing []string // Indexed items (or item@version) whose manifest could not be fetched
+}
+
+// MirrorMismatch describes a file that differs between a mirror and its upstream.
+type MirrorMismatch struct {
+	Path   string
+	Reason string
+}
+
+// Mirror writes a static, integrity-annotated copy of the source to dest.
+// The result contains every index, manifest, and item file, of older versions
+// too, in the source layout, a SHA256SUMS file, and optionally a signature,
+// and can be served by any static file server (including GitHub Pages) or
+// used directly as a source, such as on a network without internet access.
+func (c *Client) Mirror(ctx context.Context, dest string, opts *MirrorOptions) (*MirrorReport, error) {
+	if opts == nil {
+		opts = &MirrorOptions{}
+	}
+
+	// Straight from the source: the daemon would answer from its cache
+	source := c.directSource(c.Sources()[0])
+	files, missing, err := source.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var sums bytes.Buffer
+	for _, path := range paths {
+		if err := checkMirrorPath(path); err != nil {
+			return nil, err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("creating directory: %w", err)
+		}
+		if err := os.WriteFile(target, files[path], 0644); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", path, err)
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", contentHash(files[path]), path)
+	}
+
+	if err := os.WriteFile(filepath.Join(dest, ChecksumsFile), sums.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("writing checksums: %w", err)
+	}
+
+	// Keep GitHub Pages from running the mirror through Jekyll
+	if err := os.WriteFile(filepath.Join(dest, ".nojekyll"), nil, 0644); err != nil {
+		return nil, fmt.Errorf("writing .nojekyll: %w", err)
+	}
+
+	report := &MirrorReport{
+		Dir:     dest,
+		Files:   len(paths),
+		Missing: missing,
+	}
+
+	if opts.SigningKey != nil {
+		sig := Sign(opts.SigningKey, sums.Bytes())
+		if err := os.WriteFile(filepath.Join(dest, SignatureFile), []byte(sig+"\n"), 0644); err != nil {
+			return nil, fmt.Errorf("writing signature: %w", err)
+		}
+		report.Signed = true
+	}
+
+	return report, nil
+}
+
+// VerifyMirror checks that a published mirror is internally consistent and
+// matches the client's source. If key is non-nil, the checksums signature is
+// verified as well. An empty result means the mirror is identical to upstream.
+func (c *Client) VerifyMirror(ctx context.Context, dir string, key ed25519.PublicKey) ([]MirrorMismatch, error) {
+	sums, err := os.ReadFile(filepath.Join(dir, ChecksumsFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading checksums: %w", err)
+	}
+
+	if key != nil {
+		sig, err := os.ReadFile(filepath.Join(dir, SignatureFile))
+		if err != nil {
+			return nil, fmt.Errorf("reading signature: %w", err)
+		}
+		if err := VerifySignature(key, sums, string(sig)); err != nil {
+			return nil, fmt.Errorf("verifying %s: %w", ChecksumsFile, err)
+		}
+	}
+
+	recorded, err := parseChecksums(sums)
+	if err != nil {
+		return nil, err
+	}
+
+	source := c.directSource(c.Sources()[0])
+	upstream, _, err := source.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []MirrorMismatch
+
+	paths := make([]string, 0, len(recorded))
+	for path := range recorded {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := checkMirrorPath(path); err != nil {
+			mismatches = append(mismatches, MirrorMismatch{Path: path, Reason: "invalid path"})
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			mismatches = append(mismatches, MirrorMismatch{Path: path, Reason: "missing from mirror"})
+			continue
+		}
+		if contentHash(content) != recorded[path] {
+			mismatches = append(mismatches, MirrorMismatch{Path: path, Reason: "does not match recorded checksum"})
+			continue
+		}
+
+		upstreamContent, ok := upstream[path]
+		if !ok {
+			mismatches = append(mismatches, MirrorMismatch{Path: path, Reason: "no longer present upstream"})
+			continue
+		}
+		if contentHash(upstreamContent) != recorded[path] {
+			mismatches = append(mismatches, MirrorMismatch{Path: path, Reason: "differs from upstream"})
+		}
+	}
+
+	upstreamPaths := make([]string, 0, len(upstream))
+	for path := range upstream {
+		upstreamPaths = append(upstreamPaths, path)
+	}
+	sort.Strings(upstreamPaths)
+
+	for _, path := range upstreamPaths {
+		if _, ok := recorded[path]; !ok {
+			mismatches = append(mismatches, MirrorMismatch{Path: path, Reason: "missing from mirror"})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// snapshot fetches every index and manifest from the source, bypassing the cache.
+// The result maps slash-separated source paths to their content. Indexed items
+// whose manifest cannot be fetched are returned separately rather than failing
+// the whole snapshot.
+func (s *Source) snapshot(ctx context.Context) (map[string][]byte, []string, error) {
+	files := make(map[string][]byte)
+	var missing []string
+
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		indexPath := kind.Plural() + "/index.yaml"
+		content, err := s.fetchIndex(ctx, kind)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetching %s index: %w", kind.Plural(), err)
+		}
+		files[indexPath] = content
+
+		entries, profiles, err := s.parseIndex(content, kind)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		versions := make(map[string][]string)
+		signatures := make(map[string]map[string]SignatureRef)
+		for name, entry := range entries {
+			versions[name], signatures[name] = entry.Versions, entry.Signatures
+		}
+		for name, entry := range profiles {
+			versions[name], signatures[name] = entry.Versions, entry.Signatures
+		}
+
+		var names []string
+		for name := range versions {
+			if err := checkItemName(name); err != nil {
+				return nil, nil, fmt.Errorf("%s index: %w", kind.Plural(), err)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			// Map each manifest path to the name reported if it is missing
+			paths := map[string]string{
+				fmt.Sprintf("%s/%s/vega.yaml", kind.Plural(), name): FormatItemName(kind, name),
+			}
+			for _, version := range versions[name] {
+				if !isVersion(version) {
+					return nil, nil, fmt.Errorf("%s index: %s has invalid version %q", kind.Plural(), FormatItemName(kind, name), version)
+				}
+				paths[versionedManifestPath(kind, name, version)] = FormatItemName(kind, name) + "@" + version
+			}
+			for version, ref := range signatures[name] {
+				paths[ref.Path] = FormatItemName(kind, name) + "@" + version + " signature"
+			}
+			for path := range paths {
+				if err := checkMirrorPath(path); err != nil {
+					return nil, nil, fmt.Errorf("%s index: %w", kind.Plural(), err)
+				}
+			}
+
+			for path, item := range paths {
+				content, err := s.fetch(ctx, path)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, nil, ctx.Err()
+					}
+					missing = append(missing, item)
+					continue
+				}
+				files[path] = content
+			}
+
+			// Extra files listed by the item's manifest
+			manifestPath := fmt.Sprintf("%s/%s/vega.yaml", kind.Plural(), name)
+			if content, ok := files[manifestPath]; ok {
+				extra, err := s.fetchManifestFiles(ctx, kind, name, content)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, nil, ctx.Err()
+					}
+					missing = append(missing, FormatItemName(kind, name)+" files")
+					continue
+				}
+				for rel, content := range extra {
+					files[fmt.Sprintf("%s/%s/%s", kind.Plural(), name, rel)] = content
+				}
+			}
+
+			// Older versions keep their files next to their manifest
+			for _, version := range versions[name] {
+				content, ok := files[versionedManifestPath(kind, name, version)]
+				if !ok {
+					continue
+				}
+				extra, err := s.fetchVersionFiles(ctx, kind, name, version, content)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, nil, ctx.Err()
+					}
+					missing = append(missing, FormatItemName(kind, name)+"@"+version+" files")
+					continue
+				}
+				for path, content := range extra {
+					files[path] = content
+				}
+			}
+		}
+	}
+
+	// The style guide is optional
+	if content, err := s.fetch(ctx, StyleFile); err == nil {
+		files[StyleFile] = content
+	} else if !isNotFoundError(err) {
+		return nil, nil, fmt.Errorf("fetching %s: %w", StyleFile, err)
+	}
+
+	sort.Strings(missing)
+	return files, missing, nil
+}
+
+// fetchVersionFiles fetches the extra files listed by the manifest of an older
+// version of an item, archived under its versions/<version>/ directory, keyed
+// by their paths in the source.
+func (s *Source) fetchVersionFiles(ctx context.Context, kind ItemKind, name, version string, content []byte) (map[string][]byte, error) {
+	var manifest struct {
+		Files []ItemFile `yaml:"files"`
+	}
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing %s %q %s: %w", kind, name, version, err)
+	}
+
+	fetched := make(map[string][]byte, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if err := checkItemFilePath(f.Path); err != nil {
+			return nil, fmt.Errorf("%s %q %s: %w", kind, name, version, err)
+		}
+		source := fmt.Sprintf("%s/%s/versions/%s/%s", kind.Plural(), name, version, path.Clean(f.Path))
+		content, err := s.fetch(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", source, err)
+		}
+		if f.SHA256 != "" && !strings.EqualFold(f.SHA256, contentHash(content)) {
+			return nil, fmt.Errorf("%s does not match its sha256", source)
+		}
+		fetched[source] = content
+	}
+	return fetched, nil
+}
+
+// checkMirrorPath rejects a source path that is not clean and relative, and
+// so could be written or read outside the mirror directory.
+func checkMirrorPath(p string) error {
+	clean := path.Clean(p)
+	switch {
+	case p == "" || strings.ContainsAny(p, "\\\x00") || path.IsAbs(p) || filepath.IsAbs(p) || filepath.VolumeName(p) != "":
+		return fmt.Errorf("invalid mirror path %q", p)
+	case clean == ".." || strings.HasPrefix(clean, "../"):
+		return fmt.Errorf("mirror path %q leaves the mirror directory", p)
+	case clean != p:
+		return fmt.Errorf("mirror path %q is not clean", p)
+	}
+	return nil
+}
+
+// parseChecksums parses a sha256sum-formatted file into a path -> hash map.
+func parseChecksums(content []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, path, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		sums[path] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading checksums: %w", err)
+	}
+
+	return sums, nil
+}
+
+// contentHash returns the hex-encoded SHA-256 of content.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
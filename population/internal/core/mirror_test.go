@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSource writes a local source of the given files, with an empty index
+// for every kind that has none.
+func writeSource(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		index := kind.Plural() + "/index.yaml"
+		if _, ok := files[index]; !ok {
+			files[index] = kind.Plural() + ": {}\n"
+		}
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMirrorRejectsHostileIndex(t *testing.T) {
+	t.Setenv(HomeEnv, t.TempDir())
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeSource(t, src, map[string]string{
+		"skills/index.yaml": "skills:\n  ../../x:\n    version: 1.0.0\n",
+	})
+	// What the hostile entry's manifest path reaches from the source
+	writeSource(t, filepath.Join(root, "x"), map[string]string{"vega.yaml": "name: x\nversion: 1.0.0\n"})
+
+	client, err := NewClient(WithSource(src), WithNoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(root, "out", "mirror")
+	if _, err := client.Mirror(context.Background(), dest, nil); err == nil {
+		t.Error("Mirror of a hostile index succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "x")); err == nil {
+		t.Error("Mirror wrote outside the mirror directory")
+	}
+}
+
+func TestVerifyMirrorRejectsHostileChecksums(t *testing.T) {
+	t.Setenv(HomeEnv, t.TempDir())
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeSource(t, src, map[string]string{})
+
+	client, err := NewClient(WithSource(src), WithNoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "mirror")
+	if _, err := client.Mirror(context.Background(), dir, nil); err != nil {
+		t.Fatalf("Mirror: %v", err)
+	}
+
+	secret := []byte("secret\n")
+	if err := os.WriteFile(filepath.Join(root, "secret"), secret, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sums, err := os.ReadFile(filepath.Join(dir, ChecksumsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums = append(sums, contentHash(secret)+"  ../secret\n"...)
+	if err := os.WriteFile(filepath.Join(dir, ChecksumsFile), sums, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mismatches, err := client.VerifyMirror(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("VerifyMirror: %v", err)
+	}
+	if len(mismatches) != 1 || mismatches[0].Path != "../secret" || mismatches[0].Reason != "invalid path" {
+		t.Errorf("mismatches = %+v, want only ../secret with an invalid path", mismatches)
+	}
+}
+
+func TestMirrorRejectsHostileVersionsAndSignatures(t *testing.T) {
+	t.Setenv(HomeEnv, t.TempDir())
+	for _, entry := range []string{
+		"versions: [\"../../../../x\"]",
+		"versions: [\"1.0.0-/../../../../../x\"]",
+		"signatures:\n      1.0.0: {type: ssh, path: ../x/vega.yaml}",
+		"signatures:\n      1.0.0: {type: ssh, path: /etc/passwd}",
+	} {
+		root := t.TempDir()
+		src := filepath.Join(root, "src")
+		writeSource(t, src, map[string]string{
+			"skills/index.yaml":           "skills:\n  deploy-ops:\n    version: 1.0.0\n    " + entry + "\n",
+			"skills/deploy-ops/vega.yaml": "name: deploy-ops\nversion: 1.0.0\n",
+		})
+		writeSource(t, filepath.Join(root, "x"), map[string]string{"vega.yaml": "name: x\nversion: 1.0.0\n"})
+
+		client, err := NewClient(WithSource(src), WithNoCache())
+		if err != nil {
+			t.Fatal(err)
+		}
+		dest := filepath.Join(root, "out", "mirror")
+		if _, err := client.Mirror(context.Background(), dest, nil); err == nil {
+			t.Errorf("Mirror of index entry %q succeeded", entry)
+		}
+		if _, err := os.Stat(filepath.Join(root, "out", "x")); err == nil {
+			t.Errorf("Mirror of index entry %q wrote outside the mirror directory", entry)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// NotificationsFile stores notification state, relative to the vega home.
+const NotificationsFile = "notifications.json"
+
+// OutdatedItem is an installed item with a newer version available upstream.
+type OutdatedItem struct {
+	Kind      ItemKind `json:"kind" yaml:"kind"`
+	Name      string   `json:"name" yaml:"name"`
+	Installed string   `json:"installed" yaml:"installed"`
+	Latest    string   `json:"latest" yaml:"latest"`
+
+	Files []FileChange `json:"files,omitempty" yaml:"files,omitempty"` // With UpgradeOptions.DryRun, the files upgrading would change
+}
+
+// Notification is a message queued for display on the next CLI run.
+type Notification struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// NotifyOptions configures the channels used to announce upstream changes.
+// Notifications are always queued for display on the next CLI run.
+type NotifyOptions struct {
+	Webhook string // URL that receives a JSON POST of the new updates (optional)
+	Desktop bool   // Show a desktop notification (notify-send or osascript)
+}
+
+// notifyState is persisted between runs to avoid repeating notifications.
+type notifyState struct {
+	Notified map[string]string `json:"notified"`          // Item -> version already announced
+	Seen     map[string]string `json:"seen,omitempty"`    // Item -> version at the last whatsnew check
+	Pending  []Notification    `json:"pending,omitempty"` // Queued for the next CLI run
+}
+
+// Outdated returns installed items whose source version is newer than the installed one.
+func (c *Client) Outdated(ctx context.Context) ([]OutdatedItem, error) {
+	installed, err := c.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	var outdated []OutdatedItem
+
+	for _, item := range installed {
+		source, err := c.resolveSource(ctx, item.Kind, item.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// Installed items that no source knows about can't be outdated
+			continue
+		}
+		latest, ok, err := source.latestVersion(ctx, item.Kind, item.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if CompareVersions(latest, item.Version) > 0 {
+			outdated = append(outdated, OutdatedItem{
+				Kind:      item.Kind,
+				Name:      item.Name,
+				Installed: item.Version,
+				Latest:    latest,
+			})
+		}
+	}
+
+	return outdated, nil
+}
+
+// WhatsNew returns items whose source version changed since the previous call.
+// The first call records the current versions and reports nothing.
+func (c *Client) WhatsNew(ctx context.Context) ([]SearchResult, error) {
+	state, err := c.loadNotifyState()
+	if err != nil {
+		return nil, err
+	}
+
+	source := c.newSource()
+	first := state.Seen == nil
+	seen := make(map[string]string)
+	var changed []SearchResult
+
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		entries, profiles, err := source.getIndex(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		var results []SearchResult
+		for name, entry := range entries {
+			results = append(results, SearchResult{Kind: kind, Name: name, Version: entry.Version, Description: entry.Description, Tags: entry.Tags})
+		}
+		for name, entry := range profiles {
+			results = append(results, SearchResult{Kind: kind, Name: name, Version: entry.Version, Description: entry.Description})
+		}
+
+		for _, r := range results {
+			key := FormatItemName(r.Kind, r.Name)
+			seen[key] = r.Version
+			if !first && state.Seen[key] != r.Version {
+				changed = append(changed, r)
+			}
+		}
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return FormatItemName(changed[i].Kind, changed[i].Name) < FormatItemName(changed[j].Kind, changed[j].Name)
+	})
+
+	state.Seen = seen
+	if err := c.saveNotifyState(state); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// NotifyUpdates detects installed items with new upstream versions and announces
+// the ones that haven't been announced yet. It returns the newly announced items.
+// If the webhook or desktop notification fails, none of them are recorded as
+// announced, so the next call tries again.
+func (c *Client) NotifyUpdates(ctx context.Context, opts *NotifyOptions) ([]OutdatedItem, error) {
+	if opts == nil {
+		opts = &NotifyOptions{}
+	}
+
+	outdated, err := c.Outdated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.loadNotifyState()
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []OutdatedItem
+	for _, item := range outdated {
+		key := FormatItemName(item.Kind, item.Name)
+		if state.Notified[key] == item.Latest {
+			continue
+		}
+		state.Notified[key] = item.Latest
+		fresh = append(fresh, item)
+		state.Pending = append(state.Pending, Notification{
+			Time:    time.Now(),
+			Message: fmt.Sprintf("%s %s is available (installed: %s)", key, item.Latest, item.Installed),
+		})
+	}
+
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+
+	// Record the items as announced only once they have been, so a failed
+	// delivery is retried on the next check
+	if opts.Webhook != "" {
+		if err := c.postWebhook(ctx, opts.Webhook, fresh); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Desktop {
+		if err := desktopNotify(fmt.Sprintf("%d population update(s) available", len(fresh))); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.saveNotifyState(state); err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+// PendingNotifications returns queued notifications and clears the queue.
+func (c *Client) PendingNotifications() ([]Notification, error) {
+	state, err := c.loadNotifyState()
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Pending) == 0 {
+		return nil, nil
+	}
+
+	pending := state.Pending
+	state.Pending = nil
+	if err := c.saveNotifyState(state); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// latestVersion returns the version of an item in the source index.
+func (s *Source) latestVersion(ctx context.Context, kind ItemKind, name string) (string, bool, error) {
+	entries, profiles, err := s.getIndex(ctx, kind)
+	if err != nil {
+		return "", false, err
+	}
+
+	if kind == KindProfile {
+		entry, ok := profiles[name]
+		return entry.Version, ok, nil
+	}
+	entry, ok := entries[name]
+	return entry.Version, ok, nil
+}
+
+func (c *Client) loadNotifyState() (*notifyState, error) {
+	state := &notifyState{}
+
+	content, err := os.ReadFile(filepath.Join(c.home, NotificationsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading notification state: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, state); err != nil {
+			return nil, fmt.Errorf("parsing notification state: %w", err)
+		}
+	}
+
+	if state.Notified == nil {
+		state.Notified = make(map[string]string)
+	}
+	return state, nil
+}
+
+func (c *Client) saveNotifyState(state *notifyState) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding notification state: %w", err)
+	}
+
+	if err := os.MkdirAll(c.home, 0755); err != nil {
+		return fmt.Errorf("creating vega home: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.home, NotificationsFile), content, 0644); err != nil {
+		return fmt.Errorf("writing notification state: %w", err)
+	}
+	return nil
+}
+
+// postWebhook posts items to a webhook with the client's HTTP client and timeout.
+func (c *Client) postWebhook(ctx context.Context, url string, items []OutdatedItem) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event":   "population.updates",
+		"updates": items,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, cancel, err := c.http.do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("posting webhook: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func desktopNotify(message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title \"Vega\"", message))
+	case "linux":
+		cmd = exec.Command("notify-send", "Vega", message)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("showing desktop notification: %w", err)
+	}
+	return nil
+}
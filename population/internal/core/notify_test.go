@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotifyUpdatesRetriesFailedWebhook(t *testing.T) {
+	client, src := newMemoryClient(t, map[string]*Manifest{"deploy-ops": {Version: "1.0.0"}})
+	ctx := context.Background()
+	if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if err := src.Put("deploy-ops", &Manifest{Version: "1.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	status := http.StatusInternalServerError
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	opts := &NotifyOptions{Webhook: server.URL}
+
+	if _, err := client.NotifyUpdates(ctx, opts); err == nil {
+		t.Fatal("NotifyUpdates succeeded with a failing webhook")
+	}
+
+	status = http.StatusOK
+	fresh, err := client.NotifyUpdates(ctx, opts)
+	if err != nil {
+		t.Fatalf("NotifyUpdates: %v", err)
+	}
+	if len(fresh) != 1 || fresh[0].Latest != "1.1.0" || posts != 2 {
+		t.Errorf("retry announced %+v in %d posts, want deploy-ops 1.1.0 in 2", fresh, posts)
+	}
+
+	// Announced now, so not again
+	if fresh, err := client.NotifyUpdates(ctx, opts); err != nil || len(fresh) != 0 || posts != 2 {
+		t.Errorf("third check announced %+v (%v) in %d posts", fresh, err, posts)
+	}
+}
+
+func TestNotifyUpdatesWebhookTimeout(t *testing.T) {
+	client, src := newMemoryClient(t, map[string]*Manifest{"deploy-ops": {Version: "1.0.0"}})
+	ctx := context.Background()
+	if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if err := src.Put("deploy-ops", &Manifest{Version: "1.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client.http.timeout = 50 * time.Millisecond
+	if _, err := client.NotifyUpdates(ctx, &NotifyOptions{Webhook: server.URL}); err == nil {
+		t.Error("NotifyUpdates waited out a webhook that never answered")
+	}
+}
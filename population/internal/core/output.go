@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat selects how CLI commands print their results.
+type OutputFormat string
+
+// Output formats.
+const (
+	OutputTable OutputFormat = "table" // Human-readable text (default)
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+// outputCommands are the commands that accept --output.
+var outputCommands = map[string]bool{
+	"search":    true,
+	"list":      true,
+	"ls":        true,
+	"info":      true,
+	"install":   true,
+	"outdated":  true,
+	"deps":      true,
+	"why":       true,
+	"gc":        true,
+	"sync":      true,
+	"update-pr": true,
+	"doctor":    true,
+	"budget":    true,
+	"status":    true,
+}
+
+// ParseOutputFormat parses an output format name.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(s); f {
+	case "":
+		return OutputTable, nil
+	case OutputTable, OutputJSON, OutputYAML:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (use json, yaml, or table)", s)
+	}
+}
+
+// Structured reports whether the format is machine-readable.
+func (f OutputFormat) Structured() bool {
+	return f == OutputJSON || f == OutputYAML
+}
+
+// writeOutput prints v to w as JSON or YAML.
+func writeOutput(w io.Writer, format OutputFormat, v interface{}) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("output format %q is not structured", format)
+	}
+}
+
+// splitGlobalSession removes global --record and --replay flags given
+// before the command, returning the remaining arguments and the session
+// files ("" if none).
+func splitGlobalSession(args []string) (rest []string, record, replay string, err error) {
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		if name != "--record" && name != "--replay" {
+			break
+		}
+		args = args[1:]
+		if !hasValue {
+			if len(args) == 0 {
+				return nil, "", "", fmt.Errorf("%s requires a session file", name)
+			}
+			value, args = args[0], args[1:]
+		}
+		if name == "--record" {
+			record = value
+		} else {
+			replay = value
+		}
+	}
+	if record != "" && replay != "" {
+		return nil, "", "", fmt.Errorf("--record and --replay cannot be combined")
+	}
+	return args, record, replay, nil
+}
+
+// splitGlobalOutput removes a global --output flag given before the command,
+// returning the remaining arguments and the format ("" if none).
+func splitGlobalOutput(args []string) ([]string, string, error) {
+	if len(args) == 0 {
+		return args, "", nil
+	}
+	if value, ok := strings.CutPrefix(args[0], "--output="); ok {
+		return args[1:], value, nil
+	}
+	if args[0] == "--output" {
+		if len(args) < 2 {
+			return nil, "", fmt.Errorf("--output requires a format (json, yaml, or table)")
+		}
+		return args[2:], args[1], nil
+	}
+	return args, "", nil
+}
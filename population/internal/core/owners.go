@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OwnershipViolation is an index change made by someone who doesn't maintain the item.
+type OwnershipViolation struct {
+	Kind        ItemKind
+	Name        string
+	Change      string   // "added", "changed", or "removed"
+	Maintainers []string // Who may change the item
+}
+
+// CheckOwnership compares the registry's indexes with a base version and
+// reports every changed item that identity is not allowed to change.
+//
+// An item may be changed by its maintainers, or by its author when it lists
+// no maintainers. New items are checked against their own entry. base is
+// either a directory containing another copy of the registry or a git
+// revision of the registry checkout (for example "origin/main").
+func (r *LocalRegistry) CheckOwnership(base, identity string) ([]OwnershipViolation, error) {
+	if identity == "" {
+		return nil, fmt.Errorf("an author identity is required to check ownership")
+	}
+
+	var violations []OwnershipViolation
+
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile} {
+		current, err := os.ReadFile(filepath.Join(r.dir, kind.Plural(), "index.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("reading index: %w", err)
+		}
+		previous, err := r.readBaseIndex(base, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		head, err := parseIndexEntries(current, kind)
+		if err != nil {
+			return nil, err
+		}
+		old, err := parseIndexEntries(previous, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make(map[string]bool)
+		for name := range head {
+			names[name] = true
+		}
+		for name := range old {
+			names[name] = true
+		}
+
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+
+		for _, name := range sorted {
+			before, existed := old[name]
+			after, exists := head[name]
+
+			var change string
+			owners := entryOwners(before)
+			switch {
+			case !existed:
+				change = "added"
+				owners = entryOwners(after)
+			case !exists:
+				change = "removed"
+			case !reflect.DeepEqual(before, after):
+				change = "changed"
+			default:
+				continue
+			}
+
+			if !containsFold(owners, identity) {
+				violations = append(violations, OwnershipViolation{
+					Kind:        kind,
+					Name:        name,
+					Change:      change,
+					Maintainers: owners,
+				})
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// readBaseIndex reads an index from a base directory or git revision.
+func (r *LocalRegistry) readBaseIndex(base string, kind ItemKind) ([]byte, error) {
+	indexPath := kind.Plural() + "/index.yaml"
+
+	if info, err := os.Stat(base); err == nil && info.IsDir() {
+		content, err := os.ReadFile(filepath.Join(base, filepath.FromSlash(indexPath)))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading base index: %w", err)
+		}
+		return content, nil
+	}
+
+	// A revision git would read as an option, such as "--output=<file>"
+	if strings.HasPrefix(base, "-") {
+		return nil, fmt.Errorf("invalid base revision %q: starts with a dash", base)
+	}
+	cmd := exec.Command("git", "show", base+":"+indexPath)
+	cmd.Dir = r.dir
+	content, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s at %s: %w", indexPath, base, err)
+	}
+	return content, nil
+}
+
+// parseIndexEntries parses an index into generic entries for comparison.
+func parseIndexEntries(content []byte, kind ItemKind) (map[string]map[string]interface{}, error) {
+	var doc map[string]map[string]map[string]interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s index: %w", kind.Plural(), err)
+	}
+	return doc[kind.Plural()], nil
+}
+
+// entryOwners returns the maintainers of an index entry, falling back to its author.
+func entryOwners(entry map[string]interface{}) []string {
+	var owners []string
+	if list, ok := entry["maintainers"].([]interface{}); ok {
+		for _, m := range list {
+			if s, ok := m.(string); ok {
+				owners = append(owners, s)
+			}
+		}
+	}
+	if len(owners) == 0 {
+		if author, ok := entry["author"].(string); ok && author != "" {
+			owners = append(owners, author)
+		}
+	}
+	return owners
+}
+
+// containsFold reports whether list contains s, ignoring case and a leading @.
+func containsFold(list []string, s string) bool {
+	s = strings.TrimPrefix(s, "@")
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimPrefix(item, "@"), s) {
+			return true
+		}
+	}
+	return false
+}
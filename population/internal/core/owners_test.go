@@ -0,0 +1,24 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckOwnershipRejectsOptionBase(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, map[string]string{})
+	registry, err := OpenLocalRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "written")
+	if _, err := registry.CheckOwnership("--output="+out, "alice"); err == nil {
+		t.Error("CheckOwnership accepted a base starting with a dash")
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("git read the base as an option")
+	}
+}
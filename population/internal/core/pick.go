@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxPickCandidates bounds the items offered by the picker.
+const maxPickCandidates = 20
+
+// interactive reports whether the CLI is talking to a person at a terminal,
+// and so may ask questions.
+func (cl *cli) interactive() bool {
+	return isTerminal(cl.stdin) && isTerminal(cl.stderr)
+}
+
+// isTerminal reports whether v is a file open on a terminal.
+func isTerminal(v interface{}) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// pickItem resolves the name of an item that no source has by letting the
+// user pick from the items it matches, at a terminal. Otherwise, or when it
+// matches nothing, the name is returned as it is, to be reported missing.
+func (cl *cli) pickItem(client *Client, name string) (string, error) {
+	if !cl.interactive() {
+		return name, nil
+	}
+	base, version := SplitVersion(name)
+	kind, itemName := ParseItemName(base)
+	if client.hasItem(cl.ctx, kind, itemName) {
+		return name, nil
+	}
+
+	opts := &SearchOptions{Limit: maxPickCandidates}
+	if base != itemName {
+		opts.Kind = kind // Prefixed, so the kind is known
+	}
+	results, err := client.Search(cl.ctx, itemName, opts)
+	if err != nil || len(results) == 0 {
+		return name, nil
+	}
+
+	choice, err := cl.pick(fmt.Sprintf("No item is named %q. Did you mean:", base), results)
+	if err != nil {
+		return "", err
+	}
+	if choice == nil {
+		return "", fmt.Errorf("no item picked for %q", base)
+	}
+	picked := FormatItemName(choice.Kind, choice.Name)
+	if version != "" {
+		picked += "@" + version
+	}
+	return picked, nil
+}
+
+// pick asks the user to choose one of candidates on stderr, reading answers
+// from stdin a line at a time: a number picks an item, and other text
+// narrows the list to the names it fuzzily matches. It returns nil if the
+// user picks nothing.
+func (cl *cli) pick(prompt string, candidates []SearchResult) (*SearchResult, error) {
+	in := bufio.NewReader(cl.stdin)
+	shown := candidates
+
+	for {
+		fmt.Fprintln(cl.stderr, prompt)
+		for i, c := range shown {
+			fmt.Fprintf(cl.stderr, "  %2d) %-30s  %s\n", i+1, FormatItemName(c.Kind, c.Name), c.Description)
+		}
+		fmt.Fprint(cl.stderr, "Pick a number, type to narrow the list, or press Enter to cancel: ")
+
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading choice: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err == io.EOF {
+				fmt.Fprintln(cl.stderr)
+			}
+			return nil, nil
+		}
+
+		if n, err := strconv.Atoi(line); err == nil {
+			if n >= 1 && n <= len(shown) {
+				return &shown[n-1], nil
+			}
+			fmt.Fprintf(cl.stderr, "\nThere is no %d.\n", n)
+			continue
+		}
+
+		var narrowed []SearchResult
+		for _, c := range candidates {
+			if fuzzyMatch(line, FormatItemName(c.Kind, c.Name)) {
+				narrowed = append(narrowed, c)
+			}
+		}
+		switch len(narrowed) {
+		case 0:
+			fmt.Fprintf(cl.stderr, "\nNothing matches %q.\n", line)
+		case 1:
+			return &narrowed[0], nil
+		default:
+			shown = narrowed
+			fmt.Fprintln(cl.stderr)
+		}
+	}
+}
+
+// fuzzyMatch reports whether the letters of pattern appear in s in order,
+// ignoring case, as fzf matches.
+func fuzzyMatch(pattern, s string) bool {
+	rest := []rune(strings.ToLower(s))
+	for _, p := range strings.ToLower(pattern) {
+		if unicode.IsSpace(p) {
+			continue
+		}
+		i := 0
+		for i < len(rest) && rest[i] != p {
+			i++
+		}
+		if i == len(rest) {
+			return false
+		}
+		rest = rest[i+1:]
+	}
+	return true
+}
+
+// hasItem reports whether any source lists an item. Sources that can't be
+// read count as not having it.
+func (c *Client) hasItem(ctx context.Context, kind ItemKind, name string) bool {
+	for _, source := range c.newSources() {
+		if _, ok, err := source.latestVersion(ctx, kind, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
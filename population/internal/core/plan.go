@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileAction is what a command does to a file.
+type FileAction string
+
+const (
+	FileCreate FileAction = "create"
+	FileModify FileAction = "modify"
+	FileRemove FileAction = "remove"
+)
+
+// FileChange is a file a command changes, or would change with --dry-run.
+type FileChange struct {
+	Action FileAction `json:"action" yaml:"action"`
+	Path   string     `json:"path" yaml:"path"`
+}
+
+// planItemFiles returns the changes installing an item's files into dir
+// makes: files that are new or differ are created or modified, files only
+// the installed copy has are removed, and the install record is always
+// rewritten. files are keyed by their paths relative to dir.
+func planItemFiles(dir string, files map[string][]byte, cipher *contentCipher) ([]FileChange, error) {
+	existing := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			existing[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	var changes []FileChange
+	for _, rel := range sortedKeys(files) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if !existing[rel] {
+			changes = append(changes, FileChange{Action: FileCreate, Path: path})
+			continue
+		}
+		current, err := cipher.readFile(path)
+		if err != nil || !bytes.Equal(current, files[rel]) {
+			changes = append(changes, FileChange{Action: FileModify, Path: path})
+		}
+	}
+	record := FileChange{Action: FileCreate, Path: filepath.Join(dir, InstallRecordFile)}
+	if existing[InstallRecordFile] {
+		record.Action = FileModify
+	}
+	changes = append(changes, record)
+	for _, rel := range sortedKeys(existing) {
+		if _, kept := files[rel]; !kept && rel != InstallRecordFile {
+			changes = append(changes, FileChange{Action: FileRemove, Path: filepath.Join(dir, filepath.FromSlash(rel))})
+		}
+	}
+	sortFileChanges(changes)
+	return changes, nil
+}
+
+// planRemoveDir returns the changes removing dir and everything in it makes.
+func planRemoveDir(dir string) ([]FileChange, error) {
+	var changes []FileChange
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			changes = append(changes, FileChange{Action: FileRemove, Path: path})
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	return changes, nil
+}
+
+// sortFileChanges orders changes by path.
+func sortFileChanges(changes []FileChange) {
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+}
+
+// PlanUninstall returns the files uninstalling an item would remove,
+// without removing them.
+func (c *Client) PlanUninstall(name string) ([]FileChange, error) {
+	if err := ValidateItemName(name); err != nil {
+		return nil, err
+	}
+	kind, itemName := ParseItemName(name)
+	dir := c.itemDir(kind, itemName)
+	if _, err := os.Stat(filepath.Join(dir, "vega.yaml")); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s %q is not installed", kind, itemName)
+	}
+	return planRemoveDir(dir)
+}
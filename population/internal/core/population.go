@@ -0,0 +1,253 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ItemKind represents the type of population item.
+type ItemKind string
+
+const (
+	KindSkill   ItemKind = "skill"
+	KindPersona ItemKind = "persona"
+	KindProfile ItemKind = "profile"
+
+	// KindSettings carries organization defaults honored by export.
+	KindSettings ItemKind = "settings"
+)
+
+// String returns the string representation of the ItemKind.
+func (k ItemKind) String() string {
+	return string(k)
+}
+
+// Plural returns the plural form of the ItemKind (used in paths).
+func (k ItemKind) Plural() string {
+	switch k {
+	case KindSkill:
+		return "skills"
+	case KindPersona:
+		return "personas"
+	case KindProfile:
+		return "profiles"
+	case KindSettings:
+		return "settings"
+	default:
+		return string(k) + "s"
+	}
+}
+
+// SearchResult represents a single search result.
+type SearchResult struct {
+	Kind        ItemKind     `json:"kind" yaml:"kind"`
+	Name        string       `json:"name" yaml:"name"`
+	Version     string       `json:"version" yaml:"version"`
+	Description string       `json:"description" yaml:"description"`
+	Status      ReviewStatus `json:"status" yaml:"status"`
+	Tags        []string     `json:"tags" yaml:"tags"`
+	Score       float64      `json:"score" yaml:"score"`   // Relevance score 0-1
+	Source      string       `json:"source" yaml:"source"` // Source the item was found in
+	Warnings    []Warning    `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Match       string       `json:"match,omitempty" yaml:"match,omitempty"`     // Where a deep search found the query, e.g. "system_prompt: ..."
+	Explain     string       `json:"explain,omitempty" yaml:"explain,omitempty"` // How the score was reached, with SearchOptions.Explain
+}
+
+// SearchOptions configures the search behavior.
+type SearchOptions struct {
+	Kind    ItemKind     // Filter by type (empty = all)
+	Tags    []string     // Filter by tags
+	Status  ReviewStatus // Filter by review status (empty = all)
+	Limit   int          // Max results (0 = no limit)
+	Fuzzy   bool         // Also match names and tags within a few typos of the query
+	Deep    bool         // Also search manifest bodies: prompts, tools, and examples
+	Explain bool         // Explain each result's score in SearchResult.Explain
+}
+
+// InstallOptions configures the installation behavior.
+type InstallOptions struct {
+	Force  bool   // Overwrite existing installations
+	NoDeps bool   // Skip dependencies (a profile's persona and skills, a skill's dependencies)
+	DryRun bool   // Show what would be installed without actually installing
+	Env    string // Deployment environment used to evaluate conditional profile skills
+
+	// Version constrains the version to install, such as "1.2.0" or "^1.2".
+	// Empty installs the version the index points at.
+	Version string
+
+	// MinStatus is the lowest review status that may be installed. When
+	// empty, the install directory's policy file applies.
+	MinStatus ReviewStatus
+
+	// NoVerify skips checking the manifest against the hash published in the index.
+	NoVerify bool
+
+	// RequireSigned refuses items without a trusted signature, on top of any
+	// configured signature policy.
+	RequireSigned bool
+
+	// OnConflict settles items the requested items want at incompatible
+	// versions (default ConflictFail).
+	OnConflict ConflictStrategy
+
+	// AllowIncompatible installs profiles combining items their skills
+	// declare incompatible (see Compatibility), with a warning, instead of
+	// returning an *IncompatibilityError.
+	AllowIncompatible bool
+
+	// Progress receives progress messages (default: discarded).
+	Progress io.Writer
+
+	dependencyPath []string                   // Skills whose dependencies are being installed, outermost first
+	pins           map[string]string          // Versions resolved for items by display name
+	prefetched     map[string]*prefetchedItem // Items fetched ahead of installing them, by prefetchKey
+	unrecorded     bool                       // Leave recording the install with version control to the caller
+}
+
+// InstalledItem represents an installed skill, persona, or profile.
+type InstalledItem struct {
+	Kind    ItemKind `json:"kind" yaml:"kind"`
+	Name    string   `json:"name" yaml:"name"`
+	Version string   `json:"version" yaml:"version"`
+	Path    string   `json:"path" yaml:"path"`
+	Files   []string `json:"files,omitempty" yaml:"files,omitempty"` // Extra files installed with the item
+
+	Install  *InstallRecord `json:"install,omitempty" yaml:"install,omitempty"`   // How it was installed; nil if by hand
+	Modified []string       `json:"modified,omitempty" yaml:"modified,omitempty"` // Files changed since it was installed
+}
+
+// ItemInfo contains detailed information about an item.
+type ItemInfo struct {
+	Kind        ItemKind     `json:"kind" yaml:"kind"`
+	Name        string       `json:"name" yaml:"name"`
+	Version     string       `json:"version" yaml:"version"`
+	Description string       `json:"description" yaml:"description"`
+	Author      string       `json:"author" yaml:"author"`
+	Maintainers []string     `json:"maintainers,omitempty" yaml:"maintainers,omitempty"`
+	Status      ReviewStatus `json:"status" yaml:"status"`
+	Tags        []string     `json:"tags" yaml:"tags"`
+	// For profiles
+	Persona string   `json:"persona,omitempty" yaml:"persona,omitempty"`
+	Skills  []string `json:"skills,omitempty" yaml:"skills,omitempty"` // Ordered by priority
+	// For personas
+	RecommendedSkills []string `json:"recommended_skills,omitempty" yaml:"recommended_skills,omitempty"`
+	// Installation status
+	Installed     bool           `json:"installed" yaml:"installed"`
+	InstalledPath string         `json:"installed_path,omitempty" yaml:"installed_path,omitempty"`
+	Install       *InstallRecord `json:"install,omitempty" yaml:"install,omitempty"`   // How it was installed; nil if by hand
+	Modified      []string       `json:"modified,omitempty" yaml:"modified,omitempty"` // Files changed since it was installed
+	// Source the item was resolved from
+	Source string `json:"source" yaml:"source"`
+	// Problems that don't stop the item being used
+	Warnings []Warning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// ParseItemName parses an input string and returns the kind and name.
+// Names prefixed with @ are personas, + are profiles, % are settings, and
+// unprefixed are skills. It doesn't check the name; ValidateItemName does.
+func ParseItemName(input string) (ItemKind, string) {
+	if strings.HasPrefix(input, "@") {
+		return KindPersona, strings.TrimPrefix(input, "@")
+	}
+	if strings.HasPrefix(input, "+") {
+		return KindProfile, strings.TrimPrefix(input, "+")
+	}
+	if strings.HasPrefix(input, "%") {
+		return KindSettings, strings.TrimPrefix(input, "%")
+	}
+	return KindSkill, input
+}
+
+// SplitVersion splits a name such as "kubernetes-ops@^1.2" into the item
+// name and a version constraint. The constraint is empty when none is given.
+func SplitVersion(input string) (string, string) {
+	if i := strings.LastIndex(input, "@"); i > 0 {
+		return input[:i], input[i+1:]
+	}
+	return input, ""
+}
+
+// ErrInvalidItemName is wrapped by the errors for item names that can't
+// name an item, such as an empty one or one with a path separator.
+var ErrInvalidItemName = errors.New("invalid item name")
+
+// ValidateItemName checks a name as ParseItemName takes it, optionally with
+// a version as SplitVersion takes it. The name after the kind prefix must be
+// a single path element: not empty, without slashes, whitespace, or control
+// characters, not starting with a dot, and without a second kind prefix.
+// The error wraps ErrInvalidItemName.
+func ValidateItemName(input string) error {
+	kind, rest := ParseItemName(input)
+	name, _ := SplitVersion(rest)
+	if name == "" && kind != KindSkill {
+		return fmt.Errorf("%w %q: no name after the %s prefix", ErrInvalidItemName, input, kind)
+	}
+	return checkItemName(name)
+}
+
+// checkItemName rejects item names that are not a single, plain path
+// element, so they can't escape or break the paths built from them.
+func checkItemName(name string) error {
+	var problem string
+	switch {
+	case name == "":
+		problem = "empty"
+	case strings.ContainsAny(name, `/\`):
+		problem = "contains a path separator"
+	case strings.HasPrefix(name, "."):
+		problem = "starts with a dot"
+	case strings.ContainsAny(name[:1], "@+%"):
+		problem = "has more than one kind prefix"
+	case strings.IndexFunc(name, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0:
+		problem = "contains whitespace or control characters"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w %q: %s", ErrInvalidItemName, name, problem)
+}
+
+// FormatItemName returns the display name with the appropriate prefix.
+func FormatItemName(kind ItemKind, name string) string {
+	switch kind {
+	case KindPersona:
+		return "@" + name
+	case KindProfile:
+		return "+" + name
+	case KindSettings:
+		return "%" + name
+	default:
+		return name
+	}
+}
+
+// kindOrder returns where a kind sorts: skills, personas, profiles, then
+// settings, as they are listed.
+func kindOrder(kind ItemKind) int {
+	switch kind {
+	case KindSkill:
+		return 0
+	case KindPersona:
+		return 1
+	case KindProfile:
+		return 2
+	case KindSettings:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// sortedKeys returns the keys of a map in order, for iterating it the same
+// way every run.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
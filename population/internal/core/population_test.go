@@ -0,0 +1,106 @@
+package core
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// itemNameSeeds are names to start the item name fuzzers from.
+var itemNameSeeds = []string{
+	"kubernetes-ops", "@sre", "+platform-engineer", "%org", "kubernetes-ops@^1.2",
+	"@sre@2.0.0", "", "@", "+", "..", "../etc", "a/b", `a\b`, ".hidden", "@@sre",
+	"+@x", "sre ", "a\x00b", "ops\n", "日本語", "@..", "%../x",
+}
+
+func FuzzParseItemName(f *testing.F) {
+	for _, seed := range itemNameSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		kind, name := ParseItemName(input)
+		switch kind {
+		case KindSkill, KindPersona, KindProfile, KindSettings:
+		default:
+			t.Fatalf("ParseItemName(%q) returned kind %q", input, kind)
+		}
+		// Parsing only strips the prefix, so formatting puts it back
+		if got := FormatItemName(kind, name); got != input {
+			t.Fatalf("FormatItemName(ParseItemName(%q)) = %q", input, got)
+		}
+
+		err := ValidateItemName(input)
+		if err != nil && !errors.Is(err, ErrInvalidItemName) {
+			t.Fatalf("ValidateItemName(%q) = %v, which doesn't wrap ErrInvalidItemName", input, err)
+		}
+		if err == nil {
+			bare, _ := SplitVersion(name)
+			if checkItemName(bare) != nil {
+				t.Fatalf("ValidateItemName(%q) accepted %q, which checkItemName refuses", input, bare)
+			}
+		}
+	})
+}
+
+func TestFormatParseItemNameRoundTrip(t *testing.T) {
+	kinds := []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings}
+	for _, seed := range itemNameSeeds {
+		_, name := ParseItemName(seed)
+		name, _ = SplitVersion(name)
+		if checkItemName(name) != nil {
+			continue
+		}
+		for _, kind := range kinds {
+			formatted := FormatItemName(kind, name)
+			if gotKind, gotName := ParseItemName(formatted); gotKind != kind || gotName != name {
+				t.Errorf("ParseItemName(FormatItemName(%s, %q)) = %s, %q", kind, name, gotKind, gotName)
+			}
+			if err := ValidateItemName(formatted); err != nil {
+				t.Errorf("ValidateItemName(%q) = %v for a name checkItemName accepts", formatted, err)
+			}
+		}
+	}
+}
+
+func FuzzFormatParseItemName(f *testing.F) {
+	for _, seed := range itemNameSeeds {
+		f.Add(seed, uint8(0))
+	}
+	kinds := []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings}
+	f.Fuzz(func(t *testing.T, name string, k uint8) {
+		if checkItemName(name) != nil {
+			return
+		}
+		kind := kinds[int(k)%len(kinds)]
+		if gotKind, gotName := ParseItemName(FormatItemName(kind, name)); gotKind != kind || gotName != name {
+			t.Fatalf("ParseItemName(FormatItemName(%s, %q)) = %s, %q", kind, name, gotKind, gotName)
+		}
+	})
+}
+
+func FuzzItemDir(f *testing.F) {
+	for _, seed := range itemNameSeeds {
+		f.Add(seed)
+	}
+	installDir := f.TempDir()
+	client := &Client{installDir: installDir, layout: Layout{KindPersona: "agents", KindSkill: "capabilities/skills"}}
+	f.Fuzz(func(t *testing.T, input string) {
+		if ValidateItemName(input) != nil {
+			return
+		}
+		kind, rest := ParseItemName(input)
+		name, _ := SplitVersion(rest)
+
+		// An accepted name is one path element directly under its kind's directory
+		dir := client.itemDir(kind, name)
+		kindDir := filepath.Join(installDir, client.layout.Dir(kind))
+		rel, err := filepath.Rel(kindDir, dir)
+		if err != nil || rel != name || strings.ContainsRune(rel, filepath.Separator) || rel == ".." || rel == "." {
+			t.Fatalf("item %q is installed at %s, not directly under %s", input, dir, kindDir)
+		}
+		if !strings.HasPrefix(dir, installDir+string(filepath.Separator)) {
+			t.Fatalf("item %q is installed at %s, outside %s", input, dir, installDir)
+		}
+	})
+}
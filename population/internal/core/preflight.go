@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Requirements declares what a skill needs from the host it runs on:
+//
+//	requires:
+//	  binaries: [kubectl, helm]
+//	  env: [KUBECONFIG]
+//	  os: [linux, darwin]
+type Requirements struct {
+	Binaries []string `yaml:"binaries,omitempty"` // Tools that must be on PATH
+	Env      []string `yaml:"env,omitempty"`      // Environment variables that must be set
+	OS       []string `yaml:"os,omitempty"`       // Supported operating systems (empty = any)
+}
+
+// PreflightGap is a requirement the host doesn't satisfy.
+type PreflightGap struct {
+	Kind ItemKind
+	Name string // Item that declared the requirement
+	Type string // "binary", "env", or "os"
+	Need string // The missing binary or variable, or the supported systems
+	Hint string // What to do about it
+}
+
+// Check returns the requirements the platform doesn't satisfy. Environment
+// variables are read from the current process.
+func (r Requirements) Check(p Platform) []PreflightGap {
+	var gaps []PreflightGap
+
+	if len(r.OS) > 0 && !matchesList(strings.Join(r.OS, ","), p.OS) {
+		gaps = append(gaps, PreflightGap{
+			Type: "os",
+			Need: strings.Join(r.OS, ", "),
+			Hint: fmt.Sprintf("runs only on %s; this host is %s", strings.Join(r.OS, ", "), p.OS),
+		})
+	}
+
+	lookPath := p.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	for _, bin := range r.Binaries {
+		if _, err := lookPath(bin); err != nil {
+			gaps = append(gaps, PreflightGap{
+				Type: "binary",
+				Need: bin,
+				Hint: fmt.Sprintf("install %s and make sure it is on PATH", bin),
+			})
+		}
+	}
+
+	for _, name := range r.Env {
+		if os.Getenv(name) == "" {
+			gaps = append(gaps, PreflightGap{
+				Type: "env",
+				Need: name,
+				Hint: fmt.Sprintf("set %s in the agent's environment", name),
+			})
+		}
+	}
+
+	return gaps
+}
+
+// Preflight checks that this host satisfies the requirements of an item
+// before it is deployed. A profile is checked through the skills that apply
+// on this platform in env; a skill is checked on its own. With an empty name,
+// every installed skill is checked.
+func (c *Client) Preflight(ctx context.Context, name, env string) ([]PreflightGap, error) {
+	platform := CurrentPlatform(env)
+
+	manifests, err := c.preflightManifests(ctx, name, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	var gaps []PreflightGap
+	for _, m := range manifests {
+		if m.Requires == nil {
+			continue
+		}
+		for _, gap := range m.Requires.Check(platform) {
+			gap.Kind, gap.Name = ItemKind(m.Kind), m.Name
+			gaps = append(gaps, gap)
+		}
+	}
+	return gaps, nil
+}
+
+// preflightManifests returns the manifests whose requirements Preflight checks.
+func (c *Client) preflightManifests(ctx context.Context, name string, platform Platform) ([]*Manifest, error) {
+	if name == "" {
+		installed, err := c.List(KindSkill)
+		if err != nil {
+			return nil, err
+		}
+		var manifests []*Manifest
+		for _, item := range installed {
+			m, err := loadManifest(filepath.Join(item.Path, "vega.yaml"), c.cipher)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, m)
+		}
+		return manifests, nil
+	}
+
+	kind, itemName := ParseItemName(name)
+	source, err := c.resolveSource(ctx, kind, itemName)
+	if err != nil {
+		return nil, err
+	}
+	m, err := source.GetManifest(ctx, kind, itemName)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", name, err)
+	}
+
+	switch kind {
+	case KindSkill:
+		return []*Manifest{m}, nil
+	case KindProfile:
+		var manifests []*Manifest
+		for _, ref := range m.Skills.Select(platform) {
+			skill, err := c.preflightManifests(ctx, ref.Name, platform)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, skill...)
+		}
+		return manifests, nil
+	default:
+		return nil, fmt.Errorf("preflight checks skills and profiles, not %ss", kind)
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SkillRef is a skill entry in a profile.
+// It can be written as a bare skill name or as a mapping with ordering metadata
+// and conditions that gate where the skill applies:
+//
+//	skills:
+//	  - kubernetes-ops
+//	  - terraform@^1.2
+//	  - name: monitoring
+//	    version: ~2.1
+//	    priority: 10
+//	  - name: kubernetes-ops
+//	    only: linux
+//	    env: prod
+//	    requires_tool: kubectl
+type SkillRef struct {
+	Name     string `yaml:"name"`
+	Version  string `yaml:"version,omitempty"`  // Version constraint, such as "^1.2" (default: the latest)
+	Priority int    `yaml:"priority,omitempty"` // Higher priorities are placed first
+
+	// Conditions gating the skill. Empty conditions always match.
+	Only         string `yaml:"only,omitempty"`          // Comma-separated operating systems (linux, darwin, windows)
+	Env          string `yaml:"env,omitempty"`           // Comma-separated deployment environments (prod, staging, ...)
+	RequiresTool string `yaml:"requires_tool,omitempty"` // Binary that must be on PATH
+}
+
+// UnmarshalYAML accepts either a scalar skill name, optionally with
+// @<version>, or a mapping.
+func (r *SkillRef) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name, r.Version = SplitVersion(node.Value)
+		return nil
+	}
+
+	type plain SkillRef
+	return node.Decode((*plain)(r))
+}
+
+// MarshalYAML writes plain references back as bare names.
+func (r SkillRef) MarshalYAML() (interface{}, error) {
+	if r.Priority == 0 && !r.Conditional() {
+		if r.Version != "" {
+			return r.Name + "@" + r.Version, nil
+		}
+		return r.Name, nil
+	}
+
+	type plain SkillRef
+	return plain(r), nil
+}
+
+// SkillRefs is the list of skills declared by a profile.
+type SkillRefs []SkillRef
+
+// Ordered returns the skills sorted by descending priority.
+// Skills with equal priority keep the order in which they were declared.
+func (s SkillRefs) Ordered() SkillRefs {
+	ordered := make(SkillRefs, len(s))
+	copy(ordered, s)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+	return ordered
+}
+
+// Names returns the skill names in declared order.
+func (s SkillRefs) Names() []string {
+	if len(s) == 0 {
+		return nil
+	}
+	names := make([]string, len(s))
+	for i, ref := range s {
+		names[i] = ref.Name
+	}
+	return names
+}
+
+// Conditional reports whether the skill carries any conditions.
+func (r SkillRef) Conditional() bool {
+	return r.Only != "" || r.Env != "" || r.RequiresTool != ""
+}
+
+// Applies reports whether the skill's conditions are satisfied on the given platform.
+func (r SkillRef) Applies(p Platform) bool {
+	if r.Only != "" && !matchesList(r.Only, p.OS) {
+		return false
+	}
+	if r.Env != "" && !matchesList(r.Env, p.Env) {
+		return false
+	}
+	if r.RequiresTool != "" {
+		lookPath := p.LookPath
+		if lookPath == nil {
+			lookPath = exec.LookPath
+		}
+		if _, err := lookPath(r.RequiresTool); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the skills whose conditions apply on the given platform, in priority order.
+func (s SkillRefs) Select(p Platform) SkillRefs {
+	var selected SkillRefs
+	for _, ref := range s.Ordered() {
+		if ref.Applies(p) {
+			selected = append(selected, ref)
+		}
+	}
+	return selected
+}
+
+// Platform describes the context that conditional skills are evaluated against.
+type Platform struct {
+	OS  string // Operating system, as reported by runtime.GOOS
+	Env string // Deployment environment (empty matches only unconditioned skills)
+
+	// LookPath resolves tool binaries. Defaults to exec.LookPath.
+	LookPath func(file string) (string, error)
+}
+
+// CurrentPlatform returns the platform of the running host for the given environment.
+func CurrentPlatform(env string) Platform {
+	return Platform{
+		OS:  runtime.GOOS,
+		Env: env,
+	}
+}
+
+// String returns a short description of the platform.
+func (p Platform) String() string {
+	if p.Env == "" {
+		return p.OS
+	}
+	return p.OS + "/" + p.Env
+}
+
+// matchesList reports whether value appears in a comma-separated list (case-insensitive).
+func matchesList(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
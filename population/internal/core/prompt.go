@@ -0,0 +1,296 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Standard prompt sections, in the order they are rendered.
+const (
+	SectionRole          = "role"
+	SectionConstraints   = "constraints"
+	SectionToolsGuidance = "tools-guidance"
+	SectionExamples      = "examples"
+)
+
+var standardSections = []string{SectionRole, SectionConstraints, SectionToolsGuidance, SectionExamples}
+
+// textSection names the single section of a prompt authored as one string
+// when it is diffed or merged against other prompts.
+const textSection = "prompt"
+
+// Prompt is a system prompt authored either as one string or as named sections:
+//
+//	system_prompt:
+//	  role: |
+//	    You are ...
+//	  constraints: |
+//	    - Never ...
+type Prompt struct {
+	Text     string          // Set when the prompt is a single string
+	Sections []PromptSection // Set when the prompt is authored as sections, in authored order
+}
+
+// PromptSection is one named part of a sectioned prompt.
+type PromptSection struct {
+	Name string
+	Text string
+}
+
+// UnmarshalYAML accepts either a string or a mapping of section names to text.
+func (p *Prompt) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		p.Text, p.Sections = node.Value, nil
+		return nil
+	case yaml.MappingNode:
+		p.Text, p.Sections = "", nil
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: prompt section %q must be a string", value.Line, key.Value)
+			}
+			if seen[key.Value] {
+				return fmt.Errorf("line %d: duplicate prompt section %q", key.Line, key.Value)
+			}
+			seen[key.Value] = true
+			p.Sections = append(p.Sections, PromptSection{Name: key.Value, Text: value.Value})
+		}
+		return nil
+	default:
+		return fmt.Errorf("line %d: system prompt must be a string or a mapping of sections", node.Line)
+	}
+}
+
+// MarshalYAML writes a string prompt as a string and sections as a mapping.
+func (p Prompt) MarshalYAML() (interface{}, error) {
+	if p.Sections == nil {
+		return p.Text, nil
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range p.Sections {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: s.Name},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: s.Text, Style: yaml.LiteralStyle},
+		)
+	}
+	return node, nil
+}
+
+// IsZero reports whether the prompt is empty, for omitempty.
+func (p Prompt) IsZero() bool {
+	return p.Text == "" && len(p.Sections) == 0
+}
+
+// IsSectioned reports whether the prompt was authored as sections.
+func (p Prompt) IsSectioned() bool {
+	return p.Sections != nil
+}
+
+// Section returns the text of a named section.
+func (p Prompt) Section(name string) (string, bool) {
+	for _, s := range p.Sections {
+		if s.Name == name {
+			return s.Text, true
+		}
+	}
+	return "", false
+}
+
+// String renders the prompt. Sections are rendered in a fixed order: the
+// standard sections first, then any others in authored order. The role
+// section opens the prompt as-is; every other section gets a heading.
+func (p Prompt) String() string {
+	if p.Sections == nil {
+		return p.Text
+	}
+
+	var parts []string
+	for _, s := range p.Ordered() {
+		body := strings.TrimSpace(s.Text)
+		if body == "" {
+			continue
+		}
+		if s.Name == SectionRole {
+			parts = append(parts, body)
+			continue
+		}
+		parts = append(parts, "## "+sectionTitle(s.Name)+"\n\n"+body)
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// Ordered returns the sections in render order.
+func (p Prompt) Ordered() []PromptSection {
+	var ordered []PromptSection
+	for _, name := range standardSections {
+		if text, ok := p.Section(name); ok {
+			ordered = append(ordered, PromptSection{Name: name, Text: text})
+		}
+	}
+	for _, s := range p.Sections {
+		if !isStandardSection(s.Name) {
+			ordered = append(ordered, s)
+		}
+	}
+	return ordered
+}
+
+// sectionList returns the sections used for diffing and merging; a string
+// prompt is treated as a single section.
+func (p Prompt) sectionList() []PromptSection {
+	if p.Sections == nil {
+		if p.Text == "" {
+			return nil
+		}
+		return []PromptSection{{Name: textSection, Text: p.Text}}
+	}
+	return p.Sections
+}
+
+// SectionChange describes how one prompt section differs between two prompts.
+type SectionChange struct {
+	Section string
+	Change  string // "added", "removed", or "changed"
+	Old     string
+	New     string
+}
+
+// DiffPrompts compares two prompts section by section.
+func DiffPrompts(before, after Prompt) []SectionChange {
+	var changes []SectionChange
+
+	oldSections := before.sectionList()
+	newSections := after.sectionList()
+	newPrompt := Prompt{Sections: newSections}
+	oldPrompt := Prompt{Sections: oldSections}
+
+	for _, s := range oldSections {
+		text, ok := newPrompt.Section(s.Name)
+		switch {
+		case !ok:
+			changes = append(changes, SectionChange{Section: s.Name, Change: "removed", Old: s.Text})
+		case text != s.Text:
+			changes = append(changes, SectionChange{Section: s.Name, Change: "changed", Old: s.Text, New: text})
+		}
+	}
+	for _, s := range newSections {
+		if _, ok := oldPrompt.Section(s.Name); !ok {
+			changes = append(changes, SectionChange{Section: s.Name, Change: "added", New: s.Text})
+		}
+	}
+
+	return changes
+}
+
+// MergePrompts merges two edits of a base prompt section by section. A
+// section changed on only one side takes that side's text; sections changed
+// differently on both sides keep ours and are reported as conflicts.
+func MergePrompts(base, ours, theirs Prompt) (Prompt, []string) {
+	basePrompt := Prompt{Sections: base.sectionList()}
+	oursPrompt := Prompt{Sections: ours.sectionList()}
+	theirsPrompt := Prompt{Sections: theirs.sectionList()}
+
+	// Keep the order of ours, then append sections only theirs added
+	var names []string
+	seen := make(map[string]bool)
+	for _, list := range [][]PromptSection{oursPrompt.Sections, theirsPrompt.Sections, basePrompt.Sections} {
+		for _, s := range list {
+			if !seen[s.Name] {
+				seen[s.Name] = true
+				names = append(names, s.Name)
+			}
+		}
+	}
+
+	var merged []PromptSection
+	var conflicts []string
+
+	for _, name := range names {
+		b, inBase := basePrompt.Section(name)
+		o, inOurs := oursPrompt.Section(name)
+		t, inTheirs := theirsPrompt.Section(name)
+
+		oursChanged := inOurs != inBase || o != b
+		theirsChanged := inTheirs != inBase || t != b
+
+		var text string
+		var keep bool
+		switch {
+		case !theirsChanged:
+			text, keep = o, inOurs
+		case !oursChanged:
+			text, keep = t, inTheirs
+		case inOurs == inTheirs && o == t:
+			text, keep = o, inOurs
+		default:
+			conflicts = append(conflicts, name)
+			text, keep = o, inOurs
+		}
+
+		if keep {
+			merged = append(merged, PromptSection{Name: name, Text: text})
+		}
+	}
+
+	// String prompts merge back into a string prompt
+	if !base.IsSectioned() && !ours.IsSectioned() && !theirs.IsSectioned() {
+		if len(merged) == 0 {
+			return Prompt{}, conflicts
+		}
+		return Prompt{Text: merged[0].Text}, conflicts
+	}
+	return Prompt{Sections: merged}, conflicts
+}
+
+// LintPrompt reports problems with a prompt's sections.
+func LintPrompt(p Prompt) []string {
+	var problems []string
+
+	if !p.IsSectioned() {
+		if !strings.HasPrefix(strings.TrimSpace(p.Text), "You are") {
+			problems = append(problems, `prompt should start with "You are" for name extraction`)
+		}
+		return problems
+	}
+
+	for _, s := range p.Sections {
+		if strings.TrimSpace(s.Text) == "" {
+			problems = append(problems, fmt.Sprintf("section %q is empty", s.Name))
+		}
+		if !isStandardSection(s.Name) {
+			problems = append(problems, fmt.Sprintf("section %q is not a standard section (%s)", s.Name, strings.Join(standardSections, ", ")))
+		}
+	}
+
+	role, ok := p.Section(SectionRole)
+	if !ok {
+		problems = append(problems, "missing role section")
+	} else if !strings.HasPrefix(strings.TrimSpace(role), "You are") {
+		problems = append(problems, `role section should start with "You are" for name extraction`)
+	}
+
+	return problems
+}
+
+func isStandardSection(name string) bool {
+	for _, s := range standardSections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionTitle turns a section name such as "tools-guidance" into "Tools Guidance".
+func sectionTitle(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i, w := range words {
+		words[i] = titleCase(w)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,496 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Registry write protocol. A writable registry serves the usual source layout
+// for reads and accepts uploads of one item at a time:
+//
+//	PUT <registry>/<kind plural>/<name>/<file>     an extra file the manifest lists
+//	PUT <registry>/<kind plural>/<name>/vega.yaml  the manifest, which publishes the item
+//
+// Files are staged until the manifest arrives. The manifest is validated, its
+// files are checked against any pinned hashes, and its version must be newer
+// than the published one; the registry then writes the item and updates its
+// index. Each body's sha256 may be sent in the PublishSHA256Header header and
+// is checked on receipt. The manifest upload answers 201 with a JSON
+// PublishResult, 409 when the version is already published, 422 when the
+// manifest is invalid, and {"error": "..."} with other failures.
+const PublishSHA256Header = "X-Vega-SHA256"
+
+// uploadDir is where a registry stages files uploaded ahead of their manifest.
+const uploadDir = ".uploads"
+
+// maxUploadSize bounds one uploaded file or manifest.
+const maxUploadSize = 32 << 20
+
+// PublishOptions configures publishing an item to a registry.
+type PublishOptions struct {
+	Bump   string // Version part to bump when the registry already has the version (default BumpPatch)
+	Reason string // Changelog text for a bumped version (optional)
+	DryRun bool   // Report what would be published without uploading or writing anything
+
+	// AllowShadow publishes a new item even when the client's sources already
+	// have an item of that name.
+	AllowShadow bool
+
+	// Auth is the credentials for a registry server (default: the client's,
+	// if the server is on a host they may be sent to).
+	Auth *SourceAuth
+}
+
+// PublishResult describes a published item.
+type PublishResult struct {
+	Kind       ItemKind `json:"kind"`
+	Name       string   `json:"name"`
+	OldVersion string   `json:"old_version,omitempty"` // Version in the registry before publishing (empty for new items)
+	Version    string   `json:"version"`
+	SHA256     string   `json:"sha256"` // Hash of the published manifest
+	Files      []string `json:"files,omitempty"`
+	Bumped     bool     `json:"bumped,omitempty"` // The local manifest's version was bumped to publish it
+}
+
+// InvalidManifestError reports a manifest refused by validation.
+type InvalidManifestError struct {
+	Errors []ValidationError
+}
+
+func (e *InvalidManifestError) Error() string {
+	var problems []string
+	for _, err := range e.Errors {
+		problems = append(problems, err.Error())
+	}
+	return "invalid manifest: " + strings.Join(problems, "; ")
+}
+
+// VersionConflictError reports a publish of a version that is not newer than
+// the one the registry has.
+type VersionConflictError struct {
+	Kind      ItemKind
+	Name      string
+	Version   string
+	Published string
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %q %s is not newer than the published %s", e.Kind, e.Name, e.Version, e.Published)
+}
+
+// Publish validates the manifest at path (a vega.yaml file or the directory
+// containing it) and publishes it, with the extra files it lists, to a
+// registry: a registry checkout, or the URL of a registry speaking the write
+// protocol. When the registry already has the manifest's version, the version
+// is bumped, with a changelog entry, and the local manifest is updated to
+// match once the item is published.
+func (c *Client) Publish(ctx context.Context, path, registry string, opts *PublishOptions) (*PublishResult, error) {
+	return c.publish(ctx, path, registry, opts, nil)
+}
+
+// publish is Publish reading, and updating, the item through cipher, which
+// is the client's for an item in the install directory and nil for one in a
+// working copy.
+func (c *Client) publish(ctx context.Context, path, registry string, opts *PublishOptions, cipher *contentCipher) (*PublishResult, error) {
+	if opts == nil {
+		opts = &PublishOptions{}
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "vega.yaml")
+	}
+	content, err := cipher.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if errs := ValidateManifest(&manifest); len(errs) > 0 {
+		return nil, &InvalidManifestError{Errors: errs}
+	}
+	kind := ItemKind(manifest.Kind)
+
+	files, err := readItemFiles(filepath.Dir(path), manifest.Files, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	// The registry's index, read uncached, says which version is published
+	remote := isRemoteRegistry(registry)
+	var local *LocalRegistry
+	if !remote {
+		if local, err = OpenLocalRegistry(registry); err != nil {
+			return nil, err
+		}
+	}
+	source := NewSource(registry, NewCache("", true))
+	source.auth = c.authFor(SourceConfig{URL: registry, Auth: opts.Auth})
+	source.http = c.http
+
+	result := &PublishResult{
+		Kind:    kind,
+		Name:    manifest.Name,
+		Version: manifest.Version,
+		Files:   manifest.FilePaths(),
+	}
+
+	published, ok, err := source.latestVersion(ctx, kind, manifest.Name)
+	if err != nil {
+		return nil, fmt.Errorf("checking %s for %s %q: %w", registry, kind, manifest.Name, err)
+	}
+	if ok {
+		result.OldVersion = published
+	}
+
+	if result.OldVersion != "" && CompareVersions(manifest.Version, result.OldVersion) <= 0 {
+		if result.Version, err = BumpVersion(result.OldVersion, opts.Bump); err != nil {
+			return nil, err
+		}
+		if content, err = bumpContent(content, result.Version, opts.Reason, time.Now()); err != nil {
+			return nil, err
+		}
+		result.Bumped = true
+	}
+
+	if result.OldVersion == "" && !opts.AllowShadow {
+		shadows, err := c.Shadows(ctx, FormatItemName(kind, manifest.Name), registry)
+		if err != nil {
+			return nil, err
+		}
+		if len(shadows) > 0 {
+			return nil, &ShadowError{Kind: kind, Name: manifest.Name, Shadows: shadows}
+		}
+	}
+
+	result.SHA256 = contentHash(content)
+	if opts.DryRun {
+		return result, nil
+	}
+
+	var uploaded *PublishResult
+	if remote {
+		uploaded, err = source.publish(ctx, kind, manifest.Name, content, files)
+	} else {
+		uploaded, err = local.Publish(content, files)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if uploaded.SHA256 != result.SHA256 {
+		return nil, fmt.Errorf("%s published %s %q with sha256 %s, expected %s", registry, kind, manifest.Name, uploaded.SHA256, result.SHA256)
+	}
+
+	if result.Bumped {
+		if err := cipher.writeFile(path, content, 0644); err != nil {
+			return nil, fmt.Errorf("updating local manifest: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// isRemoteRegistry reports whether a registry is the URL of a registry
+// server rather than a checkout.
+func isRemoteRegistry(registry string) bool {
+	return strings.HasPrefix(registry, "http://") || strings.HasPrefix(registry, "https://")
+}
+
+// readItemFiles reads the extra files a manifest lists from its item
+// directory, decrypting them with cipher and checking any pinned hashes.
+func readItemFiles(dir string, files []ItemFile, cipher *contentCipher) (map[string][]byte, error) {
+	read := make(map[string][]byte, len(files))
+	for _, f := range files {
+		if err := checkItemFilePath(f.Path); err != nil {
+			return nil, err
+		}
+		rel := path.Clean(f.Path)
+		content, err := cipher.readFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("reading file %s: %w", rel, err)
+		}
+		if f.SHA256 != "" && !strings.EqualFold(f.SHA256, contentHash(content)) {
+			return nil, fmt.Errorf("file %s does not match its sha256 (expected %s, got %s)", rel, f.SHA256, contentHash(content))
+		}
+		read[rel] = content
+	}
+	return read, nil
+}
+
+// publish uploads an item's files, then its manifest, to a registry speaking
+// the write protocol.
+func (s *Source) publish(ctx context.Context, kind ItemKind, name string, manifest []byte, files map[string][]byte) (*PublishResult, error) {
+	prefix := kind.Plural() + "/" + name + "/"
+	for _, rel := range sortedKeys(files) {
+		if _, err := s.upload(ctx, prefix+rel, files[rel]); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := s.upload(ctx, prefix+"vega.yaml", manifest)
+	if err != nil {
+		return nil, err
+	}
+	var result PublishResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parsing response from %s: %w", s.baseURL, err)
+	}
+	return &result, nil
+}
+
+// upload PUTs content to a path of the registry and returns the response body.
+func (s *Source) upload(ctx context.Context, path string, content []byte) ([]byte, error) {
+	url := s.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set(PublishSHA256Header, contentHash(content))
+	if s.auth != nil {
+		if err := s.auth.apply(req); err != nil {
+			return nil, fmt.Errorf("authenticating to %s: %w", s.baseURL, err)
+		}
+	}
+
+	resp, done, err := s.http.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("uploading %s: %w", url, err)
+	}
+	defer done()
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response from %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("uploading %s: %s", url, failure.Error)
+		}
+		return nil, fmt.Errorf("uploading %s: status %d", url, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// Publish adds an item to the registry from its manifest content and extra
+// files, which must be exactly those the manifest lists. The manifest must be
+// valid and newer than the published version (a *VersionConflictError
+// otherwise). The replaced version moves under versions/<version>/, where it
+// is still served until garbage collected, and the index entry is updated.
+func (r *LocalRegistry) Publish(content []byte, files map[string][]byte) (*PublishResult, error) {
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if errs := ValidateManifest(&manifest); len(errs) > 0 {
+		return nil, &InvalidManifestError{Errors: errs}
+	}
+	kind := ItemKind(manifest.Kind)
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, f := range manifest.Files {
+		rel := path.Clean(f.Path)
+		listed[rel] = true
+		content, ok := files[rel]
+		if !ok {
+			return nil, fmt.Errorf("file %s listed in the manifest was not provided", rel)
+		}
+		if f.SHA256 != "" && !strings.EqualFold(f.SHA256, contentHash(content)) {
+			return nil, fmt.Errorf("file %s does not match its sha256 (expected %s, got %s)", rel, f.SHA256, contentHash(content))
+		}
+	}
+	for rel := range files {
+		if !listed[rel] {
+			return nil, fmt.Errorf("file %s is not listed in the manifest", rel)
+		}
+	}
+
+	result := &PublishResult{
+		Kind:    kind,
+		Name:    manifest.Name,
+		Version: manifest.Version,
+		SHA256:  contentHash(content),
+		Files:   manifest.FilePaths(),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source := NewSource(r.dir, NewCache("", true))
+	published, ok, err := source.latestVersion(context.Background(), kind, manifest.Name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if CompareVersions(manifest.Version, published) <= 0 {
+			return nil, &VersionConflictError{Kind: kind, Name: manifest.Name, Version: manifest.Version, Published: published}
+		}
+		result.OldVersion = published
+	}
+
+	// Move the replaced version under versions/, so it is still served
+	dir := filepath.Dir(r.ManifestPath(kind, manifest.Name))
+	if ok {
+		if err := r.archiveCurrent(kind, manifest.Name, published); err != nil {
+			return nil, err
+		}
+	} else {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if entry.Name() != "versions" {
+				if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+					return nil, fmt.Errorf("removing old files: %w", err)
+				}
+			}
+		}
+	}
+	if err := r.WriteManifest(kind, manifest.Name, content); err != nil {
+		return nil, err
+	}
+	if err := writeItemFiles(dir, files, nil); err != nil {
+		return nil, err
+	}
+	if err := r.UpdateIndex(kind, &manifest); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PublishHandler serves the upload side of the registry write protocol for
+// a registry directory. Mount it alongside a handler serving reads, and put
+// authentication in front of it.
+func PublishHandler(r *LocalRegistry) http.Handler {
+	var mu sync.Mutex // Publishes rewrite shared index files
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut {
+			w.Header().Set("Allow", http.MethodPut)
+			publishError(w, http.StatusMethodNotAllowed, errors.New("uploads must use PUT"))
+			return
+		}
+
+		kind, name, rel, err := parseUploadPath(req.URL.Path)
+		if err != nil {
+			publishError(w, http.StatusNotFound, err)
+			return
+		}
+
+		content, err := io.ReadAll(io.LimitReader(req.Body, maxUploadSize+1))
+		if err != nil {
+			publishError(w, http.StatusBadRequest, err)
+			return
+		}
+		if len(content) > maxUploadSize {
+			publishError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("%s is larger than %d bytes", rel, maxUploadSize))
+			return
+		}
+		if sum := req.Header.Get(PublishSHA256Header); sum != "" && !strings.EqualFold(sum, contentHash(content)) {
+			publishError(w, http.StatusBadRequest, fmt.Errorf("%s does not match its %s header", rel, PublishSHA256Header))
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		staging := filepath.Join(r.dir, uploadDir, kind.Plural(), name)
+		if rel != "vega.yaml" {
+			if err := writeItemFiles(staging, map[string][]byte{rel: content}, nil); err != nil {
+				publishError(w, http.StatusInternalServerError, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var manifest Manifest
+		if err := yaml.Unmarshal(content, &manifest); err != nil {
+			publishError(w, http.StatusUnprocessableEntity, fmt.Errorf("parsing manifest: %w", err))
+			return
+		}
+		if ItemKind(manifest.Kind) != kind || manifest.Name != name {
+			publishError(w, http.StatusBadRequest, fmt.Errorf("manifest is for %s %q, not %s %q", manifest.Kind, manifest.Name, kind, name))
+			return
+		}
+		files := make(map[string][]byte, len(manifest.Files))
+		for _, f := range manifest.Files {
+			if checkItemFilePath(f.Path) != nil {
+				continue // Reported by validation
+			}
+			p := path.Clean(f.Path)
+			if files[p], err = os.ReadFile(filepath.Join(staging, filepath.FromSlash(p))); err != nil {
+				publishError(w, http.StatusBadRequest, fmt.Errorf("file %s listed in the manifest was not uploaded", p))
+				return
+			}
+		}
+
+		result, err := r.Publish(content, files)
+		var invalid *InvalidManifestError
+		var conflict *VersionConflictError
+		switch {
+		case errors.As(err, &invalid):
+			publishError(w, http.StatusUnprocessableEntity, err)
+			return
+		case errors.As(err, &conflict):
+			publishError(w, http.StatusConflict, err)
+			return
+		case err != nil:
+			publishError(w, http.StatusBadRequest, err)
+			return
+		}
+		os.RemoveAll(staging)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// parseUploadPath splits an upload URL path into the item and the file
+// within it.
+func parseUploadPath(p string) (ItemKind, string, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 3)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("upload path %q is not <kind>/<name>/<file>", p)
+	}
+
+	var kind ItemKind
+	for _, k := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		if parts[0] == k.Plural() {
+			kind = k
+		}
+	}
+	if kind == "" {
+		return "", "", "", fmt.Errorf("unknown kind directory %q", parts[0])
+	}
+	if err := checkItemName(parts[1]); err != nil {
+		return "", "", "", err
+	}
+	if parts[2] != "vega.yaml" {
+		if err := checkItemFilePath(parts[2]); err != nil {
+			return "", "", "", err
+		}
+	}
+	return kind, parts[1], path.Clean(parts[2]), nil
+}
+
+// publishError writes a JSON error response.
+func publishError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,227 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PushOptions configures pushing a locally modified item back to a registry.
+type PushOptions struct {
+	Bump   string // Version part to bump: BumpMajor, BumpMinor, or BumpPatch (default)
+	Reason string // Changelog text for the new version (optional)
+	DryRun bool   // Report what would change without writing anything
+
+	// AllowShadow publishes a new item even when the client's sources already
+	// have an item of that name.
+	AllowShadow bool
+
+	// Auth is the credentials for a registry server (default: the client's,
+	// if the server is on a host they may be sent to).
+	Auth *SourceAuth
+}
+
+// PushResult describes a pushed item.
+type PushResult struct {
+	Kind       ItemKind
+	Name       string
+	OldVersion string // Version in the registry before the push (empty for new items)
+	NewVersion string
+	Path       string // Manifest path in the registry
+}
+
+// Push copies a locally modified installed item, with the files its manifest
+// lists, into a registry working copy, bumps its version with a changelog
+// entry, and publishes it there as LocalRegistry.Publish does, keeping the
+// replaced version. An invalid manifest is refused with an
+// *InvalidManifestError. The installed copy is updated to the new version as
+// well, so it is not reported as outdated.
+// Items new to the registry are refused with a *ShadowError when another of
+// the client's sources has an item of the same name, unless AllowShadow is set.
+//
+// registryDir may also be the URL of a writable registry server, which the
+// item is uploaded to as Publish does.
+func (c *Client) Push(ctx context.Context, name, registryDir string, opts *PushOptions) (*PushResult, error) {
+	if opts == nil {
+		opts = &PushOptions{}
+	}
+
+	kind, itemName := ParseItemName(name)
+	if err := checkItemName(itemName); err != nil {
+		return nil, err
+	}
+
+	installedPath := filepath.Join(c.itemDir(kind, itemName), "vega.yaml")
+	content, err := c.cipher.readFile(installedPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s %q is not installed", kind, itemName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading installed manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing installed manifest: %w", err)
+	}
+
+	if isRemoteRegistry(registryDir) {
+		return c.pushRemote(ctx, kind, itemName, content, registryDir, opts)
+	}
+
+	registry, err := OpenLocalRegistry(registryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// What a publish would check: a valid manifest, for the item it is
+	// installed as, with every file it lists
+	if errs := ValidateManifest(&manifest); len(errs) > 0 {
+		return nil, &InvalidManifestError{Errors: errs}
+	}
+	if ItemKind(manifest.Kind) != kind || manifest.Name != itemName {
+		return nil, fmt.Errorf("installed %s %q has a manifest for %s %q", kind, itemName, manifest.Kind, manifest.Name)
+	}
+	files, err := readItemFiles(c.itemDir(kind, itemName), manifest.Files, c.cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PushResult{
+		Kind: kind,
+		Name: itemName,
+		Path: registry.ManifestPath(kind, itemName),
+	}
+
+	base := manifest.Version
+	if existing, err := registry.ReadManifest(kind, itemName); err == nil {
+		if bytes.Equal(existing, content) {
+			return nil, fmt.Errorf("%s %q has no local changes to push", kind, itemName)
+		}
+
+		var registryManifest Manifest
+		if err := yaml.Unmarshal(existing, &registryManifest); err != nil {
+			return nil, fmt.Errorf("parsing registry manifest: %w", err)
+		}
+		result.OldVersion = registryManifest.Version
+		if CompareVersions(registryManifest.Version, base) > 0 {
+			base = registryManifest.Version
+		}
+	}
+
+	if result.OldVersion == "" && !opts.AllowShadow {
+		shadows, err := c.Shadows(ctx, name, registryDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(shadows) > 0 {
+			return nil, &ShadowError{Kind: kind, Name: itemName, Shadows: shadows}
+		}
+	}
+
+	result.NewVersion, err = BumpVersion(base, opts.Bump)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	updated, err := bumpContent(content, result.NewVersion, opts.Reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// Publishing archives the replaced version and updates the index
+	if _, err := registry.Publish(updated, files); err != nil {
+		return nil, err
+	}
+	if err := c.cipher.writeFile(installedPath, updated, 0644); err != nil {
+		return nil, fmt.Errorf("updating installed manifest: %w", err)
+	}
+	if err := recordPush(c.itemDir(kind, itemName), result.NewVersion, updated, files); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// pushRemote publishes a locally modified installed item to a registry
+// server. The install record tells whether the item was modified.
+func (c *Client) pushRemote(ctx context.Context, kind ItemKind, name string, content []byte, registryURL string, opts *PushOptions) (*PushResult, error) {
+	dir := c.itemDir(kind, name)
+	if record, err := LoadInstallRecord(dir); err == nil && strings.EqualFold(record.SHA256, contentHash(content)) {
+		return nil, fmt.Errorf("%s %q has no local changes to push", kind, name)
+	}
+
+	published, err := c.publish(ctx, dir, registryURL, &PublishOptions{
+		Bump:   opts.Bump,
+		Reason: opts.Reason,
+		DryRun: opts.DryRun,
+
+		AllowShadow: opts.AllowShadow,
+		Auth:        opts.Auth,
+	}, c.cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		// Publishing may have bumped the installed manifest
+		pushed, err := c.cipher.readFile(filepath.Join(dir, "vega.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("reading installed manifest: %w", err)
+		}
+		var manifest Manifest
+		if err := yaml.Unmarshal(pushed, &manifest); err != nil {
+			return nil, fmt.Errorf("parsing installed manifest: %w", err)
+		}
+		files, err := readItemFiles(dir, manifest.Files, c.cipher)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordPush(dir, published.Version, pushed, files); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PushResult{
+		Kind:       kind,
+		Name:       name,
+		OldVersion: published.OldVersion,
+		NewVersion: published.Version,
+		Path:       strings.TrimSuffix(registryURL, "/") + "/" + kind.Plural() + "/" + name + "/vega.yaml",
+	}, nil
+}
+
+// recordPush updates the install record of a pushed item to its pushed
+// content, so that the push isn't taken for a local change. Items installed
+// without a record are left without one.
+func recordPush(dir, version string, content []byte, files map[string][]byte) error {
+	record, err := LoadInstallRecord(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	record.Version = version
+	record.SHA256 = contentHash(content)
+	record.Files = nil
+	for rel, content := range files {
+		if record.Files == nil {
+			record.Files = make(map[string]string)
+		}
+		record.Files[rel] = contentHash(content)
+	}
+	return writeInstallRecord(dir, record)
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// newPushRegistry returns a registry checkout holding the given manifests,
+// keyed by skill name.
+func newPushRegistry(t *testing.T, manifests map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"personas/index.yaml": "personas:\n",
+		"profiles/index.yaml": "profiles:\n",
+	}
+	index := "skills:\n"
+	for name, content := range manifests {
+		var m Manifest
+		if err := yaml.Unmarshal([]byte(content), &m); err != nil {
+			t.Fatal(err)
+		}
+		files["skills/"+name+"/vega.yaml"] = content
+		index += "  " + name + ":\n    version: " + m.Version + "\n"
+	}
+	files["skills/index.yaml"] = index
+	writeSource(t, dir, files)
+	return dir
+}
+
+// skillYAML returns a valid deploy-ops skill manifest.
+func skillYAML(version, description string) string {
+	return "kind: skill\nname: deploy-ops\nversion: " + version + "\ndescription: " + description + "\n" +
+		"tools:\n  - name: deploy\n    description: Deploy\n    run: echo deploy\n"
+}
+
+// editInstalled rewrites an installed skill's manifest.
+func editInstalled(t *testing.T, client *Client, name, content string) {
+	t.Helper()
+	path := filepath.Join(client.itemDir(KindSkill, name), "vega.yaml")
+	if err := client.cipher.writeFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPushArchivesReplacedVersion(t *testing.T) {
+	original := skillYAML("1.0.0", "Deploys")
+	client, _ := newMemoryClient(t, map[string]*Manifest{"deploy-ops": {Version: "1.0.0", Description: "Deploys"}})
+	ctx := context.Background()
+	if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	editInstalled(t, client, "deploy-ops", skillYAML("1.0.0", "Deploys faster"))
+
+	registry := newPushRegistry(t, map[string]string{"deploy-ops": original})
+	result, err := client.Push(ctx, "deploy-ops", registry, nil)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if result.OldVersion != "1.0.0" || result.NewVersion != "1.0.1" {
+		t.Errorf("pushed %s -> %s, want 1.0.0 -> 1.0.1", result.OldVersion, result.NewVersion)
+	}
+
+	archived, err := os.ReadFile(filepath.Join(registry, "skills", "deploy-ops", "versions", "1.0.0", "vega.yaml"))
+	if err != nil || string(archived) != original {
+		t.Errorf("replaced version archived as %q (%v)", archived, err)
+	}
+	versions, _, err := NewSource(registry, NewCache("", true)).Versions(ctx, KindSkill, "deploy-ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0] != "1.0.0" || versions[1] != "1.0.1" {
+		t.Errorf("registry serves versions %v, want [1.0.0 1.0.1]", versions)
+	}
+}
+
+// serveRegistry serves a registry checkout writable, with publishToken
+// allowed to publish.
+func serveRegistry(t *testing.T, dir string) string {
+	t.Helper()
+	server, err := NewServer(dir, &ServerOptions{
+		Writable: true,
+		Tokens:   []ServerToken{{Name: "ci", Token: publishToken, Role: RolePublish}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+const publishToken = "publish-token"
+
+func TestPushRemoteEncryptedInstall(t *testing.T) {
+	client, _ := newMemoryClient(t, map[string]*Manifest{"deploy-ops": {Version: "1.0.0", Description: "Deploys"}})
+	var err error
+	if client.cipher, err = newContentCipher(bytes.Repeat([]byte{7}, 32)); err != nil {
+		t.Fatal(err)
+	}
+	client.cache.cipher = client.cipher
+	ctx := context.Background()
+	if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	editInstalled(t, client, "deploy-ops", skillYAML("1.0.0", "Deploys faster"))
+
+	registry := newPushRegistry(t, map[string]string{
+		"deploy-ops": skillYAML("1.0.0", "Deploys"),
+	})
+	url := serveRegistry(t, registry)
+	if _, err := client.Push(ctx, "deploy-ops", url, &PushOptions{Auth: &SourceAuth{Token: publishToken}}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	published, err := os.ReadFile(filepath.Join(registry, "skills", "deploy-ops", "vega.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if isEncrypted(published) || yaml.Unmarshal(published, &m) != nil || m.Version != "1.0.1" || m.Description != "Deploys faster" {
+		t.Errorf("registry got %q, want the plain bumped manifest", published)
+	}
+
+	installed := filepath.Join(client.itemDir(KindSkill, "deploy-ops"), "vega.yaml")
+	raw, err := os.ReadFile(installed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncrypted(raw) {
+		t.Error("push wrote the installed manifest back in plain text")
+	}
+	if plain, err := client.cipher.readFile(installed); err != nil || !bytes.Equal(plain, published) {
+		t.Errorf("installed manifest is %q (%v), want the published one", plain, err)
+	}
+}
+
+func TestPushCopiesFiles(t *testing.T) {
+	manifest := skillYAML("1.0.0", "Deploys") + "files:\n  - prompts/deploy.md\n"
+	client, _ := newMemoryClient(t, map[string]*Manifest{
+		"deploy-ops": {Version: "1.0.0", Description: "Deploys", Files: []ItemFile{{Path: "prompts/deploy.md"}}},
+	}, MemoryFile{Path: "skills/deploy-ops/prompts/deploy.md", Content: []byte("Deploy carefully.")})
+	ctx := context.Background()
+	if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	editInstalled(t, client, "deploy-ops", strings.Replace(manifest, "Deploys", "Deploys faster", 1))
+
+	registry := newPushRegistry(t, map[string]string{"deploy-ops": skillYAML("1.0.0", "Deploys")})
+	if _, err := client.Push(ctx, "deploy-ops", registry, nil); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	prompt, err := os.ReadFile(filepath.Join(registry, "skills", "deploy-ops", "prompts", "deploy.md"))
+	if err != nil || string(prompt) != "Deploy carefully." {
+		t.Errorf("registry has prompt %q (%v)", prompt, err)
+	}
+}
+
+func TestPushRejectsInvalidManifest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest string
+	}{
+		{"renamed", strings.Replace(skillYAML("1.0.0", "Deploys faster"), "name: deploy-ops", "name: other-ops", 1)},
+		{"invalid", "kind: skill\nname: deploy-ops\nversion: 1.0.0\ndescription: No tools\n"},
+		{"missing file", skillYAML("1.0.0", "Deploys faster") + "files:\n  - prompts/missing.md\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, _ := newMemoryClient(t, map[string]*Manifest{"deploy-ops": {Version: "1.0.0", Description: "Deploys"}})
+			ctx := context.Background()
+			if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+				t.Fatalf("Install: %v", err)
+			}
+			editInstalled(t, client, "deploy-ops", tc.manifest)
+
+			registry := newPushRegistry(t, map[string]string{"deploy-ops": skillYAML("1.0.0", "Deploys")})
+			if _, err := client.Push(ctx, "deploy-ops", registry, nil); err == nil {
+				t.Fatal("Push succeeded")
+			}
+			content, err := os.ReadFile(filepath.Join(registry, "skills", "deploy-ops", "vega.yaml"))
+			if err != nil || string(content) != skillYAML("1.0.0", "Deploys") {
+				t.Errorf("registry manifest is now %q (%v)", content, err)
+			}
+		})
+	}
+}
+
+func TestPushThenUpgrade(t *testing.T) {
+	for _, remote := range []bool{false, true} {
+		name := "local"
+		if remote {
+			name = "remote"
+		}
+		t.Run(name, func(t *testing.T) {
+			client, src := newMemoryClient(t, map[string]*Manifest{
+				"deploy-ops": {Version: "1.0.0", Description: "Deploys", Files: []ItemFile{{Path: "prompts/deploy.md"}}},
+			}, MemoryFile{Path: "skills/deploy-ops/prompts/deploy.md", Content: []byte("Deploy carefully.")})
+			ctx := context.Background()
+			if err := client.Install(ctx, "deploy-ops", nil); err != nil {
+				t.Fatalf("Install: %v", err)
+			}
+			dir := client.itemDir(KindSkill, "deploy-ops")
+			editInstalled(t, client, "deploy-ops", skillYAML("1.0.0", "Deploys faster")+"files:\n  - prompts/deploy.md\n")
+			if err := os.WriteFile(filepath.Join(dir, "prompts", "deploy.md"), []byte("Deploy faster."), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			registry := newPushRegistry(t, map[string]string{"deploy-ops": skillYAML("1.0.0", "Deploys")})
+			opts := &PushOptions{}
+			if remote {
+				registry = serveRegistry(t, registry)
+				opts.Auth = &SourceAuth{Token: publishToken}
+			}
+			if _, err := client.Push(ctx, "deploy-ops", registry, opts); err != nil {
+				t.Fatalf("Push: %v", err)
+			}
+
+			drift, err := itemDrift(dir, client.cipher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(drift.Modified) > 0 || len(drift.Missing) > 0 {
+				t.Errorf("pushed item drifted: %+v", drift)
+			}
+			if record, err := LoadInstallRecord(dir); err != nil || record.Version != "1.0.1" {
+				t.Errorf("install record is %+v (%v), want version 1.0.1", record, err)
+			}
+
+			if err := src.Put("deploy-ops", &Manifest{Version: "1.1.0", Description: "Deploys"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Upgrade(ctx, []string{"deploy-ops"}, nil); err != nil {
+				t.Fatalf("Upgrade after push: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QueryTerm is one condition of a search query.
+type QueryTerm struct {
+	// Field is what the term matches: "" for the item's name, tags, and
+	// description (and a profile's persona and skills); "name" and
+	// "description" for text in them; "author" for the author or a
+	// maintainer; "tags", "tools", "persona", or "skills" for one exactly.
+	Field  string
+	Value  string
+	Negate bool // Match items the term doesn't match
+}
+
+// Query is a parsed search query: alternatives joined by OR, each a list of
+// terms that must all hold, except that the words of an alternative rank
+// items having any of them. Parse one with ParseQuery, or build it directly:
+//
+//	query := &population.Query{Any: [][]population.QueryTerm{{
+//		{Field: "tags", Value: "ops"},
+//		{Field: "tags", Value: "deprecated", Negate: true},
+//	}}}
+//
+// A query without terms matches everything.
+type Query struct {
+	Any [][]QueryTerm
+}
+
+// queryFields are the fields a term can name, and their aliases.
+var queryFields = map[string]string{
+	"name":        "name",
+	"tag":         "tags",
+	"tags":        "tags",
+	"author":      "author",
+	"maintainer":  "author",
+	"description": "description",
+	"desc":        "description",
+	"tool":        "tools",
+	"tools":       "tools",
+	"persona":     "persona",
+	"skill":       "skills",
+	"skills":      "skills",
+}
+
+// fieldMatchScore scores items matched by field terms alone, as if by their
+// description.
+const fieldMatchScore = 0.5
+
+// ParseQuery parses a search query such as
+//
+//	name:kubernetes tags:ops -tags:deprecated author:martell
+//
+// Words rank the items having any of them, "OR" separates alternatives, a
+// leading "-" negates a term, and field:value matches one field (see
+// QueryTerm). Double quotes group words into a phrase that must appear, as
+// in description:"incident response".
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := splitQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+	var group []QueryTerm
+	for i, token := range tokens {
+		if token == "OR" {
+			if len(group) == 0 || i == len(tokens)-1 {
+				return nil, fmt.Errorf("invalid query %q: OR needs terms on both sides", s)
+			}
+			q.Any = append(q.Any, group)
+			group = nil
+			continue
+		}
+		if token == "AND" {
+			continue // Implied
+		}
+
+		raw := token
+		var term QueryTerm
+		if len(token) > 1 && token[0] == '-' {
+			term.Negate = true
+			token = token[1:]
+		}
+		if field, value, ok := strings.Cut(token, ":"); ok && isQueryField(field) {
+			canonical, known := queryFields[strings.ToLower(field)]
+			if !known {
+				return nil, fmt.Errorf("unknown search field %q (use name, tags, author, description, tools, persona, or skills)", field)
+			}
+			term.Field, token = canonical, value
+		}
+		term.Value = strings.ReplaceAll(token, `"`, "")
+		if term.Value == "" {
+			return nil, fmt.Errorf("invalid query %q: %s needs a value", s, raw)
+		}
+		group = append(group, term)
+	}
+	if len(group) > 0 {
+		q.Any = append(q.Any, group)
+	}
+	return q, nil
+}
+
+// splitQuery splits a query at spaces outside double quotes, keeping the
+// quotes.
+func splitQuery(s string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid query %q: unterminated quote", s)
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+// isQueryField reports whether s has the form of a field name, so
+// "c++:" is searched for as text.
+func isQueryField(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the query so that ParseQuery reads it back.
+func (q *Query) String() string {
+	groups := make([]string, len(q.Any))
+	for i, group := range q.Any {
+		terms := make([]string, len(group))
+		for j, term := range group {
+			value := term.Value
+			if strings.ContainsFunc(value, unicode.IsSpace) || strings.Contains(value, ":") || value == "OR" || value == "AND" {
+				value = `"` + value + `"`
+			}
+			if term.Field != "" {
+				value = term.Field + ":" + value
+			}
+			if term.Negate {
+				value = "-" + value
+			}
+			terms[j] = value
+		}
+		groups[i] = strings.Join(terms, " ")
+	}
+	return strings.Join(groups, " OR ")
+}
+
+// validate checks that every term names a known field and has a value.
+func (q *Query) validate() error {
+	for _, group := range q.Any {
+		for _, term := range group {
+			if term.Field != "" && queryFields[term.Field] != term.Field {
+				return fmt.Errorf("unknown search field %q (use name, tags, author, description, tools, persona, or skills)", term.Field)
+			}
+			if term.Value == "" {
+				return fmt.Errorf("search query has a term without a value")
+			}
+		}
+	}
+	return nil
+}
+
+// searchDoc is what a query is matched against: an index entry of any kind.
+type searchDoc struct {
+	name, description, author, persona string
+	maintainers, tags, tools, skills   []string
+}
+
+// rank scores the entries of an index for the query, returning 0 for those
+// it doesn't match, and with explain, how each score was reached. Within an
+// alternative, text terms rank entries by BM25 over their names, tags, and
+// descriptions (and a profile's persona and skills), scaled to the best
+// entry; an entry matching one or more of the words matches the alternative.
+// An entry named exactly the text scores 1, field terms alone score
+// fieldMatchScore, and with fuzzy, entries without the words match names and
+// tags within a few typos of them. Each entry scores its best alternative.
+func (q *Query) rank(ix *searchIndex, fuzzy, explain bool) ([]float64, []string) {
+	n := len(ix.docs)
+	scores := make([]float64, n)
+	var explanations []string
+	if explain {
+		explanations = make([]string, n)
+	}
+	if len(q.Any) == 0 {
+		for i := range scores {
+			scores[i] = fieldMatchScore
+		}
+		return scores, explanations
+	}
+
+	// Ranked scores are scaled once the best of them is known
+	ranked := make([]float64, n)
+	rankedWhy := make([]string, n)
+	var best float64
+	for _, group := range q.Any {
+		var text []string
+		for _, term := range group {
+			if term.Field == "" && !term.Negate {
+				text = append(text, term.Value)
+			}
+		}
+		var raw map[int]float64
+		var why map[int]string
+		if len(text) > 0 {
+			raw, why = ix.rank(queryWords(strings.Join(text, " ")), explain)
+		}
+
+		for i, d := range ix.docs {
+			if !queryGroup(group).holds(d) {
+				continue
+			}
+			var score float64
+			var reason string
+			switch {
+			case len(text) == 0:
+				score, reason = fieldMatchScore, "matched fields"
+			case strings.EqualFold(strings.Join(text, " "), d.name):
+				score, reason = 1, "exact name match"
+			case raw[i] > 0:
+				if raw[i] > ranked[i] {
+					ranked[i], rankedWhy[i] = raw[i], why[i]
+				}
+				best = max(best, raw[i])
+			case fuzzy:
+				for _, value := range text {
+					score = max(score, fuzzyScore(strings.ToLower(value), append([]string{d.name, d.persona}, d.tags...)...))
+				}
+				reason = "fuzzy match"
+			}
+			if score > scores[i] {
+				scores[i] = score
+				if explain {
+					explanations[i] = reason
+				}
+			}
+		}
+	}
+
+	for i, raw := range ranked {
+		if raw == 0 {
+			continue
+		}
+		if score := fieldMatchScore + (0.99-fieldMatchScore)*raw/best; score > scores[i] {
+			scores[i] = score
+			if explain {
+				explanations[i] = rankedWhy[i]
+			}
+		}
+	}
+	return scores, explanations
+}
+
+// queryGroup is an alternative of a query.
+type queryGroup []QueryTerm
+
+// holds reports whether the item matches the alternative's field terms,
+// negations, and phrases, which must all hold whatever its words rank.
+func (g queryGroup) holds(d *searchDoc) bool {
+	for _, term := range g {
+		if term.Field == "" && !term.Negate && !strings.ContainsFunc(term.Value, unicode.IsSpace) {
+			continue // A word, ranked
+		}
+		if term.matches(d) == term.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// deepText returns the text terms of the first alternative whose other terms
+// the item matches, for a deep search of its manifest.
+func (q *Query) deepText(d *searchDoc) (string, bool) {
+	for _, group := range q.Any {
+		var words []string
+		ok := true
+		for _, term := range group {
+			if term.Field == "" && !term.Negate {
+				words = append(words, term.Value)
+				continue
+			}
+			if term.matches(d) == term.Negate {
+				ok = false
+				break
+			}
+		}
+		if ok && len(words) > 0 {
+			return strings.Join(words, " "), true
+		}
+	}
+	return "", false
+}
+
+// matches reports whether a term matches an item, ignoring Negate. Text
+// terms match text in the item's name, tags, or description, or a profile's
+// persona or skills.
+func (t QueryTerm) matches(d *searchDoc) bool {
+	value := strings.ToLower(t.Value)
+	contains := func(s string) bool {
+		return strings.Contains(strings.ToLower(s), value)
+	}
+	anyContains := func(list []string) bool {
+		for _, s := range list {
+			if contains(s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch t.Field {
+	case "":
+		return contains(d.name) || anyContains(d.tags) || contains(d.description) ||
+			contains(d.persona) || anyContains(d.skills)
+	case "name":
+		return contains(d.name)
+	case "description":
+		return contains(d.description)
+	case "author":
+		return contains(d.author) || anyContains(d.maintainers)
+	case "tags":
+		return containsFold(d.tags, value)
+	case "tools":
+		return containsFold(d.tools, value)
+	case "persona":
+		return strings.EqualFold(d.persona, value)
+	case "skills":
+		return containsFold(d.skills, value)
+	}
+	return false
+}
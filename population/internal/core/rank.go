@@ -0,0 +1,211 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: how quickly repeats of a word stop adding to a score, and
+// how much longer entries are discounted.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// prefixWeight discounts a word matching only the start of an indexed word,
+// as "kube" does "kubernetes".
+const prefixWeight = 0.5
+
+// Ranked fields of an entry, and how much a word in each counts.
+const (
+	rankName = iota
+	rankTags
+	rankDescription
+	rankRelated // A profile's persona and skills
+	rankFields
+)
+
+var (
+	rankFieldNames   = [rankFields]string{"name", "tags", "description", "persona/skills"}
+	rankFieldWeights = [rankFields]float64{3, 2, 1, 1}
+)
+
+// stopWords are left out of ranked queries unless nothing else is left.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "for": true, "in": true, "of": true,
+	"on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// posting is the occurrences of a word in one field of an entry.
+type posting struct {
+	doc   int
+	field int
+	count int
+}
+
+// searchIndex is an inverted index of the entries being searched, ranking
+// them for a list of words with BM25, weighting each field.
+type searchIndex struct {
+	docs     []*searchDoc
+	lengths  []float64 // Weighted number of words in each entry
+	avg      float64
+	postings map[string][]posting
+	words    []string // Indexed words, sorted for prefix lookups
+}
+
+// newSearchIndex indexes the words of the entries' ranked fields.
+func newSearchIndex(docs []*searchDoc) *searchIndex {
+	ix := &searchIndex{docs: docs, lengths: make([]float64, len(docs)), postings: make(map[string][]posting)}
+	var total float64
+	for i, d := range docs {
+		fields := [rankFields][]string{
+			rankName:        tokenize(d.name),
+			rankTags:        tokenize(strings.Join(d.tags, " ")),
+			rankDescription: tokenize(d.description),
+			rankRelated:     tokenize(d.persona + " " + strings.Join(d.skills, " ")),
+		}
+		for field, words := range fields {
+			counts := make(map[string]int)
+			for _, word := range words {
+				counts[word]++
+			}
+			for word, count := range counts {
+				ix.postings[word] = append(ix.postings[word], posting{i, field, count})
+			}
+			ix.lengths[i] += rankFieldWeights[field] * float64(len(words))
+		}
+		total += ix.lengths[i]
+	}
+	if len(docs) > 0 {
+		ix.avg = total / float64(len(docs))
+	}
+	for word := range ix.postings {
+		ix.words = append(ix.words, word)
+	}
+	sort.Strings(ix.words)
+	return ix
+}
+
+// fieldCounts is how often a word occurs in each field of an entry, with
+// prefix matches discounted.
+type fieldCounts [rankFields]float64
+
+// matches returns the entries a query word occurs in, by entry.
+func (ix *searchIndex) matches(word string) map[int]*fieldCounts {
+	found := make(map[int]*fieldCounts)
+	add := func(postings []posting, weight float64) {
+		for _, p := range postings {
+			counts := found[p.doc]
+			if counts == nil {
+				counts = &fieldCounts{}
+				found[p.doc] = counts
+			}
+			counts[p.field] += weight * float64(p.count)
+		}
+	}
+	add(ix.postings[word], 1)
+	if len(word) >= 3 {
+		for i := sort.SearchStrings(ix.words, word); i < len(ix.words) && strings.HasPrefix(ix.words[i], word); i++ {
+			if ix.words[i] != word {
+				add(ix.postings[ix.words[i]], prefixWeight)
+			}
+		}
+	}
+	return found
+}
+
+// rank scores every entry for the words, returning the BM25 score of each
+// entry with any of them, and with explain, what each word contributed.
+func (ix *searchIndex) rank(words []string, explain bool) (map[int]float64, map[int]string) {
+	scores := make(map[int]float64)
+	var parts map[int][]string
+	if explain {
+		parts = make(map[int][]string)
+	}
+
+	n := float64(len(ix.docs))
+	for _, word := range words {
+		found := ix.matches(word)
+		if len(found) == 0 {
+			continue
+		}
+		df := float64(len(found))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for doc, counts := range found {
+			var tf float64
+			for field, count := range counts {
+				tf += rankFieldWeights[field] * count
+			}
+			norm := 1 - bm25B + bm25B*ix.lengths[doc]/ix.avg
+			score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			scores[doc] += score
+
+			if explain {
+				var fields []string
+				for field, count := range counts {
+					if count > 0 {
+						share := score * rankFieldWeights[field] * count / tf
+						fields = append(fields, fmt.Sprintf("%s %.2f", rankFieldNames[field], share))
+					}
+				}
+				parts[doc] = append(parts[doc], fmt.Sprintf("%s (%s)", word, strings.Join(fields, ", ")))
+			}
+		}
+	}
+
+	var explanations map[int]string
+	if explain {
+		explanations = make(map[int]string, len(parts))
+		for doc, p := range parts {
+			explanations[doc] = fmt.Sprintf("bm25 %.2f: %s", scores[doc], strings.Join(p, " + "))
+		}
+	}
+	return scores, explanations
+}
+
+// tokenize splits text into lowercase words at anything but letters and
+// digits, reducing common plurals to their singular.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range fields {
+		fields[i] = stem(word)
+	}
+	return fields
+}
+
+// queryWords returns the words of query text to rank by, leaving out stop
+// words unless nothing else is left.
+func queryWords(text string) []string {
+	words := tokenize(text)
+	var kept []string
+	for _, word := range words {
+		if !stopWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		return words
+	}
+	return kept
+}
+
+// stem reduces a plural to its singular: "policies" to "policy", "patches"
+// to "patch", and "tools" to "tool". Short words and words ending in "ss",
+// "us", or "is" are kept.
+func stem(word string) string {
+	n := len(word)
+	switch {
+	case n > 4 && strings.HasSuffix(word, "ies"):
+		return word[:n-3] + "y"
+	case n > 4 && (strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes") || strings.HasSuffix(word, "xes")):
+		return word[:n-2]
+	case n > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:n-1]
+	}
+	return word
+}
@@ -0,0 +1,268 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocalRegistry is a writable registry working copy, such as a checkout of the
+// vega-population repository. It uses the same layout as a source.
+type LocalRegistry struct {
+	dir string
+	mu  sync.Mutex // Held by publishes and garbage collection, which rewrite items and indexes
+}
+
+// OpenLocalRegistry opens the registry working copy at dir.
+func OpenLocalRegistry(dir string) (*LocalRegistry, error) {
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile} {
+		indexPath := filepath.Join(dir, kind.Plural(), "index.yaml")
+		if _, err := os.Stat(indexPath); err != nil {
+			return nil, fmt.Errorf("%s is not a registry checkout: missing %s/index.yaml", dir, kind.Plural())
+		}
+	}
+	return &LocalRegistry{dir: dir}, nil
+}
+
+// Dir returns the registry directory.
+func (r *LocalRegistry) Dir() string {
+	return r.dir
+}
+
+// ManifestPath returns the path of an item's manifest in the registry.
+func (r *LocalRegistry) ManifestPath(kind ItemKind, name string) string {
+	return filepath.Join(r.dir, kind.Plural(), name, "vega.yaml")
+}
+
+// ReadManifest reads an item's manifest from the registry.
+func (r *LocalRegistry) ReadManifest(kind ItemKind, name string) ([]byte, error) {
+	content, err := os.ReadFile(r.ManifestPath(kind, name))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s %q from registry: %w", kind, name, err)
+	}
+	return content, nil
+}
+
+// WriteManifest writes an item's manifest into the registry.
+func (r *LocalRegistry) WriteManifest(kind ItemKind, name string, content []byte) error {
+	path := r.ManifestPath(kind, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// UpdateIndex creates or updates the index entry for a manifest. Only the
+// entry's own fields are rewritten; comments, blank lines, and the layout of
+// the rest of the index are left untouched so diffs stay reviewable.
+func (r *LocalRegistry) UpdateIndex(kind ItemKind, m *Manifest) error {
+	indexPath := filepath.Join(r.dir, kind.Plural(), "index.yaml")
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		return fmt.Errorf("reading index: %w", err)
+	}
+
+	fields := []indexField{
+		{"version", m.Version},
+		{"description", m.Description},
+		{"author", m.Author},
+	}
+	if len(m.Maintainers) > 0 {
+		fields = append(fields, indexField{"maintainers", m.Maintainers})
+	}
+	if m.Status != "" {
+		fields = append(fields, indexField{"status", m.Status})
+	}
+	if m.Deprecated != "" {
+		fields = append(fields, indexField{"deprecated", m.Deprecated})
+	}
+	if kind == KindProfile {
+		fields = append(fields,
+			indexField{"persona", m.Persona},
+			indexField{"skills", m.Skills},
+		)
+	} else if len(m.Tags) > 0 {
+		fields = append(fields, indexField{"tags", m.Tags})
+	}
+
+	// Publish the manifest's hash, keeping the hashes of older versions
+	manifest, err := r.ReadManifest(kind, m.Name)
+	if err != nil {
+		return err
+	}
+	sums, err := indexChecksums(content, kind, m.Name)
+	if err != nil {
+		return err
+	}
+	sums[m.Version] = contentHash(manifest)
+	fields = append(fields, indexField{"sha256", sums})
+
+	updated, err := updateIndexEntry(content, kind.Plural(), m.Name, fields)
+	if err != nil {
+		return fmt.Errorf("updating %s index: %w", kind.Plural(), err)
+	}
+
+	if err := os.WriteFile(indexPath, updated, 0644); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+	return nil
+}
+
+// indexChecksums returns the hashes an index publishes for an item, by version.
+func indexChecksums(content []byte, kind ItemKind, name string) (map[string]string, error) {
+	var index map[string]map[string]struct {
+		SHA256 map[string]string `yaml:"sha256"`
+	}
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("parsing %s index: %w", kind.Plural(), err)
+	}
+
+	sums := index[kind.Plural()][name].SHA256
+	if sums == nil {
+		sums = make(map[string]string)
+	}
+	return sums, nil
+}
+
+// indexField is a key and value written into an index entry.
+type indexField struct {
+	key   string
+	value interface{}
+}
+
+// updateIndexEntry rewrites the fields of one entry in an index file.
+// Entries look like:
+//
+//	skills:
+//	  name:
+//	    version: 1.0.0
+//	    tags: [a, b]
+//
+// Missing fields are added at the end of the entry, and a missing entry is
+// appended to the end of the file.
+func updateIndexEntry(content []byte, section, name string, fields []indexField) ([]byte, error) {
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	rendered := make([]string, len(fields))
+	for i, f := range fields {
+		value, err := renderFlowYAML(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", f.key, err)
+		}
+		rendered[i] = f.key + ": " + value
+	}
+
+	// Find the entry: a "name:" line indented under the section
+	start, entryIndent := -1, 0
+	inSection := false
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		trimmed := strings.TrimSpace(line)
+		if indent == 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			inSection = trimmed == section+":"
+			continue
+		}
+		if inSection && indent > 0 && trimmed == name+":" {
+			start, entryIndent = i, indent
+			break
+		}
+	}
+
+	if start < 0 {
+		fieldIndent := strings.Repeat(" ", 4)
+		lines = append(lines, "", "  "+name+":")
+		for _, r := range rendered {
+			lines = append(lines, fieldIndent+r)
+		}
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	}
+
+	// The entry ends at the next non-blank line that isn't indented deeper
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+		if trimmed != "" && indent <= entryIndent {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	fieldIndent := strings.Repeat(" ", entryIndent+2)
+	for i := start + 1; i < end; i++ {
+		if trimmed := strings.TrimSpace(lines[i]); trimmed != "" {
+			fieldIndent = lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " "))]
+			break
+		}
+	}
+
+	for i, f := range fields {
+		found := false
+		for j := start + 1; j < end; j++ {
+			if strings.HasPrefix(lines[j], fieldIndent+f.key+":") {
+				lines[j] = fieldIndent + rendered[i]
+				found = true
+				break
+			}
+		}
+		if !found {
+			lines = append(lines[:end], append([]string{fieldIndent + rendered[i]}, lines[end:]...)...)
+			end++
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// renderFlowYAML renders a value on a single line, using flow style for
+// lists and maps and double quotes for multi-line strings, which would
+// otherwise become block scalars whose lines aren't indented under the entry.
+func renderFlowYAML(value interface{}) (string, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return "", err
+	}
+	setFlowStyle(&node)
+
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// setFlowStyle makes a node and everything in it render on a single line.
+func setFlowStyle(node *yaml.Node) {
+	switch {
+	case node.Kind == yaml.ScalarNode && node.Tag == "!!str" && strings.ContainsAny(node.Value, "\r\n"):
+		node.Style = yaml.DoubleQuotedStyle
+	case node.Kind != yaml.ScalarNode:
+		node.Style |= yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		setFlowStyle(child)
+	}
+}
+
+// setManifestVersion rewrites the top-level version field of raw manifest
+// content, leaving every other byte as it was.
+func setManifestVersion(content []byte, version string) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "version:") {
+			lines[i] = "version: " + version
+			return []byte(strings.Join(lines, "\n")), nil
+		}
+	}
+	return nil, fmt.Errorf("manifest has no top-level version field")
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUpdateIndexEntryMultilineRoundTrip(t *testing.T) {
+	description := "Deploys services.\nHandles rollbacks:\n  - canary\n  - blue/green\n"
+	long := strings.Repeat("word ", 40)
+	index := []byte("skills:\n  docker-ops:\n    version: 1.0.0\n    tags: [docker]\n")
+
+	for _, name := range []string{"deploy-ops", "docker-ops"} {
+		updated, err := updateIndexEntry(index, "skills", name, []indexField{
+			{"version", "1.1.0"},
+			{"description", description},
+			{"author", long},
+			{"tags", []string{"deploy", "multi\nline"}},
+			{"sha256", map[string]string{"1.1.0": "abc"}},
+		})
+		if err != nil {
+			t.Fatalf("updateIndexEntry(%s): %v", name, err)
+		}
+
+		var parsed SkillsIndex
+		if err := yaml.Unmarshal(updated, &parsed); err != nil {
+			t.Fatalf("updated index for %s does not parse: %v\n%s", name, err, updated)
+		}
+		entry, ok := parsed.Skills[name]
+		if !ok {
+			t.Fatalf("updated index has no %s:\n%s", name, updated)
+		}
+		if entry.Description != description || entry.Author != long {
+			t.Errorf("%s round-tripped as %q by %q", name, entry.Description, entry.Author)
+		}
+		if len(entry.Tags) != 2 || entry.Tags[1] != "multi\nline" {
+			t.Errorf("%s tags round-tripped as %q", name, entry.Tags)
+		}
+		if _, ok := parsed.Skills["docker-ops"]; !ok {
+			t.Errorf("updating %s lost docker-ops:\n%s", name, updated)
+		}
+		index = updated
+	}
+}
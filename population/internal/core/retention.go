@@ -0,0 +1,344 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultGCInterval is how often a writable server collects garbage when it
+// has a retention policy.
+const DefaultGCInterval = 24 * time.Hour
+
+// staleUploadAge is how old staged uploads whose manifest never arrived must
+// be before they are collected.
+const staleUploadAge = time.Hour
+
+// RetentionPolicy says which older versions of its items a registry keeps.
+// The current version of an item is always kept.
+type RetentionPolicy struct {
+	KeepLast int // Versions of each item to keep, counting the current one (at least 1)
+
+	// KeepReferenced also keeps the skill versions that the kept versions of
+	// profiles pin with a version constraint, however old.
+	KeepReferenced bool
+}
+
+// GCResult describes a garbage collection of a registry.
+type GCResult struct {
+	Removed []RemovedVersion `json:"removed"`
+	Uploads int              `json:"uploads"`         // Stale staged uploads removed
+	Files   []FileChange     `json:"files,omitempty"` // Files removed or modified, or that would be with dryRun
+	DryRun  bool             `json:"dry_run,omitempty"`
+}
+
+// RemovedVersion is an item version removed by garbage collection.
+type RemovedVersion struct {
+	Kind    ItemKind `json:"kind"`
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+}
+
+// indexHistory is the part of an index entry describing older versions.
+type indexHistory struct {
+	Versions   []string                `yaml:"versions"`
+	SHA256     map[string]string       `yaml:"sha256"`
+	Signatures map[string]SignatureRef `yaml:"signatures"`
+}
+
+// CollectGarbage removes the older versions of items that the policy doesn't
+// keep, with their index entries, and uploads staged for more than an hour
+// whose manifest never arrived. With dryRun it only reports what it would
+// remove.
+func (r *LocalRegistry) CollectGarbage(policy RetentionPolicy, dryRun bool) (*GCResult, error) {
+	if policy.KeepLast < 1 {
+		return nil, fmt.Errorf("a retention policy must keep at least 1 version")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx := context.Background()
+	source := NewSource(r.dir, NewCache("", true))
+	result := &GCResult{Removed: []RemovedVersion{}, DryRun: dryRun}
+
+	// Work out the versions to keep for every item
+	versions := make(map[requirementKey][]string) // Newest first
+	keep := make(map[requirementKey]map[string]bool)
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile} {
+		entries, profiles, err := source.getIndex(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(entries)+len(profiles))
+		for name := range entries {
+			names = append(names, name)
+		}
+		for name := range profiles {
+			names = append(names, name)
+		}
+
+		for _, name := range names {
+			all, _, err := source.Versions(ctx, kind, name)
+			if err != nil {
+				return nil, err
+			}
+			sort.SliceStable(all, func(i, j int) bool { return CompareVersions(all[i], all[j]) > 0 })
+
+			key := requirementKey{kind, name}
+			versions[key] = all
+			keep[key] = make(map[string]bool)
+			for i, v := range all {
+				if i < policy.KeepLast {
+					keep[key][v] = true
+				}
+			}
+		}
+	}
+
+	if policy.KeepReferenced {
+		// Collected apart from keep, which is being ranged over
+		referenced := make(map[requirementKey]map[string]bool)
+		for key, kept := range keep {
+			if key.kind != KindProfile {
+				continue
+			}
+			for version := range kept {
+				if err := r.keepReferenced(ctx, source, key.name, version, versions, referenced); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for key, vs := range referenced {
+			for v := range vs {
+				keep[key][v] = true
+			}
+		}
+	}
+
+	keys := make([]requirementKey, 0, len(versions))
+	for key := range versions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind < keys[j].kind
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	indexes := make(map[string]bool) // Index files listed as modified
+	for _, key := range keys {
+		var removed []string
+		for _, v := range versions[key] {
+			if !keep[key][v] {
+				removed = append(removed, v)
+				result.Removed = append(result.Removed, RemovedVersion{Kind: key.kind, Name: key.name, Version: v})
+			}
+		}
+		if len(removed) == 0 {
+			continue
+		}
+
+		itemDir := filepath.Dir(r.ManifestPath(key.kind, key.name))
+		for _, v := range removed {
+			changes, err := planRemoveDir(filepath.Join(itemDir, "versions", v))
+			if err != nil {
+				return nil, err
+			}
+			result.Files = append(result.Files, changes...)
+		}
+		if indexPath := filepath.Join(r.dir, key.kind.Plural(), "index.yaml"); !indexes[indexPath] {
+			indexes[indexPath] = true
+			result.Files = append(result.Files, FileChange{Action: FileModify, Path: indexPath})
+		}
+		if dryRun {
+			continue
+		}
+		for _, v := range removed {
+			if err := os.RemoveAll(filepath.Join(itemDir, "versions", v)); err != nil {
+				return nil, fmt.Errorf("removing %s %q %s: %w", key.kind, key.name, v, err)
+			}
+		}
+		err := r.updateHistory(key.kind, key.name, func(h *indexHistory) {
+			for _, v := range removed {
+				h.Versions = removeString(h.Versions, v)
+				delete(h.SHA256, v)
+				delete(h.Signatures, v)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stale, err := r.collectUploads(time.Now().Add(-staleUploadAge), dryRun, result)
+	if err != nil {
+		return nil, err
+	}
+	result.Uploads = stale
+	sortFileChanges(result.Files)
+	return result, nil
+}
+
+// keepReferenced adds the skill versions a profile version pins to referenced.
+func (r *LocalRegistry) keepReferenced(ctx context.Context, source *Source, profile, version string, versions map[requirementKey][]string, referenced map[requirementKey]map[string]bool) error {
+	content, _, err := source.resolveManifest(ctx, KindProfile, profile, version)
+	if err != nil {
+		return fmt.Errorf("reading profile %q %s: %w", profile, version, err)
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("parsing profile %q %s: %w", profile, version, err)
+	}
+
+	for _, ref := range manifest.Skills {
+		if ref.Version == "" {
+			continue // Resolves to the current version, which is always kept
+		}
+		vc, err := ParseConstraint(ref.Version)
+		if err != nil {
+			continue // Reported by validation
+		}
+		key := requirementKey{KindSkill, ref.Name}
+		if best, ok := vc.Best(versions[key]); ok {
+			if referenced[key] == nil {
+				referenced[key] = make(map[string]bool)
+			}
+			referenced[key][best] = true
+		}
+	}
+	return nil
+}
+
+// collectUploads removes the staged uploads of items not touched since before,
+// listing their files in result.
+func (r *LocalRegistry) collectUploads(before time.Time, dryRun bool, result *GCResult) (int, error) {
+	staging := filepath.Join(r.dir, uploadDir)
+	kinds, err := os.ReadDir(staging)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading staged uploads: %w", err)
+	}
+
+	removed := 0
+	for _, kind := range kinds {
+		items, err := os.ReadDir(filepath.Join(staging, kind.Name()))
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			info, err := item.Info()
+			if err != nil || info.ModTime().After(before) {
+				continue
+			}
+			removed++
+			dir := filepath.Join(staging, kind.Name(), item.Name())
+			changes, err := planRemoveDir(dir)
+			if err != nil {
+				return removed, err
+			}
+			result.Files = append(result.Files, changes...)
+			if dryRun {
+				continue
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				return removed, fmt.Errorf("removing staged upload: %w", err)
+			}
+		}
+	}
+	return removed, nil
+}
+
+// archiveCurrent moves the files of an item's current version under
+// versions/<version>/ and lists the version in the index, so it is still
+// served after a newer version replaces it.
+func (r *LocalRegistry) archiveCurrent(kind ItemKind, name, version string) error {
+	dir := filepath.Dir(r.ManifestPath(kind, name))
+	archive := filepath.Join(dir, "versions", version)
+	if err := os.RemoveAll(archive); err != nil {
+		return fmt.Errorf("archiving %s %q %s: %w", kind, name, version, err)
+	}
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return fmt.Errorf("archiving %s %q %s: %w", kind, name, version, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("archiving %s %q %s: %w", kind, name, version, err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == "versions" {
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, entry.Name()), filepath.Join(archive, entry.Name())); err != nil {
+			return fmt.Errorf("archiving %s %q %s: %w", kind, name, version, err)
+		}
+	}
+
+	return r.updateHistory(kind, name, func(h *indexHistory) {
+		h.Versions = append(removeString(h.Versions, version), version)
+		sort.SliceStable(h.Versions, func(i, j int) bool { return CompareVersions(h.Versions[i], h.Versions[j]) < 0 })
+		if sig, ok := h.Signatures[version]; ok {
+			sig.Path = filepath.ToSlash(filepath.Join(kind.Plural(), name, "versions", version, filepath.Base(sig.Path)))
+			h.Signatures[version] = sig
+		}
+	})
+}
+
+// updateHistory rewrites the older versions, hashes, and signatures an
+// index entry lists.
+func (r *LocalRegistry) updateHistory(kind ItemKind, name string, update func(*indexHistory)) error {
+	indexPath := filepath.Join(r.dir, kind.Plural(), "index.yaml")
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		return fmt.Errorf("reading index: %w", err)
+	}
+
+	var index map[string]map[string]indexHistory
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return fmt.Errorf("parsing %s index: %w", kind.Plural(), err)
+	}
+	history := index[kind.Plural()][name]
+	if history.SHA256 == nil {
+		history.SHA256 = make(map[string]string)
+	}
+	if history.Signatures == nil {
+		history.Signatures = make(map[string]SignatureRef)
+	}
+	update(&history)
+
+	fields := []indexField{{"versions", history.Versions}, {"sha256", history.SHA256}}
+	if len(history.Signatures) > 0 {
+		fields = append(fields, indexField{"signatures", history.Signatures})
+	}
+	if history.Versions == nil {
+		fields[0].value = []string{}
+	}
+	updated, err := updateIndexEntry(content, kind.Plural(), name, fields)
+	if err != nil {
+		return fmt.Errorf("updating %s index: %w", kind.Plural(), err)
+	}
+	if err := os.WriteFile(indexPath, updated, 0644); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+	return nil
+}
+
+// removeString returns list without s.
+func removeString(list []string, s string) []string {
+	var kept []string
+	for _, item := range list {
+		if item != s {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
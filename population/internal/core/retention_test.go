@@ -0,0 +1,32 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollectGarbageKeepsReferenced(t *testing.T) {
+	registry, err := OpenLocalRegistry(newPushRegistry(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{
+		skillYAML("1.0.0", "Deploys"),
+		skillYAML("1.1.0", "Deploys"),
+		skillYAML("1.2.0", "Deploys"),
+		"kind: profile\nname: platform\nversion: 1.0.0\ndescription: Platform\npersona: sre\nskills:\n  - deploy-ops@~1.0\n",
+	} {
+		if _, err := registry.Publish([]byte(content), nil); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	result, err := registry.CollectGarbage(RetentionPolicy{KeepLast: 1, KeepReferenced: true}, false)
+	if err != nil {
+		t.Fatalf("CollectGarbage: %v", err)
+	}
+	want := []RemovedVersion{{Kind: KindSkill, Name: "deploy-ops", Version: "1.1.0"}}
+	if !reflect.DeepEqual(result.Removed, want) {
+		t.Errorf("removed %+v, want %+v", result.Removed, want)
+	}
+}
@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Search parses a query (see ParseQuery) and searches across all item types
+// for it.
+func (s *Source) Search(ctx context.Context, query string, opts *SearchOptions) ([]SearchResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.SearchQuery(ctx, q, opts)
+}
+
+// SearchQuery searches across all item types and returns matching results.
+func (s *Source) SearchQuery(ctx context.Context, query *Query, opts *SearchOptions) ([]SearchResult, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	kinds := []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings}
+	if opts.Kind != "" {
+		kinds = []ItemKind{opts.Kind}
+	}
+
+	// Entries of every kind are ranked together, so a word common in one
+	// kind's entries counts for less in all of them
+	var candidates []SearchResult
+	var docs []*searchDoc
+	for _, kind := range kinds {
+		entries, profiles, err := s.getIndex(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		// In name order, so results that rank the same come out the same way
+		for _, name := range sortedKeys(entries) {
+			entry := entries[name]
+			if opts.Status != "" && entry.Status.Effective() != opts.Status {
+				continue
+			}
+			candidates = append(candidates, SearchResult{
+				Kind:        kind,
+				Name:        name,
+				Version:     entry.Version,
+				Description: entry.Description,
+				Status:      entry.Status.Effective(),
+				Tags:        entry.Tags,
+				Source:      s.name,
+				Warnings:    s.entryWarnings(kind, entry.Description, entry.Author, entry.Deprecated),
+			})
+			docs = append(docs, &searchDoc{
+				name:        name,
+				description: entry.Description,
+				author:      entry.Author,
+				maintainers: entry.Maintainers,
+				tags:        entry.Tags,
+				tools:       entry.Tools,
+			})
+		}
+		for _, name := range sortedKeys(profiles) {
+			entry := profiles[name]
+			if opts.Status != "" && entry.Status.Effective() != opts.Status {
+				continue
+			}
+			candidates = append(candidates, SearchResult{
+				Kind:        kind,
+				Name:        name,
+				Version:     entry.Version,
+				Description: entry.Description,
+				Status:      entry.Status.Effective(),
+				Tags:        nil, // Profiles don't have tags in the index
+				Source:      s.name,
+				Warnings:    s.entryWarnings(kind, entry.Description, entry.Author, entry.Deprecated),
+			})
+			persona, _ := SplitVersion(entry.Persona)
+			docs = append(docs, &searchDoc{
+				name:        name,
+				description: entry.Description,
+				author:      entry.Author,
+				maintainers: entry.Maintainers,
+				persona:     persona,
+				skills:      entry.Skills.Names(),
+			})
+		}
+	}
+
+	var results []SearchResult
+	var deep []SearchResult // Entries only a deep search could match
+	var deepQueries []string
+	scores, explanations := query.rank(newSearchIndex(docs), opts.Fuzzy, opts.Explain)
+	for i, result := range candidates {
+		doc := docs[i]
+		if !matchesTags(doc.tags, opts.Tags) {
+			continue
+		}
+		if result.Score = scores[i]; result.Score > 0 {
+			if opts.Explain {
+				result.Explain = explanations[i]
+			}
+			results = append(results, result)
+		} else if text, ok := query.deepText(doc); ok && opts.Deep {
+			deep = append(deep, result)
+			deepQueries = append(deepQueries, text)
+		}
+	}
+
+	if len(deep) > 0 {
+		for _, r := range s.deepSearch(ctx, deep, deepQueries) {
+			if opts.Explain {
+				r.Explain = "deep match"
+			}
+			results = append(results, r)
+		}
+	}
+
+	sortResults(results)
+
+	// Apply limit
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	return results, nil
+}
+
+// sortResults orders results by score, highest first, and results that
+// score the same by name, kind, and source, so the order never changes from
+// run to run.
+func sortResults(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch {
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		case a.Kind != b.Kind:
+			return kindOrder(a.Kind) < kindOrder(b.Kind)
+		default:
+			return a.Source < b.Source
+		}
+	})
+}
+
+// matchesTags reports whether tags include one of the filter tags, or there
+// is no filter.
+func matchesTags(tags, filterTags []string) bool {
+	if len(filterTags) == 0 {
+		return true
+	}
+	for _, filterTag := range filterTags {
+		for _, tag := range tags {
+			if strings.EqualFold(tag, filterTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxFuzzyScore caps fuzzy matches below every exact kind of match.
+const maxFuzzyScore = 0.3
+
+// fuzzyScore scores how closely the query matches any of the words, or the
+// dash-separated parts of them, allowing a typo per four letters of the
+// query. It returns 0 when nothing is close enough.
+func fuzzyScore(query string, words ...string) float64 {
+	allowed := len(query) / 4
+	if allowed == 0 {
+		return 0 // Too short to tell typos from other words
+	}
+
+	var best float64
+	for _, word := range words {
+		word = strings.ToLower(word)
+		if word == "" {
+			continue
+		}
+		for _, candidate := range append([]string{word}, strings.Split(word, "-")...) {
+			d := editDistance(query, candidate)
+			if d > allowed {
+				continue
+			}
+			longest := len(query)
+			if len(candidate) > longest {
+				longest = len(candidate)
+			}
+			if score := maxFuzzyScore * (1 - float64(d)/float64(longest)); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions,
+// and transpositions of adjacent letters that turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Three rows suffice: transpositions look two rows back
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// resultKeys returns the kind, name, and source of each result, in order.
+func resultKeys(results []SearchResult) []string {
+	keys := []string{}
+	for _, r := range results {
+		keys = append(keys, FormatItemName(r.Kind, r.Name)+" from "+r.Source)
+	}
+	return keys
+}
+
+func TestSortResultsShuffled(t *testing.T) {
+	want := []SearchResult{
+		{Kind: KindSkill, Name: "zeta", Score: 0.9, Source: "a"},
+		{Kind: KindSkill, Name: "alpha", Score: 0.5, Source: "a"},
+		{Kind: KindSkill, Name: "ops", Score: 0.5, Source: "a"},
+		{Kind: KindSkill, Name: "ops", Score: 0.5, Source: "b"},
+		{Kind: KindPersona, Name: "ops", Score: 0.5, Source: "a"},
+		{Kind: KindProfile, Name: "ops", Score: 0.5, Source: "a"},
+		{Kind: KindSettings, Name: "ops", Score: 0.5, Source: "a"},
+		{Kind: KindSkill, Name: "beta", Score: 0.1, Source: "a"},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		results := append([]SearchResult(nil), want...)
+		rng.Shuffle(len(results), func(i, j int) { results[i], results[j] = results[j], results[i] })
+		sortResults(results)
+		if got := resultKeys(results); !reflect.DeepEqual(got, resultKeys(want)) {
+			t.Fatalf("sorted to %v, want %v", got, resultKeys(want))
+		}
+	}
+}
+
+// tiedManifests are items that all score the same for the query "tied".
+func tiedManifests() map[string]*Manifest {
+	return map[string]*Manifest{
+		"ops":     {Version: "1.0.0", Description: "tied"},
+		"@ops":    {Version: "1.0.0", Description: "tied"},
+		"+ops":    {Version: "1.0.0", Description: "tied", Persona: "ops"},
+		"delta":   {Version: "1.0.0", Description: "tied"},
+		"alpha":   {Version: "1.0.0", Description: "tied"},
+		"@charly": {Version: "1.0.0", Description: "tied"},
+		"bravo":   {Version: "1.0.0", Description: "tied"},
+	}
+}
+
+func TestSearchOrderStable(t *testing.T) {
+	// Ties go by name, then kind; the profile ranks a little lower
+	want := []string{"alpha", "bravo", "@charly", "delta", "ops", "@ops", "+ops"}
+
+	// Every run builds its indexes from a map, in a different order
+	for i := 0; i < 20; i++ {
+		client, _ := newMemoryClient(t, tiedManifests())
+		results, err := client.Search(context.Background(), "tied", nil)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, FormatItemName(r.Kind, r.Name))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d found %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSearchMergedSourcesOrder(t *testing.T) {
+	// The primary's ops wins over the fallback's; profiles rank a little lower
+	want := []string{
+		"aaron from fallback", "alpha from primary", "bravo from primary", "@charly from primary",
+		"delta from primary", "ops from primary", "@ops from primary", "zulu from fallback", "+ops from primary",
+	}
+	for i := 0; i < 20; i++ {
+		t.Setenv(HomeEnv, t.TempDir())
+		primary, err := NewMemorySource(tiedManifests())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fallback, err := NewMemorySource(map[string]*Manifest{
+			"ops":   {Version: "2.0.0", Description: "tied"},
+			"aaron": {Version: "1.0.0", Description: "tied"},
+			"zulu":  {Version: "1.0.0", Description: "tied"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Given in the opposite order of their priority
+		client, err := NewClient(WithNoCache(), WithSources([]SourceConfig{
+			{Name: "fallback", URL: "fallback", Registry: fallback},
+			{Name: "primary", URL: "primary", Registry: primary, Priority: 1},
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := client.Search(context.Background(), "tied", nil)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if got := resultKeys(results); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d found %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestMemorySourceIndexStable(t *testing.T) {
+	var want []byte
+	for i := 0; i < 20; i++ {
+		src, err := NewMemorySource(tiedManifests())
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := src.GetIndex(context.Background(), KindSkill)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = content
+		}
+		if string(content) != string(want) {
+			t.Fatalf("run %d generated\n%s\nwant\n%s", i, content, want)
+		}
+	}
+}
+
+func TestListOrderStable(t *testing.T) {
+	client, _ := newMemoryClient(t, tiedManifests())
+	ctx := context.Background()
+	names := sortedKeys(tiedManifests())
+	rand.New(rand.NewSource(1)).Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+	for _, name := range names {
+		// Installing +ops has already installed @ops
+		if err := client.Install(ctx, name, &InstallOptions{Force: true}); err != nil {
+			t.Fatalf("Install %s: %v", name, err)
+		}
+	}
+
+	want := []string{"alpha@1.0.0", "bravo@1.0.0", "delta@1.0.0", "ops@1.0.0", "@charly@1.0.0", "@ops@1.0.0", "+ops@1.0.0"}
+	for i := 0; i < 5; i++ {
+		if got := installedNames(t, client); !reflect.DeepEqual(got, want) {
+			t.Fatalf("List = %v, want %v", got, want)
+		}
+	}
+}
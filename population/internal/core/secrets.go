@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider resolves named secrets referenced from exported content.
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// DefaultSecretEnvPrefix prefixes the environment variables EnvSecrets reads
+// when it has no Prefix.
+const DefaultSecretEnvPrefix = "VEGA_SECRET_"
+
+// EnvSecrets resolves secrets from environment variables named by a prefix
+// and the secret's name, so that content can only read the variables set
+// aside for it, never credentials like AWS_SECRET_ACCESS_KEY.
+type EnvSecrets struct {
+	Prefix string // Prefix of the variables (default DefaultSecretEnvPrefix)
+}
+
+// Secret returns the value of the environment variable Prefix+name.
+func (e EnvSecrets) Secret(ctx context.Context, name string) (string, error) {
+	prefix := e.Prefix
+	if prefix == "" {
+		prefix = DefaultSecretEnvPrefix
+	}
+	value, ok := os.LookupEnv(prefix + name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s%s is not set", prefix, name)
+	}
+	return value, nil
+}
+
+// noSecrets resolves no secrets, for content rendered without a provider.
+type noSecrets struct{}
+
+func (noSecrets) Secret(ctx context.Context, name string) (string, error) {
+	return "", fmt.Errorf("%s: no secret provider is configured (use --secrets)", name)
+}
+
+// FileSecrets resolves secrets from files in a directory, one file per
+// secret, as with Docker or Kubernetes mounted secrets.
+type FileSecrets struct {
+	Dir string
+}
+
+// Secret returns the content of Dir/name without its trailing newline.
+func (f FileSecrets) Secret(ctx context.Context, name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	content, err := os.ReadFile(filepath.Join(f.Dir, name))
+	if err != nil {
+		return "", fmt.Errorf("reading secret %s: %w", name, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// VaultSecrets resolves secrets from the keys of one HashiCorp Vault KV v2
+// secret. The secret is read once and reused for every lookup.
+type VaultSecrets struct {
+	Addr  string // Vault address, e.g. https://vault.example.com:8200
+	Token string
+	Path  string       // API path of the secret, e.g. secret/data/vega
+	HTTP  *http.Client // (default: one timing out after 30 seconds)
+
+	once sync.Once
+	data map[string]string
+	err  error
+}
+
+// Secret returns the value stored under name in the Vault secret.
+func (v *VaultSecrets) Secret(ctx context.Context, name string) (string, error) {
+	v.once.Do(func() { v.data, v.err = v.read(ctx) })
+	if v.err != nil {
+		return "", v.err
+	}
+
+	value, ok := v.data[name]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %s", v.Path, name)
+	}
+	return value, nil
+}
+
+// vaultTimeout bounds a Vault read when VaultSecrets has no HTTP client.
+const vaultTimeout = 30 * time.Second
+
+func (v *VaultSecrets) read(ctx context.Context) (map[string]string, error) {
+	url := strings.TrimSuffix(v.Addr, "/") + "/v1/" + strings.TrimPrefix(v.Path, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	client := v.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: vaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault secret %s: %w", v.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading vault secret %s: status %d", v.Path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("parsing vault response: %w", err)
+	}
+	return body.Data.Data, nil
+}
+
+// NewSecretProvider creates a provider from a spec: "env" or "env:<prefix>",
+// "file:<dir>", or "vault:<path>". Vault reads its address and token from
+// VAULT_ADDR and VAULT_TOKEN. An empty spec is no provider, which is nil.
+func NewSecretProvider(spec string) (SecretProvider, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "":
+		return nil, nil
+	case "env":
+		return EnvSecrets{Prefix: arg}, nil
+	case "file":
+		if arg == "" {
+			return nil, fmt.Errorf("file secrets need a directory (file:<dir>)")
+		}
+		return FileSecrets{Dir: arg}, nil
+	case "vault":
+		if arg == "" {
+			return nil, fmt.Errorf("vault secrets need a path (vault:<path>)")
+		}
+		addr := os.Getenv("VAULT_ADDR")
+		if addr == "" {
+			return nil, fmt.Errorf("VAULT_ADDR is not set")
+		}
+		return &VaultSecrets{Addr: addr, Token: os.Getenv("VAULT_TOKEN"), Path: arg}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret provider %q (want env[:<prefix>], file:<dir>, or vault:<path>)", kind)
+	}
+}
+
+// secretRef matches {{secret "NAME"}} references.
+var secretRef = regexp.MustCompile(`\{\{\s*secret\s+"([^"]+)"\s*\}\}`)
+
+// RenderSecrets replaces every {{secret "NAME"}} reference in text with the
+// value from provider. Other template syntax is left untouched. All missing
+// secrets are reported together. A nil provider resolves none, so text
+// referencing secrets fails to render.
+func RenderSecrets(ctx context.Context, text string, provider SecretProvider) (string, error) {
+	if provider == nil {
+		provider = noSecrets{}
+	}
+	var missing []string
+	values := make(map[string]string)
+	seen := make(map[string]bool)
+
+	for _, match := range secretRef.FindAllStringSubmatch(text, -1) {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		value, err := provider.Secret(ctx, name)
+		if err != nil {
+			missing = append(missing, err.Error())
+			continue
+		}
+		values[name] = value
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("resolving secrets: %s", strings.Join(missing, "; "))
+	}
+
+	return secretRef.ReplaceAllStringFunc(text, func(ref string) string {
+		return values[secretRef.FindStringSubmatch(ref)[1]]
+	}), nil
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRenderSecretsEnv(t *testing.T) {
+	t.Setenv("VEGA_SECRET_DB_URL", "postgres://db")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "hunter2")
+	ctx := context.Background()
+
+	got, err := RenderSecrets(ctx, `Connect to {{secret "DB_URL"}}.`, EnvSecrets{})
+	if err != nil || got != "Connect to postgres://db." {
+		t.Errorf("RenderSecrets = %q, %v", got, err)
+	}
+
+	// Only variables under the prefix can be read
+	if got, err := RenderSecrets(ctx, `{{secret "AWS_SECRET_ACCESS_KEY"}}`, EnvSecrets{}); err == nil {
+		t.Errorf("RenderSecrets read AWS_SECRET_ACCESS_KEY: %q", got)
+	}
+	t.Setenv("ACME_DB_URL", "mysql://db")
+	if got, err := RenderSecrets(ctx, `{{secret "DB_URL"}}`, EnvSecrets{Prefix: "ACME_"}); err != nil || got != "mysql://db" {
+		t.Errorf("RenderSecrets with prefix ACME_ = %q, %v", got, err)
+	}
+}
+
+func TestRenderSecretsWithoutProvider(t *testing.T) {
+	t.Setenv("VEGA_SECRET_DB_URL", "postgres://db")
+	ctx := context.Background()
+
+	if got, err := RenderSecrets(ctx, "No secrets here.", nil); err != nil || got != "No secrets here." {
+		t.Errorf("RenderSecrets = %q, %v", got, err)
+	}
+	_, err := RenderSecrets(ctx, `{{secret "DB_URL"}} {{secret "TOKEN"}}`, nil)
+	if err == nil || !strings.Contains(err.Error(), "DB_URL") || !strings.Contains(err.Error(), "TOKEN") {
+		t.Errorf("RenderSecrets without a provider = %v, want both names reported", err)
+	}
+}
+
+func TestNewSecretProvider(t *testing.T) {
+	for spec, want := range map[string]SecretProvider{
+		"":               nil,
+		"env":            EnvSecrets{},
+		"env:ACME_":      EnvSecrets{Prefix: "ACME_"},
+		"file:/run/vega": FileSecrets{Dir: "/run/vega"},
+	} {
+		got, err := NewSecretProvider(spec)
+		if err != nil || got != want {
+			t.Errorf("NewSecretProvider(%q) = %#v, %v; want %#v", spec, got, err, want)
+		}
+	}
+	if _, err := NewSecretProvider("ssm:x"); err == nil {
+		t.Error("NewSecretProvider accepted an unknown provider")
+	}
+}
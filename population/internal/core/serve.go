@@ -0,0 +1,541 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is what a server token allows.
+type Role string
+
+const (
+	RoleRead    Role = "read"    // Fetch indexes, manifests, and files
+	RolePublish Role = "publish" // Read, and upload items to a writable server
+)
+
+// ServerToken is a token a server accepts, and the role it grants.
+type ServerToken struct {
+	Name  string `yaml:"name"` // Who holds the token, for logs (optional)
+	Token string `yaml:"token"`
+	Role  Role   `yaml:"role"`
+}
+
+// ServerOptions configures a registry server.
+type ServerOptions struct {
+	Token string // Token clients must send as a bearer token or basic auth password (optional)
+
+	// Tokens are further tokens the server accepts, each with a role. Reads
+	// need a token when Token or any read-only token is set; otherwise
+	// anyone may read and the tokens only guard uploads.
+	Tokens []ServerToken
+
+	// Writable accepts uploads using the registry write protocol (see
+	// PublishSHA256Header) from clients with a publish token.
+	Writable bool
+
+	// Retention, on a writable server, collects the older versions it
+	// doesn't keep every GCInterval (default DefaultGCInterval), starting
+	// when Run starts.
+	Retention  *RetentionPolicy
+	GCInterval time.Duration
+
+	Log       io.Writer // Where background work, such as garbage collection, is reported (optional)
+	AccessLog io.Writer // Where each request is logged as a line of JSON (optional)
+
+	// UI serves a web UI for browsing and searching the items under /ui/,
+	// and redirects / there. It needs the same token as other reads, which
+	// browsers prompt for as a basic auth password.
+	UI bool
+
+	// Installed serves an install directory, such as ~/.vega, generating the
+	// indexes from the installed manifests.
+	Installed bool
+
+	// EncryptionKey decrypts the items of an install directory encrypted
+	// with WithEncryptionKey, so they are served in the clear.
+	EncryptionKey []byte
+
+	// RateLimit is how many requests per second each client IP may make, in
+	// bursts of up to RateBurst (default the rate, at least 1). Zero means no
+	// limit. Clients over it get 429 Too Many Requests.
+	RateLimit float64
+	RateBurst int
+
+	// MaxConcurrent caps how many requests are served at once; more get 503
+	// Service Unavailable. Zero means no limit.
+	MaxConcurrent int
+
+	// ShutdownTimeout bounds how long Run waits, once ctx is cancelled, for
+	// requests in progress (default DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
+}
+
+// Server serves a registry directory, or an install directory, as an HTTP
+// source using the same layout as any other source: <kind plural>/index.yaml
+// and <kind plural>/<name>/vega.yaml, with the items' extra files.
+type Server struct {
+	dir      string
+	opts     ServerOptions
+	limiter  *limiter       // nil without limits
+	publish  http.Handler   // nil unless writable
+	registry *LocalRegistry // nil when serving installed items
+	ui       http.Handler   // nil unless serving the web UI
+	cipher   *contentCipher // Decrypts installed items (nil = plain)
+	layout   Layout         // Where installed items are (nil = default)
+
+	mu       sync.Mutex
+	server   *http.Server // Set while running
+	draining bool         // Set once Shutdown is called
+}
+
+// NewServer creates a server for dir.
+func NewServer(dir string, opts *ServerOptions) (*Server, error) {
+	s := &Server{dir: dir}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.ShutdownTimeout <= 0 {
+		s.opts.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if s.opts.GCInterval <= 0 {
+		s.opts.GCInterval = DefaultGCInterval
+	}
+	if s.opts.Log == nil {
+		s.opts.Log = io.Discard
+	}
+	if s.opts.RateLimit < 0 || s.opts.RateBurst < 0 || s.opts.MaxConcurrent < 0 {
+		return nil, fmt.Errorf("rate and concurrency limits can't be negative")
+	}
+	s.limiter = newLimiter(s.opts.RateLimit, s.opts.RateBurst, s.opts.MaxConcurrent)
+
+	publishers := 0
+	for _, t := range s.opts.Tokens {
+		if t.Token == "" {
+			return nil, fmt.Errorf("token %q is empty", t.Name)
+		}
+		switch t.Role {
+		case RoleRead:
+		case RolePublish:
+			publishers++
+		default:
+			return nil, fmt.Errorf("token %q has unknown role %q (want %s or %s)", t.Name, t.Role, RoleRead, RolePublish)
+		}
+	}
+
+	if s.opts.UI {
+		s.ui = s.uiHandler()
+	}
+
+	if s.opts.Installed {
+		if s.opts.Writable {
+			return nil, fmt.Errorf("installed items can't be served writable")
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		var err error
+		if s.cipher, err = newContentCipher(s.opts.EncryptionKey); err != nil {
+			return nil, err
+		}
+		if s.layout, err = LoadLayout(dir); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	if len(s.opts.EncryptionKey) > 0 {
+		return nil, fmt.Errorf("an encryption key applies only to installed items")
+	}
+
+	registry, err := OpenLocalRegistry(dir)
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.Writable {
+		if publishers == 0 {
+			return nil, fmt.Errorf("a writable server needs at least one token with the %s role", RolePublish)
+		}
+		s.publish = PublishHandler(registry)
+	}
+	if s.opts.Retention != nil {
+		if !s.opts.Writable {
+			return nil, fmt.Errorf("retention applies only to a writable server")
+		}
+		if s.opts.Retention.KeepLast < 1 {
+			return nil, fmt.Errorf("a retention policy must keep at least 1 version")
+		}
+	}
+	s.registry = registry
+	return s, nil
+}
+
+// LoadServerTokens reads server tokens from a YAML file with a list of
+// name, token, and role entries under "tokens".
+func LoadServerTokens(path string) ([]ServerToken, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tokens file: %w", err)
+	}
+	var file struct {
+		Tokens []ServerToken `yaml:"tokens"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("parsing tokens file %s: %w", path, err)
+	}
+	return file.Tokens, nil
+}
+
+// Run serves on addr until ctx is cancelled, then shuts down as Shutdown
+// does, waiting at most ShutdownTimeout.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	s.mu.Lock()
+	s.server = server
+	s.mu.Unlock()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	if s.opts.Retention != nil {
+		gcCtx, stopGC := context.WithCancel(ctx)
+		defer stopGC()
+		go s.collectGarbage(gcCtx)
+	}
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+		defer cancel()
+		return s.Shutdown(shutdownCtx)
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("serving %s: %w", s.dir, err)
+	}
+}
+
+// collectGarbage applies the retention policy every GCInterval until ctx is
+// done.
+func (s *Server) collectGarbage(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.GCInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := s.registry.CollectGarbage(*s.opts.Retention, false)
+		if err != nil {
+			fmt.Fprintf(s.opts.Log, "Garbage collection failed: %v\n", err)
+		} else if len(result.Removed) > 0 || result.Uploads > 0 {
+			fmt.Fprintf(s.opts.Log, "Garbage collection removed %d old version(s) and %d stale upload(s)\n", len(result.Removed), result.Uploads)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Shutdown stops accepting connections and waits for requests in progress to
+// finish. Connections still open when ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	server := s.server
+	s.draining = true
+	s.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("draining requests: %w", err)
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler serving the source, applying the rate and
+// concurrency limits. It also serves the /healthz and /readyz probes, which
+// the limits don't apply to, and logs requests to AccessLog.
+func (s *Server) Handler() http.Handler {
+	h := s.handler()
+	if s.limiter != nil {
+		h = s.limiter.wrap(h)
+	}
+	h = withHealth(h, s.ready)
+	if s.opts.AccessLog != nil {
+		h = accessLog(h, s.opts.AccessLog)
+	}
+	return h
+}
+
+// ready reports why the server can't serve requests: it is shutting down, or
+// the directory it serves can't be read.
+func (s *Server) ready() error {
+	s.mu.Lock()
+	draining := s.draining
+	s.mu.Unlock()
+	if draining {
+		return errors.New("shutting down")
+	}
+
+	if _, err := os.ReadDir(s.dir); err != nil {
+		return fmt.Errorf("reading %s: %w", s.dir, err)
+	}
+	return nil
+}
+
+// handler serves the source without limits.
+func (s *Server) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, known := s.role(r)
+		if r.Method == http.MethodPut && s.publish != nil {
+			switch {
+			case !known:
+				unauthorized(w)
+			case role != RolePublish:
+				publishError(w, http.StatusForbidden, errors.New("token does not have the publish role"))
+			default:
+				s.publish.ServeHTTP(w, r)
+			}
+			return
+		}
+		ui := s.ui != nil && isUIPath(r.URL.Path)
+		if !known && s.readsNeedToken() {
+			if ui {
+				// Browsers prompt for basic auth, whose password can be a token
+				w.Header().Set("WWW-Authenticate", `Basic realm="vega-population"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			unauthorized(w)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			allow := "GET, HEAD"
+			if s.publish != nil {
+				allow += ", PUT"
+			}
+			w.Header().Set("Allow", allow)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if ui {
+			s.ui.ServeHTTP(w, r)
+			return
+		}
+
+		kind, rel, ok := servedPath(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if rel == "index.yaml" && s.opts.Installed {
+			content, err := installedIndex(s.dir, s.layout, kind, s.cipher)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/yaml")
+			w.Header().Set("ETag", contentETag(content))
+			http.ServeContent(w, r, "index.yaml", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		f, err := os.Open(filepath.Join(s.dir, s.layout.Dir(kind), filepath.FromSlash(rel)))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(rel, ".yaml") || s.cipher != nil {
+			// Tag manifests and indexes by content, so clients revalidate them
+			// exactly even when a publish keeps the modification time
+			content, err := io.ReadAll(f)
+			if err == nil {
+				content, err = s.cipher.open(content)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			switch {
+			case isManifestPath(rel):
+				// Negotiate the manifest schema, so clients that can't read
+				// it get a 406 rather than a manifest they misread
+				contentType, ok := negotiateManifest(r.Header.Get("Accept"), content)
+				w.Header().Set("Vary", "Accept")
+				if !ok {
+					http.Error(w, "manifest schema not acceptable", http.StatusNotAcceptable)
+					return
+				}
+				w.Header().Set("Content-Type", contentType)
+			case strings.HasSuffix(rel, ".yaml"):
+				w.Header().Set("Content-Type", "application/yaml")
+			}
+			w.Header().Set("ETag", contentETag(content))
+			http.ServeContent(w, r, info.Name(), info.ModTime(), bytes.NewReader(content))
+			return
+		}
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	})
+}
+
+// contentETag returns a strong ETag for content.
+func contentETag(content []byte) string {
+	return `"` + contentHash(content)[:32] + `"`
+}
+
+// role returns the role of the token a request carries, and whether the
+// server knows the token.
+func (s *Server) role(r *http.Request) (Role, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		// netrc users send the token as a basic auth password
+		_, token, ok = r.BasicAuth()
+	}
+	if !ok || token == "" {
+		return "", false
+	}
+
+	// Check every token so the time taken doesn't say which one matched
+	var role Role
+	if s.opts.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1 {
+		role = RoleRead
+	}
+	for _, t := range s.opts.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 && role != RolePublish {
+			role = t.Role
+		}
+	}
+	return role, role != ""
+}
+
+// readsNeedToken reports whether reads are restricted to token holders.
+func (s *Server) readsNeedToken() bool {
+	if s.opts.Token != "" {
+		return true
+	}
+	for _, t := range s.opts.Tokens {
+		if t.Role == RoleRead {
+			return true
+		}
+	}
+	return false
+}
+
+// unauthorized answers a request without a known token.
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="vega-population"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// servedPath splits a request path into the kind directory and the path
+// within it. Only the population directories are served, and nothing hidden,
+// such as install records or a registry's .git.
+func servedPath(p string) (ItemKind, string, bool) {
+	clean := strings.TrimPrefix(path.Clean("/"+p), "/")
+	first, rel, ok := strings.Cut(clean, "/")
+	if !ok || rel == "" {
+		return "", "", false
+	}
+	for _, segment := range strings.Split(rel, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", "", false
+		}
+	}
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		if first == kind.Plural() {
+			return kind, rel, true
+		}
+	}
+	return "", "", false
+}
+
+// installedIndex generates the index of a kind from the manifests installed
+// under dir, decrypted with cipher, publishing each manifest's hash.
+func installedIndex(dir string, layout Layout, kind ItemKind, cipher *contentCipher) ([]byte, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, layout.Dir(kind)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s directory: %w", kind.Plural(), err)
+	}
+
+	items := make(map[string]interface{})
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := cipher.readFile(filepath.Join(dir, layout.Dir(kind), entry.Name(), "vega.yaml"))
+		if err != nil {
+			continue
+		}
+		var m Manifest
+		if err := yaml.Unmarshal(content, &m); err != nil {
+			continue // Skip items with invalid manifests, as List does
+		}
+
+		sums := map[string]string{m.Version: contentHash(content)}
+		if kind == KindProfile {
+			items[entry.Name()] = ProfileIndexEntry{
+				Version:     m.Version,
+				Description: m.Description,
+				Author:      m.Author,
+				Maintainers: m.Maintainers,
+				Status:      m.Status,
+				Deprecated:  m.Deprecated,
+				SHA256:      sums,
+				Persona:     m.Persona,
+				Skills:      m.Skills,
+			}
+			continue
+		}
+		var tools []string
+		for _, tool := range m.Tools {
+			tools = append(tools, tool.Name)
+		}
+		items[entry.Name()] = IndexEntry{
+			Version:     m.Version,
+			Description: m.Description,
+			Author:      m.Author,
+			Maintainers: m.Maintainers,
+			Status:      m.Status,
+			Deprecated:  m.Deprecated,
+			SHA256:      sums,
+			Tags:        m.Tags,
+			Tools:       tools,
+		}
+	}
+
+	content, err := yaml.Marshal(map[string]interface{}{kind.Plural(): items})
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s index: %w", kind.Plural(), err)
+	}
+	return content, nil
+}
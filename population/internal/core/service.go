@@ -0,0 +1,172 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Service managers supported by GenerateService.
+const (
+	ServiceSystemd = "systemd"
+	ServiceLaunchd = "launchd"
+)
+
+// serviceLabel identifies the daemon service to systemd and launchd.
+const serviceLabel = "com.vega.population"
+
+// ServiceOptions configures service file generation for the daemon.
+type ServiceOptions struct {
+	Manager    string        // ServiceSystemd or ServiceLaunchd (default: detected from the OS)
+	Executable string        // Path to the vega binary (default: the running executable)
+	Source     string        // Source passed to the daemon (optional)
+	Interval   time.Duration // Refresh and sync interval (default DefaultDaemonInterval)
+	Deps       string        // Deps file synced on every interval (optional)
+}
+
+// ServiceFile is a generated service definition.
+type ServiceFile struct {
+	Manager string
+	Path    string // Where the file is conventionally installed for the current user
+	Content []byte
+	Enable  string   // Command that enables the service after the file is written
+	Dirs    []string // Directories the service needs, to create along with the file
+}
+
+// GenerateService renders a systemd user unit or launchd agent that runs the
+// daemon, optionally syncing a deps file on every interval.
+func GenerateService(opts ServiceOptions) (*ServiceFile, error) {
+	if opts.Manager == "" {
+		opts.Manager = ServiceSystemd
+		if runtime.GOOS == "darwin" {
+			opts.Manager = ServiceLaunchd
+		}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultDaemonInterval
+	}
+	if opts.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("locating vega executable: %w", err)
+		}
+		opts.Executable = exe
+	}
+	if opts.Deps != "" {
+		deps, err := filepath.Abs(opts.Deps)
+		if err != nil {
+			return nil, fmt.Errorf("resolving deps file: %w", err)
+		}
+		opts.Deps = deps
+	}
+
+	args := []string{opts.Executable, "population", "daemon", "--interval", opts.Interval.String()}
+	if opts.Source != "" {
+		args = append(args, "--source", opts.Source)
+	}
+	if opts.Deps != "" {
+		args = append(args, "--sync", opts.Deps)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine home directory: %w", err)
+	}
+	vegaHome, err := VegaHome()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ServiceFile{Manager: opts.Manager}
+	var tmpl *template.Template
+
+	switch opts.Manager {
+	case ServiceSystemd:
+		tmpl = systemdTemplate
+		file.Path = filepath.Join(home, ".config", "systemd", "user", "vega-population.service")
+		file.Enable = "systemctl --user daemon-reload && systemctl --user enable --now vega-population.service"
+	case ServiceLaunchd:
+		tmpl = launchdTemplate
+		file.Path = filepath.Join(home, "Library", "LaunchAgents", serviceLabel+".plist")
+		file.Enable = "launchctl load -w " + file.Path
+		// launchd opens the log files but won't create their directory
+		file.Dirs = []string{filepath.Join(vegaHome, "logs")}
+	default:
+		return nil, fmt.Errorf("unknown service manager %q (want %s or %s)", opts.Manager, ServiceSystemd, ServiceLaunchd)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{
+		"Label": serviceLabel,
+		"Args":  args,
+		"Logs":  filepath.Join(vegaHome, "logs"),
+	}); err != nil {
+		return nil, fmt.Errorf("rendering %s service: %w", opts.Manager, err)
+	}
+	file.Content = buf.Bytes()
+
+	return file, nil
+}
+
+// systemdQuote quotes a word of a systemd command line, doubling % and $ so
+// that systemd doesn't expand them as specifiers or environment variables.
+func systemdQuote(s string) string {
+	return `"` + systemdEscaper.Replace(s) + `"`
+}
+
+var systemdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%", "$", "$$")
+
+// xmlEscape escapes text for an XML element.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+var systemdTemplate = template.Must(template.New("systemd").Funcs(template.FuncMap{
+	"quote": systemdQuote,
+}).Parse(`[Unit]
+Description=Vega population daemon
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+ExecStart={{ range $i, $a := .Args }}{{ if $i }} {{ end }}{{ quote $a }}{{ end }}
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdTemplate = template.Must(template.New("launchd").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{ xml .Label }}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args }}
+		<string>{{ xml . }}</string>
+{{- end }}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>{{ xml .Logs }}/population-daemon.log</string>
+	<key>StandardErrorPath</key>
+	<string>{{ xml .Logs }}/population-daemon.log</string>
+</dict>
+</plist>
+`))
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotRecorded is wrapped by the errors of replayed reads that the session
+// never recorded.
+var ErrNotRecorded = errors.New("not in the recorded session")
+
+// sessionFormat is the version of the session file format.
+const sessionFormat = 1
+
+// Session records everything sources read, so a run can be replayed later
+// without the network or the sources: a recording session captures each
+// read, and a replaying session serves the recorded reads back.
+type Session struct {
+	Format   int            `json:"format"`
+	Recorded time.Time      `json:"recorded"`
+	Command  []string       `json:"command,omitempty"` // Arguments of the recorded run, for reference
+	Reads    []SessionEntry `json:"reads"`
+
+	mu     sync.Mutex
+	replay bool
+	index  map[string]int // Position in Reads by source and path
+}
+
+// SessionEntry is one recorded read: a file of a source, or the error
+// reading it gave.
+type SessionEntry struct {
+	Source   string `json:"source"`
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // "base64" for binary bodies
+	Body     string `json:"body,omitempty"`
+	Error    string `json:"error,omitempty"`
+	NotFound bool   `json:"not_found,omitempty"`
+}
+
+// NewSession creates a session that records reads.
+func NewSession(command ...string) *Session {
+	return &Session{
+		Format:   sessionFormat,
+		Recorded: time.Now().UTC(),
+		Command:  command,
+		Reads:    []SessionEntry{},
+		index:    make(map[string]int),
+	}
+}
+
+// LoadSession loads a recorded session to replay.
+func LoadSession(path string) (*Session, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+
+	s := &Session{}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("parsing session %s: %w", path, err)
+	}
+	if s.Format > sessionFormat {
+		return nil, fmt.Errorf("session %s has format %d; this version reads up to %d", path, s.Format, sessionFormat)
+	}
+	s.replay = true
+	s.index = make(map[string]int, len(s.Reads))
+	for i, entry := range s.Reads {
+		s.index[sessionKey(entry.Source, entry.Path)] = i
+	}
+	return s, nil
+}
+
+// Replaying reports whether the session serves recorded reads rather than
+// recording new ones.
+func (s *Session) Replaying() bool {
+	return s.replay
+}
+
+// Save writes the session to a file.
+func (s *Session) Save(path string) error {
+	s.mu.Lock()
+	content, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding session: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing session: %w", err)
+	}
+	return nil
+}
+
+// read returns what reading a source's path gives: the recorded result when
+// replaying, or else the result of calling read, which is recorded. A nil
+// session just reads.
+func (s *Session) read(source, path string, read func() ([]byte, error)) ([]byte, error) {
+	if s == nil {
+		return read()
+	}
+	key := sessionKey(source, path)
+	if s.replay {
+		return s.replayed(key)
+	}
+
+	content, err := read()
+	entry := SessionEntry{Source: source, Path: path}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.NotFound = isNotFoundError(err)
+	} else {
+		entry.SHA256 = contentHash(content)
+		entry.Body = string(content)
+		if !utf8.Valid(content) {
+			entry.Encoding = "base64"
+			entry.Body = base64.StdEncoding.EncodeToString(content)
+		}
+	}
+
+	// A path read again replaces its earlier read, as the later one is
+	// what the run went on with
+	s.mu.Lock()
+	if i, ok := s.index[key]; ok {
+		s.Reads[i] = entry
+	} else {
+		s.index[key] = len(s.Reads)
+		s.Reads = append(s.Reads, entry)
+	}
+	s.mu.Unlock()
+	return content, err
+}
+
+// replayed returns a recorded read.
+func (s *Session) replayed(key string) ([]byte, error) {
+	s.mu.Lock()
+	i, ok := s.index[key]
+	var entry SessionEntry
+	if ok {
+		entry = s.Reads[i]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("replaying %s: %w", key, ErrNotRecorded)
+	}
+	if entry.Error != "" {
+		if entry.NotFound {
+			return nil, fmt.Errorf("%s (replayed): %w", entry.Error, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("%s (replayed)", entry.Error)
+	}
+
+	content := []byte(entry.Body)
+	if entry.Encoding == "base64" {
+		var err error
+		if content, err = base64.StdEncoding.DecodeString(entry.Body); err != nil {
+			return nil, fmt.Errorf("replaying %s: decoding body: %w", key, err)
+		}
+	}
+	if entry.SHA256 != "" && !strings.EqualFold(contentHash(content), entry.SHA256) {
+		return nil, fmt.Errorf("replaying %s: body does not match its sha256", key)
+	}
+	return content, nil
+}
+
+// sessionKey identifies a path of a source.
+func sessionKey(source, path string) string {
+	return strings.TrimSuffix(source, "/") + "/" + path
+}
+
+// WithSession records the client's reads into a session, or serves them
+// from one being replayed.
+func WithSession(s *Session) Option {
+	return func(c *Client) {
+		c.session = s
+	}
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are organization defaults distributed as a settings item and
+// honored when exporting agents.
+type Settings struct {
+	// Models maps model aliases to model IDs. The "default" alias is used
+	// when no model is requested.
+	Models map[string]string `yaml:"models,omitempty"`
+
+	DefaultBudget string   `yaml:"default_budget,omitempty"` // Budget used when none is requested, e.g. "$3.00"
+	MaxBudget     string   `yaml:"max_budget,omitempty"`     // Highest budget an agent may be given
+	BannedTools   []string `yaml:"banned_tools,omitempty"`   // Tools removed from exported agents
+
+	// MaxTotalBudget is the highest combined budget of agents deployed
+	// together, as projected by Client.Budget.
+	MaxTotalBudget string `yaml:"max_total_budget,omitempty"`
+}
+
+// LoadSettings reads the settings fields of a settings manifest.
+func LoadSettings(path string) (*Settings, error) {
+	return loadSettings(path, nil)
+}
+
+// loadSettings reads the settings fields of a settings manifest, decrypting
+// it with cipher if it is encrypted.
+func loadSettings(path string, cipher *contentCipher) (*Settings, error) {
+	content, err := cipher.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading settings: %w", err)
+	}
+
+	var settings Settings
+	if err := yaml.Unmarshal(content, &settings); err != nil {
+		return nil, fmt.Errorf("parsing settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// Settings returns the combined installed settings. When several settings
+// items are installed they are applied in name order: later model aliases and
+// defaults win, the lowest budget cap applies, and banned tools accumulate.
+func (c *Client) Settings() (*Settings, error) {
+	items, err := c.List(KindSettings)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	merged := &Settings{Models: make(map[string]string)}
+	for _, item := range items {
+		settings, err := loadSettings(filepath.Join(item.Path, "vega.yaml"), c.cipher)
+		if err != nil {
+			return nil, fmt.Errorf("loading settings %q: %w", item.Name, err)
+		}
+		if err := merged.merge(settings); err != nil {
+			return nil, fmt.Errorf("loading settings %q: %w", item.Name, err)
+		}
+	}
+
+	return merged, nil
+}
+
+func (s *Settings) merge(other *Settings) error {
+	for alias, model := range other.Models {
+		s.Models[alias] = model
+	}
+	if other.DefaultBudget != "" {
+		s.DefaultBudget = other.DefaultBudget
+	}
+	var err error
+	if s.MaxBudget, err = lowerBudget(s.MaxBudget, other.MaxBudget); err != nil {
+		return err
+	}
+	if s.MaxTotalBudget, err = lowerBudget(s.MaxTotalBudget, other.MaxTotalBudget); err != nil {
+		return err
+	}
+	for _, tool := range other.BannedTools {
+		if !containsFold(s.BannedTools, tool) {
+			s.BannedTools = append(s.BannedTools, tool)
+		}
+	}
+	return nil
+}
+
+// lowerBudget returns the lower of two budget caps, where an empty cap is
+// no cap at all.
+func lowerBudget(a, b string) (string, error) {
+	if a == "" || b == "" {
+		return a + b, nil
+	}
+	x, err := parseBudget(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseBudget(b)
+	if err != nil {
+		return "", err
+	}
+	if y < x {
+		return b, nil
+	}
+	return a, nil
+}
+
+// Model resolves a requested model, which may be an alias. An empty request
+// resolves to the "default" alias, or fallback if there is none.
+func (s *Settings) Model(requested, fallback string) string {
+	if requested == "" {
+		requested = "default"
+		if _, ok := s.Models[requested]; !ok {
+			return fallback
+		}
+	}
+	if model, ok := s.Models[requested]; ok {
+		return model
+	}
+	return requested
+}
+
+// Budget resolves a requested budget against the default and the cap.
+// An empty request resolves to the default budget, or fallback.
+func (s *Settings) Budget(requested, fallback string) (string, error) {
+	budget := requested
+	if budget == "" {
+		budget = s.DefaultBudget
+	}
+	if budget == "" {
+		budget = fallback
+	}
+
+	if s.MaxBudget == "" {
+		return budget, nil
+	}
+
+	amount, err := parseBudget(budget)
+	if err != nil {
+		return "", err
+	}
+	limit, err := parseBudget(s.MaxBudget)
+	if err != nil {
+		return "", err
+	}
+	if amount > limit {
+		return "", fmt.Errorf("budget %s exceeds the organization cap of %s", budget, s.MaxBudget)
+	}
+	return budget, nil
+}
+
+// AllowedTools returns tools with the banned ones removed.
+func (s *Settings) AllowedTools(tools []string) []string {
+	var allowed []string
+	for _, tool := range tools {
+		if !containsFold(s.BannedTools, tool) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// formatBudget formats a dollar amount as parseBudget parses it.
+func formatBudget(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// parseBudget parses a dollar amount such as "$3.00".
+func parseBudget(s string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(s), "$"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid budget %q", s)
+	}
+	return amount, nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Shadow is an existing item in a configured source that a newly published
+// item would share a name with.
+type Shadow struct {
+	Kind   ItemKind
+	Name   string
+	Source string
+}
+
+// ShadowError reports a publish refused because the name is already taken
+// in another source.
+type ShadowError struct {
+	Kind    ItemKind
+	Name    string
+	Shadows []Shadow
+}
+
+func (e *ShadowError) Error() string {
+	var taken []string
+	for _, s := range e.Shadows {
+		taken = append(taken, fmt.Sprintf("%s in %s", FormatItemName(s.Kind, s.Name), s.Source))
+	}
+	return fmt.Sprintf("%s %q would shadow %s (use --allow-shadow to publish anyway)", e.Kind, e.Name, strings.Join(taken, ", "))
+}
+
+// Shadows returns the items in the configured sources, other than the
+// registry at registryDir, that share a name with an item. Every kind is
+// checked, since "cmo" and "@cmo" are easily confused. Sources that can't
+// be reached are skipped with a warning.
+func (c *Client) Shadows(ctx context.Context, name, registryDir string) ([]Shadow, error) {
+	_, itemName := ParseItemName(name)
+	registry, _ := filepath.Abs(registryDir)
+
+	var shadows []Shadow
+	for _, source := range c.newSources() {
+		if source.isLocal {
+			if dir, err := filepath.Abs(source.baseURL); err == nil && dir == registry {
+				continue
+			}
+		}
+
+		for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+			_, ok, err := source.latestVersion(ctx, kind, itemName)
+			if err != nil && isNotFoundError(err) {
+				continue // Sources need not carry every kind
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				c.log().Warn("skipping source "+source.name, "source", source.name, "error", err)
+				break
+			}
+			if ok {
+				shadows = append(shadows, Shadow{Kind: kind, Name: itemName, Source: source.name})
+			}
+		}
+	}
+	return shadows, nil
+}
@@ -0,0 +1,351 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest signature types.
+const (
+	SignatureEd25519  = "ed25519"  // Base64 Ed25519 signature, as written by Sign
+	SignatureGPG      = "gpg"      // Armored detached GPG signature
+	SignatureSigstore = "sigstore" // Sigstore bundle, as written by cosign sign-blob --bundle
+)
+
+// signatureExt maps signature types to the file written next to a manifest.
+var signatureExt = map[string]string{
+	SignatureEd25519:  ".sig",
+	SignatureGPG:      ".asc",
+	SignatureSigstore: ".sigstore.json",
+}
+
+// SignatureRef points at the detached signature of one manifest version.
+// Index entries list them by version:
+//
+//	signatures: {1.2.0: {type: ed25519, path: skills/docker-ops/vega.yaml.sig}}
+type SignatureRef struct {
+	Type string `yaml:"type"`
+	Path string `yaml:"path"` // Path of the signature file, relative to the source root
+}
+
+// SignaturePolicy configures which manifest signatures are trusted. It can be
+// set with WithSignatureVerification or under "signatures" in the policy file.
+type SignaturePolicy struct {
+	Require bool `yaml:"require,omitempty"` // Refuse unsigned items (otherwise only signed items are checked)
+
+	Keys     []ed25519.PublicKey `yaml:"-"`                     // Trusted Ed25519 publisher keys
+	KeyFiles []string            `yaml:"keys,omitempty"`        // PEM files of trusted Ed25519 keys
+	Keyring  string              `yaml:"gpg_keyring,omitempty"` // GPG keyring checked with gpgv (default: gpg's own keyring)
+
+	// Sigstore signatures must carry a certificate for this identity, issued
+	// by this OIDC issuer (both required to accept sigstore signatures).
+	SigstoreIdentity string `yaml:"sigstore_identity,omitempty"`
+	SigstoreIssuer   string `yaml:"sigstore_issuer,omitempty"`
+}
+
+// WithSignatureVerification verifies manifest signatures at install time
+// according to policy, instead of the install directory's policy file.
+func WithSignatureVerification(policy *SignaturePolicy) Option {
+	return func(c *Client) {
+		c.signatures = policy
+	}
+}
+
+// signaturePolicy returns the signature policy for an install: the client's,
+// else the policy file's, requiring signatures if require is set.
+func (c *Client) signaturePolicy(require bool) (*SignaturePolicy, error) {
+	policy := c.signatures
+	if policy == nil {
+		p, err := c.Policy()
+		if err != nil {
+			return nil, err
+		}
+		policy = p.Signatures
+	}
+
+	if require {
+		required := SignaturePolicy{Require: true}
+		if policy != nil {
+			required = *policy
+			required.Require = true
+		}
+		policy = &required
+	}
+	return policy, nil
+}
+
+// SignatureError reports a manifest whose signature is missing or doesn't verify.
+type SignatureError struct {
+	Kind    ItemKind
+	Name    string
+	Version string
+	Err     error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("signature check failed for %s %q %s: %v", e.Kind, e.Name, e.Version, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// verifySignature checks a manifest version against its published signature.
+// It reports whether a signature was verified; unsigned items pass unless the
+// policy requires signatures.
+func (s *Source) verifySignature(ctx context.Context, kind ItemKind, name, version string, content []byte) (bool, error) {
+	if s.signatures == nil {
+		return false, nil
+	}
+
+	ref, err := s.publishedSignature(ctx, kind, name, version)
+	if err != nil {
+		return false, err
+	}
+	if ref == nil {
+		if s.signatures.Require {
+			return false, &SignatureError{Kind: kind, Name: name, Version: version, Err: fmt.Errorf("item is not signed")}
+		}
+		return false, nil
+	}
+
+	sig, err := s.fetch(ctx, ref.Path)
+	if err != nil {
+		return false, &SignatureError{Kind: kind, Name: name, Version: version, Err: err}
+	}
+	if err := s.signatures.verify(ctx, s.tempDir, ref.Type, content, sig); err != nil {
+		return false, &SignatureError{Kind: kind, Name: name, Version: version, Err: err}
+	}
+	return true, nil
+}
+
+// publishedSignature returns the signature the index lists for a version of
+// an item, or nil if there is none.
+func (s *Source) publishedSignature(ctx context.Context, kind ItemKind, name, version string) (*SignatureRef, error) {
+	entries, profiles, err := s.getIndex(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := entries[name].Signatures
+	if kind == KindProfile {
+		refs = profiles[name].Signatures
+	}
+	ref, ok := refs[version]
+	if !ok {
+		return nil, nil
+	}
+	return &ref, nil
+}
+
+// verify checks a detached signature of content.
+func (p *SignaturePolicy) verify(ctx context.Context, tempRoot, typ string, content, sig []byte) error {
+	switch typ {
+	case SignatureEd25519:
+		keys := p.Keys
+		for _, path := range p.KeyFiles {
+			key, err := LoadPublicKey(expandHome(path))
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("no trusted ed25519 keys are configured")
+		}
+		for _, key := range keys {
+			if VerifySignature(key, content, string(sig)) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("signature does not match any trusted key")
+
+	case SignatureGPG:
+		return verifyExternal(ctx, tempRoot, content, sig, func(data, sigPath string) *exec.Cmd {
+			if p.Keyring != "" {
+				return exec.CommandContext(ctx, "gpgv", "--keyring", expandHome(p.Keyring), sigPath, data)
+			}
+			return exec.CommandContext(ctx, "gpg", "--batch", "--verify", sigPath, data)
+		})
+
+	case SignatureSigstore:
+		if p.SigstoreIdentity == "" || p.SigstoreIssuer == "" {
+			return fmt.Errorf("sigstore signatures need a trusted identity and issuer")
+		}
+		return verifyExternal(ctx, tempRoot, content, sig, func(data, sigPath string) *exec.Cmd {
+			return exec.CommandContext(ctx, "cosign", "verify-blob",
+				"--bundle", sigPath,
+				"--certificate-identity", p.SigstoreIdentity,
+				"--certificate-oidc-issuer", p.SigstoreIssuer,
+				data)
+		})
+
+	default:
+		return fmt.Errorf("unknown signature type %q", typ)
+	}
+}
+
+// verifyExternal writes content and its signature to a temporary directory
+// under tempRoot and runs a verification command on them.
+func verifyExternal(ctx context.Context, tempRoot string, content, sig []byte, command func(data, sigPath string) *exec.Cmd) error {
+	dir, err := newTempDir(tempRoot, "verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	data := filepath.Join(dir, "vega.yaml")
+	sigPath := filepath.Join(dir, "vega.yaml.sig")
+	if err := os.WriteFile(data, content, 0600); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := os.WriteFile(sigPath, sig, 0600); err != nil {
+		return fmt.Errorf("writing signature: %w", err)
+	}
+
+	cmd := command(data, sigPath)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// SignOptions configures signing a manifest.
+type SignOptions struct {
+	Type   string             // SignatureEd25519 (default), SignatureGPG, or SignatureSigstore
+	Key    ed25519.PrivateKey // Ed25519 signing key
+	GPGKey string             // GPG key to sign with (default: gpg's default key)
+}
+
+// SignResult describes a signed manifest.
+type SignResult struct {
+	Path         string // The signature file
+	Version      string
+	IndexUpdated bool // The manifest lives in a registry checkout whose index now lists the signature
+}
+
+// SignManifest writes a detached signature next to the manifest at path (a
+// vega.yaml file or the directory containing it). Ed25519 signatures are made
+// directly; GPG and sigstore signatures with gpg and cosign. When the manifest
+// lives in a registry checkout, its index entry lists the signature.
+func SignManifest(ctx context.Context, path string, opts *SignOptions) (*SignResult, error) {
+	if opts == nil {
+		opts = &SignOptions{}
+	}
+	typ := opts.Type
+	if typ == "" {
+		typ = SignatureEd25519
+	}
+	ext, ok := signatureExt[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown signature type %q", typ)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "vega.yaml")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	sigPath := path + ext
+	os.Remove(sigPath) // gpg and cosign won't overwrite an existing signature
+
+	var cmd *exec.Cmd
+	switch typ {
+	case SignatureEd25519:
+		if opts.Key == nil {
+			return nil, fmt.Errorf("ed25519 signing needs a private key")
+		}
+		if err := os.WriteFile(sigPath, []byte(Sign(opts.Key, content)+"\n"), 0644); err != nil {
+			return nil, fmt.Errorf("writing signature: %w", err)
+		}
+	case SignatureGPG:
+		args := []string{"--batch", "--armor", "--detach-sign", "--output", sigPath}
+		if opts.GPGKey != "" {
+			args = append(args, "--local-user", opts.GPGKey)
+		}
+		cmd = exec.CommandContext(ctx, "gpg", append(args, path)...)
+	case SignatureSigstore:
+		cmd = exec.CommandContext(ctx, "cosign", "sign-blob", "--yes", "--bundle", sigPath, path)
+	}
+	if cmd != nil {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("signing with %s: %w", cmd.Args[0], err)
+		}
+	}
+
+	result := &SignResult{Path: sigPath, Version: manifest.Version}
+	if registry, kind, ok := registryForManifest(path); ok {
+		rel, err := filepath.Rel(registry.Dir(), sigPath)
+		if err != nil {
+			return nil, err
+		}
+		ref := SignatureRef{Type: typ, Path: filepath.ToSlash(rel)}
+		if err := registry.RecordSignature(kind, manifest.Name, manifest.Version, ref); err != nil {
+			return nil, err
+		}
+		result.IndexUpdated = true
+	}
+	return result, nil
+}
+
+// RecordSignature lists a signature for a version of an item in its index
+// entry, keeping the signatures of other versions.
+func (r *LocalRegistry) RecordSignature(kind ItemKind, name, version string, ref SignatureRef) error {
+	indexPath := filepath.Join(r.dir, kind.Plural(), "index.yaml")
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		return fmt.Errorf("reading index: %w", err)
+	}
+
+	var index map[string]map[string]struct {
+		Signatures map[string]SignatureRef `yaml:"signatures"`
+	}
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return fmt.Errorf("parsing %s index: %w", kind.Plural(), err)
+	}
+	refs := index[kind.Plural()][name].Signatures
+	if refs == nil {
+		refs = make(map[string]SignatureRef)
+	}
+	refs[version] = ref
+
+	updated, err := updateIndexEntry(content, kind.Plural(), name, []indexField{{"signatures", refs}})
+	if err != nil {
+		return fmt.Errorf("updating %s index: %w", kind.Plural(), err)
+	}
+	if err := os.WriteFile(indexPath, updated, 0644); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+	return nil
+}
+
+// expandHome expands a leading ~/ in a configured path.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
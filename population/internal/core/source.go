@@ -0,0 +1,619 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrOffline is wrapped by the errors of offline fetches of anything that
+// isn't cached.
+var ErrOffline = errors.New("offline and not cached")
+
+// Source handles fetching content from local or remote sources.
+type Source struct {
+	name     string // Label used to attribute results
+	baseURL  string
+	cache    *Cache
+	isLocal  bool
+	git      *gitRepo     // Set for git repository sources
+	archive  *archiveRepo // Set for tarball and zip sources
+	registry Registry     // Set for sources read through a Registry
+	auth     *SourceAuth  // Credentials for remote requests (optional)
+	http     httpPolicy   // How remote requests are made and retried
+
+	signatures  *SignaturePolicy // Signatures to verify when fetching manifests (optional)
+	daemon      *daemonConn      // Background daemon serving prefetched content (optional)
+	concurrency int              // Dependencies fetched at once while installing (at least 1)
+	offline     bool             // Read only what is cached, never the network
+	installDir  string           // Installed items standing in for an uncached index when offline (optional)
+	layout      Layout           // Directories of each kind in installDir
+	tempDir     string           // Where temporary files go (default: the system's temporary directory)
+	session     *Session         // Records or replays what is read (optional)
+	cipher      *contentCipher   // Encrypts installed items (nil = plain)
+	logger      *slog.Logger     // Diagnostics (nil = warnings to stderr)
+	warnings    indexWarnings    // About the indexes read, for the results from them
+}
+
+// NewSource creates a new Source instance. The base URL may be a local
+// directory, a raw HTTP tree, a git repository URL (git@host:org/repo.git,
+// ssh://..., or https://....git, optionally followed by #branch), or a local
+// or remote .tar, .tar.gz, .tgz, or .zip archive of a registry.
+func NewSource(baseURL string, cache *Cache) *Source {
+	if archiveFormat(baseURL) != "" {
+		return &Source{
+			name:    baseURL,
+			baseURL: baseURL,
+			cache:   cache,
+			archive: newArchiveRepo(baseURL, cache.dir),
+			http:    defaultHTTPPolicy(),
+		}
+	}
+	if isGitSource(baseURL) {
+		return &Source{
+			name:    baseURL,
+			baseURL: baseURL,
+			cache:   cache,
+			git:     newGitRepo(baseURL, cache.dir),
+			http:    defaultHTTPPolicy(),
+		}
+	}
+
+	// Normalize the URL
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	isLocal := !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://")
+
+	return &Source{
+		name:    baseURL,
+		baseURL: baseURL,
+		cache:   cache,
+		isLocal: isLocal,
+		http:    defaultHTTPPolicy(),
+	}
+}
+
+// fetch retrieves content from the source.
+func (s *Source) fetch(ctx context.Context, path string) ([]byte, error) {
+	return s.session.read(s.baseURL, path, func() ([]byte, error) {
+		return s.fetchSource(ctx, path)
+	})
+}
+
+// fetchSource retrieves content from wherever the source keeps it.
+func (s *Source) fetchSource(ctx context.Context, path string) ([]byte, error) {
+	if s.registry != nil {
+		return readRegistry(ctx, s.registry, path)
+	}
+	if s.isLocal {
+		return s.fetchLocal(path)
+	}
+	if s.daemon != nil {
+		if content, ok := s.daemon.get(ctx, s.baseURL, path); ok {
+			return content, nil
+		}
+	}
+	if s.git != nil {
+		return s.fetchGit(ctx, path)
+	}
+	if s.archive != nil {
+		return s.fetchArchive(ctx, path)
+	}
+
+	// Keep a copy of every remote file, to read when offline
+	cacheKey := s.cacheKey("files-" + url.PathEscape(path))
+	content, err := s.fetchRemote(ctx, path)
+	if errors.Is(err, ErrOffline) {
+		if cached, ok := s.cache.GetStale(cacheKey); ok {
+			return cached, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(cacheKey, content); err != nil {
+		s.log().Warn("failed to cache "+path, "source", s.name, "error", err)
+	}
+	return content, nil
+}
+
+func (s *Source) fetchLocal(path string) ([]byte, error) {
+	fullPath := filepath.Join(strings.TrimSuffix(s.baseURL, "/"), path)
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading local file %s: %w", fullPath, err)
+	}
+	return content, nil
+}
+
+// Index file structures
+
+// SettingsIndex represents the settings/index.yaml structure.
+type SettingsIndex struct {
+	Settings map[string]IndexEntry `yaml:"settings"`
+}
+
+// SkillsIndex represents the skills/index.yaml structure.
+type SkillsIndex struct {
+	Skills map[string]IndexEntry `yaml:"skills"`
+}
+
+// PersonasIndex represents the personas/index.yaml structure.
+type PersonasIndex struct {
+	Personas map[string]IndexEntry `yaml:"personas"`
+}
+
+// ProfilesIndex represents the profiles/index.yaml structure.
+type ProfilesIndex struct {
+	Profiles map[string]ProfileIndexEntry `yaml:"profiles"`
+}
+
+// IndexEntry represents an entry in the skills or personas index.
+type IndexEntry struct {
+	Version     string                  `yaml:"version"`
+	Description string                  `yaml:"description"`
+	Author      string                  `yaml:"author"`
+	Maintainers []string                `yaml:"maintainers,omitempty"`
+	Status      ReviewStatus            `yaml:"status,omitempty"`
+	Deprecated  string                  `yaml:"deprecated,omitempty"` // Why the item is deprecated, and what to use instead
+	Versions    []string                `yaml:"versions,omitempty"`   // Older versions served under versions/<version>/
+	SHA256      map[string]string       `yaml:"sha256,omitempty"`     // Manifest hashes by version
+	Signatures  map[string]SignatureRef `yaml:"signatures,omitempty"` // Manifest signatures by version
+	Tags        []string                `yaml:"tags"`
+	Tools       []string                `yaml:"tools,omitempty"`
+}
+
+// ProfileIndexEntry represents an entry in the profiles index.
+type ProfileIndexEntry struct {
+	Version     string                  `yaml:"version"`
+	Description string                  `yaml:"description"`
+	Author      string                  `yaml:"author"`
+	Maintainers []string                `yaml:"maintainers,omitempty"`
+	Status      ReviewStatus            `yaml:"status,omitempty"`
+	Deprecated  string                  `yaml:"deprecated,omitempty"` // Why the item is deprecated, and what to use instead
+	Versions    []string                `yaml:"versions,omitempty"`   // Older versions served under versions/<version>/
+	SHA256      map[string]string       `yaml:"sha256,omitempty"`     // Manifest hashes by version
+	Signatures  map[string]SignatureRef `yaml:"signatures,omitempty"` // Manifest signatures by version
+	Persona     string                  `yaml:"persona"`              // Persona name, optionally with a version constraint, e.g. "incident-commander@^2"
+	Skills      SkillRefs               `yaml:"skills"`
+}
+
+// Manifest represents a vega.yaml file.
+type Manifest struct {
+	SchemaVersion      int               `yaml:"schema_version,omitempty"` // Manifest schema; 0 means 1, see ManifestSchemaVersion
+	Kind               string            `yaml:"kind"`
+	Name               string            `yaml:"name"`
+	Version            string            `yaml:"version"`
+	Description        string            `yaml:"description"`
+	Author             string            `yaml:"author"`
+	Maintainers        []string          `yaml:"maintainers,omitempty"`
+	Status             ReviewStatus      `yaml:"status,omitempty"`
+	Deprecated         string            `yaml:"deprecated,omitempty"` // Why the item is deprecated, and what to use instead
+	Tags               []string          `yaml:"tags,omitempty"`
+	Persona            string            `yaml:"persona,omitempty"` // A profile's persona, optionally with a version constraint, e.g. "incident-commander@^2"
+	Skills             SkillRefs         `yaml:"skills,omitempty"`
+	RecommendedSkills  []string          `yaml:"recommended_skills,omitempty"`
+	Dependencies       []string          `yaml:"dependencies,omitempty"`  // Skills a skill needs, optionally name@version
+	Compatibility      *Compatibility    `yaml:"compatibility,omitempty"` // Items a skill can and can't be combined with
+	Requires           *Requirements     `yaml:"requires,omitempty"`
+	SystemPrompt       Prompt            `yaml:"system_prompt,omitempty"`
+	SystemPromptAppend string            `yaml:"system_prompt_append,omitempty"` // Added to the persona's prompt by a profile
+	Budget             string            `yaml:"budget,omitempty"`               // A persona's or profile's agent budget when none is requested, e.g. "$5.00"
+	Examples           []Example         `yaml:"examples,omitempty"`
+	Files              []ItemFile        `yaml:"files,omitempty"` // Extra files installed with the item
+	Tools              []Tool            `yaml:"tools,omitempty"`
+	Prompts            map[string]string `yaml:"prompts,omitempty"` // A skill's named guidance, such as "debugging"
+
+	Changelog []ChangelogEntry `yaml:"changelog,omitempty"`
+}
+
+// getIndex fetches and parses an index file.
+func (s *Source) getIndex(ctx context.Context, kind ItemKind) (map[string]IndexEntry, map[string]ProfileIndexEntry, error) {
+	content, err := s.session.read(s.baseURL, kind.Plural()+"/index.yaml", func() ([]byte, error) {
+		return s.readIndex(ctx, kind)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.parseIndex(content, kind)
+}
+
+// readIndex reads the raw index of a kind from the cache, or else the source.
+func (s *Source) readIndex(ctx context.Context, kind ItemKind) ([]byte, error) {
+	cacheKey := s.cacheKey(kind.Plural() + "-index.yaml")
+
+	// Try cache first, however old when offline
+	if content, ok := s.cache.Get(cacheKey); ok {
+		return content, nil
+	}
+	if s.offline {
+		if content, ok := s.cache.GetStale(cacheKey); ok {
+			s.warnings.add(kind, staleWarning(kind, false))
+			return content, nil
+		}
+	}
+
+	// Fetch from source
+	content, err := s.refreshIndex(ctx, kind)
+	if errors.Is(err, ErrOffline) && s.installDir != "" {
+		// Never cached, so the installed items are all that is known
+		s.warnings.add(kind, staleWarning(kind, true))
+		content, err = installedIndex(s.installDir, s.layout, kind, s.cipher)
+	}
+	return content, err
+}
+
+// refreshIndex fetches the raw index of a kind into the cache. A remote index
+// cached with validators is revalidated with a conditional request, so an
+// unchanged index costs a 304 rather than a download.
+func (s *Source) refreshIndex(ctx context.Context, kind ItemKind) ([]byte, error) {
+	cacheKey := s.cacheKey(kind.Plural() + "-index.yaml")
+
+	var cached Validators
+	stale, ok := s.cache.GetStale(cacheKey)
+	if ok {
+		cached = s.cache.Validators(cacheKey)
+	}
+	content, v, notModified, err := s.fetchIndexConditional(ctx, kind, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log but don't fail on cache errors
+	if notModified {
+		if err := s.cache.Touch(cacheKey); err != nil {
+			s.log().Warn("failed to refresh "+cacheKey, "source", s.name, "error", err)
+		}
+		return stale, nil
+	}
+	if err := s.cache.SetValidated(cacheKey, content, v); err != nil {
+		s.log().Warn("failed to cache "+cacheKey, "source", s.name, "error", err)
+	}
+	return content, nil
+}
+
+// revalidates reports whether the source is fetched over HTTP directly, so
+// cached indexes can be revalidated with conditional requests.
+func (s *Source) revalidates() bool {
+	return !s.isLocal && s.git == nil && s.archive == nil && s.registry == nil && s.daemon == nil
+}
+
+// fetchIndexConditional is fetchIndex with a conditional request for sources
+// that support one.
+func (s *Source) fetchIndexConditional(ctx context.Context, kind ItemKind, cached Validators) ([]byte, Validators, bool, error) {
+	if !s.revalidates() {
+		content, err := s.fetchIndex(ctx, kind)
+		return content, Validators{}, false, err
+	}
+
+	content, v, notModified, err := s.fetchConditional(ctx, kind.Plural()+"/index.yaml", cached)
+	if err != nil && kind == KindSettings && isNotFoundError(err) {
+		return []byte("settings: {}\n"), Validators{}, false, nil
+	}
+	return content, v, notModified, err
+}
+
+// cacheKey namespaces a cache file name by source, so sources sharing a
+// cache directory never read each other's indexes.
+func (s *Source) cacheKey(name string) string {
+	sum := sha256.Sum256([]byte(s.baseURL))
+	return hex.EncodeToString(sum[:6]) + "-" + name
+}
+
+// fetchIndex fetches the raw index of a kind. Settings are optional, so a
+// source without a settings index yields an empty one.
+func (s *Source) fetchIndex(ctx context.Context, kind ItemKind) ([]byte, error) {
+	content, err := s.fetch(ctx, kind.Plural()+"/index.yaml")
+	if err != nil && kind == KindSettings && isNotFoundError(err) {
+		return []byte("settings: {}\n"), nil
+	}
+	return content, err
+}
+
+// isNotFoundError checks if the error is a missing local file or an HTTP 404.
+func isNotFoundError(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || containsString(err.Error(), "status 404")
+}
+
+func (s *Source) parseIndex(content []byte, kind ItemKind) (map[string]IndexEntry, map[string]ProfileIndexEntry, error) {
+	switch kind {
+	case KindSkill:
+		var idx SkillsIndex
+		if err := yaml.Unmarshal(content, &idx); err != nil {
+			return nil, nil, fmt.Errorf("parsing skills index: %w", err)
+		}
+		return idx.Skills, nil, nil
+
+	case KindPersona:
+		var idx PersonasIndex
+		if err := yaml.Unmarshal(content, &idx); err != nil {
+			return nil, nil, fmt.Errorf("parsing personas index: %w", err)
+		}
+		return idx.Personas, nil, nil
+
+	case KindProfile:
+		var idx ProfilesIndex
+		if err := yaml.Unmarshal(content, &idx); err != nil {
+			return nil, nil, fmt.Errorf("parsing profiles index: %w", err)
+		}
+		return nil, idx.Profiles, nil
+
+	case KindSettings:
+		var idx SettingsIndex
+		if err := yaml.Unmarshal(content, &idx); err != nil {
+			return nil, nil, fmt.Errorf("parsing settings index: %w", err)
+		}
+		return idx.Settings, nil, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown item kind: %s", kind)
+	}
+}
+
+// GetManifest fetches a manifest file for a specific item.
+func (s *Source) GetManifest(ctx context.Context, kind ItemKind, name string) (*Manifest, error) {
+	content, err := s.GetManifestRaw(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// GetManifestRaw fetches the raw content of a manifest file. Manifests in
+// a schema newer than ManifestSchemaVersion fail with an error wrapping
+// ErrManifestSchema.
+func (s *Source) GetManifestRaw(ctx context.Context, kind ItemKind, name string) ([]byte, error) {
+	path := fmt.Sprintf("%s/%s/vega.yaml", kind.Plural(), name)
+	var content []byte
+	var err error
+	if s.revalidates() {
+		content, err = s.session.read(s.baseURL, path, func() ([]byte, error) {
+			return s.readManifest(ctx, path)
+		})
+	} else {
+		content, err = s.fetch(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkManifestSchema(content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// readManifest reads a remote manifest through the cache, revalidating a
+// cached copy with a conditional request so an unchanged manifest costs a
+// 304. Copies are kept by the schema version they were negotiated for, so
+// a client reading a newer schema never reuses an older client's.
+func (s *Source) readManifest(ctx context.Context, path string) ([]byte, error) {
+	cacheKey := s.cacheKey(fmt.Sprintf("manifest-v%d-%s", ManifestSchemaVersion, url.PathEscape(path)))
+
+	var cached Validators
+	stale, ok := s.cache.GetStale(cacheKey)
+	if ok {
+		if s.offline {
+			return stale, nil
+		}
+		cached = s.cache.Validators(cacheKey)
+	}
+	content, v, notModified, err := s.fetchConditional(ctx, path, cached)
+	if errors.Is(err, ErrOffline) {
+		// Copies fetched before manifests were negotiated
+		if content, ok := s.cache.GetStale(s.cacheKey("files-" + url.PathEscape(path))); ok {
+			return content, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Log but don't fail on cache errors
+	if notModified {
+		if err := s.cache.Touch(cacheKey); err != nil {
+			s.log().Warn("failed to refresh "+cacheKey, "source", s.name, "error", err)
+		}
+		return stale, nil
+	}
+	if err := s.cache.SetValidated(cacheKey, content, v); err != nil {
+		s.log().Warn("failed to cache "+cacheKey, "source", s.name, "error", err)
+	}
+	return content, nil
+}
+
+// GetVersionedManifestRaw fetches the raw manifest of a specific published version.
+func (s *Source) GetVersionedManifestRaw(ctx context.Context, kind ItemKind, name, version string) ([]byte, error) {
+	content, err := s.fetch(ctx, versionedManifestPath(kind, name, version))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkManifestSchema(content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// versionedManifestPath returns the source path of an archived manifest version.
+func versionedManifestPath(kind ItemKind, name, version string) string {
+	return fmt.Sprintf("%s/%s/versions/%s/vega.yaml", kind.Plural(), name, version)
+}
+
+// Versions returns every published version of an item, oldest first, and the
+// version the index currently points at.
+func (s *Source) Versions(ctx context.Context, kind ItemKind, name string) ([]string, string, error) {
+	entries, profiles, err := s.getIndex(ctx, kind)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var current string
+	var older []string
+	if kind == KindProfile {
+		entry, ok := profiles[name]
+		if !ok {
+			return nil, "", fmt.Errorf("%s %q not found", kind, name)
+		}
+		current, older = entry.Version, entry.Versions
+	} else {
+		entry, ok := entries[name]
+		if !ok {
+			return nil, "", fmt.Errorf("%s %q not found", kind, name)
+		}
+		current, older = entry.Version, entry.Versions
+	}
+
+	versions := append([]string{current}, older...)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+	return versions, current, nil
+}
+
+// resolveManifest fetches the manifest of the best version matching a
+// constraint. An empty constraint fetches the current version.
+func (s *Source) resolveManifest(ctx context.Context, kind ItemKind, name, constraint string) ([]byte, string, error) {
+	if constraint == "" {
+		content, err := s.GetManifestRaw(ctx, kind, name)
+		return content, "", err
+	}
+
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return nil, "", err
+	}
+
+	versions, current, err := s.Versions(ctx, kind, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	version, ok := c.Best(versions)
+	if !ok {
+		return nil, "", fmt.Errorf("no version of %s %q matches %s (available: %s)", kind, name, constraint, strings.Join(versions, ", "))
+	}
+
+	if version == current {
+		content, err := s.GetManifestRaw(ctx, kind, name)
+		return content, version, err
+	}
+	content, err := s.GetVersionedManifestRaw(ctx, kind, name, version)
+	return content, version, err
+}
+
+// LoadManifest loads a manifest from a local file path. An encrypted
+// installed manifest fails with an error wrapping ErrEncrypted.
+func LoadManifest(path string) (*Manifest, error) {
+	return loadManifest(path, nil)
+}
+
+// loadManifest loads a manifest from a local file path, decrypting it with
+// cipher if it is encrypted.
+func loadManifest(path string, cipher *contentCipher) (*Manifest, error) {
+	content, err := cipher.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// Info returns detailed information about an item.
+func (s *Source) Info(ctx context.Context, kind ItemKind, name string, installDir string) (*ItemInfo, error) {
+	// Fetch from index first for basic info
+	entries, profiles, err := s.getIndex(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ItemInfo{
+		Kind:   kind,
+		Name:   name,
+		Source: s.name,
+	}
+
+	if kind == KindProfile {
+		entry, ok := profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("%s %q not found", kind, name)
+		}
+		info.Version = entry.Version
+		info.Description = entry.Description
+		info.Author = entry.Author
+		info.Maintainers = entry.Maintainers
+		info.Status = entry.Status.Effective()
+		info.Persona = entry.Persona
+		info.Skills = entry.Skills.Ordered().Names()
+		info.Warnings = s.entryWarnings(kind, entry.Description, entry.Author, entry.Deprecated)
+	} else {
+		entry, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("%s %q not found", kind, name)
+		}
+		info.Version = entry.Version
+		info.Description = entry.Description
+		info.Author = entry.Author
+		info.Maintainers = entry.Maintainers
+		info.Status = entry.Status.Effective()
+		info.Tags = entry.Tags
+		info.Warnings = s.entryWarnings(kind, entry.Description, entry.Author, entry.Deprecated)
+	}
+
+	// Check if installed
+	installedPath := filepath.Join(installDir, s.layout.Dir(kind), name, "vega.yaml")
+	if _, err := os.Stat(installedPath); err == nil {
+		info.Installed = true
+		info.InstalledPath = filepath.Dir(installedPath)
+	}
+
+	return info, nil
+}
+
+// UpdateCache refreshes the source's cached index files.
+func (s *Source) UpdateCache(ctx context.Context) error {
+	if s.git != nil {
+		// Pull the checkout before re-reading the indexes
+		if err := s.cache.Invalidate(s.cacheKey(gitSyncKey)); err != nil {
+			return fmt.Errorf("invalidating cache: %w", err)
+		}
+		s.git.resync()
+	}
+	if s.archive != nil {
+		if err := s.cache.Invalidate(s.cacheKey(archiveSyncKey)); err != nil {
+			return fmt.Errorf("invalidating cache: %w", err)
+		}
+		s.archive.resync()
+	}
+
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		// Refetch, or revalidate, whether or not the cached index is fresh
+		if _, err := s.refreshIndex(ctx, kind); err != nil {
+			return fmt.Errorf("fetching %s index: %w", kind.Plural(), err)
+		}
+	}
+
+	return nil
+}
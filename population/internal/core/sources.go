@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// SourceConfig configures one of several sources a Client reads from.
+type SourceConfig struct {
+	Name     string // Label used to attribute results (default: the URL)
+	URL      string // Source URL or local path
+	Priority int    // Higher priorities are searched and installed from first
+
+	Auth *SourceAuth // Credentials for this source (default: the client's)
+
+	// Registry is read instead of URL when set.
+	Registry Registry
+}
+
+// WithSources configures several sources, such as an internal registry in
+// front of the public one. Sources are consulted in priority order, with
+// ties keeping the order given; the first is also the primary source used by
+// the daemon, mirrors, and whatsnew.
+func WithSources(sources []SourceConfig) Option {
+	return func(c *Client) {
+		c.sources = append([]SourceConfig(nil), sources...)
+		sort.SliceStable(c.sources, func(i, j int) bool {
+			return c.sources[i].Priority > c.sources[j].Priority
+		})
+		if len(c.sources) > 0 {
+			c.source = c.sources[0].URL
+		}
+		c.untrusted = false
+	}
+}
+
+// WithRegistry reads items from r, such as a registry backed by a database
+// in a hosted product, in place of a source URL. It replaces the configured
+// sources; to search r alongside others, set SourceConfig.Registry in
+// WithSources instead.
+func WithRegistry(r Registry) Option {
+	return func(c *Client) {
+		name := registryName(r)
+		c.sources = []SourceConfig{{Name: name, URL: name, Registry: r}}
+		c.source = name
+		c.untrusted = false
+	}
+}
+
+// Sources returns the configured sources in priority order.
+func (c *Client) Sources() []SourceConfig {
+	if len(c.sources) == 0 {
+		return []SourceConfig{{Name: c.source, URL: c.source}}
+	}
+	return c.sources
+}
+
+// newSources creates a Source for every configured source, in priority order.
+func (c *Client) newSources() []*Source {
+	var sources []*Source
+	for _, cfg := range c.Sources() {
+		sources = append(sources, c.newSourceFor(cfg))
+	}
+	return sources
+}
+
+// resolveSource returns the highest-priority source whose index has the item.
+// Sources that can't be reached are skipped with a warning.
+func (c *Client) resolveSource(ctx context.Context, kind ItemKind, name string) (*Source, error) {
+	if err := checkItemName(name); err != nil {
+		return nil, err
+	}
+	sources := c.newSources()
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+
+	for _, source := range sources {
+		_, ok, err := source.latestVersion(ctx, kind, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.log().Warn("skipping source "+source.name, "source", source.name, "error", err)
+			continue
+		}
+		if ok {
+			return source, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s %q not found in any source", kind, name)
+}
+
+// searchSources searches every source and merges the results. An item found
+// in several sources is reported once, from the highest-priority source.
+func (c *Client) searchSources(ctx context.Context, query *Query, opts *SearchOptions) ([]SearchResult, error) {
+	sources := c.newSources()
+	if len(sources) == 1 {
+		return sources[0].SearchQuery(ctx, query, opts)
+	}
+
+	// Collect everything, then rank and limit the merged list
+	all := *opts
+	all.Limit = 0
+
+	var merged []SearchResult
+	seen := make(map[string]bool)
+	reached := 0
+
+	for _, source := range sources {
+		results, err := source.SearchQuery(ctx, query, &all)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.log().Warn("skipping source "+source.name, "source", source.name, "error", err)
+			continue
+		}
+		reached++
+
+		for _, r := range results {
+			key := FormatItemName(r.Kind, r.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, r)
+		}
+	}
+
+	if reached == 0 {
+		return nil, fmt.Errorf("no source could be searched")
+	}
+
+	sortResults(merged)
+	if opts.Limit > 0 && len(merged) > opts.Limit {
+		merged = merged[:opts.Limit]
+	}
+	return merged, nil
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReviewStatus is the review state of an item in a registry.
+type ReviewStatus string
+
+const (
+	StatusDraft    ReviewStatus = "draft"
+	StatusReviewed ReviewStatus = "reviewed"
+	StatusApproved ReviewStatus = "approved"
+)
+
+// PolicyFile is the name of the workspace policy file in the install directory.
+const PolicyFile = "policy.yaml"
+
+// Policy holds the rules a workspace applies to installs.
+type Policy struct {
+	MinStatus  ReviewStatus     `yaml:"min_status,omitempty"` // Lowest review status allowed to be installed
+	Signatures *SignaturePolicy `yaml:"signatures,omitempty"` // Manifest signatures to verify (optional)
+}
+
+// ParseReviewStatus parses a review status name.
+func ParseReviewStatus(s string) (ReviewStatus, error) {
+	status := ReviewStatus(strings.ToLower(strings.TrimSpace(s)))
+	switch status {
+	case StatusDraft, StatusReviewed, StatusApproved:
+		return status, nil
+	default:
+		return "", fmt.Errorf("unknown status %q (want draft, reviewed, or approved)", s)
+	}
+}
+
+// Effective returns the status, treating items without one as approved.
+// Items published before review states existed are considered approved.
+func (s ReviewStatus) Effective() ReviewStatus {
+	if s == "" {
+		return StatusApproved
+	}
+	return s
+}
+
+// AtLeast reports whether s is at or beyond min in the promotion workflow.
+func (s ReviewStatus) AtLeast(min ReviewStatus) bool {
+	return s.Effective().rank() >= min.rank()
+}
+
+func (s ReviewStatus) rank() int {
+	switch s {
+	case StatusDraft:
+		return 1
+	case StatusReviewed:
+		return 2
+	case StatusApproved:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// LoadPolicy reads the policy file from a workspace directory.
+// A missing file yields an empty policy.
+func LoadPolicy(dir string) (*Policy, error) {
+	policy := &Policy{}
+
+	content, err := os.ReadFile(filepath.Join(dir, PolicyFile))
+	if os.IsNotExist(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading policy: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+	if policy.MinStatus != "" {
+		if policy.MinStatus, err = ParseReviewStatus(string(policy.MinStatus)); err != nil {
+			return nil, fmt.Errorf("parsing policy: %w", err)
+		}
+	}
+
+	return policy, nil
+}
+
+// Policy returns the policy of the client's install directory.
+func (c *Client) Policy() (*Policy, error) {
+	return LoadPolicy(c.installDir)
+}
+
+// itemStatus returns the review status of an item from the index.
+func (s *Source) itemStatus(ctx context.Context, kind ItemKind, name string) (ReviewStatus, error) {
+	entries, profiles, err := s.getIndex(ctx, kind)
+	if err != nil {
+		return "", err
+	}
+
+	if kind == KindProfile {
+		entry, ok := profiles[name]
+		if !ok {
+			return "", fmt.Errorf("%s %q not found", kind, name)
+		}
+		return entry.Status, nil
+	}
+	entry, ok := entries[name]
+	if !ok {
+		return "", fmt.Errorf("%s %q not found", kind, name)
+	}
+	return entry.Status, nil
+}
+
+// checkStatus returns an error if an item's review status is below min.
+func (s *Source) checkStatus(ctx context.Context, kind ItemKind, name string, min ReviewStatus) error {
+	if min == "" {
+		return nil
+	}
+
+	status, err := s.itemStatus(ctx, kind, name)
+	if err != nil {
+		return err
+	}
+	if !status.AtLeast(min) {
+		return fmt.Errorf("%s %q is %s; this workspace requires %s", kind, name, status.Effective(), min)
+	}
+	return nil
+}
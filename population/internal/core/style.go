@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StyleFile is the name of a registry's prompt style guide, at its root.
+const StyleFile = "style.yaml"
+
+// StyleGuide holds the prompt conventions a registry enforces for its items.
+type StyleGuide struct {
+	RequiredSections  []string       `yaml:"required_sections,omitempty"`   // Sections every sectioned prompt must have
+	MaxSectionLength  int            `yaml:"max_section_length,omitempty"`  // Character limit for any section (0 = none)
+	MaxSectionLengths map[string]int `yaml:"max_section_lengths,omitempty"` // Per-section limits, overriding MaxSectionLength
+	BannedPhrases     []string       `yaml:"banned_phrases,omitempty"`      // Phrases that may not appear (case-insensitive)
+	RequiredVariables []string       `yaml:"required_variables,omitempty"`  // Names that must be referenced as {{...}}
+}
+
+// LoadStyleGuide reads a style guide file.
+func LoadStyleGuide(path string) (*StyleGuide, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading style guide: %w", err)
+	}
+
+	var guide StyleGuide
+	if err := yaml.Unmarshal(content, &guide); err != nil {
+		return nil, fmt.Errorf("parsing style guide: %w", err)
+	}
+	return &guide, nil
+}
+
+// StyleGuide returns the registry's style guide, or nil if it has none.
+func (r *LocalRegistry) StyleGuide() (*StyleGuide, error) {
+	path := filepath.Join(r.dir, StyleFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadStyleGuide(path)
+}
+
+// Check reports every way a prompt departs from the style guide.
+func (g *StyleGuide) Check(p Prompt) []string {
+	var problems []string
+
+	if p.IsSectioned() {
+		for _, name := range g.RequiredSections {
+			if _, ok := p.Section(name); !ok {
+				problems = append(problems, fmt.Sprintf("missing required section %q", name))
+			}
+		}
+		for _, s := range p.Ordered() {
+			limit := g.MaxSectionLength
+			if l, ok := g.MaxSectionLengths[s.Name]; ok {
+				limit = l
+			}
+			if n := len(strings.TrimSpace(s.Text)); limit > 0 && n > limit {
+				problems = append(problems, fmt.Sprintf("section %q is %d characters (max %d)", s.Name, n, limit))
+			}
+		}
+	} else if len(g.RequiredSections) > 0 {
+		problems = append(problems, fmt.Sprintf("prompt must be written as sections (%s)", strings.Join(g.RequiredSections, ", ")))
+	}
+
+	text := p.String()
+	lower := strings.ToLower(text)
+	for _, phrase := range g.BannedPhrases {
+		if strings.Contains(lower, strings.ToLower(phrase)) {
+			problems = append(problems, fmt.Sprintf("uses banned phrase %q", phrase))
+		}
+	}
+
+	for _, name := range g.RequiredVariables {
+		ref := regexp.MustCompile(`\{\{[^}]*\b` + regexp.QuoteMeta(name) + `\b[^}]*\}\}`)
+		if !ref.MatchString(text) {
+			problems = append(problems, fmt.Sprintf("does not reference required variable %q", name))
+		}
+	}
+
+	return problems
+}
+
+// Manifests returns the manifest paths of every item in the registry.
+func (r *LocalRegistry) Manifests() ([]string, error) {
+	var paths []string
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		matches, err := filepath.Glob(filepath.Join(r.dir, kind.Plural(), "*", "vega.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", kind.Plural(), err)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDepsFile is the conventional name of a deps file.
+const DefaultDepsFile = "vega.deps.yaml"
+
+// Deps declares the items that should be installed, for example:
+//
+//	env: prod
+//	items:
+//	  - kubernetes-ops
+//	  - "@incident-commander"
+//	  - "+sre-oncall"
+type Deps struct {
+	Env   string   `yaml:"env,omitempty"` // Deployment environment for conditional profile skills
+	Items []string `yaml:"items"`
+}
+
+// SyncAction is a change Sync makes to an item.
+type SyncAction string
+
+const (
+	SyncInstall SyncAction = "install" // Listed but not installed
+	SyncUpgrade SyncAction = "upgrade" // Installed at a version the spec or lockfile doesn't allow
+	SyncRemove  SyncAction = "remove"  // Installed but neither listed nor needed, with Prune
+)
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	Prune    bool      // Remove installed items the spec neither lists nor needs
+	DryRun   bool      // Report the changes without making them
+	Progress io.Writer // Receives install progress (default: discarded)
+
+	// Lock is the path of a lockfile (see Lock). Items are installed at their
+	// locked versions when the spec allows, the sources are checked to serve
+	// the locked content before anything changes, and the lockfile is then
+	// rewritten to match what is installed. Empty means no lockfile.
+	Lock string
+}
+
+// SyncChange is a change Sync made, or would make with DryRun.
+type SyncChange struct {
+	Action SyncAction `json:"action" yaml:"action"`
+	Kind   ItemKind   `json:"kind" yaml:"kind"`
+	Name   string     `json:"name" yaml:"name"`
+	Want   string     `json:"want,omitempty" yaml:"want,omitempty"` // Version constraint from the spec
+	From   string     `json:"from,omitempty" yaml:"from,omitempty"` // Version installed before
+	To     string     `json:"to,omitempty" yaml:"to,omitempty"`     // Version installed after
+
+	Files []FileChange `json:"files,omitempty" yaml:"files,omitempty"` // With DryRun, the files the change would touch
+}
+
+// SyncReport summarizes the changes made by Sync.
+type SyncReport struct {
+	Changes   []SyncChange `json:"changes" yaml:"changes"`
+	Unchanged []string     `json:"unchanged" yaml:"unchanged"` // Listed items already installed at an allowed version
+	DryRun    bool         `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+	Lock      string       `json:"lock,omitempty" yaml:"lock,omitempty"` // Lockfile written
+}
+
+// LoadDeps loads a deps file.
+func LoadDeps(path string) (*Deps, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading deps file: %w", err)
+	}
+
+	var deps Deps
+	if err := yaml.Unmarshal(content, &deps); err != nil {
+		return nil, fmt.Errorf("parsing deps file %s: %w", path, err)
+	}
+
+	return &deps, nil
+}
+
+// Sync reconciles the installed items with deps, like applying a plan:
+// listed items that aren't installed are installed, those installed at a
+// version their constraint doesn't allow are reinstalled at one it does, and
+// with opts.Prune, installed items that no listed item needs are removed.
+// Items a listed profile or skill depends on count as needed. With
+// opts.Lock, the installed items are also checked against a lockfile.
+func (c *Client) Sync(ctx context.Context, deps *Deps, opts *SyncOptions) (*SyncReport, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	report := &SyncReport{Changes: []SyncChange{}, Unchanged: []string{}, DryRun: opts.DryRun}
+	if !opts.DryRun {
+		defer func() {
+			var changes []string
+			for _, change := range report.Changes {
+				line := fmt.Sprintf("%s %s", change.Action, FormatItemName(change.Kind, change.Name))
+				switch {
+				case change.Action == SyncRemove:
+				case change.From != "" && change.To != "":
+					line += fmt.Sprintf(" %s -> %s", change.From, change.To)
+				case change.To != "":
+					line += " " + change.To
+				}
+				changes = append(changes, line)
+			}
+			if len(changes) > 0 {
+				c.recordChange(ctx, changeMessage("Sync:", changes))
+			}
+		}()
+	}
+
+	lock := &Lock{}
+	if opts.Lock != "" {
+		var err error
+		if lock, err = LoadLock(opts.Lock); err != nil {
+			return nil, err
+		}
+	}
+
+	// Plan everything first, so a bad entry changes nothing. A locked version
+	// the constraint allows is the one wanted.
+	type planned struct {
+		name       string
+		change     SyncChange
+		constraint *VersionConstraint
+		pin        string
+	}
+	var plan []planned
+	listed := make([]string, 0, len(deps.Items))
+	for _, name := range deps.Items {
+		base, version := SplitVersion(name)
+		kind, itemName := ParseItemName(base)
+		if err := checkItemName(itemName); err != nil {
+			return nil, err
+		}
+		listed = append(listed, FormatItemName(kind, itemName))
+
+		var vc *VersionConstraint
+		if version != "" {
+			var err error
+			if vc, err = ParseConstraint(version); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		var pin string
+		if entry := lock.entry(kind, itemName); entry != nil && (vc == nil || vc.Match(entry.Version)) {
+			pin = entry.Version
+		}
+
+		dir := c.itemDir(kind, itemName)
+		change := SyncChange{Kind: kind, Name: itemName, Want: version}
+		if _, err := os.Stat(filepath.Join(dir, "vega.yaml")); err != nil {
+			change.Action = SyncInstall
+		} else if change.From = installedVersion(dir, c.cipher); !syncAllows(change.From, vc, pin) {
+			change.Action = SyncUpgrade
+		} else {
+			report.Unchanged = append(report.Unchanged, name)
+			continue
+		}
+		plan = append(plan, planned{name, change, vc, pin})
+	}
+
+	if err := c.verifyLock(ctx, lock); err != nil {
+		return nil, err
+	}
+
+	for _, p := range plan {
+		change, err := c.syncItem(ctx, p.name, p.change, p.constraint, p.pin, deps.Env, opts)
+		if err != nil {
+			return report, err
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	// Locked dependencies of the listed items are held at their locked
+	// versions too
+	isListed := make(map[string]bool, len(listed))
+	for _, name := range listed {
+		isListed[name] = true
+	}
+	needed := c.syncNeeded(listed)
+	for _, entry := range lock.Items {
+		name := FormatItemName(entry.Kind, entry.Name)
+		if isListed[name] || !needed[name] {
+			continue
+		}
+		dir := c.itemDir(entry.Kind, entry.Name)
+		change := SyncChange{Kind: entry.Kind, Name: entry.Name, Action: SyncUpgrade}
+		if _, err := os.Stat(filepath.Join(dir, "vega.yaml")); err != nil {
+			change.Action = SyncInstall
+		} else if change.From = installedVersion(dir, c.cipher); change.From == entry.Version {
+			continue
+		}
+		change, err := c.syncItem(ctx, name, change, nil, entry.Version, deps.Env, opts)
+		if err != nil {
+			return report, err
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	if opts.Prune {
+		items, err := c.List("")
+		if err != nil {
+			return report, err
+		}
+		needed := c.syncNeeded(listed)
+		for _, item := range items {
+			name := FormatItemName(item.Kind, item.Name)
+			if needed[name] {
+				continue
+			}
+			change := SyncChange{Action: SyncRemove, Kind: item.Kind, Name: item.Name, From: item.Version}
+			if opts.DryRun {
+				if change.Files, err = c.PlanUninstall(name); err != nil {
+					return report, err
+				}
+			} else if err := c.uninstall(name); err != nil {
+				return report, err
+			}
+			report.Changes = append(report.Changes, change)
+		}
+	}
+
+	if opts.Lock != "" && !opts.DryRun {
+		installed, err := c.lockInstalled(c.syncNeeded(listed), lock)
+		if err != nil {
+			return report, err
+		}
+		if err := installed.Save(opts.Lock); err != nil {
+			return report, err
+		}
+		report.Lock = opts.Lock
+	}
+
+	return report, nil
+}
+
+// syncItem makes a planned change, unless an earlier item installed the
+// item as a dependency at a version that is allowed. The item is installed
+// at pin when there is one. With opts.DryRun nothing is installed, and the
+// change lists the files installing would touch.
+func (c *Client) syncItem(ctx context.Context, name string, change SyncChange, vc *VersionConstraint, pin, env string, opts *SyncOptions) (SyncChange, error) {
+	dir := c.itemDir(change.Kind, change.Name)
+	_, err := os.Stat(filepath.Join(dir, "vega.yaml"))
+	if exists := err == nil; !exists || !syncAllows(installedVersion(dir, c.cipher), vc, pin) {
+		installOpts := &InstallOptions{
+			Force:    exists,
+			DryRun:   opts.DryRun,
+			Env:      env,
+			Progress: opts.Progress,
+
+			unrecorded: true,
+		}
+		if opts.DryRun {
+			installOpts.Progress = nil // the change reports the plan
+		}
+		install := name
+		if pin != "" {
+			install = FormatItemName(change.Kind, change.Name) + "@" + pin
+		}
+		installed, err := c.InstallWithReport(ctx, install, installOpts)
+		if err != nil {
+			return change, fmt.Errorf("installing %s: %w", name, err)
+		}
+		if opts.DryRun {
+			for _, item := range installed.Items {
+				change.Files = append(change.Files, item.Files...)
+			}
+			return change, nil
+		}
+	}
+	change.To = installedVersion(dir, c.cipher)
+	return change, nil
+}
+
+// syncAllows reports whether an installed version is the one wanted: the
+// pinned version if there is one, else any the constraint allows.
+func syncAllows(version string, vc *VersionConstraint, pin string) bool {
+	if pin != "" {
+		return version == pin
+	}
+	return vc == nil || vc.Match(version)
+}
+
+// syncNeeded returns the display names of the listed items and of every
+// item their installed manifests depend on, directly or not.
+func (c *Client) syncNeeded(listed []string) map[string]bool {
+	needed := make(map[string]bool)
+	var visit func(kind ItemKind, name string)
+	visit = func(kind ItemKind, name string) {
+		key := FormatItemName(kind, name)
+		if needed[key] {
+			return
+		}
+		needed[key] = true
+
+		m, err := loadManifest(filepath.Join(c.itemDir(kind, name), "vega.yaml"), c.cipher)
+		if err != nil {
+			return
+		}
+		if persona, _ := SplitVersion(m.Persona); persona != "" {
+			visit(KindPersona, persona)
+		}
+		for _, skill := range m.Skills.Names() {
+			visit(KindSkill, skill)
+		}
+		for _, dep := range m.Dependencies {
+			skill, _ := SplitVersion(dep)
+			visit(KindSkill, skill)
+		}
+	}
+
+	for _, name := range listed {
+		visit(ParseItemName(name))
+	}
+	return needed
+}
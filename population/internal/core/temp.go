@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// staleTempAge is how old a temporary directory must be to be removed even
+// though the process that made it may still be running.
+const staleTempAge = 24 * time.Hour
+
+// newTempDir creates a temporary directory under root, or under the system's
+// temporary directory if root is empty. Its name starts with the process ID,
+// so cleanTemp can tell when it has been left behind.
+func newTempDir(root, pattern string) (string, error) {
+	if root != "" {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return "", fmt.Errorf("creating temporary directory: %w", err)
+		}
+	}
+	dir, err := os.MkdirTemp(root, fmt.Sprintf("%d-%s-", os.Getpid(), pattern))
+	if err != nil {
+		return "", fmt.Errorf("creating temporary directory: %w", err)
+	}
+	return dir, nil
+}
+
+// cleanTemp removes the temporary directories under root whose process is no
+// longer running, or that are more than a day old, and returns how many it
+// removed.
+func cleanTemp(root string) (int, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading temporary directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stale := time.Since(info.ModTime()) > staleTempAge
+		prefix, _, _ := strings.Cut(entry.Name(), "-")
+		if pid, err := strconv.Atoi(prefix); err == nil {
+			if pid == os.Getpid() {
+				continue
+			}
+			stale = stale || !processRunning(pid)
+		}
+		if !stale {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			return removed, fmt.Errorf("removing temporary directory: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// processRunning reports whether a process is running. Where signals can't
+// probe for one, as on Windows, finding it is enough.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// moveDir moves a directory staged in a temporary directory to dst, replacing
+// whatever is there. Across filesystems it is copied instead.
+func moveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("removing %s: %w", dst, err)
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := copyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("moving %s into place: %w", dst, err)
+	}
+	os.RemoveAll(src)
+	return nil
+}
+
+// copyDir copies the tree at src to dst, keeping file modes and symlinks.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
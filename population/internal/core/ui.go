@@ -0,0 +1,202 @@
+package core
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// uiFiles holds the web UI pages, so the server needs nothing besides its
+// own binary to serve them.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// uiItem is an item in the web UI's catalog.
+type uiItem struct {
+	Kind        ItemKind     `json:"kind"`
+	Name        string       `json:"name"`
+	Version     string       `json:"version"`
+	Description string       `json:"description"`
+	Author      string       `json:"author,omitempty"`
+	Status      ReviewStatus `json:"status,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+}
+
+// uiDetail is an item's page in the web UI.
+type uiDetail struct {
+	uiItem
+	Maintainers       []string    `json:"maintainers,omitempty"`
+	Persona           string      `json:"persona,omitempty"`
+	Skills            []string    `json:"skills,omitempty"`
+	RecommendedSkills []string    `json:"recommended_skills,omitempty"`
+	Dependencies      []string    `json:"dependencies,omitempty"`
+	Tools             []uiTool    `json:"tools,omitempty"`
+	Prompt            string      `json:"prompt,omitempty"` // The system prompt as it is rendered for the model
+	Examples          []uiExample `json:"examples,omitempty"`
+	Install           string      `json:"install"` // Command installing the item
+}
+
+// uiTool is a tool an item provides.
+type uiTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Dangerous   bool   `json:"dangerous,omitempty"`
+	ReadOnly    bool   `json:"read_only,omitempty"`
+}
+
+// uiExample is an example conversation.
+type uiExample struct {
+	Title    string      `json:"title,omitempty"`
+	Messages []uiMessage `json:"messages"`
+}
+
+// uiMessage is one turn of an example conversation.
+type uiMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// isUIPath reports whether a request path belongs to the web UI.
+func isUIPath(path string) bool {
+	return path == "/" || path == "/ui" || strings.HasPrefix(path, "/ui/")
+}
+
+// uiHandler serves the web UI: the embedded pages under /ui/, and the JSON
+// they read from /ui/api/catalog and /ui/api/items/<kind>/<name>.
+func (s *Server) uiHandler() http.Handler {
+	pages, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err) // The embedded directory always exists
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ui/", http.StripPrefix("/ui/", http.FileServer(http.FS(pages))))
+	mux.HandleFunc("/ui/api/catalog", s.serveCatalog)
+	mux.HandleFunc("/ui/api/items/", s.serveItem)
+	mux.Handle("/", http.RedirectHandler("/ui/", http.StatusFound))
+	return mux
+}
+
+// serveCatalog lists every served item.
+func (s *Server) serveCatalog(w http.ResponseWriter, r *http.Request) {
+	items := []uiItem{}
+	for _, kind := range []ItemKind{KindProfile, KindPersona, KindSkill} {
+		content, err := s.indexContent(kind)
+		if err != nil {
+			publishError(w, http.StatusInternalServerError, err)
+			return
+		}
+		entries, profiles, err := (&Source{}).parseIndex(content, kind)
+		if err != nil {
+			publishError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var names []string
+		for name := range entries {
+			names = append(names, name)
+		}
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			item := uiItem{Kind: kind, Name: name}
+			if entry, ok := entries[name]; ok {
+				item.Version, item.Description, item.Author = entry.Version, entry.Description, entry.Author
+				item.Status, item.Tags = entry.Status, entry.Tags
+			} else {
+				entry := profiles[name]
+				item.Version, item.Description, item.Author = entry.Version, entry.Description, entry.Author
+				item.Status = entry.Status
+			}
+			items = append(items, item)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// serveItem describes an item from its manifest.
+func (s *Server) serveItem(w http.ResponseWriter, r *http.Request) {
+	kindName, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/ui/api/items/"), "/")
+	kind := ItemKind(kindName)
+	if !ok || checkItemName(name) != nil || (kind != KindSkill && kind != KindPersona && kind != KindProfile) {
+		publishError(w, http.StatusNotFound, fmt.Errorf("no item at %s", r.URL.Path))
+		return
+	}
+
+	content, err := s.cipher.readFile(filepath.Join(s.dir, s.layout.Dir(kind), name, "vega.yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		publishError(w, http.StatusNotFound, fmt.Errorf("%s %q not found", kind, name))
+		return
+	}
+	if err != nil {
+		publishError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		publishError(w, http.StatusInternalServerError, fmt.Errorf("parsing %s %q: %w", kind, name, err))
+		return
+	}
+
+	detail := uiDetail{
+		uiItem: uiItem{
+			Kind:        kind,
+			Name:        name,
+			Version:     m.Version,
+			Description: m.Description,
+			Author:      m.Author,
+			Status:      m.Status,
+			Tags:        m.Tags,
+		},
+		Maintainers:       m.Maintainers,
+		Persona:           m.Persona,
+		Skills:            m.Skills.Names(),
+		RecommendedSkills: m.RecommendedSkills,
+		Dependencies:      m.Dependencies,
+		Prompt:            m.SystemPrompt.String(),
+		Install:           "vega population install " + FormatItemName(kind, name),
+	}
+	for _, tool := range m.Tools {
+		detail.Tools = append(detail.Tools, uiTool{Name: tool.Name, Description: tool.Description, Dangerous: tool.Dangerous, ReadOnly: tool.ReadOnly})
+	}
+	for _, example := range m.Examples {
+		e := uiExample{Title: example.Title, Messages: []uiMessage{}}
+		for _, msg := range example.Messages {
+			e.Messages = append(e.Messages, uiMessage{Role: msg.Role, Content: msg.Content})
+		}
+		detail.Examples = append(detail.Examples, e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// indexContent returns the index the server serves for a kind.
+func (s *Server) indexContent(kind ItemKind) ([]byte, error) {
+	if s.opts.Installed {
+		return installedIndex(s.dir, s.layout, kind, s.cipher)
+	}
+	content, err := os.ReadFile(filepath.Join(s.dir, kind.Plural(), "index.yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return []byte{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s index: %w", kind.Plural(), err)
+	}
+	return content, nil
+}
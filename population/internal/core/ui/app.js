@@ -0,0 +1,177 @@
+// The catalog browser for vega population serve --ui. It reads the JSON the
+// server provides under api/ and routes on the URL hash:
+//   #/                    catalog
+//   #/<kind>/<name>       item detail
+(function () {
+  "use strict";
+
+  var main = document.getElementById("main");
+  var search = document.getElementById("search");
+  var kindButtons = document.querySelectorAll("#kinds button");
+
+  var catalog = null;
+  var kind = "";
+
+  // el creates an element with text content, so nothing from a manifest is
+  // ever parsed as HTML.
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      node.setAttribute(key, attrs[key]);
+    });
+    (children || []).forEach(function (child) {
+      if (child === null || child === undefined) return;
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    });
+    return node;
+  }
+
+  function badge(text, cls) {
+    return el("span", { class: "badge" + (cls ? " " + cls : "") }, [text]);
+  }
+
+  // Display names prefix personas with @ and profiles with +, as the CLI does.
+  var prefixes = { persona: "@", profile: "+" };
+
+  function displayName(item) {
+    return (prefixes[item.kind] || "") + item.name;
+  }
+
+  function getJSON(path) {
+    return fetch(path, { credentials: "same-origin" }).then(function (resp) {
+      if (!resp.ok) {
+        return resp.json().catch(function () { return {}; }).then(function (body) {
+          throw new Error(body.error || "status " + resp.status);
+        });
+      }
+      return resp.json();
+    });
+  }
+
+  function showError(err) {
+    main.replaceChildren(el("p", { class: "muted" }, ["Could not load: " + err.message]));
+  }
+
+  function matches(item, query) {
+    if (kind && item.kind !== kind) return false;
+    if (!query) return true;
+    var text = [item.name, item.description, item.author].concat(item.tags || []).join(" ").toLowerCase();
+    return query.toLowerCase().split(/\s+/).every(function (word) {
+      return text.indexOf(word) !== -1;
+    });
+  }
+
+  function renderCatalog() {
+    var query = search.value.trim();
+    var items = catalog.filter(function (item) { return matches(item, query); });
+    if (items.length === 0) {
+      main.replaceChildren(el("p", { class: "muted" }, ["No items found."]));
+      return;
+    }
+
+    main.replaceChildren(el("div", { class: "grid" }, items.map(function (item) {
+      return el("a", { class: "card", href: "#/" + item.kind + "/" + encodeURIComponent(item.name) }, [
+        el("h3", {}, [displayName(item), " ", el("span", { class: "muted" }, [item.version])]),
+        el("p", {}, [item.description]),
+        el("div", {}, [badge(item.kind, "kind")].concat(
+          item.status ? [badge(item.status)] : [],
+          (item.tags || []).map(function (tag) { return badge(tag); })
+        )),
+      ]);
+    })));
+  }
+
+  function list(title, names, linkKind) {
+    if (!names || names.length === 0) return null;
+    return el("section", {}, [
+      el("h4", {}, [title]),
+      el("div", {}, names.map(function (name) {
+        var bare = name.split("@")[0];
+        return el("a", { class: "badge", href: "#/" + linkKind + "/" + encodeURIComponent(bare) }, [name]);
+      })),
+    ]);
+  }
+
+  function renderItem(item) {
+    var tools = (item.tools || []).map(function (tool) {
+      return el("li", {}, [
+        el("strong", {}, [tool.name]), " — ", tool.description, " ",
+        tool.dangerous ? badge("dangerous", "danger") : null,
+        tool.read_only ? badge("read-only") : null,
+      ]);
+    });
+    var examples = (item.examples || []).map(function (example) {
+      return el("div", {}, [
+        example.title ? el("p", {}, [el("em", {}, [example.title])]) : null,
+      ].concat(example.messages.map(function (msg) {
+        return el("div", { class: "message" }, [
+          el("span", { class: "role" }, [msg.role + ": "]),
+          el("pre", {}, [msg.content]),
+        ]);
+      })));
+    });
+
+    main.replaceChildren(
+      el("p", {}, [el("a", { href: "#/" }, ["← All items"])]),
+      el("h2", {}, [displayName(item), " ", el("span", { class: "muted" }, [item.version])]),
+      el("p", {}, [item.description]),
+      el("div", {}, [badge(item.kind, "kind")].concat(
+        item.status ? [badge(item.status)] : [],
+        (item.tags || []).map(function (tag) { return badge(tag); })
+      )),
+      el("p", { class: "muted" }, [
+        item.author ? "By " + item.author : "",
+        item.maintainers && item.maintainers.length ? " · Maintained by " + item.maintainers.join(", ") : "",
+      ]),
+      el("section", {}, [el("code", { class: "install" }, [item.install])]),
+      item.persona ? list("Persona", [item.persona], "persona") : null,
+      list("Skills", item.skills, "skill"),
+      list("Recommended skills", item.recommended_skills, "skill"),
+      list("Dependencies", item.dependencies, "skill"),
+      tools.length ? el("section", {}, [el("h4", {}, ["Tools"]), el("ul", {}, tools)]) : null,
+      item.prompt ? el("section", {}, [el("h4", {}, ["System prompt"]), el("pre", {}, [item.prompt])]) : null,
+      examples.length ? el("section", {}, [el("h4", {}, ["Examples"])].concat(examples)) : null
+    );
+  }
+
+  function route() {
+    var parts = location.hash.replace(/^#\/?/, "").split("/");
+    if (parts.length === 2 && parts[1]) {
+      main.replaceChildren(el("p", { class: "muted" }, ["Loading…"]));
+      getJSON("api/items/" + parts[0] + "/" + parts[1]).then(renderItem, showError);
+      window.scrollTo(0, 0);
+      return;
+    }
+    if (catalog === null) {
+      getJSON("api/catalog").then(function (body) {
+        catalog = body.items;
+        renderCatalog();
+      }, showError);
+      return;
+    }
+    renderCatalog();
+  }
+
+  search.addEventListener("input", function () {
+    if (location.hash && location.hash !== "#/") {
+      location.hash = "#/";
+    } else if (catalog !== null) {
+      renderCatalog();
+    }
+  });
+
+  kindButtons.forEach(function (button) {
+    button.addEventListener("click", function () {
+      kind = button.getAttribute("data-kind");
+      kindButtons.forEach(function (b) { b.classList.toggle("active", b === button); });
+      if (location.hash && location.hash !== "#/") {
+        location.hash = "#/";
+      } else if (catalog !== null) {
+        renderCatalog();
+      }
+    });
+  });
+
+  window.addEventListener("hashchange", route);
+  route();
+})();
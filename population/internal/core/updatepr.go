@@ -0,0 +1,361 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultUpdateBranch is the branch UpdatePR commits to unless told otherwise.
+const DefaultUpdateBranch = "vega/population-updates"
+
+// DefaultGitHubAPI is the GitHub API UpdatePR opens pull requests with.
+const DefaultGitHubAPI = "https://api.github.com"
+
+// LockUpdate is a newer version of a locked item that its spec allows.
+type LockUpdate struct {
+	Kind    ItemKind         `json:"kind" yaml:"kind"`
+	Name    string           `json:"name" yaml:"name"`
+	From    string           `json:"from" yaml:"from"`
+	To      string           `json:"to" yaml:"to"`
+	Changes []ChangelogEntry `json:"changes,omitempty" yaml:"changes,omitempty"` // Changelog entries after From up to To, newest first
+}
+
+// UpdatePROptions configures UpdatePR.
+type UpdatePROptions struct {
+	Branch string // Branch to commit the update to (default DefaultUpdateBranch)
+	Base   string // Branch the pull request targets (default: the current branch)
+	DryRun bool   // Report the updates without writing or committing anything
+
+	// Token is a GitHub token. With one, the branch is pushed to origin and
+	// a pull request is opened, or the open one for the branch updated.
+	Token string
+	API   string // GitHub API URL (default DefaultGitHubAPI)
+}
+
+// UpdatePRResult describes the updates UpdatePR found and what it did.
+type UpdatePRResult struct {
+	Updates     []LockUpdate `json:"updates" yaml:"updates"`
+	Title       string       `json:"title" yaml:"title"`
+	Body        string       `json:"body" yaml:"body"`
+	Branch      string       `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Commit      string       `json:"commit,omitempty" yaml:"commit,omitempty"`
+	PullRequest string       `json:"pull_request,omitempty" yaml:"pull_request,omitempty"` // URL of the pull request
+	DryRun      bool         `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+}
+
+// UpdateLock returns a copy of lock with every item at the newest version its
+// constraint in deps allows (any version for items deps doesn't constrain),
+// and the updates that makes. Items no source offers any more are kept as
+// they are.
+func (c *Client) UpdateLock(ctx context.Context, deps *Deps, lock *Lock) (*Lock, []LockUpdate, error) {
+	constraints := make(map[string]string)
+	for _, item := range deps.Items {
+		base, version := SplitVersion(item)
+		constraints[FormatItemName(ParseItemName(base))] = version
+	}
+
+	updated := &Lock{Items: make([]LockEntry, 0, len(lock.Items))}
+	var updates []LockUpdate
+	for _, entry := range lock.Items {
+		name := FormatItemName(entry.Kind, entry.Name)
+		source, err := c.resolveSource(ctx, entry.Kind, entry.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			updated.Items = append(updated.Items, entry)
+			continue
+		}
+		content, _, err := source.resolveManifest(ctx, entry.Kind, entry.Name, constraints[name])
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving %s: %w", name, err)
+		}
+		var m Manifest
+		if err := yaml.Unmarshal(content, &m); err != nil {
+			return nil, nil, fmt.Errorf("parsing %s manifest: %w", name, err)
+		}
+		if CompareVersions(m.Version, entry.Version) <= 0 {
+			updated.Items = append(updated.Items, entry)
+			continue
+		}
+
+		updates = append(updates, LockUpdate{
+			Kind:    entry.Kind,
+			Name:    entry.Name,
+			From:    entry.Version,
+			To:      m.Version,
+			Changes: changelogBetween(m.Changelog, entry.Version, m.Version),
+		})
+		updated.Items = append(updated.Items, LockEntry{
+			Kind:    entry.Kind,
+			Name:    entry.Name,
+			Version: m.Version,
+			Source:  source.baseURL,
+			SHA256:  contentHash(content),
+		})
+	}
+	return updated, updates, nil
+}
+
+// changelogBetween returns the changelog entries after from up to to, newest
+// first.
+func changelogBetween(changelog []ChangelogEntry, from, to string) []ChangelogEntry {
+	var entries []ChangelogEntry
+	for i := len(changelog) - 1; i >= 0; i-- {
+		v := changelog[i].Version
+		if CompareVersions(v, from) > 0 && CompareVersions(v, to) <= 0 {
+			entries = append(entries, changelog[i])
+		}
+	}
+	return entries
+}
+
+// UpdatePR updates the lockfile at lockPath to the newest versions deps
+// allows and commits it to a branch of the git repository holding it, with
+// the items' changelogs in the commit message, like a dependency update bot.
+// With opts.Token it also pushes the branch and opens a pull request. The
+// current branch is checked out again afterwards, so the working tree is
+// left as it was.
+func (c *Client) UpdatePR(ctx context.Context, deps *Deps, lockPath string, opts *UpdatePROptions) (*UpdatePRResult, error) {
+	if opts == nil {
+		opts = &UpdatePROptions{}
+	}
+	// git would read such a branch as an option
+	if strings.HasPrefix(opts.Branch, "-") {
+		return nil, fmt.Errorf("invalid branch %q: starts with a dash", opts.Branch)
+	}
+	lock, err := LoadLock(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(lock.Items) == 0 {
+		return nil, fmt.Errorf("%s is missing or empty; run sync to create it", lockPath)
+	}
+
+	updated, updates, err := c.UpdateLock(ctx, deps, lock)
+	if err != nil {
+		return nil, err
+	}
+	result := &UpdatePRResult{Updates: updates, DryRun: opts.DryRun}
+	if result.Updates == nil {
+		result.Updates = []LockUpdate{}
+	}
+	result.Title, result.Body = updateMessage(filepath.Base(lockPath), updates)
+	if len(updates) == 0 || opts.DryRun {
+		return result, nil
+	}
+
+	dir := filepath.Dir(lockPath)
+	branch := opts.Branch
+	if branch == "" {
+		branch = DefaultUpdateBranch
+	}
+	if err := runGit(ctx, dir, "check-ref-format", "--branch", branch); err != nil {
+		return nil, fmt.Errorf("invalid branch %q: %w", branch, err)
+	}
+	if status, err := gitOutput(ctx, dir, "status", "--porcelain", "--", filepath.Base(lockPath)); err != nil {
+		return nil, fmt.Errorf("checking %s: %w", lockPath, err)
+	} else if status != "" {
+		return nil, fmt.Errorf("%s has uncommitted changes; commit it first", lockPath)
+	}
+	current, err := gitOutput(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("finding the current branch: %w", err)
+	}
+	if current == branch {
+		return nil, fmt.Errorf("already on %s; check out the branch to update from first", branch)
+	}
+	base := opts.Base
+	if base == "" {
+		base = current
+	}
+
+	// Recreate the branch from here, so it carries exactly one update
+	if err := runGit(ctx, dir, "checkout", "--quiet", "-B", branch); err != nil {
+		return nil, fmt.Errorf("creating branch %s: %w", branch, err)
+	}
+	commit, err := func() (string, error) {
+		if err := updated.Save(lockPath); err != nil {
+			return "", err
+		}
+		if err := runGit(ctx, dir, "add", "--", filepath.Base(lockPath)); err != nil {
+			return "", fmt.Errorf("staging %s: %w", lockPath, err)
+		}
+		if err := runGit(ctx, dir, "commit", "--quiet", "-m", result.Title, "-m", result.Body, "--", filepath.Base(lockPath)); err != nil {
+			return "", fmt.Errorf("committing: %w", err)
+		}
+		return gitOutput(ctx, dir, "rev-parse", "HEAD")
+	}()
+	if err != nil {
+		// Put the lockfile back, so the original branch checks out cleanly
+		runGit(ctx, dir, "checkout", "--quiet", "HEAD", "--", filepath.Base(lockPath))
+	}
+	if restoreErr := runGit(ctx, dir, "checkout", "--quiet", current); restoreErr != nil && err == nil {
+		err = fmt.Errorf("checking out %s again: %w", current, restoreErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Branch, result.Commit = branch, commit
+
+	if opts.Token == "" {
+		return result, nil
+	}
+	if err := runGit(ctx, dir, "push", "--quiet", "--force", "origin", branch); err != nil {
+		return result, fmt.Errorf("pushing %s: %w", branch, err)
+	}
+	remote, err := gitOutput(ctx, dir, "remote", "get-url", "origin")
+	if err != nil {
+		return result, fmt.Errorf("reading the origin remote: %w", err)
+	}
+	repo, err := githubRepo(remote)
+	if err != nil {
+		return result, err
+	}
+	api := opts.API
+	if api == "" {
+		api = DefaultGitHubAPI
+	}
+	if result.PullRequest, err = c.openPullRequest(ctx, api, opts.Token, repo, branch, base, result.Title, result.Body); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// updateMessage returns the title and markdown body of an update's commit
+// and pull request.
+func updateMessage(lockFile string, updates []LockUpdate) (string, string) {
+	if len(updates) == 0 {
+		return "No agent population updates", ""
+	}
+
+	title := fmt.Sprintf("Update %d agent population items", len(updates))
+	if len(updates) == 1 {
+		title = fmt.Sprintf("Update %s to %s", FormatItemName(updates[0].Kind, updates[0].Name), updates[0].To)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Updates %s to the newest versions its spec allows.\n\n", lockFile)
+	b.WriteString("| Item | From | To |\n|------|------|----|\n")
+	for _, u := range updates {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", FormatItemName(u.Kind, u.Name), u.From, u.To)
+	}
+	for _, u := range updates {
+		if len(u.Changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s %s\n\n", FormatItemName(u.Kind, u.Name), u.To)
+		for _, change := range u.Changes {
+			line := "- **" + change.Version + "**"
+			if change.Date != "" {
+				line += " (" + change.Date + ")"
+			}
+			if text := strings.TrimSpace(change.Changes); text != "" {
+				line += ": " + strings.ReplaceAll(text, "\n", " ")
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	b.WriteString("\nRun `vega population sync` after merging to install them.\n")
+	return title, b.String()
+}
+
+// githubRepo returns the "owner/repo" of a GitHub remote URL, such as
+// https://github.com/owner/repo.git or git@github.com:owner/repo.git.
+func githubRepo(remote string) (string, error) {
+	path := strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == ':' })
+	if len(parts) < 3 {
+		return "", fmt.Errorf("origin %s is not a GitHub repository", remote)
+	}
+	return parts[len(parts)-2] + "/" + parts[len(parts)-1], nil
+}
+
+// pullRequest is the part of a GitHub pull request UpdatePR reads.
+type pullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// openPullRequest opens a pull request from branch into base and returns its
+// URL. If one is already open for the branch, its title and body are updated
+// instead.
+func (c *Client) openPullRequest(ctx context.Context, api, token, repo, branch, base, title, body string) (string, error) {
+	pulls := strings.TrimSuffix(api, "/") + "/repos/" + repo + "/pulls"
+
+	var existing []pullRequest
+	owner, _, _ := strings.Cut(repo, "/")
+	query := url.Values{"head": {owner + ":" + branch}, "state": {"open"}}
+	if err := c.githubRequest(ctx, http.MethodGet, pulls+"?"+query.Encode(), token, nil, &existing); err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		pr := existing[0]
+		update := map[string]string{"title": title, "body": body}
+		if err := c.githubRequest(ctx, http.MethodPatch, fmt.Sprintf("%s/%d", pulls, pr.Number), token, update, &pr); err != nil {
+			return "", err
+		}
+		return pr.HTMLURL, nil
+	}
+
+	var pr pullRequest
+	create := map[string]string{"title": title, "body": body, "head": branch, "base": base}
+	if err := c.githubRequest(ctx, http.MethodPost, pulls, token, create, &pr); err != nil {
+		return "", err
+	}
+	return pr.HTMLURL, nil
+}
+
+// githubRequest sends a GitHub API request with a JSON body, if any, and
+// decodes the JSON response into out.
+func (c *Client) githubRequest(ctx context.Context, method, u, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		body = bytes.NewReader(content)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, cancel, err := c.http.do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, u, err)
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, u, err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(content, &apiErr)
+		return fmt.Errorf("%s %s: status %d: %s", method, u, resp.StatusCode, apiErr.Message)
+	}
+	if err := json.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("parsing response of %s %s: %w", method, u, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdatePRRejectsInvalidBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	client, src := newMemoryClient(t, map[string]*Manifest{"deploy-ops": {Version: "1.0.0"}})
+	if err := src.Put("deploy-ops", &Manifest{Version: "1.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "vega.lock")
+	lock := &Lock{Items: []LockEntry{{Kind: KindSkill, Name: "deploy-ops", Version: "1.0.0"}}}
+	if err := lock.Save(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "vega.lock"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "lock"},
+	} {
+		if err := runGit(ctx, dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	head, err := gitOutput(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, branch := range []string{"--upload-pack=touch pwned", "-b", "bad..branch", "bad branch"} {
+		if _, err := client.UpdatePR(ctx, &Deps{}, lockPath, &UpdatePROptions{Branch: branch}); err == nil || !strings.Contains(err.Error(), "invalid branch") {
+			t.Errorf("UpdatePR with branch %q: %v, want it refused as invalid", branch, err)
+		}
+	}
+	if branches, err := gitOutput(ctx, dir, "branch", "--format=%(refname:short)"); err != nil || strings.Contains(branches, "\n") {
+		t.Errorf("branches are %q (%v), want only the original", branches, err)
+	}
+	if after, err := gitOutput(ctx, dir, "rev-parse", "HEAD"); err != nil || after != head {
+		t.Errorf("HEAD moved to %s (%v)", after, err)
+	}
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UpgradeOptions configures upgrading installed items.
+type UpgradeOptions struct {
+	DryRun bool   // Report what would be upgraded without changing anything
+	Env    string // Deployment environment, as for InstallOptions
+	Force  bool   // Upgrade items changed since they were installed, discarding the changes
+}
+
+// Upgrade replaces outdated installed items with the source's latest
+// versions. With no names, every outdated item is upgraded. A profile is
+// upgraded on its own; its persona and skills are upgraded when they are
+// outdated themselves. A persona is only upgraded as far as the installed
+// profiles' constraints on it allow, and a profile whose new version needs
+// another version of its installed persona brings that version with it.
+// Items changed since they were installed, according to their install
+// records, fail with a *LocalChangesError unless opts.Force is set. It
+// returns the items that were (or would be) upgraded.
+func (c *Client) Upgrade(ctx context.Context, names []string, opts *UpgradeOptions) ([]OutdatedItem, error) {
+	if opts == nil {
+		opts = &UpgradeOptions{}
+	}
+
+	want := make(map[string]bool)
+	for _, name := range names {
+		kind, itemName := ParseItemName(name)
+		if err := checkItemName(itemName); err != nil {
+			return nil, err
+		}
+		manifestPath := filepath.Join(c.itemDir(kind, itemName), "vega.yaml")
+		if _, err := os.Stat(manifestPath); err != nil {
+			return nil, fmt.Errorf("%s %q is not installed", kind, itemName)
+		}
+		want[FormatItemName(kind, itemName)] = true
+	}
+
+	outdated, err := c.Outdated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	held, err := c.personaRequirements()
+	if err != nil {
+		return nil, err
+	}
+
+	var upgraded []OutdatedItem
+	if !opts.DryRun {
+		defer func() {
+			var changes []string
+			for _, item := range upgraded {
+				changes = append(changes, fmt.Sprintf("%s %s -> %s", FormatItemName(item.Kind, item.Name), item.Installed, item.Latest))
+			}
+			if len(changes) > 0 {
+				c.recordChange(ctx, changeMessage("Upgrade", changes))
+			}
+		}()
+	}
+	for _, item := range outdated {
+		name := FormatItemName(item.Kind, item.Name)
+		if len(want) > 0 && !want[name] {
+			continue
+		}
+
+		version := ""
+		if reqs := held[item.Name]; item.Kind == KindPersona && len(reqs) > 0 {
+			allowed, err := c.allowedVersion(ctx, item.Kind, item.Name, reqs)
+			if err != nil {
+				return upgraded, err
+			}
+			if allowed == "" || CompareVersions(allowed, item.Installed) <= 0 {
+				continue // Held back by the profiles using it
+			}
+			item.Latest, version = allowed, allowed
+		}
+		if item.Files, err = c.upgradeItem(ctx, name, version, opts); err != nil {
+			return upgraded, err
+		}
+		upgraded = append(upgraded, item)
+
+		if item.Kind == KindProfile {
+			persona, err := c.profilePersona(ctx, item.Name, item.Latest)
+			if err != nil {
+				return upgraded, err
+			}
+			if persona != nil {
+				if persona.Files, err = c.upgradeItem(ctx, FormatItemName(KindPersona, persona.Name), persona.Latest, opts); err != nil {
+					return upgraded, err
+				}
+				upgraded = append(upgraded, *persona)
+			}
+		}
+	}
+
+	return upgraded, nil
+}
+
+// upgradeItem reinstalls an item at the newest version, or at the given one.
+// With opts.DryRun it changes nothing, and returns the files it would change.
+func (c *Client) upgradeItem(ctx context.Context, name, version string, opts *UpgradeOptions) ([]FileChange, error) {
+	if !opts.Force {
+		kind, itemName := ParseItemName(name)
+		if _, changed := installState(c.itemDir(kind, itemName), c.cipher); len(changed) > 0 {
+			return nil, &LocalChangesError{Kind: kind, Name: itemName, Files: changed}
+		}
+	}
+	installOpts := &InstallOptions{
+		Force:   true,
+		NoDeps:  true,
+		DryRun:  opts.DryRun,
+		Env:     opts.Env,
+		Version: version,
+
+		unrecorded: true,
+	}
+	report, err := c.InstallWithReport(ctx, name, installOpts)
+	if err != nil {
+		return nil, fmt.Errorf("upgrading %s: %w", name, err)
+	}
+	var changes []FileChange
+	for _, item := range report.Items {
+		changes = append(changes, item.Files...)
+	}
+	return changes, nil
+}
+
+// personaRequirements returns the version constraints the installed profiles
+// place on their personas, by persona name.
+func (c *Client) personaRequirements() (map[string][]VersionRequirement, error) {
+	profiles, err := c.List(KindProfile)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make(map[string][]VersionRequirement)
+	for _, profile := range profiles {
+		m, err := loadManifest(filepath.Join(c.itemDir(KindProfile, profile.Name), "vega.yaml"), c.cipher)
+		if err != nil {
+			continue
+		}
+		if persona, version := SplitVersion(m.Persona); version != "" {
+			reqs[persona] = append(reqs[persona], VersionRequirement{By: FormatItemName(KindProfile, profile.Name), Constraint: version})
+		}
+	}
+	return reqs, nil
+}
+
+// allowedVersion returns the newest version of an item that meets every
+// requirement, or "" if none does.
+func (c *Client) allowedVersion(ctx context.Context, kind ItemKind, name string, reqs []VersionRequirement) (string, error) {
+	source, err := c.resolveSource(ctx, kind, name)
+	if err != nil {
+		return "", err
+	}
+	versions, _, err := source.Versions(ctx, kind, name)
+	if err != nil {
+		return "", err
+	}
+	constraints := make([]*VersionConstraint, len(reqs))
+	for i, req := range reqs {
+		if constraints[i], err = ParseConstraint(req.Constraint); err != nil {
+			return "", fmt.Errorf("%s: persona %q: %w", req.By, name, err)
+		}
+	}
+
+	best := ""
+	for _, v := range versions {
+		allowed := true
+		for _, vc := range constraints {
+			allowed = allowed && vc.Match(v)
+		}
+		if allowed && (best == "" || CompareVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	return best, nil
+}
+
+// profilePersona returns the change to the installed persona of a profile
+// that its given version needs, or nil if the installed one is allowed.
+func (c *Client) profilePersona(ctx context.Context, profile, version string) (*OutdatedItem, error) {
+	source, err := c.resolveSource(ctx, KindProfile, profile)
+	if err != nil {
+		return nil, err
+	}
+	content, _, err := source.resolveManifest(ctx, KindProfile, profile, version)
+	if err != nil {
+		return nil, fmt.Errorf("fetching profile %q %s: %w", profile, version, err)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("parsing profile %q: %w", profile, err)
+	}
+
+	persona, constraint := SplitVersion(m.Persona)
+	installed := installedVersion(c.itemDir(KindPersona, persona), c.cipher)
+	if constraint == "" || installed == "" {
+		return nil, nil
+	}
+	vc, err := ParseConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: persona %q: %w", profile, persona, err)
+	}
+	if vc.Match(installed) {
+		return nil, nil
+	}
+
+	req := []VersionRequirement{{By: FormatItemName(KindProfile, profile), Constraint: constraint}}
+	allowed, err := c.allowedVersion(ctx, KindPersona, persona, req)
+	if err != nil {
+		return nil, err
+	}
+	if allowed == "" {
+		return nil, fmt.Errorf("profile %q %s needs persona %q %s, which no version satisfies", profile, version, persona, constraint)
+	}
+	return &OutdatedItem{Kind: KindPersona, Name: persona, Installed: installed, Latest: allowed}, nil
+}
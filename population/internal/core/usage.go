@@ -0,0 +1,140 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UsageFile is the usage log, relative to the install directory. Each line is
+// a JSON usageEvent, appended by agent runtimes through RecordUsage.
+const UsageFile = "usage.log"
+
+// usageEvent is one line of the usage log.
+type usageEvent struct {
+	Item string    `json:"item"`
+	Time time.Time `json:"time"`
+}
+
+// RecordUsage notes that an installed item was exercised, such as a skill's
+// tool being called or a persona being loaded. Runtimes call it as items are
+// used so unused ones can be found and pruned later.
+func (c *Client) RecordUsage(name string) error {
+	kind, itemName := ParseItemName(name)
+	if err := checkItemName(itemName); err != nil {
+		return err
+	}
+	line, err := json.Marshal(usageEvent{Item: FormatItemName(kind, itemName), Time: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("encoding usage: %w", err)
+	}
+
+	if err := os.MkdirAll(c.installDir, 0755); err != nil {
+		return fmt.Errorf("creating install directory: %w", err)
+	}
+	// Appends of a single short line are atomic, so concurrent runtimes can share the log
+	f, err := os.OpenFile(filepath.Join(c.installDir, UsageFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening usage log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing usage log: %w", err)
+	}
+	return nil
+}
+
+// LastUsed returns when each item in the usage log was last used, keyed by
+// its formatted name (e.g. "@cmo").
+func (c *Client) LastUsed() (map[string]time.Time, error) {
+	used := make(map[string]time.Time)
+
+	f, err := os.Open(filepath.Join(c.installDir, UsageFile))
+	if os.IsNotExist(err) {
+		return used, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading usage log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event usageEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// Skip lines torn by a crashed writer
+			continue
+		}
+		if event.Time.After(used[event.Item]) {
+			used[event.Item] = event.Time
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading usage log: %w", err)
+	}
+	return used, nil
+}
+
+// Unused returns installed items that haven't been used within the given
+// window. Items installed within the window count as used, so a fresh
+// install isn't reported before it has had a chance to run.
+func (c *Client) Unused(kind ItemKind, since time.Duration) ([]InstalledItem, error) {
+	items, err := c.List(kind)
+	if err != nil {
+		return nil, err
+	}
+	used, err := c.LastUsed()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-since)
+	var unused []InstalledItem
+	for _, item := range items {
+		last := used[FormatItemName(item.Kind, item.Name)]
+		if installed := installTime(item.Path); installed.After(last) {
+			last = installed
+		}
+		if last.Before(cutoff) {
+			unused = append(unused, item)
+		}
+	}
+	return unused, nil
+}
+
+// installTime returns when the item in dir was installed, from its install
+// record or else the manifest's modification time.
+func installTime(dir string) time.Time {
+	if record, err := LoadInstallRecord(dir); err == nil {
+		return record.InstalledAt
+	}
+	if info, err := os.Stat(filepath.Join(dir, "vega.yaml")); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// ParseAge parses a duration such as "30d", "2w", or "12h".
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			days, err := strconv.Atoi(n)
+			if err != nil || days < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(days) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30d, 2w, or 12h)", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,346 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Tool is a command a skill exposes to agents.
+type Tool struct {
+	Name        string               `yaml:"name"`
+	Description string               `yaml:"description"`
+	Params      map[string]ToolParam `yaml:"params,omitempty"`
+	Run         string               `yaml:"run,omitempty"`    // Shell command template
+	Script      string               `yaml:"script,omitempty"` // Path to a script file (alternative to Run)
+	Dangerous   bool                 `yaml:"dangerous,omitempty"`
+	ReadOnly    bool                 `yaml:"read_only,omitempty"`
+}
+
+// ToolParam is a parameter of a skill tool.
+type ToolParam struct {
+	Type        string      `yaml:"type,omitempty"` // string, number, or boolean
+	Required    bool        `yaml:"required,omitempty"`
+	Default     interface{} `yaml:"default,omitempty"`
+	Description string      `yaml:"description,omitempty"`
+}
+
+// ValidationError is a problem with one field of a manifest.
+type ValidationError struct {
+	Field   string // e.g. "version" or "tools[1].name"
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+var (
+	itemNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	versionPattern  = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+	tagPattern      = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	toolNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// maxDescriptionLength is the longest description the schemas allow.
+const maxDescriptionLength = 200
+
+// ValidateManifest checks a manifest against the item schemas: required
+// fields, name and version formats, tag conventions, and the fields each
+// kind may and must set. References to other items are checked by
+// LocalRegistry.ValidateReferences.
+func ValidateManifest(m *Manifest) []ValidationError {
+	var errs []ValidationError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	kind := ItemKind(m.Kind)
+	switch kind {
+	case KindSkill, KindPersona, KindProfile, KindSettings:
+	case "":
+		add("kind", "is required")
+	default:
+		add("kind", "must be skill, persona, profile, or settings, not %q", m.Kind)
+	}
+
+	if m.Name == "" {
+		add("name", "is required")
+	} else if !itemNamePattern.MatchString(m.Name) {
+		add("name", "%q must be lowercase letters, digits, and hyphens, starting with a letter", m.Name)
+	}
+	if m.Version == "" {
+		add("version", "is required")
+	} else if !versionPattern.MatchString(m.Version) {
+		add("version", "%q must be a semantic version such as 1.0.0", m.Version)
+	}
+	if m.Description == "" {
+		add("description", "is required")
+	} else if n := len(m.Description); n > maxDescriptionLength {
+		add("description", "is %d characters (max %d)", n, maxDescriptionLength)
+	}
+	if m.Status != "" {
+		if _, err := ParseReviewStatus(string(m.Status)); err != nil {
+			add("status", "%v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, tag := range m.Tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		if !tagPattern.MatchString(tag) {
+			add(field, "%q must be lowercase letters, digits, and hyphens", tag)
+		}
+		if seen[tag] {
+			add(field, "duplicate tag %q", tag)
+		}
+		seen[tag] = true
+	}
+
+	if m.Requires != nil {
+		for i, goos := range m.Requires.OS {
+			if !knownOS(goos) {
+				add(fmt.Sprintf("requires.os[%d]", i), "unknown operating system %q (want linux, darwin, or windows)", goos)
+			}
+		}
+	}
+	for i, f := range m.Files {
+		field := fmt.Sprintf("files[%d]", i)
+		if err := checkItemFilePath(f.Path); err != nil {
+			add(field, "%v", err)
+		}
+		if f.SHA256 != "" && !sha256Pattern.MatchString(f.SHA256) {
+			add(field+".sha256", "%q is not a hex sha256", f.SHA256)
+		}
+	}
+
+	// Fields that belong to other kinds
+	if kind != KindPersona && !m.SystemPrompt.IsZero() {
+		add("system_prompt", "only personas have a system prompt")
+	}
+	if kind != KindPersona && len(m.Examples) > 0 {
+		add("examples", "only personas have example conversations")
+	}
+	if kind != KindSkill && len(m.Tools) > 0 {
+		add("tools", "only skills have tools")
+	}
+	if kind != KindProfile && m.Persona != "" {
+		add("persona", "only profiles reference a persona")
+	}
+	if kind != KindProfile && len(m.Skills) > 0 {
+		add("skills", "only profiles list skills (personas use recommended_skills)")
+	}
+	if kind != KindSkill && m.Compatibility != nil {
+		add("compatibility", "only skills declare compatibility")
+	}
+	if kind != KindSkill && len(m.Dependencies) > 0 {
+		add("dependencies", "only skills have dependencies")
+	}
+
+	switch kind {
+	case KindSkill:
+		if len(m.Tools) == 0 {
+			add("tools", "a skill needs at least one tool")
+		}
+		names := make(map[string]bool)
+		for i, tool := range m.Tools {
+			field := fmt.Sprintf("tools[%d]", i)
+			if !toolNamePattern.MatchString(tool.Name) {
+				add(field+".name", "%q must be snake_case", tool.Name)
+			}
+			if names[tool.Name] {
+				add(field+".name", "duplicate tool %q", tool.Name)
+			}
+			names[tool.Name] = true
+			if tool.Description == "" {
+				add(field+".description", "is required")
+			}
+			if tool.Run == "" && tool.Script == "" {
+				add(field, "needs run or script")
+			}
+			if tool.Script != "" && checkItemFilePath(tool.Script) != nil {
+				add(field+".script", "%q must be a path inside the skill", tool.Script)
+			}
+			for _, name := range sortedKeys(tool.Params) {
+				switch param := tool.Params[name]; param.Type {
+				case "", "string", "number", "boolean":
+				default:
+					add(fmt.Sprintf("%s.params.%s.type", field, name), "must be string, number, or boolean, not %q", param.Type)
+				}
+			}
+		}
+		deps := make(map[string]bool)
+		for i, dep := range m.Dependencies {
+			field := fmt.Sprintf("dependencies[%d]", i)
+			name, _ := SplitVersion(dep)
+			switch {
+			case checkItemName(name) != nil:
+				add(field, "%q is not a valid skill name", dep)
+			case name == m.Name:
+				add(field, "a skill can't depend on itself")
+			case deps[name]:
+				add(field, "duplicate dependency %q", name)
+			}
+			deps[name] = true
+		}
+		if c := m.Compatibility; c != nil {
+			checkNames := func(field string, kind ItemKind, names []string) {
+				for i, name := range names {
+					if checkItemName(name) != nil {
+						add(fmt.Sprintf("compatibility.%s[%d]", field, i), "%q is not a valid %s name", name, kind)
+					}
+				}
+			}
+			checkNames("personas", KindPersona, c.Personas)
+			checkNames("incompatible_personas", KindPersona, c.IncompatiblePersonas)
+			checkNames("incompatible_skills", KindSkill, c.IncompatibleSkills)
+			for i, name := range c.IncompatiblePersonas {
+				if containsFold(c.Personas, name) {
+					add(fmt.Sprintf("compatibility.incompatible_personas[%d]", i), "%q is also listed as compatible", name)
+				}
+			}
+			for i, name := range c.IncompatibleSkills {
+				switch {
+				case name == m.Name:
+					add(fmt.Sprintf("compatibility.incompatible_skills[%d]", i), "a skill can't be incompatible with itself")
+				case deps[name]:
+					add(fmt.Sprintf("compatibility.incompatible_skills[%d]", i), "%q is also a dependency", name)
+				}
+			}
+			for i, tool := range c.IncompatibleTools {
+				if names[tool] {
+					add(fmt.Sprintf("compatibility.incompatible_tools[%d]", i), "%q is one of the skill's own tools", tool)
+				}
+			}
+		}
+
+	case KindPersona:
+		if m.SystemPrompt.IsZero() {
+			add("system_prompt", "a persona needs a system prompt")
+		}
+		for _, problem := range LintExamples(m.Examples) {
+			add("examples", "%s", problem)
+		}
+
+	case KindProfile:
+		if m.Persona == "" {
+			add("persona", "a profile needs a persona")
+		} else if name, version := SplitVersion(m.Persona); checkItemName(name) != nil {
+			add("persona", "%q is not a valid persona name", m.Persona)
+		} else if _, err := ParseConstraint(version); version != "" && err != nil {
+			add("persona", "%v", err)
+		}
+		if len(m.Skills) == 0 {
+			add("skills", "a profile needs at least one skill")
+		}
+		for i, ref := range m.Skills {
+			field := fmt.Sprintf("skills[%d]", i)
+			if ref.Name == "" {
+				add(field+".name", "is required")
+			}
+			for _, goos := range splitList(ref.Only) {
+				if !knownOS(goos) {
+					add(field+".only", "unknown operating system %q (want linux, darwin, or windows)", goos)
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// knownOS reports whether goos is an operating system conditions can name.
+func knownOS(goos string) bool {
+	return goos == "linux" || goos == "darwin" || goos == "windows"
+}
+
+// splitList splits a comma-separated list, dropping blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ValidateReferences checks a manifest at path against the registry: its
+// directory must match its kind and name, and a profile's persona and skills
+// (and a persona's recommended skills, and a skill's dependencies) must exist
+// in the registry's indexes, and a profile's skills must be compatible with
+// each other and its persona.
+func (r *LocalRegistry) ValidateReferences(manifestPath string, m *Manifest) ([]ValidationError, error) {
+	var errs []ValidationError
+
+	itemDir := filepath.Dir(manifestPath)
+	if dir := filepath.Base(itemDir); m.Name != "" && dir != m.Name {
+		errs = append(errs, ValidationError{Field: "name", Message: fmt.Sprintf("%q does not match its directory %q", m.Name, dir)})
+	}
+	if plural := filepath.Base(filepath.Dir(itemDir)); m.Kind != "" && plural != ItemKind(m.Kind).Plural() {
+		errs = append(errs, ValidationError{Field: "kind", Message: fmt.Sprintf("%s lives under %s/", m.Kind, plural)})
+	}
+
+	check := func(field string, kind ItemKind, name string) error {
+		if name == "" {
+			return nil
+		}
+		ok, err := r.hasItem(kind, name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("%s %q is not in the registry", kind, name)})
+		}
+		return nil
+	}
+
+	persona, _ := SplitVersion(m.Persona)
+	if err := check("persona", KindPersona, persona); err != nil {
+		return nil, err
+	}
+	for i, ref := range m.Skills {
+		if err := check(fmt.Sprintf("skills[%d]", i), KindSkill, ref.Name); err != nil {
+			return nil, err
+		}
+	}
+	for i, name := range m.RecommendedSkills {
+		if err := check(fmt.Sprintf("recommended_skills[%d]", i), KindSkill, name); err != nil {
+			return nil, err
+		}
+	}
+	for i, dep := range m.Dependencies {
+		name, _ := SplitVersion(dep)
+		if err := check(fmt.Sprintf("dependencies[%d]", i), KindSkill, name); err != nil {
+			return nil, err
+		}
+	}
+
+	// A profile's skills must be compatible with each other and its persona;
+	// skills missing from the registry are reported above
+	if ItemKind(m.Kind) == KindProfile {
+		skills := make(map[string]*Manifest)
+		for _, ref := range m.Skills {
+			if skill, err := LoadManifest(filepath.Join(r.dir, KindSkill.Plural(), ref.Name, "vega.yaml")); err == nil {
+				skills[ref.Name] = skill
+			}
+		}
+		for _, i := range checkCompatibility(persona, skills) {
+			message := fmt.Sprintf("%s is incompatible with %s", i.Item, i.With)
+			if i.Reason != "" {
+				message += ": " + i.Reason
+			}
+			errs = append(errs, ValidationError{Field: "skills", Message: message})
+		}
+	}
+	return errs, nil
+}
+
+// hasItem reports whether the registry's index lists an item.
+func (r *LocalRegistry) hasItem(kind ItemKind, name string) (bool, error) {
+	source := NewSource(r.dir, NewCache("", true))
+	_, ok, err := source.latestVersion(context.Background(), kind, name)
+	return ok, err
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VersionControl records the state of an install directory after each
+// change the client makes to it, giving the directory a history that can
+// be diffed and rolled back. GitVersionControl is the built-in one.
+type VersionControl interface {
+	// Commit records paths, relative to dir, as they now are, with message
+	// describing the change. Paths that no longer exist are recorded as
+	// removed. Nothing is recorded when nothing changed.
+	Commit(ctx context.Context, dir string, paths []string, message string) error
+}
+
+// GitVersionControl keeps an install directory in a git repository,
+// initializing it on the first commit with a .gitignore that leaves out
+// everything but the items, so caches and logs in the vega home stay out of
+// history. Commits use the user's git identity when there is one.
+type GitVersionControl struct{}
+
+// Commit implements VersionControl.
+func (GitVersionControl) Commit(ctx context.Context, dir string, paths []string, message string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if err := runGit(ctx, dir, "init", "--quiet"); err != nil {
+			return fmt.Errorf("initializing git repository in %s: %w", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(gitignoreFor(paths)), 0644); err != nil {
+			return fmt.Errorf("writing .gitignore: %w", err)
+		}
+		paths = append(paths, ".gitignore")
+	} else if err != nil {
+		return fmt.Errorf("checking %s for a git repository: %w", dir, err)
+	}
+
+	// Paths matching nothing, on disk or in history, would fail the add
+	args := []string{"add", "--all", "--"}
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(dir, p)); err == nil {
+			args = append(args, p)
+		} else if tracked, _ := gitOutput(ctx, dir, "ls-files", "--", p); tracked != "" {
+			args = append(args, p)
+		}
+	}
+	if err := runGit(ctx, dir, args...); err != nil {
+		return fmt.Errorf("staging changes in %s: %w", dir, err)
+	}
+	if changed, err := gitOutput(ctx, dir, "diff", "--cached", "--name-only"); err != nil {
+		return fmt.Errorf("checking changes in %s: %w", dir, err)
+	} else if changed == "" {
+		return nil
+	}
+
+	var identity []string
+	if email, _ := gitOutput(ctx, dir, "config", "user.email"); email == "" {
+		identity = []string{"-c", "user.name=vega", "-c", "user.email=vega@localhost"}
+	}
+	args = append(identity, "commit", "--quiet", "--no-verify", "-m", message)
+	if err := runGit(ctx, dir, args...); err != nil {
+		return fmt.Errorf("committing changes in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// gitignoreFor returns a .gitignore ignoring everything at the top of a
+// directory except paths.
+func gitignoreFor(paths []string) string {
+	lines := []string{"# Written by vega population: only items are kept in history", "/*", "!/.gitignore"}
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		top, _, _ := strings.Cut(filepath.ToSlash(p), "/")
+		if !seen[top] {
+			seen[top] = true
+			lines = append(lines, "!/"+top)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// WithVersionControl records every install, upgrade, uninstall, and sync in
+// the install directory with vc, such as GitVersionControl{}.
+func WithVersionControl(vc VersionControl) Option {
+	return func(c *Client) {
+		c.versionControl = vc
+	}
+}
+
+// recordChange commits the item directories of the install directory with
+// the configured version control, if any. The change has already been made,
+// so failing to record it is logged rather than returned.
+func (c *Client) recordChange(ctx context.Context, message string) {
+	if c.versionControl == nil {
+		return
+	}
+	paths := []string{LayoutFile}
+	for _, kind := range []ItemKind{KindSkill, KindPersona, KindProfile, KindSettings} {
+		paths = append(paths, c.layout.Dir(kind))
+	}
+	if err := c.versionControl.Commit(ctx, c.installDir, paths, message); err != nil {
+		c.log().Warn("failed to record change to "+c.installDir, "error", err)
+	}
+}
+
+// changeMessage describes a change to several items as a commit subject,
+// such as "Install kubernetes-ops 1.2.0, @sre 2.0.0".
+func changeMessage(verb string, changes []string) string {
+	return verb + " " + strings.Join(changes, ", ")
+}
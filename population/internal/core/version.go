@@ -0,0 +1,315 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two dotted version strings such as "1.2.0" by
+// SemVer 2.0 precedence. It returns -1 if a < b, 0 if they are equal, and 1
+// if a > b. A pre-release sorts below its release (1.0.0-rc.1 < 1.0.0), and
+// build metadata is ignored. Missing segments count as zero, and a leading
+// "v" is ignored.
+func CompareVersions(a, b string) int {
+	as := versionSegments(a)
+	bs := versionSegments(b)
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return comparePrerelease(versionPrerelease(a), versionPrerelease(b))
+}
+
+// comparePrerelease compares the pre-release parts of two versions with
+// equal segments. A version without one is the higher; otherwise their
+// dot-separated identifiers are compared in turn, numbers numerically and
+// below any others, which compare in ASCII order, and a prefix is the lower.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xerr := strconv.ParseUint(as[i], 10, 64)
+		y, yerr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case xerr == nil && yerr == nil:
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		case xerr == nil:
+			return -1
+		case yerr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// Version parts accepted by BumpVersion.
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// BumpVersion increments the given part of a MAJOR.MINOR.PATCH version,
+// resetting the lower parts: 1.2.3 bumped by minor becomes 1.3.0. A
+// pre-release of the version the bump would reach is released instead:
+// 1.3.0-rc.1 bumped by minor becomes 1.3.0, and by patch too.
+func BumpVersion(version, part string) (string, error) {
+	segments := versionSegments(version)
+	for len(segments) < 3 {
+		segments = append(segments, 0)
+	}
+	pre := versionPrerelease(version) != ""
+
+	switch part {
+	case BumpMajor:
+		if !pre || segments[1] != 0 || segments[2] != 0 {
+			segments[0]++
+		}
+		segments = []int{segments[0], 0, 0}
+	case BumpMinor:
+		if !pre || segments[2] != 0 {
+			segments[1]++
+		}
+		segments = []int{segments[0], segments[1], 0}
+	case BumpPatch, "":
+		if !pre {
+			segments[2]++
+		}
+		segments = []int{segments[0], segments[1], segments[2]}
+	default:
+		return "", fmt.Errorf("unknown version part %q (want major, minor, or patch)", part)
+	}
+
+	return fmt.Sprintf("%d.%d.%d", segments[0], segments[1], segments[2]), nil
+}
+
+// versionSegments returns the numeric segments of a version, without its
+// pre-release and build metadata.
+func versionSegments(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	var segments []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			n = 0
+		}
+		segments = append(segments, n)
+	}
+	return segments
+}
+
+// versionPrerelease returns the pre-release part of a version, such as
+// "rc.1" in "1.0.0-rc.1+build.5", or "" for a release.
+func versionPrerelease(v string) string {
+	v, _, _ = strings.Cut(strings.TrimSpace(v), "+")
+	_, pre, _ := strings.Cut(v, "-")
+	return pre
+}
+
+// VersionConstraint is a set of version bounds that must all hold.
+type VersionConstraint struct {
+	raw    string
+	bounds []versionBound
+}
+
+type versionBound struct {
+	op      string // One of "=", ">", ">=", "<", "<="
+	version string
+}
+
+// ParseConstraint parses a version constraint. It accepts exact versions
+// ("1.2.0"), partial versions matching a range ("1.2" is any 1.2.x), caret
+// and tilde ranges ("^1.2", "~1.2.3"), and comparisons (">=1.2 <2"), which
+// may be separated by spaces or commas.
+func ParseConstraint(s string) (*VersionConstraint, error) {
+	c := &VersionConstraint{raw: s}
+
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	for _, field := range fields {
+		bounds, err := parseConstraintTerm(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.bounds = append(c.bounds, bounds...)
+	}
+
+	return c, nil
+}
+
+func parseConstraintTerm(term string) ([]versionBound, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(term, op) {
+			v := strings.TrimPrefix(term, op)
+			if !isVersion(v) {
+				return nil, fmt.Errorf("%q is not a version", v)
+			}
+			return []versionBound{{op, v}}, nil
+		}
+	}
+
+	var op string
+	if strings.HasPrefix(term, "^") || strings.HasPrefix(term, "~") {
+		op, term = term[:1], term[1:]
+	}
+	term = strings.TrimSuffix(strings.TrimSuffix(term, ".x"), ".*")
+	if !isVersion(term) {
+		return nil, fmt.Errorf("%q is not a version", term)
+	}
+
+	segments := versionSegments(term)
+	lower := formatSegments(segments)
+	if pre := versionPrerelease(term); pre != "" {
+		lower += "-" + pre
+	}
+
+	// Work out which segment the upper bound increments
+	bump := len(segments) - 1
+	switch op {
+	case "^":
+		bump = 0
+		for bump < len(segments)-1 && segments[bump] == 0 {
+			bump++
+		}
+	case "~":
+		if len(segments) > 1 {
+			bump = 1
+		}
+	default:
+		if len(segments) >= 3 {
+			return []versionBound{{"=", term}}, nil
+		}
+	}
+
+	upper := append([]int(nil), segments[:bump+1]...)
+	upper[bump]++
+	return []versionBound{{">=", lower}, {"<", formatSegments(upper)}}, nil
+}
+
+// Match reports whether version satisfies the constraint. A pre-release
+// only satisfies a constraint with a bound on a pre-release of the same
+// version, so "^1.2" matches 1.3.0 but not 1.3.0-beta.1, while
+// ">=1.3.0-beta.1" matches both it and 1.3.0-beta.2.
+func (c *VersionConstraint) Match(version string) bool {
+	if versionPrerelease(version) != "" && !c.allowsPrerelease(version) {
+		return false
+	}
+	for _, b := range c.bounds {
+		cmp := CompareVersions(version, b.version)
+		var ok bool
+		switch b.op {
+		case "=":
+			ok = cmp == 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsPrerelease reports whether a bound is on a pre-release with the same
+// segments as version.
+func (c *VersionConstraint) allowsPrerelease(version string) bool {
+	core := formatSegments(versionSegments(version))
+	for _, b := range c.bounds {
+		if versionPrerelease(b.version) != "" && formatSegments(versionSegments(b.version)) == core {
+			return true
+		}
+	}
+	return false
+}
+
+// Best returns the highest of versions that satisfies the constraint.
+func (c *VersionConstraint) Best(versions []string) (string, bool) {
+	var best string
+	for _, v := range versions {
+		if c.Match(v) && (best == "" || CompareVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	return best, best != ""
+}
+
+// String returns the constraint as written.
+func (c *VersionConstraint) String() string {
+	return c.raw
+}
+
+func isVersion(v string) bool {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return false
+	}
+	for _, part := range strings.Split(v, ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func formatSegments(segments []int) string {
+	for len(segments) < 3 {
+		segments = append(segments, 0)
+	}
+	parts := make([]string, len(segments))
+	for i, n := range segments {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	// The SemVer 2.0 precedence example, lowest first
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := CompareVersions(a, b); got != want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+
+	for _, equal := range [][2]string{
+		{"1.0.0+build.1", "1.0.0+build.2"},
+		{"1.0.0-rc.1+build.1", "1.0.0-rc.1"},
+		{"v1.2", "1.2.0"},
+	} {
+		if got := CompareVersions(equal[0], equal[1]); got != 0 {
+			t.Errorf("CompareVersions(%q, %q) = %d, want 0", equal[0], equal[1], got)
+		}
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+	for _, tt := range []struct{ version, part, want string }{
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"1.2.3", BumpMinor, "1.3.0"},
+		{"1.2.3", BumpMajor, "2.0.0"},
+		{"1.0.0-rc.1", BumpPatch, "1.0.0"},
+		{"1.3.0-rc.1", BumpMinor, "1.3.0"},
+		{"1.3.1-rc.1", BumpMinor, "1.4.0"},
+		{"2.0.0-beta", BumpMajor, "2.0.0"},
+		{"2.1.0-beta", BumpMajor, "3.0.0"},
+	} {
+		got, err := BumpVersion(tt.version, tt.part)
+		if err != nil || got != tt.want {
+			t.Errorf("BumpVersion(%q, %q) = %q, %v; want %q", tt.version, tt.part, got, err, tt.want)
+		}
+		if CompareVersions(got, tt.version) <= 0 {
+			t.Errorf("BumpVersion(%q, %q) = %q, which is not newer", tt.version, tt.part, got)
+		}
+	}
+}
+
+func TestConstraintPrerelease(t *testing.T) {
+	for _, tt := range []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^1.2", "1.3.0", true},
+		{"^1.2", "1.3.0-beta.1", false},
+		{"^1.2", "2.0.0-rc.1", false},
+		{">=1.3.0-beta.1", "1.3.0-beta.2", true},
+		{">=1.3.0-beta.1", "1.3.0", true},
+		{">=1.3.0-beta.1", "1.4.0-beta.1", false},
+		{"^1.3.0-beta.1", "1.3.0-beta.1", true},
+		{"^1.3.0-beta.1", "1.3.0-alpha", false},
+		{"1.0.0-rc.1", "1.0.0-rc.1", true},
+		{"1.0.0-rc.1", "1.0.0", false},
+		{"1.0.0", "1.0.0+build.7", true},
+	} {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tt.constraint, err)
+		}
+		if got := c.Match(tt.version); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+
+	c, _ := ParseConstraint("^1.0")
+	if best, _ := c.Best([]string{"1.0.0-rc.1", "1.0.0", "1.1.0-beta"}); best != "1.0.0" {
+		t.Errorf("Best = %q, want 1.0.0", best)
+	}
+}
+
+func TestOutdatedOffersReleaseOverPrerelease(t *testing.T) {
+	client, src := newMemoryClient(t, map[string]*Manifest{"kubernetes-ops": {Version: "1.0.0-rc.1"}})
+	ctx := context.Background()
+	if err := client.Install(ctx, "kubernetes-ops", nil); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if err := src.Put("kubernetes-ops", &Manifest{Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	outdated, err := client.Outdated(ctx)
+	if err != nil {
+		t.Fatalf("Outdated: %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].Latest != "1.0.0" {
+		t.Errorf("Outdated = %+v, want 1.0.0-rc.1 -> 1.0.0", outdated)
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WarningCode identifies the kind of a Warning, for programs that handle
+// some kinds specially.
+type WarningCode string
+
+const (
+	// WarnStaleCache means the item came from an expired cache, or from the
+	// installed items, because the source couldn't be reached.
+	WarnStaleCache WarningCode = "stale_cache"
+
+	// WarnDeprecated means the item's publisher has deprecated it.
+	WarnDeprecated WarningCode = "deprecated"
+
+	// WarnMissingField means the item lacks an optional field, such as its
+	// description or author.
+	WarnMissingField WarningCode = "missing_field"
+
+	// WarnUnsigned means signatures are configured but the item is unsigned,
+	// so it can't be verified.
+	WarnUnsigned WarningCode = "unsigned"
+)
+
+// Warning is a problem with a result that doesn't stop it being used.
+type Warning struct {
+	Code    WarningCode `json:"code" yaml:"code"`
+	Message string      `json:"message" yaml:"message"`
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// indexWarnings records warnings about a source's indexes as they are read,
+// to attach to the results that come from them.
+type indexWarnings struct {
+	mu    sync.Mutex
+	kinds map[ItemKind][]Warning
+}
+
+// add records a warning about the index of a kind.
+func (w *indexWarnings) add(kind ItemKind, warning Warning) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.kinds == nil {
+		w.kinds = make(map[ItemKind][]Warning)
+	}
+	for _, existing := range w.kinds[kind] {
+		if existing == warning {
+			return // The index was read again
+		}
+	}
+	w.kinds[kind] = append(w.kinds[kind], warning)
+}
+
+// get returns the warnings about the index of a kind.
+func (w *indexWarnings) get(kind ItemKind) []Warning {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Warning(nil), w.kinds[kind]...)
+}
+
+// entryWarnings returns the warnings about an index entry, after those about
+// the index it is in.
+func (s *Source) entryWarnings(kind ItemKind, description, author, deprecated string) []Warning {
+	warnings := s.warnings.get(kind)
+	if deprecated != "" {
+		warnings = append(warnings, Warning{Code: WarnDeprecated, Message: "deprecated: " + deprecated})
+	}
+	if description == "" {
+		warnings = append(warnings, Warning{Code: WarnMissingField, Message: "has no description"})
+	}
+	if author == "" {
+		warnings = append(warnings, Warning{Code: WarnMissingField, Message: "has no author"})
+	}
+	return warnings
+}
+
+// staleWarning describes an index read from an expired cache.
+func staleWarning(kind ItemKind, fromInstalled bool) Warning {
+	if fromInstalled {
+		return Warning{Code: WarnStaleCache, Message: fmt.Sprintf("offline and the %s index was never cached; only installed items are known", kind)}
+	}
+	return Warning{Code: WarnStaleCache, Message: fmt.Sprintf("offline; the %s index is from an expired cache", kind)}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// watchInterval is how often Watch rescans the install directory.
+const watchInterval = time.Second
+
+// ChangeType describes how an installed item changed.
+type ChangeType string
+
+const (
+	ChangeInstalled ChangeType = "installed"
+	ChangeUpdated   ChangeType = "updated" // Reinstalled, upgraded, or edited in place
+	ChangeRemoved   ChangeType = "removed"
+)
+
+// ChangeEvent reports a change to an installed item.
+type ChangeEvent struct {
+	Type    ChangeType
+	Kind    ItemKind
+	Name    string
+	Version string // The installed version; empty when removed
+	Path    string // The item's directory
+}
+
+// itemState is what Watch compares between scans of an installed item.
+type itemState struct {
+	manifest int64 // Modification time of the manifest, in nanoseconds
+	size     int64
+	record   int64 // Modification time of the install record, if any
+}
+
+type watchedItem struct {
+	kind ItemKind
+	name string
+}
+
+// Watch reports changes to installed items, such as another process
+// installing, upgrading, or removing a persona, so long-running runtimes can
+// reload them. It polls the install directory's manifests and install
+// records, and closes the channel when ctx is cancelled.
+func (c *Client) Watch(ctx context.Context) (<-chan ChangeEvent, error) {
+	seen, err := c.scanInstalled()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ChangeEvent)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// A failed scan (e.g. a directory replaced mid-install) is retried on the next tick
+			current, err := c.scanInstalled()
+			if err != nil {
+				continue
+			}
+			for _, event := range c.diffInstalled(seen, current) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			seen = current
+		}
+	}()
+	return events, nil
+}
+
+// scanInstalled records the state of every installed item.
+func (c *Client) scanInstalled() (map[watchedItem]itemState, error) {
+	items, err := c.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[watchedItem]itemState, len(items))
+	for _, item := range items {
+		info, err := os.Stat(filepath.Join(item.Path, "vega.yaml"))
+		if err != nil {
+			continue
+		}
+		state := itemState{manifest: info.ModTime().UnixNano(), size: info.Size()}
+		if info, err := os.Stat(filepath.Join(item.Path, InstallRecordFile)); err == nil {
+			state.record = info.ModTime().UnixNano()
+		}
+		states[watchedItem{item.Kind, item.Name}] = state
+	}
+	return states, nil
+}
+
+// diffInstalled returns the changes between two scans.
+func (c *Client) diffInstalled(before, after map[watchedItem]itemState) []ChangeEvent {
+	var events []ChangeEvent
+	for item, state := range after {
+		event := ChangeEvent{Kind: item.kind, Name: item.name, Path: c.itemDir(item.kind, item.name)}
+		if old, ok := before[item]; !ok {
+			event.Type = ChangeInstalled
+		} else if old != state {
+			event.Type = ChangeUpdated
+		} else {
+			continue
+		}
+		if manifest, err := loadManifest(filepath.Join(event.Path, "vega.yaml"), c.cipher); err == nil {
+			event.Version = manifest.Version
+		}
+		events = append(events, event)
+	}
+	for item := range before {
+		if _, ok := after[item]; !ok {
+			events = append(events, ChangeEvent{
+				Type: ChangeRemoved,
+				Kind: item.kind,
+				Name: item.name,
+				Path: c.itemDir(item.kind, item.name),
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Kind != events[j].Kind {
+			return kindOrder(events[i].Kind) < kindOrder(events[j].Kind)
+		}
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
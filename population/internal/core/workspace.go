@@ -0,0 +1,205 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// WorkspaceFile declares the items a project needs, for example:
+	//
+	//	source: https://registry.internal.example/
+	//	env: prod
+	//	profiles:
+	//	  - sre-oncall
+	//	personas:
+	//	  - incident-commander
+	//	skills:
+	//	  - kubernetes-ops@^1.2
+	WorkspaceFile = "vega-population.yaml"
+
+	// WorkspaceInstallDir is where a workspace's items are installed,
+	// relative to its workspace file.
+	WorkspaceInstallDir = ".vega"
+)
+
+// ErrNoWorkspace is returned by FindWorkspace when no directory has a
+// workspace file.
+var ErrNoWorkspace = errors.New("no " + WorkspaceFile + " found")
+
+// Workspace is a project that installs its own items, declared in its
+// WorkspaceFile, into its own install directory.
+type Workspace struct {
+	Dir string `yaml:"-"` // Directory holding the workspace file
+
+	Source   string   `yaml:"source,omitempty"` // Source to install from (default: the client's); relative paths are from Dir
+	Env      string   `yaml:"env,omitempty"`    // Deployment environment for conditional profile skills
+	Profiles []string `yaml:"profiles,omitempty"`
+	Personas []string `yaml:"personas,omitempty"`
+	Skills   []string `yaml:"skills,omitempty"`
+}
+
+// LoadWorkspace loads a workspace file.
+func LoadWorkspace(path string) (*Workspace, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading workspace: %w", err)
+	}
+
+	var ws Workspace
+	if err := yaml.Unmarshal(content, &ws); err != nil {
+		return nil, fmt.Errorf("parsing workspace %s: %w", path, err)
+	}
+	if ws.Dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("resolving workspace directory: %w", err)
+	}
+	return &ws, nil
+}
+
+// FindWorkspace loads the workspace file in dir or the nearest directory
+// above it. It returns ErrNoWorkspace if there is none.
+func FindWorkspace(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", dir, err)
+	}
+
+	for {
+		path := filepath.Join(dir, WorkspaceFile)
+		if _, err := os.Stat(path); err == nil {
+			return LoadWorkspace(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNoWorkspace
+		}
+		dir = parent
+	}
+}
+
+// InstallDir returns the directory the workspace's items are installed in.
+func (w *Workspace) InstallDir() string {
+	return filepath.Join(w.Dir, WorkspaceInstallDir)
+}
+
+// SourceURL returns the workspace's source, with a relative local path
+// resolved against the workspace directory, or "" if it has none.
+func (w *Workspace) SourceURL() string {
+	if w.Source == "" || strings.Contains(w.Source, "://") || isGitSource(w.Source) || filepath.IsAbs(w.Source) {
+		return w.Source
+	}
+	return filepath.Join(w.Dir, w.Source)
+}
+
+// Deps returns the workspace's items as deps for Sync, profiles first so
+// their personas and skills come from the profile.
+func (w *Workspace) Deps() *Deps {
+	deps := &Deps{Env: w.Env, Items: []string{}}
+	for _, group := range []struct {
+		prefix string
+		names  []string
+	}{{"+", w.Profiles}, {"@", w.Personas}, {"", w.Skills}} {
+		for _, name := range group.names {
+			deps.Items = append(deps.Items, group.prefix+strings.TrimPrefix(name, group.prefix))
+		}
+	}
+	return deps
+}
+
+// WithWorkspace installs into the workspace's install directory, from the
+// workspace's source if it names one. NewClient already does this for the
+// workspace found from the working directory; options given after this one
+// still override it. Since anyone can write a workspace file, a source it
+// names gets the client's default credentials only if its host is listed in
+// SourceAuth.Hosts.
+func WithWorkspace(ws *Workspace) Option {
+	return func(c *Client) {
+		c.workspace = ws
+		c.installDir = ws.InstallDir()
+		if source := ws.SourceURL(); source != "" {
+			c.source = source
+			c.sources = nil
+			c.untrusted = true
+		}
+	}
+}
+
+// Workspace returns the workspace the client installs into, or nil.
+func (c *Client) Workspace() *Workspace {
+	return c.workspace
+}
+
+// skippedDirs are directories FindWorkspaces doesn't descend into, besides
+// hidden ones such as .git and .vega.
+var skippedDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// FindWorkspaces returns the workspace of every directory under root, root
+// included, that holds a WorkspaceFile or else a deps file, such as each
+// service of a monorepo. A deps file is read as a workspace without a source
+// (see WorkspaceFromDeps). Hidden directories, node_modules, and vendor are
+// skipped. The workspaces are in lexical order of their directories.
+func FindWorkspaces(root string) ([]*Workspace, error) {
+	var workspaces []*Workspace
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+
+		if ws, err := LoadWorkspace(filepath.Join(path, WorkspaceFile)); err == nil {
+			workspaces = append(workspaces, ws)
+			return nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		deps, err := LoadDeps(filepath.Join(path, DefaultDepsFile))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ws, err := WorkspaceFromDeps(path, deps)
+		if err != nil {
+			return err
+		}
+		workspaces = append(workspaces, ws)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("finding workspaces in %s: %w", root, err)
+	}
+	return workspaces, nil
+}
+
+// WorkspaceFromDeps returns the workspace declared by a deps file in dir: its
+// items, installed into the install directory next to it from the client's
+// source.
+func WorkspaceFromDeps(dir string, deps *Deps) (*Workspace, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving workspace directory: %w", err)
+	}
+	ws := &Workspace{Dir: abs, Env: deps.Env}
+	for _, item := range deps.Items {
+		switch {
+		case strings.HasPrefix(item, "+"):
+			ws.Profiles = append(ws.Profiles, strings.TrimPrefix(item, "+"))
+		case strings.HasPrefix(item, "@"):
+			ws.Personas = append(ws.Personas, strings.TrimPrefix(item, "@"))
+		default: // Skills, and settings, which keep their prefix
+			ws.Skills = append(ws.Skills, item)
+		}
+	}
+	return ws, nil
+}
@@ -0,0 +1,22 @@
+package population
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// LoadOptions configures loading an item into memory.
+type LoadOptions = core.LoadOptions
+
+// LoadedItem is an item resolved in memory by Load.
+type LoadedItem = core.LoadedItem
+
+// Load resolves an item and its dependencies entirely in memory, for runtimes
+// that hydrate agents on demand, such as containers with read-only
+// filesystems. Nothing is written to disk: the index cache is bypassed and
+// nothing is installed. The name takes the same forms as Install, including
+// a version constraint (e.g. "+sre-oncall@^1.2").
+func (c *Client) Load(ctx context.Context, name string, opts *LoadOptions) (*LoadedItem, error) {
+	return (*core.Client)(c).Load(ctx, name, opts)
+}
@@ -0,0 +1,12 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ManifestSchemaVersion is the newest manifest schema this client reads.
+// Manifests without a schema_version are version 1.
+const ManifestSchemaVersion = core.ManifestSchemaVersion
+
+// ErrManifestSchema is wrapped by the errors for manifests in a schema newer
+// than ManifestSchemaVersion, and for registries that no longer serve one
+// this client reads.
+var ErrManifestSchema = core.ErrManifestSchema
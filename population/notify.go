@@ -0,0 +1,15 @@
+package population
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// OutdatedItem is an installed item with a newer version available upstream.
+type OutdatedItem = core.OutdatedItem
+
+// Outdated returns installed items whose source version is newer than the installed one.
+func (c *Client) Outdated(ctx context.Context) ([]OutdatedItem, error) {
+	return (*core.Client)(c).Outdated(ctx)
+}
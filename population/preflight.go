@@ -0,0 +1,11 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Requirements declares what a skill needs from the host it runs on:
+//
+//	requires:
+//	  binaries: [kubectl, helm]
+//	  env: [KUBECONFIG]
+//	  os: [linux, darwin]
+type Requirements = core.Requirements
@@ -0,0 +1,30 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// SkillRef is a skill entry in a profile.
+// It can be written as a bare skill name or as a mapping with ordering metadata
+// and conditions that gate where the skill applies:
+//
+//	skills:
+//	  - kubernetes-ops
+//	  - terraform@^1.2
+//	  - name: monitoring
+//	    version: ~2.1
+//	    priority: 10
+//	  - name: kubernetes-ops
+//	    only: linux
+//	    env: prod
+//	    requires_tool: kubectl
+type SkillRef = core.SkillRef
+
+// SkillRefs is the list of skills declared by a profile.
+type SkillRefs = core.SkillRefs
+
+// Platform describes the context that conditional skills are evaluated against.
+type Platform = core.Platform
+
+// CurrentPlatform returns the platform of the running host for the given environment.
+func CurrentPlatform(env string) Platform {
+	return core.CurrentPlatform(env)
+}
@@ -0,0 +1,23 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Standard prompt sections, in the order they are rendered.
+const (
+	SectionRole          = core.SectionRole
+	SectionConstraints   = core.SectionConstraints
+	SectionToolsGuidance = core.SectionToolsGuidance
+	SectionExamples      = core.SectionExamples
+)
+
+// Prompt is a system prompt authored either as one string or as named sections:
+//
+//	system_prompt:
+//	  role: |
+//	    You are ...
+//	  constraints: |
+//	    - Never ...
+type Prompt = core.Prompt
+
+// PromptSection is one named part of a sectioned prompt.
+type PromptSection = core.PromptSection
@@ -0,0 +1,30 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// QueryTerm is one condition of a search query.
+type QueryTerm = core.QueryTerm
+
+// Query is a parsed search query: alternatives joined by OR, each a list of
+// terms that must all hold, except that the words of an alternative rank
+// items having any of them. Parse one with ParseQuery, or build it directly:
+//
+//	query := &population.Query{Any: [][]population.QueryTerm{{
+//		{Field: "tags", Value: "ops"},
+//		{Field: "tags", Value: "deprecated", Negate: true},
+//	}}}
+//
+// A query without terms matches everything.
+type Query = core.Query
+
+// ParseQuery parses a search query such as
+//
+//	name:kubernetes tags:ops -tags:deprecated author:martell
+//
+// Words rank the items having any of them, "OR" separates alternatives, a
+// leading "-" negates a term, and field:value matches one field (see
+// QueryTerm). Double quotes group words into a phrase that must appear, as
+// in description:"incident response".
+func ParseQuery(s string) (*Query, error) {
+	return core.ParseQuery(s)
+}
@@ -0,0 +1,29 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Manifest signature types.
+const (
+	SignatureEd25519  = core.SignatureEd25519  // Base64 Ed25519 signature, as written by registry.Sign
+	SignatureGPG      = core.SignatureGPG      // Armored detached GPG signature
+	SignatureSigstore = core.SignatureSigstore // Sigstore bundle, as written by cosign sign-blob --bundle
+)
+
+// SignatureRef points at the detached signature of one manifest version.
+// Index entries list them by version:
+//
+//	signatures: {1.2.0: {type: ed25519, path: skills/docker-ops/vega.yaml.sig}}
+type SignatureRef = core.SignatureRef
+
+// SignaturePolicy configures which manifest signatures are trusted. It can be
+// set with WithSignatureVerification or under "signatures" in the policy file.
+type SignaturePolicy = core.SignaturePolicy
+
+// WithSignatureVerification verifies manifest signatures at install time
+// according to policy, instead of the install directory's policy file.
+func WithSignatureVerification(policy *SignaturePolicy) Option {
+	return core.WithSignatureVerification(policy)
+}
+
+// SignatureError reports a manifest whose signature is missing or doesn't verify.
+type SignatureError = core.SignatureError
@@ -0,0 +1,19 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// SourceConfig configures one of several sources a Client reads from.
+type SourceConfig = core.SourceConfig
+
+// WithSources configures several sources, such as an internal registry in
+// front of the public one. Sources are consulted in priority order, with
+// ties keeping the order given; the first is also the primary source used by
+// the daemon, mirrors, and whatsnew.
+func WithSources(sources []SourceConfig) Option {
+	return core.WithSources(sources)
+}
+
+// Sources returns the configured sources in priority order.
+func (c *Client) Sources() []SourceConfig {
+	return (*core.Client)(c).Sources()
+}
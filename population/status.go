@@ -0,0 +1,17 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ReviewStatus is the review state of an item in a registry.
+type ReviewStatus = core.ReviewStatus
+
+const (
+	StatusDraft    = core.StatusDraft
+	StatusReviewed = core.StatusReviewed
+	StatusApproved = core.StatusApproved
+)
+
+// ParseReviewStatus parses a review status name.
+func ParseReviewStatus(s string) (ReviewStatus, error) {
+	return core.ParseReviewStatus(s)
+}
@@ -0,0 +1,23 @@
+package population
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// UpgradeOptions configures upgrading installed items.
+type UpgradeOptions = core.UpgradeOptions
+
+// Upgrade replaces outdated installed items with the source's latest
+// versions. With no names, every outdated item is upgraded. A profile is
+// upgraded on its own; its persona and skills are upgraded when they are
+// outdated themselves. A persona is only upgraded as far as the installed
+// profiles' constraints on it allow, and a profile whose new version needs
+// another version of its installed persona brings that version with it.
+// Items changed since they were installed, according to their install
+// records, fail with a *LocalChangesError unless opts.Force is set. It
+// returns the items that were (or would be) upgraded.
+func (c *Client) Upgrade(ctx context.Context, names []string, opts *UpgradeOptions) ([]OutdatedItem, error) {
+	return (*core.Client)(c).Upgrade(ctx, names, opts)
+}
@@ -0,0 +1,9 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Tool is a command a skill exposes to agents.
+type Tool = core.Tool
+
+// ToolParam is a parameter of a skill tool.
+type ToolParam = core.ToolParam
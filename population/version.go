@@ -0,0 +1,23 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// CompareVersions compares two dotted version strings such as "1.2.0" by
+// SemVer 2.0 precedence. It returns -1 if a < b, 0 if they are equal, and 1
+// if a > b. A pre-release sorts below its release (1.0.0-rc.1 < 1.0.0), and
+// build metadata is ignored. Missing segments count as zero, and a leading
+// "v" is ignored.
+func CompareVersions(a, b string) int {
+	return core.CompareVersions(a, b)
+}
+
+// VersionConstraint is a set of version bounds that must all hold.
+type VersionConstraint = core.VersionConstraint
+
+// ParseConstraint parses a version constraint. It accepts exact versions
+// ("1.2.0"), partial versions matching a range ("1.2" is any 1.2.x), caret
+// and tilde ranges ("^1.2", "~1.2.3"), and comparisons (">=1.2 <2"), which
+// may be separated by spaces or commas.
+func ParseConstraint(s string) (*VersionConstraint, error) {
+	return core.ParseConstraint(s)
+}
@@ -0,0 +1,24 @@
+package population
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// WarningCode identifies the kind of a Warning, for programs that handle
+// some kinds specially.
+type WarningCode = core.WarningCode
+
+const (
+	// WarnStaleCache means the item came from an expired cache, or from the
+	// installed items, because the source couldn't be reached.
+	WarnStaleCache = core.WarnStaleCache
+	// WarnDeprecated means the item's publisher has deprecated it.
+	WarnDeprecated = core.WarnDeprecated
+	// WarnMissingField means the item lacks an optional field, such as its
+	// description or author.
+	WarnMissingField = core.WarnMissingField
+	// WarnUnsigned means signatures are configured but the item is unsigned,
+	// so it can't be verified.
+	WarnUnsigned = core.WarnUnsigned
+)
+
+// Warning is a problem with a result that doesn't stop it being used.
+type Warning = core.Warning
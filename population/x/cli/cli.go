@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// RunCLI is the entry point for the CLI interface.
+func RunCLI(args []string) error {
+	return core.RunCLI(args)
+}
+
+// RunCLIWithOutput runs the CLI like RunCLI, writing command output to stdout
+// and notices, progress, and flag errors to stderr, so embedders can capture
+// or redirect them.
+func RunCLIWithOutput(args []string, stdout, stderr io.Writer) error {
+	return core.RunCLIWithOutput(args, stdout, stderr)
+}
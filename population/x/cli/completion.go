@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// WriteCompletion writes the completion script for a shell: bash, zsh, or
+// fish.
+func WriteCompletion(w io.Writer, shell string) error {
+	return core.WriteCompletion(w, shell)
+}
@@ -0,0 +1,4 @@
+// Package cli runs the vega population command, for programs embedding it.
+//
+// Like every package under population/x, it may change in any release.
+package cli
@@ -0,0 +1,18 @@
+package cli
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// OutputFormat selects how CLI commands print their results.
+type OutputFormat = core.OutputFormat
+
+// Output formats.
+const (
+	OutputTable = core.OutputTable // Human-readable text (default)
+	OutputJSON  = core.OutputJSON
+	OutputYAML  = core.OutputYAML
+)
+
+// ParseOutputFormat parses an output format name.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	return core.ParseOutputFormat(s)
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+const (
+	// DefaultVegaHome is the default vega home directory.
+	DefaultVegaHome = core.DefaultVegaHome
+	// DefaultTempDir is where temporary files are kept, relative to vega home.
+	DefaultTempDir = core.DefaultTempDir
+)
+
+// WithOffline keeps the client off the network. Indexes and manifests are read
+// from the cache however old, the installed items stand in for an index that
+// was never cached, and anything else fails with an error wrapping
+// ErrOffline. Local directory sources are read as usual.
+func WithOffline() population.Option {
+	return core.WithOffline()
+}
+
+// ForInstallDir returns a client like c that installs into dir, such as one
+// per tenant of a service managing many populations. It shares c's sources,
+// credentials, cache, and other settings, so deriving one is cheap and
+// indexes fetched for one tenant serve them all, but its installed items,
+// usage log, and layout are dir's own; c's layout is kept if it was set with
+// WithLayout, and a workspace c found is not. c and the clients derived
+// from it may be used concurrently.
+func ForInstallDir(c *population.Client, dir string) (*population.Client, error) {
+	derived, err := (*core.Client)(c).WithInstallDir(dir)
+	return (*population.Client)(derived), err
+}
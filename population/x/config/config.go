@@ -0,0 +1,70 @@
+package config
+
+import (
+	"time"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+const (
+	// ConfigFile is the user's configuration in the vega home, setting the
+	// client's defaults, for example:
+	//
+	// 	sources:
+	// 	  - https://registry.internal.example/
+	// 	  - https://raw.githubusercontent.com/martellcode/vega-population/main/
+	// 	install_dir: ~/agents
+	// 	cache_dir: /var/cache/vega
+	// 	cache_ttl: 6h
+	// 	output: json
+	// 	auth:
+	// 	  keychain: registry.internal.example
+	ConfigFile = core.ConfigFile
+	// HomeEnv is the environment variable overriding the vega home, where
+	// ConfigFile is read from and items are installed. By default it is
+	// ~/.vega or, on Linux with $XDG_DATA_HOME set and no ~/.vega yet,
+	// $XDG_DATA_HOME/vega.
+	HomeEnv = core.HomeEnv
+)
+
+// Config holds the defaults population.NewClient reads from ConfigFile.
+// Environment variables override it (see ConfigKeys), a workspace overrides
+// both, and options override everything.
+type Config = core.Config
+
+// ConfigKey is a setting of Config, as read and written by the CLI's config
+// command.
+type ConfigKey = core.ConfigKey
+
+// ConfigKeys are the settings of Config.
+var ConfigKeys = core.ConfigKeys
+
+// LookupConfigKey returns the setting named name.
+func LookupConfigKey(name string) (ConfigKey, error) {
+	return core.LookupConfigKey(name)
+}
+
+// VegaHome returns the vega home: $VEGA_HOME, or ~/.vega. On Linux, with
+// $XDG_DATA_HOME set and no ~/.vega, it is $XDG_DATA_HOME/vega instead.
+func VegaHome() (string, error) {
+	return core.VegaHome()
+}
+
+// LoadConfig reads a config file. A missing file yields an empty config.
+func LoadConfig(path string) (*Config, error) {
+	return core.LoadConfig(path)
+}
+
+// WithConfig applies a config's defaults. population.NewClient already does
+// this for the config file in the vega home; options given after this one
+// still override it.
+func WithConfig(cfg *Config) population.Option {
+	return core.WithConfig(cfg)
+}
+
+// WithCacheTTL sets how long cached indexes are used before they are
+// fetched again (default source.CacheTTL).
+func WithCacheTTL(ttl time.Duration) population.Option {
+	return core.WithCacheTTL(ttl)
+}
@@ -0,0 +1,6 @@
+// Package config configures a client beyond the options of the population
+// package: the config file and its keys, the install layout, encryption at
+// rest, recorded sessions, offline use, logging, and install history.
+//
+// Like every package under population/x, it may change in any release.
+package config
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+const (
+	// EncryptionKeyEnv is the environment variable holding the key the CLI
+	// encrypts the cache and installed items with: 32 bytes, base64 or hex
+	// encoded, or "keychain" to read it from the OS keychain entry
+	// EncryptionKeychainService.
+	EncryptionKeyEnv = core.EncryptionKeyEnv
+	// EncryptionKeychainService is the OS keychain entry holding the key.
+	EncryptionKeychainService = core.EncryptionKeychainService
+)
+
+// ErrEncrypted is wrapped by errors reading encrypted content without the
+// key it was encrypted with.
+var ErrEncrypted = core.ErrEncrypted
+
+// WithEncryptionKey encrypts the cache and installed items at rest with
+// AES-256-GCM under key, which must be 32 bytes. Content is decrypted
+// transparently on read, and content written before encryption was turned
+// on is still read as it is; manage.Doctor finds and fixes such a mix.
+func WithEncryptionKey(key []byte) population.Option {
+	return core.WithEncryptionKey(key)
+}
+
+// ParseEncryptionKey decodes a key given as base64 or hex, as in
+// EncryptionKeyEnv.
+func ParseEncryptionKey(s string) ([]byte, error) {
+	return core.ParseEncryptionKey(s)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// LayoutFile is the file in an install directory that names the directory
+// each kind of item is installed in, for runtimes expecting other names:
+//
+//	persona: agents
+//	skill: capabilities
+const LayoutFile = core.LayoutFile
+
+// Layout maps kinds to the directories of an install directory their items
+// are installed in. Kinds it doesn't name use their plural, e.g. "skills".
+type Layout = core.Layout
+
+// ParseLayout parses a layout given as comma-separated kind=dir pairs, such
+// as "persona=agents,skill=capabilities".
+func ParseLayout(s string) (Layout, error) {
+	return core.ParseLayout(s)
+}
+
+// LoadLayout loads the layout of an install directory. An install directory
+// without a LayoutFile has the default layout, which is nil.
+func LoadLayout(installDir string) (Layout, error) {
+	return core.LoadLayout(installDir)
+}
+
+// WithLayout installs items into the given directories of the install
+// directory instead of those in its LayoutFile.
+func WithLayout(layout Layout) population.Option {
+	return core.WithLayout(layout)
+}
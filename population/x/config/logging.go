@@ -0,0 +1,23 @@
+package config
+
+import (
+	"log/slog"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// LogLevelEnv is the environment variable the CLI reads a log level from
+// (debug, info, warn, or error). When set, diagnostics are written to stderr
+// as slog text at that level, instead of only warnings as plain lines.
+const LogLevelEnv = core.LogLevelEnv
+
+// WithLogger sends the client's diagnostics to logger: warnings, such as a
+// source being skipped or the cache failing to write, carry the error as an
+// "error" attribute; HTTP fetches and cache hits, misses, and writes are
+// logged at debug level. By default warnings are written to stderr and the
+// rest is dropped. A logger whose handler discards everything silences the
+// client.
+func WithLogger(logger *slog.Logger) population.Option {
+	return core.WithLogger(logger)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// ErrNotRecorded is wrapped by the errors of replayed reads that the session
+// never recorded.
+var ErrNotRecorded = core.ErrNotRecorded
+
+// Session records everything sources read, so a run can be replayed later
+// without the network or the sources: a recording session captures each
+// read, and a replaying session serves the recorded reads back.
+type Session = core.Session
+
+// SessionEntry is one recorded read: a file of a source, or the error
+// reading it gave.
+type SessionEntry = core.SessionEntry
+
+// NewSession creates a session that records reads.
+func NewSession(command ...string) *Session {
+	return core.NewSession(command...)
+}
+
+// LoadSession loads a recorded session to replay.
+func LoadSession(path string) (*Session, error) {
+	return core.LoadSession(path)
+}
+
+// WithSession records the client's reads into a session, or serves them
+// from one being replayed.
+func WithSession(s *Session) population.Option {
+	return core.WithSession(s)
+}
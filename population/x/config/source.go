@@ -0,0 +1,7 @@
+package config
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ErrOffline is wrapped by the errors of offline fetches of anything that
+// isn't cached.
+var ErrOffline = core.ErrOffline
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// PolicyFile is the name of the workspace policy file in the install directory.
+const PolicyFile = core.PolicyFile
+
+// Policy holds the rules a workspace applies to installs.
+type Policy = core.Policy
+
+// LoadPolicy reads the policy file from a workspace directory.
+// A missing file yields an empty policy.
+func LoadPolicy(dir string) (*Policy, error) {
+	return core.LoadPolicy(dir)
+}
+
+// ClientPolicy returns the policy of the client's install directory.
+func ClientPolicy(c *population.Client) (*Policy, error) {
+	return (*core.Client)(c).Policy()
+}
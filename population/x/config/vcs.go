@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// VersionControl records the state of an install directory after each
+// change the client makes to it, giving the directory a history that can
+// be diffed and rolled back. GitVersionControl is the built-in one.
+type VersionControl = core.VersionControl
+
+// GitVersionControl keeps an install directory in a git repository,
+// initializing it on the first commit with a .gitignore that leaves out
+// everything but the items, so caches and logs in the vega home stay out of
+// history. Commits use the user's git identity when there is one.
+type GitVersionControl = core.GitVersionControl
+
+// WithVersionControl records every install, upgrade, uninstall, and sync in
+// the install directory with vc, such as GitVersionControl{}.
+func WithVersionControl(vc VersionControl) population.Option {
+	return core.WithVersionControl(vc)
+}
@@ -0,0 +1,28 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// BudgetReport is the projected spend of a set of agents deployed together,
+// checked against the organization's caps.
+type BudgetReport = core.BudgetReport
+
+// AgentBudget is the budget one persona or profile would be exported with.
+type AgentBudget = core.AgentBudget
+
+// BudgetOptions configures Budget. Empty fields fall back to the manifests,
+// installed settings, and export defaults, as for AgentOptions.
+type BudgetOptions = core.BudgetOptions
+
+// Budget projects the spend of personas and profiles deployed together: the
+// model and budget each would be exported with in opts.Env, as LoadAgent
+// resolves them, and their total. Budgets over the settings' max_budget, and
+// a total over max_total_budget or opts.Cap, are reported as over the cap
+// rather than failing, so the whole set can be reviewed.
+func Budget(ctx context.Context, c *population.Client, names []string, opts *BudgetOptions) (*BudgetReport, error) {
+	return (*core.Client)(c).Budget(ctx, names, opts)
+}
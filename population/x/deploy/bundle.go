@@ -0,0 +1,26 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// BundleFile is the file describing a bundle, at its root.
+const BundleFile = core.BundleFile
+
+// Bundle is a self-contained export of a persona or profile: the rendered
+// agent, its system prompt, and every item it is made of with their files,
+// so a runtime can run the agent without a vega home.
+type Bundle = core.Bundle
+
+// BundleItem is an item copied into a bundle.
+type BundleItem = core.BundleItem
+
+// ExportBundle exports a persona or profile into dir as a bundle. The bundle
+// is staged first, so dir is only created once it is complete; dir must not
+// exist or be empty.
+func ExportBundle(ctx context.Context, c *population.Client, name, dir string, opts *ExportOptions) (*Bundle, error) {
+	return (*core.Client)(c).ExportBundle(ctx, name, dir, opts)
+}
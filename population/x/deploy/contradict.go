@@ -0,0 +1,23 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// Contradiction is an instruction in an agent's combined prompts forbidding
+// what another part of them asks for, or what one of its skills needs.
+type Contradiction = core.Contradiction
+
+// Contradictions checks the combined prompts of a persona or profile, as
+// rendered for deployment with opts, for instructions that contradict each
+// other: a prohibition such as "never use web search" in one part against
+// an instruction to do it in another, or against a skill's web_search tool
+// or required binary. The check is a heuristic matching the words after
+// never, do not, avoid, and the like, so it can miss contradictions phrased
+// differently and flag prohibitions with exceptions spelled out.
+func Contradictions(ctx context.Context, c *population.Client, name string, opts *AgentOptions) ([]Contradiction, error) {
+	return (*core.Client)(c).Contradictions(ctx, name, opts)
+}
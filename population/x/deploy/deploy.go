@@ -0,0 +1,66 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// Agent is a persona or profile rendered for deployment, with organization
+// settings applied.
+type Agent = core.Agent
+
+// AgentOptions configures how a persona is rendered as an Agent. Empty fields
+// fall back to installed settings, then to the export defaults.
+type AgentOptions = core.AgentOptions
+
+// LoadAgent renders a persona or profile for deployment, with the tools of its
+// skills. A profile is rendered as its persona with the profile's prompt
+// appended, and brings the skills that apply in opts.Env; a persona brings
+// its recommended skills.
+func LoadAgent(ctx context.Context, c *population.Client, name string, opts *AgentOptions) (*Agent, error) {
+	return (*core.Client)(c).Agent(ctx, name, opts)
+}
+
+// Target is a deploy destination for agents.
+type Target = core.Target
+
+// TargetHandler creates a Target from the part of an address after its
+// scheme, e.g. "./prompts" for "file:./prompts".
+type TargetHandler = core.TargetHandler
+
+// RegisterTarget makes a target scheme available to NewTarget, replacing any
+// handler already registered for it.
+func RegisterTarget(scheme string, handler TargetHandler) {
+	core.RegisterTarget(scheme, handler)
+}
+
+// TargetSchemes returns the registered target schemes, sorted.
+func TargetSchemes() []string {
+	return core.TargetSchemes()
+}
+
+// NewTarget creates a Target from an address such as "file:./prompts",
+// "claude:./repo", or "tron:./tron.vega.yaml#agents.Maya".
+func NewTarget(address string) (Target, error) {
+	return core.NewTarget(address)
+}
+
+// FileTarget writes each agent's system prompt to <Dir>/<id>.md.
+type FileTarget = core.FileTarget
+
+// ClaudeTarget writes each agent as a Claude subagent definition in a
+// repository, at <Dir>/.claude/agents/<id>.md.
+type ClaudeTarget = core.ClaudeTarget
+
+// TronTarget writes each agent into a tron.vega.yaml file at a dotted key
+// path, keeping the rest of the file.
+type TronTarget = core.TronTarget
+
+// AgentConfig is an agent as configured in tron.vega.yaml, ready to marshal
+// as YAML or JSON. It goes under agents.<Name>.
+type AgentConfig = core.AgentConfig
+
+// Supervision is how tron restarts a failed agent.
+type Supervision = core.Supervision
@@ -0,0 +1,6 @@
+// Package deploy exports installed items as agent configs, bundles, and
+// prompts, and deploys agents to targets, with the secrets their prompts
+// reference resolved by a SecretProvider.
+//
+// Like every package under population/x, it may change in any release.
+package deploy
@@ -0,0 +1,36 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// DefaultExportFormat is the format export uses unless told otherwise.
+const DefaultExportFormat = core.DefaultExportFormat
+
+// ExportData is what an export template is executed with.
+type ExportData = core.ExportData
+
+// ExportOptions configures how a persona or profile is exported.
+type ExportOptions = core.ExportOptions
+
+// ExportFormats returns the names of the built-in export formats, sorted.
+func ExportFormats() []string {
+	return core.ExportFormats()
+}
+
+// Export renders a persona or profile in a built-in format or through a
+// custom template.
+func Export(ctx context.Context, c *population.Client, name string, opts *ExportOptions) ([]byte, error) {
+	return (*core.Client)(c).Export(ctx, name, opts)
+}
+
+// ExportAgentConfig renders a persona manifest as a tron agent configuration
+// with the tools of the given skill manifests, without a client: no sources
+// are read and no installed settings are applied. opts.Format and
+// opts.Template are ignored.
+func ExportAgentConfig(manifest *population.Manifest, skills []*population.Manifest, opts ExportOptions) (*AgentConfig, error) {
+	return core.ExportAgentConfig(manifest, skills, opts)
+}
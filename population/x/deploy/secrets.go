@@ -0,0 +1,42 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// SecretProvider resolves named secrets referenced from exported content.
+type SecretProvider = core.SecretProvider
+
+// DefaultSecretEnvPrefix prefixes the environment variables EnvSecrets reads
+// when it has no Prefix.
+const DefaultSecretEnvPrefix = core.DefaultSecretEnvPrefix
+
+// EnvSecrets resolves secrets from environment variables named by a prefix
+// and the secret's name, so that content can only read the variables set
+// aside for it, never credentials like AWS_SECRET_ACCESS_KEY.
+type EnvSecrets = core.EnvSecrets
+
+// FileSecrets resolves secrets from files in a directory, one file per
+// secret, as with Docker or Kubernetes mounted secrets.
+type FileSecrets = core.FileSecrets
+
+// VaultSecrets resolves secrets from the keys of one HashiCorp Vault KV v2
+// secret. The secret is read once and reused for every lookup.
+type VaultSecrets = core.VaultSecrets
+
+// NewSecretProvider creates a provider from a spec: "env" or "env:<prefix>",
+// "file:<dir>", or "vault:<path>". Vault reads its address and token from
+// VAULT_ADDR and VAULT_TOKEN. An empty spec is no provider, which is nil.
+func NewSecretProvider(spec string) (SecretProvider, error) {
+	return core.NewSecretProvider(spec)
+}
+
+// RenderSecrets replaces every {{secret "NAME"}} reference in text with the
+// value from provider. Other template syntax is left untouched. All missing
+// secrets are reported together. A nil provider resolves none, so text
+// referencing secrets fails to render.
+func RenderSecrets(ctx context.Context, text string, provider SecretProvider) (string, error) {
+	return core.RenderSecrets(ctx, text, provider)
+}
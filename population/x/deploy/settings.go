@@ -0,0 +1,23 @@
+package deploy
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// Settings are organization defaults distributed as a settings item and
+// honored when exporting agents.
+type Settings = core.Settings
+
+// LoadSettings reads the settings fields of a settings manifest.
+func LoadSettings(path string) (*Settings, error) {
+	return core.LoadSettings(path)
+}
+
+// ClientSettings returns the combined settings installed with c. When
+// several settings items are installed they are applied in name order: later
+// model aliases and defaults win, the lowest budget cap applies, and banned
+// tools accumulate.
+func ClientSettings(c *population.Client) (*Settings, error) {
+	return (*core.Client)(c).Settings()
+}
@@ -0,0 +1,33 @@
+package manage
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// BackupReport describes a backup written or restored.
+type BackupReport = core.BackupReport
+
+// RestoreOptions configures Restore.
+type RestoreOptions = core.RestoreOptions
+
+// Backup writes the vega home's installed items, install records, config,
+// deps file and lockfile, policy, and usage log to file, a .tar.gz, .tgz, or
+// .tar archive, with their checksums. Items encrypted at rest stay encrypted
+// in the backup.
+func Backup(ctx context.Context, c *population.Client, file string) (*BackupReport, error) {
+	return (*core.Client)(c).Backup(ctx, file)
+}
+
+// Restore replaces the vega home's state with a backup written by Backup,
+// after extracting it to a temporary directory and checking every file
+// against the backup's checksums, so a damaged backup changes nothing. The
+// backup is then staged next to the files it replaces and swapped in, the
+// old files moved aside until the swap is done, so a failure partway puts
+// them back. The installed items are replaced as a whole; restoring over
+// installed items fails unless opts.Force is set.
+func Restore(ctx context.Context, c *population.Client, file string, opts *RestoreOptions) (*BackupReport, error) {
+	return (*core.Client)(c).Restore(ctx, file, opts)
+}
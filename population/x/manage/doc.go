@@ -0,0 +1,6 @@
+// Package manage looks after a client's installed items: it sets up a vega
+// home, backs it up and restores it, checks it with Doctor and Drift, and
+// reports usage, updates, and changes as they happen.
+//
+// Like every package under population/x, it may change in any release.
+package manage
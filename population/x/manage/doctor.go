@@ -0,0 +1,20 @@
+package manage
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// DoctorCheck is the outcome of one of Doctor's checks.
+type DoctorCheck = core.DoctorCheck
+
+// DoctorOptions configures Doctor.
+type DoctorOptions = core.DoctorOptions
+
+// Doctor checks the vega home: that the install and cache directories are
+// there, and that their content is all encrypted with the client's key, or
+// all plain without one. A mix comes from turning encryption on or off, or
+// changing the key, with content already cached or installed.
+func Doctor(c *population.Client, opts *DoctorOptions) ([]DoctorCheck, error) {
+	return (*core.Client)(c).Doctor(opts)
+}
@@ -0,0 +1,18 @@
+package manage
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// ItemDrift is how an installed item's files differ from what its install
+// record says was installed: the local edits an upgrade or a forced install
+// would discard.
+type ItemDrift = core.ItemDrift
+
+// Drift hashes the files of every installed item against its install record
+// and returns the items that drifted from it, including ones without a
+// record, in kind and name order.
+func Drift(c *population.Client) ([]ItemDrift, error) {
+	return (*core.Client)(c).Drift()
+}
@@ -0,0 +1,21 @@
+package manage
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// InitOptions configures setting up a vega home.
+type InitOptions = core.InitOptions
+
+// InitResult describes a vega home that was set up.
+type InitResult = core.InitResult
+
+// Init sets up the vega home: the install and cache directories and a
+// starter policy file, then the starter profile if one is given. Anything
+// that already exists is left alone, so it is safe to run again.
+func Init(ctx context.Context, c *population.Client, opts *InitOptions) (*InitResult, error) {
+	return (*core.Client)(c).Init(ctx, opts)
+}
@@ -0,0 +1,37 @@
+package manage
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// NotificationsFile stores notification state, relative to the vega home.
+const NotificationsFile = core.NotificationsFile
+
+// Notification is a message queued for display on the next CLI run.
+type Notification = core.Notification
+
+// NotifyOptions configures the channels used to announce upstream changes.
+// Notifications are always queued for display on the next CLI run.
+type NotifyOptions = core.NotifyOptions
+
+// WhatsNew returns items whose source version changed since the previous call.
+// The first call records the current versions and reports nothing.
+func WhatsNew(ctx context.Context, c *population.Client) ([]population.SearchResult, error) {
+	return (*core.Client)(c).WhatsNew(ctx)
+}
+
+// NotifyUpdates detects installed items with new upstream versions and announces
+// the ones that haven't been announced yet. It returns the newly announced items.
+// If the webhook or desktop notification fails, none of them are recorded as
+// announced, so the next call tries again.
+func NotifyUpdates(ctx context.Context, c *population.Client, opts *NotifyOptions) ([]population.OutdatedItem, error) {
+	return (*core.Client)(c).NotifyUpdates(ctx, opts)
+}
+
+// PendingNotifications returns queued notifications and clears the queue.
+func PendingNotifications(c *population.Client) ([]Notification, error) {
+	return (*core.Client)(c).PendingNotifications()
+}
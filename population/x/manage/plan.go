@@ -0,0 +1,24 @@
+package manage
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// FileAction is what a command does to a file.
+type FileAction = core.FileAction
+
+const (
+	FileCreate = core.FileCreate
+	FileModify = core.FileModify
+	FileRemove = core.FileRemove
+)
+
+// FileChange is a file a command changes, or would change with --dry-run.
+type FileChange = core.FileChange
+
+// PlanUninstall returns the files uninstalling an item would remove,
+// without removing them.
+func PlanUninstall(c *population.Client, name string) ([]FileChange, error) {
+	return (*core.Client)(c).PlanUninstall(name)
+}
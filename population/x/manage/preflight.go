@@ -0,0 +1,19 @@
+package manage
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// PreflightGap is a requirement the host doesn't satisfy.
+type PreflightGap = core.PreflightGap
+
+// Preflight checks that this host satisfies the requirements of an item
+// before it is deployed. A profile is checked through the skills that apply
+// on this platform in env; a skill is checked on its own. With an empty name,
+// every installed skill is checked.
+func Preflight(ctx context.Context, c *population.Client, name, env string) ([]PreflightGap, error) {
+	return (*core.Client)(c).Preflight(ctx, name, env)
+}
@@ -0,0 +1,37 @@
+package manage
+
+import (
+	"time"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// UsageFile is the usage log, relative to the install directory. Each line is
+// a JSON event, appended by agent runtimes through RecordUsage.
+const UsageFile = core.UsageFile
+
+// RecordUsage notes that an installed item was exercised, such as a skill's
+// tool being called or a persona being loaded. Runtimes call it as items are
+// used so unused ones can be found and pruned later.
+func RecordUsage(c *population.Client, name string) error {
+	return (*core.Client)(c).RecordUsage(name)
+}
+
+// LastUsed returns when each item in the usage log was last used, keyed by
+// its formatted name (e.g. "@cmo").
+func LastUsed(c *population.Client) (map[string]time.Time, error) {
+	return (*core.Client)(c).LastUsed()
+}
+
+// Unused returns installed items that haven't been used within the given
+// window. Items installed within the window count as used, so a fresh
+// install isn't reported before it has had a chance to run.
+func Unused(c *population.Client, kind population.ItemKind, since time.Duration) ([]population.InstalledItem, error) {
+	return (*core.Client)(c).Unused(kind, since)
+}
+
+// ParseAge parses a duration such as "30d", "2w", or "12h".
+func ParseAge(s string) (time.Duration, error) {
+	return core.ParseAge(s)
+}
@@ -0,0 +1,28 @@
+package manage
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// ChangeType describes how an installed item changed.
+type ChangeType = core.ChangeType
+
+const (
+	ChangeInstalled = core.ChangeInstalled
+	ChangeUpdated   = core.ChangeUpdated // Reinstalled, upgraded, or edited in place
+	ChangeRemoved   = core.ChangeRemoved
+)
+
+// ChangeEvent reports a change to an installed item.
+type ChangeEvent = core.ChangeEvent
+
+// Watch reports changes to installed items, such as another process
+// installing, upgrading, or removing a persona, so long-running runtimes can
+// reload them. It polls the install directory's manifests and install
+// records, and closes the channel when ctx is cancelled.
+func Watch(ctx context.Context, c *population.Client) (<-chan ChangeEvent, error) {
+	return (*core.Client)(c).Watch(ctx)
+}
@@ -0,0 +1,187 @@
+// Package populationtest provides registries for testing programs built on
+// the population client: a Registry served from memory, seeded with items and
+// checked with AssertInstalled, and a Source that misbehaves on purpose, for
+// testing how they cope with slow and unreliable sources.
+//
+// A Source serves a registry directory over HTTP and injects faults into
+// its responses at the configured rates:
+//
+//	src := populationtest.NewSource("./registry", populationtest.Faults{
+//	    Latency:      200 * time.Millisecond,
+//	    ErrorRate:    0.2,
+//	    TruncateRate: 0.1,
+//	    Seed:         1,
+//	})
+//	defer src.Close()
+//
+//	client, err := population.NewClient(population.WithSource(src.URL), population.WithNoCache())
+//
+// Like every package under population/x, it may change in any release.
+package populationtest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Faults configures what a Source injects. Rates are fractions of requests,
+// from 0 (never) to 1 (always); each request suffers at most one of the
+// error, truncation, and corruption faults.
+type Faults struct {
+	Latency time.Duration // Delay before every response
+	Jitter  time.Duration // Random extra delay, up to this long
+
+	ErrorRate    float64 // Answered with a 500, 502, 503, or 504
+	TruncateRate float64 // Body cut off halfway, with the connection closed
+	CorruptRate  float64 // YAML bodies replaced with YAML that fails to parse
+
+	Seed int64 // Seeds the random faults, so runs repeat (default: the time)
+}
+
+// Stats counts the requests a Source has served and the faults it injected.
+type Stats struct {
+	Requests  int
+	Errors    int
+	Truncated int
+	Corrupted int
+}
+
+// Source is a registry served over HTTP with faults injected. Point a client
+// at its URL.
+type Source struct {
+	*httptest.Server
+
+	dir    string
+	mu     sync.Mutex
+	faults Faults
+	rng    *rand.Rand
+	stats  Stats
+}
+
+// NewSource starts serving the registry in dir with the given faults. Call
+// Close when done.
+func NewSource(dir string, faults Faults) *Source {
+	s := &Source{dir: dir}
+	s.SetFaults(faults)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// SetFaults changes the faults injected from the next request on, reseeding
+// the random faults.
+func (s *Source) SetFaults(faults Faults) {
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = faults
+	s.rng = rand.New(rand.NewSource(seed))
+}
+
+// Stats returns the counts of requests and injected faults so far.
+func (s *Source) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// fault is what is done to one response.
+type fault int
+
+const (
+	faultNone fault = iota
+	faultError
+	faultTruncate
+	faultCorrupt
+)
+
+// errorStatuses are the server errors injected, which clients should retry.
+var errorStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// roll picks the delay and fault for a request, and counts them.
+func (s *Source) roll(yamlBody bool) (time.Duration, fault, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Requests++
+	delay := s.faults.Latency
+	if s.faults.Jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(s.faults.Jitter)))
+	}
+
+	r := s.rng.Float64()
+	switch {
+	case r < s.faults.ErrorRate:
+		s.stats.Errors++
+		return delay, faultError, errorStatuses[s.rng.Intn(len(errorStatuses))]
+	case r < s.faults.ErrorRate+s.faults.TruncateRate:
+		s.stats.Truncated++
+		return delay, faultTruncate, 0
+	case yamlBody && r < s.faults.ErrorRate+s.faults.TruncateRate+s.faults.CorruptRate:
+		s.stats.Corrupted++
+		return delay, faultCorrupt, 0
+	}
+	return delay, faultNone, 0
+}
+
+// serve answers a request for a registry file, with faults.
+func (s *Source) serve(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+	delay, f, status := s.roll(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml"))
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+	if f == faultError {
+		http.Error(w, fmt.Sprintf("injected %d", status), status)
+		return
+	}
+
+	content, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch f {
+	case faultTruncate:
+		// Promise the whole body but send half, then drop the connection
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(content[:len(content)/2])
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+			}
+		}
+	case faultCorrupt:
+		w.Write(corrupt(content))
+	default:
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+	}
+}
+
+// corrupt returns YAML that fails to parse, keeping most of the original so
+// the damage looks like a bad edit rather than an empty file.
+func corrupt(content []byte) []byte {
+	return append([]byte("kind: [unterminated\n\t- mixed: indentation\n"), content...)
+}
@@ -0,0 +1,143 @@
+package populationtest
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/x/registry"
+)
+
+// Registry is a registry served over HTTP from memory, for integration tests
+// of programs built on the population client. It is seeded with manifests,
+// such as those Skill, Persona, and Profile build, and can be changed while
+// clients use it through the embedded MemorySource:
+//
+//	reg, err := populationtest.NewRegistry(map[string]*population.Manifest{
+//	    "kubernetes-ops":     populationtest.Skill("1.2.0", "kubectl_get"),
+//	    "@sre":               populationtest.Persona("2.0.0", "You are an SRE.", "kubernetes-ops"),
+//	    "+platform-engineer": populationtest.Profile("1.0.0", "sre", "kubernetes-ops"),
+//	})
+//	defer reg.Close()
+//
+//	client, err := reg.NewClient(t.TempDir())
+type Registry struct {
+	*httptest.Server
+	*registry.MemorySource
+}
+
+// NewRegistry starts serving manifests, keyed by item name (name, @persona,
+// +profile, or %settings), and files. Call Close when done.
+func NewRegistry(manifests map[string]*population.Manifest, files ...registry.MemoryFile) (*Registry, error) {
+	src, err := registry.NewMemorySource(manifests, files...)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{
+		Server:       httptest.NewServer(registry.RegistryHandler(src)),
+		MemorySource: src,
+	}, nil
+}
+
+// NewClient returns a client of the registry installing into installDir,
+// without a cache so that it sees changes to the registry at once.
+func (r *Registry) NewClient(installDir string, opts ...population.Option) (*population.Client, error) {
+	opts = append([]population.Option{
+		population.WithSource(r.URL),
+		population.WithInstallDir(installDir),
+		population.WithNoCache(),
+	}, opts...)
+	return population.NewClient(opts...)
+}
+
+// Skill returns the manifest of a skill with a tool of each name.
+func Skill(version string, tools ...string) *population.Manifest {
+	m := &population.Manifest{
+		Version:     version,
+		Description: "Test skill",
+		Author:      "populationtest",
+	}
+	for _, tool := range tools {
+		m.Tools = append(m.Tools, population.Tool{Name: tool, Description: "Test tool " + tool})
+	}
+	return m
+}
+
+// Persona returns the manifest of a persona with a system prompt and
+// recommended skills.
+func Persona(version, prompt string, skills ...string) *population.Manifest {
+	return &population.Manifest{
+		Version:           version,
+		Description:       "Test persona",
+		Author:            "populationtest",
+		SystemPrompt:      population.Prompt{Text: prompt},
+		RecommendedSkills: skills,
+	}
+}
+
+// Profile returns the manifest of a profile of a persona, named without its
+// @, with skills.
+func Profile(version, persona string, skills ...string) *population.Manifest {
+	m := &population.Manifest{
+		Version:     version,
+		Description: "Test profile",
+		Author:      "populationtest",
+		Persona:     persona,
+	}
+	for _, skill := range skills {
+		m.Skills = append(m.Skills, population.SkillRef{Name: skill})
+	}
+	return m
+}
+
+// File returns an extra file of an item for NewRegistry, at path relative to
+// the item's directory. List the path in the item's manifest for it to be
+// installed.
+func File(name, path string, content []byte) registry.MemoryFile {
+	kind, itemName := population.ParseItemName(name)
+	return registry.MemoryFile{Path: kind.Plural() + "/" + itemName + "/" + path, Content: content}
+}
+
+// AssertInstalled fails t unless client has the item installed, at version
+// unless it is empty, with its manifest and extra files in place.
+func AssertInstalled(t testing.TB, client *population.Client, name, version string) {
+	t.Helper()
+	item, ok := installed(t, client, name)
+	if !ok {
+		t.Fatalf("%s is not installed in %s", name, client.InstallDir())
+	}
+	if version != "" && item.Version != version {
+		t.Fatalf("%s is installed at %s, want %s", name, item.Version, version)
+	}
+	for _, file := range append([]string{"vega.yaml"}, item.Files...) {
+		if _, err := os.Stat(filepath.Join(item.Path, filepath.FromSlash(file))); err != nil {
+			t.Fatalf("%s is missing %s: %v", name, file, err)
+		}
+	}
+}
+
+// AssertNotInstalled fails t if client has the item installed.
+func AssertNotInstalled(t testing.TB, client *population.Client, name string) {
+	t.Helper()
+	if item, ok := installed(t, client, name); ok {
+		t.Fatalf("%s is installed at %s in %s", name, item.Version, item.Path)
+	}
+}
+
+// installed returns the installed item of a name, if there is one.
+func installed(t testing.TB, client *population.Client, name string) (population.InstalledItem, bool) {
+	t.Helper()
+	kind, itemName := population.ParseItemName(name)
+	items, err := client.List(kind)
+	if err != nil {
+		t.Fatalf("listing installed items: %v", err)
+	}
+	for _, item := range items {
+		if item.Name == itemName {
+			return item, true
+		}
+	}
+	return population.InstalledItem{}, false
+}
@@ -0,0 +1,17 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// ExtractArchive extracts a .tar, .tar.gz, .tgz, or .zip file into dest,
+// which must not exist yet. Entries with absolute paths or paths leaving
+// dest, symlinks pointing outside dest, hard links, and device files are
+// rejected, as are archives with a file over 64 MiB or over 1 GiB of files in
+// all. Files are written in parallel, then read back and checked against the
+// archive before returning.
+func ExtractArchive(ctx context.Context, file, dest string) error {
+	return core.ExtractArchive(ctx, file, dest)
+}
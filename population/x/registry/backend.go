@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"net/http"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+	"github.com/everydev1618/vega-population/population/x/source"
+)
+
+// Registry is a backend a client reads a registry's contents from, in place
+// of a source URL. HTTPSource, LocalSource, and GitSource are the built-in
+// ones; embedders can supply their own, such as a registry kept in a
+// database, with WithRegistry or population.SourceConfig.Registry. The client
+// caches, verifies, and records what a Registry returns as it does for any
+// source. Content that doesn't exist must be reported with an error wrapping
+// fs.ErrNotExist.
+type Registry = core.Registry
+
+// RegistryHandler serves a Registry over HTTP as a source tree, so clients,
+// and tools that only take source URLs, can use it by URL.
+func RegistryHandler(r Registry) http.Handler {
+	return core.RegistryHandler(r)
+}
+
+// LocalSource is a Registry reading a source tree in a local directory.
+type LocalSource = core.LocalSource
+
+// NewLocalSource returns a Registry reading the source tree in dir.
+func NewLocalSource(dir string) *LocalSource {
+	return core.NewLocalSource(dir)
+}
+
+// HTTPSource is a Registry reading a raw HTTP tree, such as a GitHub raw
+// URL or a server run by `vega population serve`. Files read are kept in
+// the cache, to read when offline.
+type HTTPSource = core.HTTPSource
+
+// NewHTTPSource returns a Registry reading the HTTP tree at baseURL,
+// caching in cache.
+func NewHTTPSource(baseURL string, cache *source.Cache) *HTTPSource {
+	return core.NewHTTPSource(baseURL, cache)
+}
+
+// GitSource is a Registry reading a git repository, from a checkout kept in
+// the cache directory and pulled at most once per cache TTL.
+type GitSource = core.GitSource
+
+// NewGitSource returns a Registry reading the git repository at url
+// (git@host:org/repo.git, ssh://..., or https://....git, optionally followed
+// by #branch), checked out under cache's directory.
+func NewGitSource(url string, cache *source.Cache) *GitSource {
+	return core.NewGitSource(url, cache)
+}
@@ -0,0 +1,19 @@
+package registry
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ChangelogEntry is one entry in a manifest's changelog.
+type ChangelogEntry = core.ChangelogEntry
+
+// BumpOptions configures a version bump.
+type BumpOptions = core.BumpOptions
+
+// BumpResult describes a version bump.
+type BumpResult = core.BumpResult
+
+// BumpManifest bumps the version of the manifest at path (a vega.yaml file or
+// the directory containing it), appends a changelog entry, and, when the
+// manifest lives in a registry checkout, updates its index entry too.
+func BumpManifest(path string, opts *BumpOptions) (*BumpResult, error) {
+	return core.BumpManifest(path, opts)
+}
@@ -0,0 +1,22 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// CopyOptions configures copying items from a source into a registry checkout.
+type CopyOptions = core.CopyOptions
+
+// CopyResult describes one copied item.
+type CopyResult = core.CopyResult
+
+// Copy fetches an item from the client's source, verifying it as Install
+// does, and adds it to the registry checkout at registryDir, updating its
+// index and optionally re-signing it. This curates internal registries from
+// public ones. A profile is copied together with its persona and skills.
+func Copy(ctx context.Context, c *population.Client, name, registryDir string, opts *CopyOptions) ([]CopyResult, error) {
+	return (*core.Client)(c).Copy(ctx, name, registryDir, opts)
+}
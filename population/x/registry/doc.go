@@ -0,0 +1,17 @@
+// Package registry reads items from registries other than a source URL and
+// authors the items in one.
+//
+// A Registry serves indexes, manifests, and files from anywhere, such as a
+// database in a hosted product. MemorySource is one built from manifests in
+// memory:
+//
+//	src, err := registry.NewMemorySource(map[string]*population.Manifest{
+//	    "kubernetes-ops": {Version: "1.2.0", Description: "Kubernetes helpers"},
+//	})
+//	client, err := population.NewClient(registry.WithRegistry(src), population.WithNoCache())
+//
+// LocalRegistry is a registry directory, which Publish, Push, Copy, and
+// BumpManifest change, and Mirror copies a source into.
+//
+// Like every package under population/x, it may change in any release.
+package registry
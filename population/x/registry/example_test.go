@@ -0,0 +1,48 @@
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/x/registry"
+)
+
+func ExampleWithRegistry() {
+	dir, err := os.MkdirTemp("", "vega-example-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := registry.NewMemorySource(map[string]*population.Manifest{
+		"kubernetes-ops": {Version: "1.2.0", Description: "Kubernetes helpers"},
+		"@sre":           {Version: "2.0.0", SystemPrompt: population.Prompt{Text: "You are an SRE."}},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := population.NewClient(registry.WithRegistry(src), population.WithNoCache(), population.WithInstallDir(dir))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, name := range []string{"kubernetes-ops", "@sre"} {
+		if err := client.Install(ctx, name, nil); err != nil {
+			log.Fatal(err)
+		}
+	}
+	items, err := client.List("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, item := range items {
+		fmt.Println(population.FormatItemName(item.Kind, item.Name), item.Version)
+	}
+	// Output:
+	// kubernetes-ops 1.2.0
+	// @sre 2.0.0
+}
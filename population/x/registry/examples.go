@@ -0,0 +1,17 @@
+package registry
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// LintExamples reports problems with a persona's example conversations.
+func LintExamples(examples []population.Example) []string {
+	return core.LintExamples(examples)
+}
+
+// FormatExamples renders examples as few-shot transcripts for runtimes that
+// take them as part of the system prompt.
+func FormatExamples(examples []population.Example) string {
+	return core.FormatExamples(examples)
+}
@@ -0,0 +1,31 @@
+package registry
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// MemorySource is a Registry holding its items in memory, for tests and
+// for programs that build their items at run time. Its indexes are derived
+// from the manifests, with the sha256 of each, so installs verify as they
+// would from a published registry. It is safe for concurrent use, and may be
+// changed while clients read it; use population.WithNoCache so they see
+// changes at once.
+//
+//	src, err := registry.NewMemorySource(map[string]*population.Manifest{
+//	    "kubernetes-ops": {Version: "1.2.0", Description: "Kubernetes helpers"},
+//	    "@sre":           {Version: "2.0.0", SystemPrompt: population.Prompt{Text: "You are an SRE."}},
+//	})
+//	client, err := population.NewClient(registry.WithRegistry(src), population.WithNoCache())
+type MemorySource = core.MemorySource
+
+// MemoryFile is a file a MemorySource serves besides indexes and manifests,
+// such as one listed in a manifest's files.
+type MemoryFile = core.MemoryFile
+
+// NewMemorySource returns a MemorySource serving manifests, keyed by item
+// name (name, @persona, +profile, or %settings), and files. A manifest's
+// kind and name are filled in from its key when empty.
+func NewMemorySource(manifests map[string]*population.Manifest, files ...MemoryFile) (*MemorySource, error) {
+	return core.NewMemorySource(manifests, files...)
+}
@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+const (
+	// ChecksumsFile is the integrity file written at the root of a published mirror.
+	// It uses the sha256sum format, so mirrors can also be checked with `sha256sum -c`.
+	ChecksumsFile = core.ChecksumsFile
+	// SignatureFile holds the optional Ed25519 signature of ChecksumsFile.
+	SignatureFile = core.SignatureFile
+)
+
+// MirrorOptions configures mirror publishing.
+type MirrorOptions = core.MirrorOptions
+
+// MirrorReport summarizes a published mirror.
+type MirrorReport = core.MirrorReport
+
+// MirrorMismatch describes a file that differs between a mirror and its upstream.
+type MirrorMismatch = core.MirrorMismatch
+
+// Mirror writes a static, integrity-annotated copy of the source to dest.
+// The result contains every index, manifest, and item file, of older versions
+// too, in the source layout, a SHA256SUMS file, and optionally a signature,
+// and can be served by any static file server (including GitHub Pages) or
+// used directly as a source, such as on a network without internet access.
+func Mirror(ctx context.Context, c *population.Client, dest string, opts *MirrorOptions) (*MirrorReport, error) {
+	return (*core.Client)(c).Mirror(ctx, dest, opts)
+}
+
+// VerifyMirror checks that a published mirror is internally consistent and
+// matches the client's source. If key is non-nil, the checksums signature is
+// verified as well. An empty result means the mirror is identical to upstream.
+func VerifyMirror(ctx context.Context, c *population.Client, dir string, key ed25519.PublicKey) ([]MirrorMismatch, error) {
+	return (*core.Client)(c).VerifyMirror(ctx, dir, key)
+}
@@ -0,0 +1,6 @@
+package registry
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// OwnershipViolation is an index change made by someone who doesn't maintain the item.
+type OwnershipViolation = core.OwnershipViolation
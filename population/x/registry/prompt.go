@@ -0,0 +1,26 @@
+package registry
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// SectionChange describes how one prompt section differs between two prompts.
+type SectionChange = core.SectionChange
+
+// DiffPrompts compares two prompts section by section.
+func DiffPrompts(before, after population.Prompt) []SectionChange {
+	return core.DiffPrompts(before, after)
+}
+
+// MergePrompts merges two edits of a base prompt section by section. A
+// section changed on only one side takes that side's text; sections changed
+// differently on both sides keep ours and are reported as conflicts.
+func MergePrompts(base, ours, theirs population.Prompt) (population.Prompt, []string) {
+	return core.MergePrompts(base, ours, theirs)
+}
+
+// LintPrompt reports problems with a prompt's sections.
+func LintPrompt(p population.Prompt) []string {
+	return core.LintPrompt(p)
+}
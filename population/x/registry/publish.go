@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// Registry write protocol. A writable registry serves the usual source layout
+// for reads and accepts uploads of one item at a time:
+//
+//	PUT <registry>/<kind plural>/<name>/<file>     an extra file the manifest lists
+//	PUT <registry>/<kind plural>/<name>/vega.yaml  the manifest, which publishes the item
+//
+// Files are staged until the manifest arrives. The manifest is validated, its
+// files are checked against any pinned hashes, and its version must be newer
+// than the published one; the registry then writes the item and updates its
+// index. Each body's sha256 may be sent in the PublishSHA256Header header and
+// is checked on receipt. The manifest upload answers 201 with a JSON
+// PublishResult, 409 when the version is already published, 422 when the
+// manifest is invalid, and {"error": "..."} with other failures.
+const PublishSHA256Header = core.PublishSHA256Header
+
+// PublishOptions configures publishing an item to a registry.
+type PublishOptions = core.PublishOptions
+
+// PublishResult describes a published item.
+type PublishResult = core.PublishResult
+
+// InvalidManifestError reports a manifest refused by validation.
+type InvalidManifestError = core.InvalidManifestError
+
+// VersionConflictError reports a publish of a version that is not newer than
+// the one the registry has.
+type VersionConflictError = core.VersionConflictError
+
+// Publish validates the manifest at path (a vega.yaml file or the directory
+// containing it) and publishes it, with the extra files it lists, to a
+// registry: a registry checkout, or the URL of a registry speaking the write
+// protocol. When the registry already has the manifest's version, the version
+// is bumped, with a changelog entry, and the local manifest is updated to
+// match once the item is published.
+func Publish(ctx context.Context, c *population.Client, path, registry string, opts *PublishOptions) (*PublishResult, error) {
+	return (*core.Client)(c).Publish(ctx, path, registry, opts)
+}
+
+// PublishHandler serves the upload side of the registry write protocol for
+// a registry directory. Mount it alongside a handler serving reads, and put
+// authentication in front of it.
+func PublishHandler(r *LocalRegistry) http.Handler {
+	return core.PublishHandler(r)
+}
@@ -0,0 +1,26 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// PushOptions configures pushing a locally modified item back to a registry.
+type PushOptions = core.PushOptions
+
+// PushResult describes a pushed item.
+type PushResult = core.PushResult
+
+// Push copies a locally modified installed item into a registry working copy,
+// bumps its version with a changelog entry, and updates the registry index. The installed copy is
+// updated to the new version as well, so it is not reported as outdated.
+// Items new to the registry are refused with a *ShadowError when another of
+// the client's sources has an item of the same name, unless AllowShadow is set.
+//
+// registryDir may also be the URL of a writable registry server, which the
+// item is uploaded to as Publish does.
+func Push(ctx context.Context, c *population.Client, name, registryDir string, opts *PushOptions) (*PushResult, error) {
+	return (*core.Client)(c).Push(ctx, name, registryDir, opts)
+}
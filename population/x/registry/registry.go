@@ -0,0 +1,12 @@
+package registry
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// LocalRegistry is a writable registry working copy, such as a checkout of the
+// vega-population repository. It uses the same layout as a source.
+type LocalRegistry = core.LocalRegistry
+
+// OpenLocalRegistry opens the registry working copy at dir.
+func OpenLocalRegistry(dir string) (*LocalRegistry, error) {
+	return core.OpenLocalRegistry(dir)
+}
@@ -0,0 +1,17 @@
+package registry
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// DefaultGCInterval is how often a writable server collects garbage when it
+// has a retention policy.
+const DefaultGCInterval = core.DefaultGCInterval
+
+// RetentionPolicy says which older versions of its items a registry keeps.
+// The current version of an item is always kept.
+type RetentionPolicy = core.RetentionPolicy
+
+// GCResult describes a garbage collection of a registry.
+type GCResult = core.GCResult
+
+// RemovedVersion is an item version removed by garbage collection.
+type RemovedVersion = core.RemovedVersion
@@ -0,0 +1,24 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// Shadow is an existing item in a configured source that a newly published
+// item would share a name with.
+type Shadow = core.Shadow
+
+// ShadowError reports a publish refused because the name is already taken
+// in another source.
+type ShadowError = core.ShadowError
+
+// Shadows returns the items in the configured sources, other than the
+// registry at registryDir, that share a name with an item. Every kind is
+// checked, since "cmo" and "@cmo" are easily confused. Sources that can't
+// be reached are skipped with a warning.
+func Shadows(ctx context.Context, c *population.Client, name, registryDir string) ([]Shadow, error) {
+	return (*core.Client)(c).Shadows(ctx, name, registryDir)
+}
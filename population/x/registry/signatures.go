@@ -0,0 +1,21 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// SignOptions configures signing a manifest.
+type SignOptions = core.SignOptions
+
+// SignResult describes a signed manifest.
+type SignResult = core.SignResult
+
+// SignManifest writes a detached signature next to the manifest at path (a
+// vega.yaml file or the directory containing it). Ed25519 signatures are made
+// directly; GPG and sigstore signatures with gpg and cosign. When the manifest
+// lives in a registry checkout, its index entry lists the signature.
+func SignManifest(ctx context.Context, path string, opts *SignOptions) (*SignResult, error) {
+	return core.SignManifest(ctx, path, opts)
+}
@@ -0,0 +1,29 @@
+package registry
+
+import (
+	"crypto/ed25519"
+
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// LoadPrivateKey loads an Ed25519 private key from a PEM-encoded PKCS#8 file,
+// such as one created with `openssl genpkey -algorithm ed25519`.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	return core.LoadPrivateKey(path)
+}
+
+// LoadPublicKey loads an Ed25519 public key from a PEM-encoded PKIX file,
+// such as one created with `openssl pkey -pubout`.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	return core.LoadPublicKey(path)
+}
+
+// Sign returns the base64-encoded Ed25519 signature of content.
+func Sign(key ed25519.PrivateKey, content []byte) string {
+	return core.Sign(key, content)
+}
+
+// VerifySignature checks a base64-encoded Ed25519 signature of content.
+func VerifySignature(key ed25519.PublicKey, content []byte, signature string) error {
+	return core.VerifySignature(key, content, signature)
+}
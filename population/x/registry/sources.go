@@ -0,0 +1,14 @@
+package registry
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// WithRegistry reads items from r, such as a registry backed by a database
+// in a hosted product, in place of a source URL. It replaces the configured
+// sources; to search r alongside others, set population.SourceConfig.Registry
+// in population.WithSources instead.
+func WithRegistry(r Registry) population.Option {
+	return core.WithRegistry(r)
+}
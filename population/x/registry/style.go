@@ -0,0 +1,14 @@
+package registry
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// StyleFile is the name of a registry's prompt style guide, at its root.
+const StyleFile = core.StyleFile
+
+// StyleGuide holds the prompt conventions a registry enforces for its items.
+type StyleGuide = core.StyleGuide
+
+// LoadStyleGuide reads a style guide file.
+func LoadStyleGuide(path string) (*StyleGuide, error) {
+	return core.LoadStyleGuide(path)
+}
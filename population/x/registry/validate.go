@@ -0,0 +1,17 @@
+package registry
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// ValidationError is a problem with one field of a manifest.
+type ValidationError = core.ValidationError
+
+// ValidateManifest checks a manifest against the item schemas: required
+// fields, name and version formats, tag conventions, and the fields each
+// kind may and must set. References to other items are checked by
+// LocalRegistry.ValidateReferences.
+func ValidateManifest(m *population.Manifest) []ValidationError {
+	return core.ValidateManifest(m)
+}
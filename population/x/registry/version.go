@@ -0,0 +1,18 @@
+package registry
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Version parts accepted by BumpVersion.
+const (
+	BumpMajor = core.BumpMajor
+	BumpMinor = core.BumpMinor
+	BumpPatch = core.BumpPatch
+)
+
+// BumpVersion increments the given part of a MAJOR.MINOR.PATCH version,
+// resetting the lower parts: 1.2.3 bumped by minor becomes 1.3.0. A
+// pre-release of the version the bump would reach is released instead:
+// 1.3.0-rc.1 bumped by minor becomes 1.3.0, and by patch too.
+func BumpVersion(version, part string) (string, error) {
+	return core.BumpVersion(version, part)
+}
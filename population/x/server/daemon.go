@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+const (
+	// DefaultDaemonInterval is how often the daemon refreshes indexes by default.
+	DefaultDaemonInterval = core.DefaultDaemonInterval
+	// DaemonSocketName is the daemon socket file name, relative to the vega home.
+	DaemonSocketName = core.DaemonSocketName
+	// DaemonStatusFile is the freshness metrics file, relative to the cache directory.
+	DaemonStatusFile = core.DaemonStatusFile
+	// DefaultShutdownTimeout is how long servers drain in-flight work on shutdown.
+	DefaultShutdownTimeout = core.DefaultShutdownTimeout
+)
+
+// DaemonOptions configures the background refresh daemon.
+type DaemonOptions = core.DaemonOptions
+
+// DaemonStatus reports the freshness of the daemon's data.
+type DaemonStatus = core.DaemonStatus
+
+// Daemon periodically refreshes the cached indexes, prefetches manifests, and
+// serves them over a local socket so interactive commands never wait on the network.
+type Daemon = core.Daemon
+
+// NewDaemon creates a daemon for the client's source.
+func NewDaemon(c *population.Client, opts *DaemonOptions) *Daemon {
+	return (*core.Client)(c).NewDaemon(opts)
+}
+
+// Status returns the freshness metrics of the running daemon, or of the
+// last daemon run recorded in the cache if none is running.
+func Status(ctx context.Context, c *population.Client) (*DaemonStatus, bool, error) {
+	return (*core.Client)(c).DaemonStatus(ctx)
+}
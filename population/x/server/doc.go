@@ -0,0 +1,5 @@
+// Package server serves a registry over HTTP, runs the daemon keeping a
+// client's indexes fresh, and writes the service files that start them.
+//
+// Like every package under population/x, it may change in any release.
+package server
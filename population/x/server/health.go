@@ -0,0 +1,8 @@
+package server
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// RequestIDHeader carries a request's ID. A server keeps the ID a client or
+// proxy sends, or makes one up, and returns it in the response and its
+// access log.
+const RequestIDHeader = core.RequestIDHeader
@@ -0,0 +1,8 @@
+package server
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// ManifestMediaType returns the media type of a manifest schema version.
+func ManifestMediaType(version int) string {
+	return core.ManifestMediaType(version)
+}
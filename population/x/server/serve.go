@@ -0,0 +1,33 @@
+package server
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Role is what a server token allows.
+type Role = core.Role
+
+const (
+	RoleRead    = core.RoleRead    // Fetch indexes, manifests, and files
+	RolePublish = core.RolePublish // Read, and upload items to a writable server
+)
+
+// ServerToken is a token a server accepts, and the role it grants.
+type ServerToken = core.ServerToken
+
+// ServerOptions configures a registry server.
+type ServerOptions = core.ServerOptions
+
+// Server serves a registry directory, or an install directory, as an HTTP
+// source using the same layout as any other source: <kind plural>/index.yaml
+// and <kind plural>/<name>/vega.yaml, with the items' extra files.
+type Server = core.Server
+
+// NewServer creates a server for dir.
+func NewServer(dir string, opts *ServerOptions) (*Server, error) {
+	return core.NewServer(dir, opts)
+}
+
+// LoadServerTokens reads server tokens from a YAML file with a list of
+// name, token, and role entries under "tokens".
+func LoadServerTokens(path string) ([]ServerToken, error) {
+	return core.LoadServerTokens(path)
+}
@@ -0,0 +1,21 @@
+package server
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Service managers supported by GenerateService.
+const (
+	ServiceSystemd = core.ServiceSystemd
+	ServiceLaunchd = core.ServiceLaunchd
+)
+
+// ServiceOptions configures service file generation for the daemon.
+type ServiceOptions = core.ServiceOptions
+
+// ServiceFile is a generated service definition.
+type ServiceFile = core.ServiceFile
+
+// GenerateService renders a systemd user unit or launchd agent that runs the
+// daemon, optionally syncing a deps file on every interval.
+func GenerateService(opts ServiceOptions) (*ServiceFile, error) {
+	return core.GenerateService(opts)
+}
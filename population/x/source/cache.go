@@ -0,0 +1,20 @@
+package source
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+const (
+	// CacheTTL is the default cache time-to-live for index files.
+	CacheTTL = core.CacheTTL
+)
+
+// Validators are the HTTP validators a server sent with a file, used to
+// revalidate a cached copy with a conditional request once it expires.
+type Validators = core.Validators
+
+// Cache handles local caching of index files.
+type Cache = core.Cache
+
+// NewCache creates a new Cache instance.
+func NewCache(dir string, disabled bool) *Cache {
+	return core.NewCache(dir, disabled)
+}
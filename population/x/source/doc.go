@@ -0,0 +1,5 @@
+// Package source reads a single source's indexes and manifests, through the
+// cache clients keep them in.
+//
+// Like every package under population/x, it may change in any release.
+package source
@@ -0,0 +1,32 @@
+package source
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// Source handles fetching content from local or remote sources.
+type Source = core.Source
+
+// NewSource creates a new Source instance. The base URL may be a local
+// directory, a raw HTTP tree, a git repository URL (git@host:org/repo.git,
+// ssh://..., or https://....git, optionally followed by #branch), or a local
+// or remote .tar, .tar.gz, .tgz, or .zip archive of a registry.
+func NewSource(baseURL string, cache *Cache) *Source {
+	return core.NewSource(baseURL, cache)
+}
+
+// SettingsIndex represents the settings/index.yaml structure.
+type SettingsIndex = core.SettingsIndex
+
+// SkillsIndex represents the skills/index.yaml structure.
+type SkillsIndex = core.SkillsIndex
+
+// PersonasIndex represents the personas/index.yaml structure.
+type PersonasIndex = core.PersonasIndex
+
+// ProfilesIndex represents the profiles/index.yaml structure.
+type ProfilesIndex = core.ProfilesIndex
+
+// IndexEntry represents an entry in the skills or personas index.
+type IndexEntry = core.IndexEntry
+
+// ProfileIndexEntry represents an entry in the profiles index.
+type ProfileIndexEntry = core.ProfileIndexEntry
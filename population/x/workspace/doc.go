@@ -0,0 +1,6 @@
+// Package workspace installs the items a project's deps file lists into the
+// project's own directory, keeps them to the versions its lockfile records,
+// and opens pull requests updating that lockfile.
+//
+// Like every package under population/x, it may change in any release.
+package workspace
@@ -0,0 +1,25 @@
+package workspace
+
+import "github.com/everydev1618/vega-population/population/internal/core"
+
+// LockFile is the conventional name of a lockfile, kept next to the spec it
+// locks.
+const LockFile = core.LockFile
+
+// Lock records the exact content each item was synced with. The hashes are
+// of the manifests themselves, so they are the same from any mirror of a
+// source, and a later sync from a different mirror can check that it serves
+// identical content before installing anything from it.
+type Lock = core.Lock
+
+// LockEntry pins the content of an item.
+type LockEntry = core.LockEntry
+
+// LockMismatchError reports a source serving different content for a locked
+// version of an item than the lockfile recorded.
+type LockMismatchError = core.LockMismatchError
+
+// LoadLock loads a lockfile. A missing lockfile is an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	return core.LoadLock(path)
+}
@@ -0,0 +1,53 @@
+package workspace
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// DefaultDepsFile is the conventional name of a deps file.
+const DefaultDepsFile = core.DefaultDepsFile
+
+// Deps declares the items that should be installed, for example:
+//
+//	env: prod
+//	items:
+//	  - kubernetes-ops
+//	  - "@incident-commander"
+//	  - "+sre-oncall"
+type Deps = core.Deps
+
+// SyncAction is a change Sync makes to an item.
+type SyncAction = core.SyncAction
+
+const (
+	SyncInstall = core.SyncInstall // Listed but not installed
+	SyncUpgrade = core.SyncUpgrade // Installed at a version the spec or lockfile doesn't allow
+	SyncRemove  = core.SyncRemove  // Installed but neither listed nor needed, with Prune
+)
+
+// SyncOptions configures Sync.
+type SyncOptions = core.SyncOptions
+
+// SyncChange is a change Sync made, or would make with DryRun.
+type SyncChange = core.SyncChange
+
+// SyncReport summarizes the changes made by Sync.
+type SyncReport = core.SyncReport
+
+// LoadDeps loads a deps file.
+func LoadDeps(path string) (*Deps, error) {
+	return core.LoadDeps(path)
+}
+
+// Sync reconciles the installed items with deps, like applying a plan:
+// listed items that aren't installed are installed, those installed at a
+// version their constraint doesn't allow are reinstalled at one it does, and
+// with opts.Prune, installed items that no listed item needs are removed.
+// Items a listed profile or skill depends on count as needed. With
+// opts.Lock, the installed items are also checked against a lockfile.
+func Sync(ctx context.Context, c *population.Client, deps *Deps, opts *SyncOptions) (*SyncReport, error) {
+	return (*core.Client)(c).Sync(ctx, deps, opts)
+}
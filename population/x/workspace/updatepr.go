@@ -0,0 +1,41 @@
+package workspace
+
+import (
+	"context"
+
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+// DefaultUpdateBranch is the branch UpdatePR commits to unless told otherwise.
+const DefaultUpdateBranch = core.DefaultUpdateBranch
+
+// DefaultGitHubAPI is the GitHub API UpdatePR opens pull requests with.
+const DefaultGitHubAPI = core.DefaultGitHubAPI
+
+// LockUpdate is a newer version of a locked item that its spec allows.
+type LockUpdate = core.LockUpdate
+
+// UpdatePROptions configures UpdatePR.
+type UpdatePROptions = core.UpdatePROptions
+
+// UpdatePRResult describes the updates UpdatePR found and what it did.
+type UpdatePRResult = core.UpdatePRResult
+
+// UpdateLock returns a copy of lock with every item at the newest version its
+// constraint in deps allows (any version for items deps doesn't constrain),
+// and the updates that makes. Items no source offers any more are kept as
+// they are.
+func UpdateLock(ctx context.Context, c *population.Client, deps *Deps, lock *Lock) (*Lock, []LockUpdate, error) {
+	return (*core.Client)(c).UpdateLock(ctx, deps, lock)
+}
+
+// UpdatePR updates the lockfile at lockPath to the newest versions deps
+// allows and commits it to a branch of the git repository holding it, with
+// the items' changelogs in the commit message, like a dependency update bot.
+// With opts.Token it also pushes the branch and opens a pull request. The
+// current branch is checked out again afterwards, so the working tree is
+// left as it was.
+func UpdatePR(ctx context.Context, c *population.Client, deps *Deps, lockPath string, opts *UpdatePROptions) (*UpdatePRResult, error) {
+	return (*core.Client)(c).UpdatePR(ctx, deps, lockPath, opts)
+}
@@ -0,0 +1,71 @@
+package workspace
+
+import (
+	"github.com/everydev1618/vega-population/population"
+	"github.com/everydev1618/vega-population/population/internal/core"
+)
+
+const (
+	// WorkspaceFile declares the items a project needs, for example:
+	//
+	// 	source: https://registry.internal.example/
+	// 	env: prod
+	// 	profiles:
+	// 	  - sre-oncall
+	// 	personas:
+	// 	  - incident-commander
+	// 	skills:
+	// 	  - kubernetes-ops@^1.2
+	WorkspaceFile = core.WorkspaceFile
+	// WorkspaceInstallDir is where a workspace's items are installed,
+	// relative to its workspace file.
+	WorkspaceInstallDir = core.WorkspaceInstallDir
+)
+
+// ErrNoWorkspace is returned by FindWorkspace when no directory has a
+// workspace file.
+var ErrNoWorkspace = core.ErrNoWorkspace
+
+// Workspace is a project that installs its own items, declared in its
+// WorkspaceFile, into its own install directory.
+type Workspace = core.Workspace
+
+// LoadWorkspace loads a workspace file.
+func LoadWorkspace(path string) (*Workspace, error) {
+	return core.LoadWorkspace(path)
+}
+
+// FindWorkspace loads the workspace file in dir or the nearest directory
+// above it. It returns ErrNoWorkspace if there is none.
+func FindWorkspace(dir string) (*Workspace, error) {
+	return core.FindWorkspace(dir)
+}
+
+// WithWorkspace installs into the workspace's install directory, from the
+// workspace's source if it names one. population.NewClient already does this
+// for the workspace found from the working directory; options given after
+// this one still override it.
+func WithWorkspace(ws *Workspace) population.Option {
+	return core.WithWorkspace(ws)
+}
+
+// Current returns the workspace the client installs into, or nil.
+func Current(c *population.Client) *Workspace {
+	return (*core.Client)(c).Workspace()
+}
+
+// FindWorkspaces returns the workspace of every directory under root, root
+// included, that holds a WorkspaceFile or else a deps file, such as each
+// service of a monorepo. A deps file is read as a workspace without a source
+// (see WorkspaceFromDeps). Hidden directories, node_modules, and vendor are
+// skipped. The workspaces are in lexical order of their directories.
+func FindWorkspaces(root string) ([]*Workspace, error) {
+	return core.FindWorkspaces(root)
+}
+
+// WorkspaceFromDeps returns the workspace declared by a deps file in dir: its
+// items, installed into the install directory next to it from the client's
+// source.
+func WorkspaceFromDeps(dir string, deps *Deps) (*Workspace, error) {
+	return core.WorkspaceFromDeps(dir, deps)
+}